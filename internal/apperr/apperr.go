@@ -0,0 +1,176 @@
+// Package apperr 业务错误模型：Service/Repo层返回带错误码与HTTP状态码的*Error，
+// 由middleware.ErrorHandler统一转换为响应（客户端按响应体error字段的错误码分支处理，不依赖提示文案）
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code 机器可读错误码（对外稳定，新增可以，已有的不能改名）
+type Code string
+
+// 通用错误码
+const (
+	CodeInvalidArgument    Code = "INVALID_ARGUMENT"    // 参数错误
+	CodeFailedPrecondition Code = "FAILED_PRECONDITION" // 当前状态不允许该操作（状态未变化、已处于目标状态等）
+	CodeUnauthenticated    Code = "UNAUTHENTICATED"     // 未登录或登录凭证无效
+	CodePermissionDenied   Code = "PERMISSION_DENIED"   // 无权操作
+	CodeNotFound           Code = "NOT_FOUND"           // 对象不存在
+	CodeAlreadyExists      Code = "ALREADY_EXISTS"      // 唯一字段已被占用
+	CodeRateLimited        Code = "RATE_LIMITED"        // 请求过于频繁
+	CodeUnavailable        Code = "UNAVAILABLE"         // 服务暂不可用（依赖不可用或正在关闭）
+	CodeInternal           Code = "INTERNAL"            // 系统错误（详细原因只记录日志，不返回客户端）
+)
+
+// 业务错误码
+const (
+	CodeUserNotFound           Code = "USER_NOT_FOUND"
+	CodeAccountNotFound        Code = "ACCOUNT_NOT_FOUND"
+	CodeResourceNotFound       Code = "RESOURCE_NOT_FOUND"
+	CodeCommentNotFound        Code = "COMMENT_NOT_FOUND"
+	CodeCategoryNotFound       Code = "CATEGORY_NOT_FOUND"
+	CodeOrderNotFound          Code = "ORDER_NOT_FOUND"
+	CodeInvalidCredentials     Code = "INVALID_CREDENTIALS"      // 账号或密码错误
+	CodeWrongPassword          Code = "WRONG_PASSWORD"           // 已登录用户校验密码失败（修改密码、关闭两步验证）
+	CodeAccountLocked          Code = "ACCOUNT_LOCKED"           // 登录失败次数过多，临时锁定
+	CodeAccountDisabled        Code = "ACCOUNT_DISABLED"         // 账号已停用/封禁
+	CodeEmailUnverified        Code = "EMAIL_UNVERIFIED"         // 邮箱未验证
+	CodeVerifyCodeInvalid      Code = "VERIFY_CODE_INVALID"      // 邮箱验证码错误/过期/次数过多
+	CodeSendTooFrequent        Code = "SEND_TOO_FREQUENT"        // 验证码发送冷却中
+	CodeTokenInvalid           Code = "TOKEN_INVALID"            // 访问Token/刷新令牌无效或已过期
+	CodeTwoFactorCodeInvalid   Code = "TWO_FACTOR_CODE_INVALID"  // 动态码/恢复码错误
+	CodeTwoFactorChallenge     Code = "TWO_FACTOR_CHALLENGE"     // 两步验证挑战令牌无效、过期或错误次数过多
+	CodeInsufficientBalance    Code = "INSUFFICIENT_BALANCE"     // 余额不足
+	CodeIdempotencyConflict    Code = "IDEMPOTENCY_CONFLICT"     // 幂等键已被用于其他请求
+	CodeAlreadyPurchased       Code = "ALREADY_PURCHASED"        // 已购买该资源
+	CodePaymentCallbackInvalid Code = "PAYMENT_CALLBACK_INVALID" // 支付回调验签失败/金额不一致等
+)
+
+// Error 业务错误：Code供客户端判断，Status为HTTP状态码，Msg为返回给用户的提示，cause为底层原因（只记录日志）
+type Error struct {
+	Code   Code
+	Status int
+	Msg    string
+	cause  error
+}
+
+// New 创建业务错误
+func New(code Code, status int, msg string) *Error {
+	return &Error{Code: code, Status: status, Msg: msg}
+}
+
+// Error 实现error接口（含底层原因，用于日志）
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Msg + "：" + e.cause.Error()
+	}
+	return e.Msg
+}
+
+// Unwrap 返回底层原因
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is 错误码相同即视为同一错误（errors.Is(err, apperr.ErrUserNotFound)不受提示文案影响）
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithMsg 复制错误并替换提示文案（错误码、状态码不变）
+func (e *Error) WithMsg(msg string) *Error {
+	cp := *e
+	cp.Msg = msg
+	return &cp
+}
+
+// WithMsgf 复制错误并按格式替换提示文案
+func (e *Error) WithMsgf(format string, args ...interface{}) *Error {
+	return e.WithMsg(fmt.Sprintf(format, args...))
+}
+
+// Wrap 复制错误并附加底层原因
+func (e *Error) Wrap(cause error) *Error {
+	cp := *e
+	cp.cause = cause
+	return &cp
+}
+
+// As 从错误链中取出*Error
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// 通用错误（通过WithMsg替换为具体提示）
+var (
+	ErrInvalidArgument    = New(CodeInvalidArgument, http.StatusBadRequest, "参数错误")
+	ErrFailedPrecondition = New(CodeFailedPrecondition, http.StatusBadRequest, "当前状态不允许该操作")
+	ErrUnauthenticated    = New(CodeUnauthenticated, http.StatusUnauthorized, "未获取到用户身份信息，请先登录")
+	ErrPermissionDenied   = New(CodePermissionDenied, http.StatusForbidden, "无权操作")
+	ErrNotFound           = New(CodeNotFound, http.StatusNotFound, "对象不存在")
+	ErrAlreadyExists      = New(CodeAlreadyExists, http.StatusConflict, "数据已存在")
+	ErrRateLimited        = New(CodeRateLimited, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+	ErrUnavailable        = New(CodeUnavailable, http.StatusServiceUnavailable, "服务暂不可用，请稍后重试")
+	ErrInternal           = New(CodeInternal, http.StatusInternalServerError, "服务器内部错误，请稍后重试")
+)
+
+// 业务错误
+var (
+	ErrUserNotFound           = New(CodeUserNotFound, http.StatusNotFound, "用户不存在")
+	ErrAccountNotFound        = New(CodeAccountNotFound, http.StatusNotFound, "未查询到用户账户信息")
+	ErrResourceNotFound       = New(CodeResourceNotFound, http.StatusNotFound, "资源不存在")
+	ErrCommentNotFound        = New(CodeCommentNotFound, http.StatusNotFound, "评论不存在")
+	ErrCategoryNotFound       = New(CodeCategoryNotFound, http.StatusNotFound, "分类不存在")
+	ErrOrderNotFound          = New(CodeOrderNotFound, http.StatusNotFound, "充值单不存在")
+	ErrInvalidCredentials     = New(CodeInvalidCredentials, http.StatusUnauthorized, "账号或密码错误")
+	ErrWrongPassword          = New(CodeWrongPassword, http.StatusBadRequest, "密码错误")
+	ErrAccountLocked          = New(CodeAccountLocked, http.StatusTooManyRequests, "登录失败次数过多，请稍后再试")
+	ErrAccountDisabled        = New(CodeAccountDisabled, http.StatusForbidden, "账号已停用，请联系管理员")
+	ErrEmailUnverified        = New(CodeEmailUnverified, http.StatusForbidden, "邮箱未验证")
+	ErrVerifyCodeInvalid      = New(CodeVerifyCodeInvalid, http.StatusBadRequest, "验证码无效")
+	ErrSendTooFrequent        = New(CodeSendTooFrequent, http.StatusTooManyRequests, "发送过于频繁，请稍后再试")
+	ErrTokenInvalid           = New(CodeTokenInvalid, http.StatusUnauthorized, "登录凭证无效，请重新登录")
+	ErrTwoFactorCodeInvalid   = New(CodeTwoFactorCodeInvalid, http.StatusBadRequest, "动态码或恢复码错误")
+	ErrTwoFactorChallenge     = New(CodeTwoFactorChallenge, http.StatusUnauthorized, "登录挑战无效或已过期，请重新登录")
+	ErrInsufficientBalance    = New(CodeInsufficientBalance, http.StatusBadRequest, "账户余额不足")
+	ErrIdempotencyConflict    = New(CodeIdempotencyConflict, http.StatusConflict, "幂等键已被用于其他请求")
+	ErrAlreadyPurchased       = New(CodeAlreadyPurchased, http.StatusConflict, "已购买该资源，无需重复购买")
+	ErrPaymentCallbackInvalid = New(CodePaymentCallbackInvalid, http.StatusBadRequest, "支付回调无效")
+)
+
+// InvalidArgument 参数错误
+func InvalidArgument(msg string) *Error {
+	return ErrInvalidArgument.WithMsg(msg)
+}
+
+// InvalidArgumentf 参数错误（格式化提示）
+func InvalidArgumentf(format string, args ...interface{}) *Error {
+	return ErrInvalidArgument.WithMsgf(format, args...)
+}
+
+// FailedPrecondition 当前状态不允许该操作
+func FailedPrecondition(msg string) *Error {
+	return ErrFailedPrecondition.WithMsg(msg)
+}
+
+// PermissionDenied 无权操作
+func PermissionDenied(msg string) *Error {
+	return ErrPermissionDenied.WithMsg(msg)
+}
+
+// NotFound 对象不存在
+func NotFound(msg string) *Error {
+	return ErrNotFound.WithMsg(msg)
+}
+
+// AlreadyExists 唯一字段已被占用
+func AlreadyExists(msg string) *Error {
+	return ErrAlreadyExists.WithMsg(msg)
+}
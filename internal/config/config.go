@@ -0,0 +1,517 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	netmail "net/mail"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// DefaultPath 默认配置文件路径（可通过环境变量CMS_CONFIG指定其他路径）
+const DefaultPath = "config/app.yaml"
+
+// EnvConfigPath 指定配置文件路径的环境变量
+const EnvConfigPath = "CMS_CONFIG"
+
+// Config 应用配置（YAML文件 + 环境变量覆盖，启动时校验）
+// 每个字段的env标签为对应的环境变量名，环境变量优先级高于配置文件；密钥类配置建议只通过环境变量注入
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Log        LogConfig        `yaml:"log"`
+	Database   DatabaseConfig   `yaml:"database"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Mail       MailConfig       `yaml:"mail"`
+	SMTP       SMTPConfig       `yaml:"smtp"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Payment    PaymentConfig    `yaml:"payment"`
+	VerifyCode VerifyCodeConfig `yaml:"verify_code"`
+	LoginGuard LoginGuardConfig `yaml:"login_guard"`
+	TwoFactor  TwoFactorConfig  `yaml:"two_factor"`
+	Search     SearchConfig     `yaml:"search"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Port            int           `yaml:"port" env:"CMS_SERVER_PORT"`                         // 监听端口
+	BaseURL         string        `yaml:"base_url" env:"CMS_SERVER_BASE_URL"`                 // 对外访问地址（用于拼接回调地址、文件访问URL），不带末尾斜杠
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"CMS_SERVER_READ_TIMEOUT"`         // 读取完整请求（含请求体）的超时时间
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"CMS_SERVER_WRITE_TIMEOUT"`       // 写出响应的超时时间（从读完请求头开始计算）
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"CMS_SERVER_IDLE_TIMEOUT"`         // keep-alive空闲连接的保持时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"CMS_SERVER_SHUTDOWN_TIMEOUT"` // 优雅关闭时等待进行中请求完成的最长时间
+	MetricsAddr     string        `yaml:"metrics_addr" env:"CMS_SERVER_METRICS_ADDR"`         // /metrics单独监听的地址（host:port，默认只监听本机；留空不暴露指标），不经过对外端口
+	TrustedProxies  []string      `yaml:"trusted_proxies" env:"CMS_SERVER_TRUSTED_PROXIES"`   // 可信反向代理的IP/CIDR（环境变量用逗号分隔）；只有来自这些地址的X-Forwarded-For/X-Real-IP才会被采信，默认为空即直接使用连接对端IP
+}
+
+// 日志输出格式
+const (
+	LogFormatJSON = "json" // 每行一个JSON对象（生产环境，便于日志平台采集）
+	LogFormatText = "text" // key=value文本（本地开发阅读）
+)
+
+// LogConfig 日志配置（log/slog，输出到标准输出）
+type LogConfig struct {
+	Level  string `yaml:"level" env:"CMS_LOG_LEVEL"`   // 最低输出级别：debug/info/warn/error
+	Format string `yaml:"format" env:"CMS_LOG_FORMAT"` // 输出格式：json/text
+}
+
+// DatabaseConfig MySQL连接配置
+type DatabaseConfig struct {
+	DSN             string        `yaml:"dsn" env:"CMS_DATABASE_DSN"`                             // 连接串（需带parseTime=True）
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"CMS_DATABASE_MAX_OPEN_CONNS"`       // 最大打开连接数
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"CMS_DATABASE_MAX_IDLE_CONNS"`       // 最大空闲连接数
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"CMS_DATABASE_CONN_MAX_LIFETIME"` // 连接最大存活时间
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"CMS_DATABASE_CONN_MAX_IDLE_TIME"`
+}
+
+// JWTConfig 登录令牌配置
+type JWTConfig struct {
+	Secret     string        `yaml:"secret" env:"CMS_JWT_SECRET"`           // 签名密钥（至少32字节）
+	Issuer     string        `yaml:"issuer" env:"CMS_JWT_ISSUER"`           // 签发者
+	AccessTTL  time.Duration `yaml:"access_ttl" env:"CMS_JWT_ACCESS_TTL"`   // 访问Token有效期
+	RefreshTTL time.Duration `yaml:"refresh_ttl" env:"CMS_JWT_REFRESH_TTL"` // 刷新令牌有效期
+}
+
+// 邮件发送方式
+const (
+	MailDriverSMTP   = "smtp"   // 通过SMTP服务器发送
+	MailDriverFile   = "file"   // 写入drop_dir目录下的.eml文件（本地开发查看邮件）
+	MailDriverMemory = "memory" // 只保存在进程内存中（联调/测试）
+)
+
+// MailConfig 邮件发送配置（Driver为空表示未启用邮件发送）
+type MailConfig struct {
+	Driver   string `yaml:"driver" env:"CMS_MAIL_DRIVER"`       // 发送方式：smtp/file/memory
+	From     string `yaml:"from" env:"CMS_MAIL_FROM"`           // 发件人地址（为空时使用smtp.username）
+	FromName string `yaml:"from_name" env:"CMS_MAIL_FROM_NAME"` // 发件人显示名称
+	DropDir  string `yaml:"drop_dir" env:"CMS_MAIL_DROP_DIR"`   // file方式的邮件保存目录
+}
+
+// SMTP连接加密方式
+const (
+	SMTPSecuritySSL      = "ssl"      // 直接TLS连接（465端口）
+	SMTPSecurityStartTLS = "starttls" // 明文连接后升级TLS（587端口）
+	SMTPSecurityNone     = "none"     // 不加密（仅用于本地SMTP测试服务器）
+)
+
+// SMTPConfig SMTP服务器配置（mail.driver为smtp时使用）
+type SMTPConfig struct {
+	Host     string        `yaml:"host" env:"CMS_SMTP_HOST"`         // SMTP服务器（如smtp.qq.com）
+	Port     int           `yaml:"port" env:"CMS_SMTP_PORT"`         // 端口（ssl一般为465，starttls一般为587）
+	Security string        `yaml:"security" env:"CMS_SMTP_SECURITY"` // 加密方式：ssl/starttls/none
+	Username string        `yaml:"username" env:"CMS_SMTP_USERNAME"` // 登录账号（一般为发件邮箱，为空时不登录）
+	Password string        `yaml:"password" env:"CMS_SMTP_PASSWORD"` // 授权码（非邮箱登录密码）
+	Timeout  time.Duration `yaml:"timeout" env:"CMS_SMTP_TIMEOUT"`   // 单封邮件发送超时
+}
+
+// StorageConfig 本地文件存储配置
+type StorageConfig struct {
+	AvatarDir       string `yaml:"avatar_dir" env:"CMS_STORAGE_AVATAR_DIR"`               // 头像保存目录
+	AvatarURLPrefix string `yaml:"avatar_url_prefix" env:"CMS_STORAGE_AVATAR_URL_PREFIX"` // 头像访问URL前缀
+	MdDir           string `yaml:"md_dir" env:"CMS_STORAGE_MD_DIR"`                       // MD文件保存目录
+	MdURLPrefix     string `yaml:"md_url_prefix" env:"CMS_STORAGE_MD_URL_PREFIX"`         // MD文件访问URL前缀（为空时由server.base_url拼接）
+}
+
+// PaymentConfig 支付渠道配置
+type PaymentConfig struct {
+	MockEnabled   bool   `yaml:"mock_enabled" env:"CMS_PAYMENT_MOCK_ENABLED"`       // 是否启用Mock渠道及/payment/mock/pay（仅本地开发联调，生产环境必须关闭）
+	MockSecret    string `yaml:"mock_secret" env:"CMS_PAYMENT_MOCK_SECRET"`         // Mock渠道回调签名密钥
+	MockNotifyURL string `yaml:"mock_notify_url" env:"CMS_PAYMENT_MOCK_NOTIFY_URL"` // Mock渠道回调地址（为空时由server.base_url拼接）
+}
+
+// 验证码存储方式
+const (
+	CodeStoreMySQL  = "mysql"  // MySQL（多实例共享）
+	CodeStoreMemory = "memory" // 进程内存（单实例/本地开发）
+)
+
+// VerifyCodeConfig 邮箱验证码配置
+type VerifyCodeConfig struct {
+	Store         string        `yaml:"store" env:"CMS_VERIFY_CODE_STORE"`                   // 存储方式：mysql/memory
+	TTL           time.Duration `yaml:"ttl" env:"CMS_VERIFY_CODE_TTL"`                       // 有效期
+	EmailCooldown time.Duration `yaml:"email_cooldown" env:"CMS_VERIFY_CODE_EMAIL_COOLDOWN"` // 同一邮箱两次发送的最小间隔
+	IPCooldown    time.Duration `yaml:"ip_cooldown" env:"CMS_VERIFY_CODE_IP_COOLDOWN"`       // 同一IP两次发送的最小间隔
+	MaxAttempts   int           `yaml:"max_attempts" env:"CMS_VERIFY_CODE_MAX_ATTEMPTS"`     // 单个验证码最多校验次数，用完需重新获取
+	SweepInterval time.Duration `yaml:"sweep_interval" env:"CMS_VERIFY_CODE_SWEEP_INTERVAL"` // 过期记录清理间隔
+}
+
+// LoginGuardConfig 密码登录防暴力破解配置
+// 同一账号/IP在失败窗口内连续失败达到阈值后临时锁定，之后每多失败一次锁定时长翻倍（不超过lockout_max）
+type LoginGuardConfig struct {
+	AccountMaxFailures int           `yaml:"account_max_failures" env:"CMS_LOGIN_GUARD_ACCOUNT_MAX_FAILURES"` // 同一账号触发锁定的连续失败次数
+	IPMaxFailures      int           `yaml:"ip_max_failures" env:"CMS_LOGIN_GUARD_IP_MAX_FAILURES"`           // 同一IP触发锁定的连续失败次数
+	FailureWindow      time.Duration `yaml:"failure_window" env:"CMS_LOGIN_GUARD_FAILURE_WINDOW"`             // 失败计数窗口（超过该时长没有失败且未锁定则重新计数）
+	LockoutBase        time.Duration `yaml:"lockout_base" env:"CMS_LOGIN_GUARD_LOCKOUT_BASE"`                 // 首次锁定时长
+	LockoutMax         time.Duration `yaml:"lockout_max" env:"CMS_LOGIN_GUARD_LOCKOUT_MAX"`                   // 最长锁定时长
+	HistoryRetention   time.Duration `yaml:"history_retention" env:"CMS_LOGIN_GUARD_HISTORY_RETENTION"`       // 登录记录保留时长
+	SweepInterval      time.Duration `yaml:"sweep_interval" env:"CMS_LOGIN_GUARD_SWEEP_INTERVAL"`             // 过期锁定/登录记录清理间隔
+}
+
+// TwoFactorConfig TOTP两步验证配置
+type TwoFactorConfig struct {
+	Issuer               string        `yaml:"issuer" env:"CMS_TWO_FACTOR_ISSUER"`                                 // 验证器App中显示的服务名称
+	EncryptionKey        string        `yaml:"encryption_key" env:"CMS_TWO_FACTOR_ENCRYPTION_KEY"`                 // TOTP密钥加密密钥（至少32字节，为空时使用jwt.secret；设置后不能再修改）
+	ChallengeTTL         time.Duration `yaml:"challenge_ttl" env:"CMS_TWO_FACTOR_CHALLENGE_TTL"`                   // 登录挑战令牌有效期
+	ChallengeMaxAttempts int           `yaml:"challenge_max_attempts" env:"CMS_TWO_FACTOR_CHALLENGE_MAX_ATTEMPTS"` // 单个挑战令牌最多校验次数
+	RecoveryCodes        int           `yaml:"recovery_codes" env:"CMS_TWO_FACTOR_RECOVERY_CODES"`                 // 每次生成的恢复码个数
+	Skew                 int           `yaml:"skew" env:"CMS_TWO_FACTOR_SKEW"`                                     // 允许的时钟偏差（前后时间步数）
+	SweepInterval        time.Duration `yaml:"sweep_interval" env:"CMS_TWO_FACTOR_SWEEP_INTERVAL"`                 // 过期挑战令牌清理间隔
+}
+
+// 资源全文检索方式
+const (
+	SearchDriverMySQL  = "mysql"  // MySQL FULLTEXT（ngram分词，多实例共享，索引由migrate up创建）
+	SearchDriverMemory = "memory" // 进程内倒排索引（启动时全量加载，单实例/小数据量）
+)
+
+// SearchConfig 资源全文检索配置
+type SearchConfig struct {
+	Driver          string        `yaml:"driver" env:"CMS_SEARCH_DRIVER"`                     // 检索方式：mysql/memory
+	RebuildInterval time.Duration `yaml:"rebuild_interval" env:"CMS_SEARCH_REBUILD_INTERVAL"` // memory方式的全量重建间隔（同步其他实例的改动）
+	SnippetLength   int           `yaml:"snippet_length" env:"CMS_SEARCH_SNIPPET_LENGTH"`     // 高亮摘要长度（字符数）
+}
+
+// Default 默认配置（不含任何密钥，DSN与密钥必须由配置文件或环境变量提供）
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8080,
+			BaseURL:         "http://localhost:8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 20 * time.Second,
+			MetricsAddr:     "127.0.0.1:9090",
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: LogFormatJSON,
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: time.Hour,
+			ConnMaxIdleTime: 30 * time.Minute,
+		},
+		JWT: JWTConfig{
+			Issuer:     "cms-interview-platform",
+			AccessTTL:  30 * time.Minute,
+			RefreshTTL: 7 * 24 * time.Hour,
+		},
+		Mail: MailConfig{
+			FromName: "CMS",
+			DropDir:  "./tmp/mail",
+		},
+		SMTP: SMTPConfig{
+			Port:     465,
+			Security: SMTPSecuritySSL,
+			Timeout:  30 * time.Second,
+		},
+		Storage: StorageConfig{
+			AvatarDir:       "./static/avatars",
+			AvatarURLPrefix: "/static/avatars/",
+			MdDir:           "./uploads/md",
+		},
+		VerifyCode: VerifyCodeConfig{
+			Store:         CodeStoreMySQL,
+			TTL:           5 * time.Minute,
+			EmailCooldown: time.Minute,
+			IPCooldown:    10 * time.Second,
+			MaxAttempts:   5,
+			SweepInterval: 10 * time.Minute,
+		},
+		LoginGuard: LoginGuardConfig{
+			AccountMaxFailures: 5,
+			IPMaxFailures:      20,
+			FailureWindow:      15 * time.Minute,
+			LockoutBase:        time.Minute,
+			LockoutMax:         time.Hour,
+			HistoryRetention:   90 * 24 * time.Hour,
+			SweepInterval:      time.Hour,
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:               "CMS",
+			ChallengeTTL:         5 * time.Minute,
+			ChallengeMaxAttempts: 5,
+			RecoveryCodes:        10,
+			Skew:                 1,
+			SweepInterval:        10 * time.Minute,
+		},
+		Search: SearchConfig{
+			Driver:          SearchDriverMySQL,
+			RebuildInterval: 10 * time.Minute,
+			SnippetLength:   120,
+		},
+	}
+}
+
+// Load 加载配置：默认值 → YAML文件 → 环境变量覆盖 → 补全派生项 → 校验
+// path为空时使用CMS_CONFIG环境变量或DefaultPath；未显式指定且默认文件不存在时只使用环境变量
+func Load(path string) (*Config, error) {
+	cfg, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadDatabase 与Load读取方式相同，但只校验数据库配置（migrate子命令使用：执行迁移不需要JWT密钥、SMTP等业务配置）
+func LoadDatabase(path string) (*Config, error) {
+	cfg, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	if errs := cfg.validateDatabase(); len(errs) > 0 {
+		return nil, fmt.Errorf("配置校验失败：\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return cfg, nil
+}
+
+// read 读取配置：默认值 → YAML文件 → 环境变量覆盖 → 补全派生项（不校验）
+func read(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(EnvConfigPath)
+		explicit = path != ""
+	}
+	if path == "" {
+		path = DefaultPath
+	}
+
+	cfg := Default()
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.NewDecoder(bytes.NewReader(data), yaml.DisallowUnknownField()).Decode(cfg); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败（%s）：%w", path, err)
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// 默认配置文件不存在：全部配置来自环境变量
+	default:
+		return nil, fmt.Errorf("读取配置文件失败（%s）：%w", path, err)
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+	cfg.fillDerived()
+	return cfg, nil
+}
+
+// applyEnv 按env标签用环境变量覆盖配置字段（递归处理嵌套结构体）
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field); err != nil {
+				return err
+			}
+			continue
+		}
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("环境变量%s格式错误：%w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField 将环境变量字符串转换为字段类型并赋值
+func setField(field reflect.Value, raw string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(raw)
+	case []string:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	default:
+		return fmt.Errorf("不支持的字段类型%s", field.Type())
+	}
+	return nil
+}
+
+// fillDerived 补全派生配置（未填写的URL由server.base_url拼接，发件人默认取smtp.username，TOTP加密密钥默认取jwt.secret）
+func (c *Config) fillDerived() {
+	c.Server.BaseURL = strings.TrimRight(c.Server.BaseURL, "/")
+	if c.Mail.From == "" {
+		c.Mail.From = c.SMTP.Username
+	}
+	if c.TwoFactor.EncryptionKey == "" {
+		c.TwoFactor.EncryptionKey = c.JWT.Secret
+	}
+	if c.Storage.MdURLPrefix == "" {
+		c.Storage.MdURLPrefix = c.Server.BaseURL + "/uploads/md/"
+	}
+	if c.Payment.MockNotifyURL == "" {
+		c.Payment.MockNotifyURL = c.Server.BaseURL + "/payment/callback/mock"
+	}
+}
+
+// Validate 校验配置（汇总全部错误一次性返回，便于启动时定位）
+func (c *Config) Validate() error {
+	var errs []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port必须在1~65535之间（当前%d）", c.Server.Port)
+	check(isHTTPURL(c.Server.BaseURL), "server.base_url必须是http(s)地址（当前%q）", c.Server.BaseURL)
+	check(c.Server.ReadTimeout > 0 && c.Server.WriteTimeout > 0 && c.Server.IdleTimeout > 0, "server.read_timeout/write_timeout/idle_timeout必须大于0")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout必须大于0")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(net.ParseIP(proxy) != nil || cidrErr == nil, "server.trusted_proxies只能填写IP或CIDR（当前%q）", proxy)
+	}
+	if c.Server.MetricsAddr != "" {
+		_, port, err := net.SplitHostPort(c.Server.MetricsAddr)
+		check(err == nil && port != "" && port != strconv.Itoa(c.Server.Port), "server.metrics_addr必须是host:port且端口不能与server.port相同（当前%q）", c.Server.MetricsAddr)
+	}
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level只能是debug/info/warn/error（当前%q）", c.Log.Level)
+	check(c.Log.Format == LogFormatJSON || c.Log.Format == LogFormatText, "log.format只能是json/text（当前%q）", c.Log.Format)
+
+	errs = append(errs, c.validateDatabase()...)
+
+	check(len(c.JWT.Secret) >= 32, "jwt.secret长度不能少于32字节（可通过%s设置）", "CMS_JWT_SECRET")
+	check(c.JWT.Issuer != "", "jwt.issuer不能为空")
+	check(c.JWT.AccessTTL > 0, "jwt.access_ttl必须大于0")
+	check(c.JWT.RefreshTTL > c.JWT.AccessTTL, "jwt.refresh_ttl必须大于access_ttl")
+
+	switch c.Mail.Driver {
+	case "":
+	case MailDriverSMTP:
+		check(c.SMTP.Host != "", "smtp.host不能为空")
+		check(c.SMTP.Port > 0 && c.SMTP.Port <= 65535, "smtp.port必须在1~65535之间（当前%d）", c.SMTP.Port)
+		check(c.SMTP.Timeout > 0, "smtp.timeout必须大于0")
+		switch c.SMTP.Security {
+		case SMTPSecuritySSL, SMTPSecurityStartTLS:
+			check(c.SMTP.Username != "", "smtp.username不能为空")
+			check(c.SMTP.Password != "", "smtp.password不能为空（可通过%s设置）", "CMS_SMTP_PASSWORD")
+		case SMTPSecurityNone:
+		default:
+			check(false, "smtp.security只能是ssl/starttls/none（当前%q）", c.SMTP.Security)
+		}
+	case MailDriverFile:
+		check(c.Mail.DropDir != "", "mail.drop_dir不能为空")
+	case MailDriverMemory:
+	default:
+		check(false, "mail.driver只能是smtp/file/memory或留空（当前%q）", c.Mail.Driver)
+	}
+	if c.Mail.Driver != "" {
+		_, err := netmail.ParseAddress(c.Mail.From)
+		check(err == nil, "mail.from不是有效的邮箱地址（当前%q）", c.Mail.From)
+	}
+
+	check(c.Storage.AvatarDir != "", "storage.avatar_dir不能为空")
+	check(strings.HasSuffix(c.Storage.AvatarURLPrefix, "/"), "storage.avatar_url_prefix必须以/结尾")
+	check(c.Storage.MdDir != "", "storage.md_dir不能为空")
+	check(strings.HasSuffix(c.Storage.MdURLPrefix, "/"), "storage.md_url_prefix必须以/结尾")
+
+	if c.Payment.MockEnabled {
+		check(len(c.Payment.MockSecret) >= 16, "payment.mock_secret长度不能少于16字节（可通过%s设置）", "CMS_PAYMENT_MOCK_SECRET")
+		check(isHTTPURL(c.Payment.MockNotifyURL), "payment.mock_notify_url必须是http(s)地址（当前%q）", c.Payment.MockNotifyURL)
+	}
+
+	check(c.VerifyCode.Store == CodeStoreMySQL || c.VerifyCode.Store == CodeStoreMemory, "verify_code.store只能是mysql/memory（当前%q）", c.VerifyCode.Store)
+	check(c.VerifyCode.TTL >= time.Minute, "verify_code.ttl不能小于1分钟")
+	check(c.VerifyCode.EmailCooldown >= 0 && c.VerifyCode.IPCooldown >= 0, "verify_code发送间隔不能为负数")
+	check(c.VerifyCode.MaxAttempts >= 1 && c.VerifyCode.MaxAttempts <= 10, "verify_code.max_attempts必须在1~10之间（当前%d）", c.VerifyCode.MaxAttempts)
+	check(c.VerifyCode.SweepInterval > 0, "verify_code.sweep_interval必须大于0")
+
+	check(c.LoginGuard.AccountMaxFailures >= 1, "login_guard.account_max_failures必须大于0（当前%d）", c.LoginGuard.AccountMaxFailures)
+	check(c.LoginGuard.IPMaxFailures >= c.LoginGuard.AccountMaxFailures, "login_guard.ip_max_failures不能小于account_max_failures（当前%d）", c.LoginGuard.IPMaxFailures)
+	check(c.LoginGuard.FailureWindow >= time.Minute, "login_guard.failure_window不能小于1分钟")
+	check(c.LoginGuard.LockoutBase >= time.Second, "login_guard.lockout_base不能小于1秒")
+	check(c.LoginGuard.LockoutMax >= c.LoginGuard.LockoutBase, "login_guard.lockout_max不能小于lockout_base")
+	check(c.LoginGuard.HistoryRetention >= 24*time.Hour, "login_guard.history_retention不能小于24小时")
+	check(c.LoginGuard.SweepInterval > 0, "login_guard.sweep_interval必须大于0")
+
+	check(c.TwoFactor.Issuer != "" && !strings.Contains(c.TwoFactor.Issuer, ":"), "two_factor.issuer不能为空且不能包含冒号（当前%q）", c.TwoFactor.Issuer)
+	check(len(c.TwoFactor.EncryptionKey) >= 32, "two_factor.encryption_key长度不能少于32字节（可通过%s设置，为空时使用jwt.secret）", "CMS_TWO_FACTOR_ENCRYPTION_KEY")
+	check(c.TwoFactor.ChallengeTTL >= time.Minute && c.TwoFactor.ChallengeTTL <= 30*time.Minute, "two_factor.challenge_ttl必须在1~30分钟之间")
+	check(c.TwoFactor.ChallengeMaxAttempts >= 1 && c.TwoFactor.ChallengeMaxAttempts <= 10, "two_factor.challenge_max_attempts必须在1~10之间（当前%d）", c.TwoFactor.ChallengeMaxAttempts)
+	check(c.TwoFactor.RecoveryCodes >= 4 && c.TwoFactor.RecoveryCodes <= 20, "two_factor.recovery_codes必须在4~20之间（当前%d）", c.TwoFactor.RecoveryCodes)
+	check(c.TwoFactor.Skew >= 0 && c.TwoFactor.Skew <= 2, "two_factor.skew必须在0~2之间（当前%d）", c.TwoFactor.Skew)
+	check(c.TwoFactor.SweepInterval > 0, "two_factor.sweep_interval必须大于0")
+
+	check(c.Search.Driver == SearchDriverMySQL || c.Search.Driver == SearchDriverMemory, "search.driver只能是mysql/memory（当前%q）", c.Search.Driver)
+	check(c.Search.RebuildInterval >= time.Minute, "search.rebuild_interval不能小于1分钟")
+	check(c.Search.SnippetLength >= 20 && c.Search.SnippetLength <= 500, "search.snippet_length必须在20~500之间（当前%d）", c.Search.SnippetLength)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败：\n  - %s", strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// validateDatabase 校验数据库配置，返回全部错误描述
+func (c *Config) validateDatabase() []string {
+	var errs []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+	check(c.Database.DSN != "", "database.dsn不能为空（可通过%s设置）", "CMS_DATABASE_DSN")
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns必须大于0")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.max_idle_conns必须在0~max_open_conns之间")
+	check(c.Database.ConnMaxLifetime >= 0 && c.Database.ConnMaxIdleTime >= 0, "database连接存活时间不能为负数")
+	return errs
+}
+
+// Addr HTTP监听地址（如:8080）
+func (s ServerConfig) Addr() string {
+	return ":" + strconv.Itoa(s.Port)
+}
+
+// isHTTPURL 判断是否为带主机名的http(s)地址
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// validConfig 默认配置补齐必填项后的合法配置
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.DSN = "user:pass@tcp(127.0.0.1:3306)/cms?parseTime=True"
+	cfg.JWT.Secret = testSecret
+	cfg.fillDerived()
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // 期望错误包含的内容，为空表示校验通过
+	}{
+		{"默认配置补齐必填项", func(c *Config) {}, ""},
+		{"缺少DSN", func(c *Config) { c.Database.DSN = "" }, "database.dsn不能为空"},
+		{"缺少JWT密钥", func(c *Config) { c.JWT.Secret = "" }, "jwt.secret长度不能少于32字节"},
+		{"JWT密钥过短", func(c *Config) { c.JWT.Secret = testSecret[:31] }, "jwt.secret长度不能少于32字节"},
+		{"TOTP加密密钥过短", func(c *Config) { c.TwoFactor.EncryptionKey = "short" }, "two_factor.encryption_key长度不能少于32字节"},
+		{"启用Mock渠道缺少密钥", func(c *Config) { c.Payment.MockEnabled = true }, "payment.mock_secret长度不能少于16字节"},
+		{"未启用Mock渠道不校验密钥", func(c *Config) { c.Payment.MockSecret = "" }, ""},
+		{"SMTP缺少授权码", func(c *Config) {
+			c.Mail.Driver = MailDriverSMTP
+			c.SMTP.Host, c.SMTP.Username = "smtp.example.com", "noreply@example.com"
+			c.Mail.From = c.SMTP.Username
+		}, "smtp.password不能为空"},
+		{"刷新令牌有效期不大于访问令牌", func(c *Config) { c.JWT.RefreshTTL = c.JWT.AccessTTL }, "jwt.refresh_ttl必须大于access_ttl"},
+		{"超时为0", func(c *Config) { c.Server.ReadTimeout = 0 }, "server.read_timeout"},
+		{"可信代理为IP与CIDR", func(c *Config) { c.Server.TrustedProxies = []string{"127.0.0.1", "10.0.0.0/8"} }, ""},
+		{"可信代理格式错误", func(c *Config) { c.Server.TrustedProxies = []string{"proxy.local"} }, "server.trusted_proxies只能填写IP或CIDR"},
+		{"指标端口与服务端口相同", func(c *Config) { c.Server.MetricsAddr = "127.0.0.1:8080" }, "server.metrics_addr"},
+		{"未知日志级别", func(c *Config) { c.Log.Level = "verbose" }, "log.level"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			tc.modify(cfg)
+			err := cfg.Validate()
+			if tc.want == "" {
+				if err != nil {
+					t.Fatalf("期望校验通过，实际返回：%v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("返回%v，期望包含%q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateCollectsAllErrors(t *testing.T) {
+	cfg := Default()
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("缺少DSN与JWT密钥时应校验失败")
+	}
+	for _, want := range []string{"database.dsn", "jwt.secret"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误信息缺少%s：%v", want, err)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cases := []struct {
+		env   string
+		value string
+		got   func(c *Config) any
+		want  any
+	}{
+		{"CMS_SERVER_PORT", " 9000 ", func(c *Config) any { return c.Server.Port }, 9000},
+		{"CMS_DATABASE_DSN", "env-dsn", func(c *Config) any { return c.Database.DSN }, "env-dsn"},
+		{"CMS_JWT_ACCESS_TTL", "45m", func(c *Config) any { return c.JWT.AccessTTL }, 45 * time.Minute},
+		{"CMS_VERIFY_CODE_IP_COOLDOWN", "1m30s", func(c *Config) any { return c.VerifyCode.IPCooldown }, 90 * time.Second},
+		{"CMS_PAYMENT_MOCK_ENABLED", "true", func(c *Config) any { return c.Payment.MockEnabled }, true},
+		{"CMS_SERVER_TRUSTED_PROXIES", " 10.0.0.1, ,192.168.0.0/16,", func(c *Config) any { return c.Server.TrustedProxies }, []string{"10.0.0.1", "192.168.0.0/16"}},
+		{"CMS_SERVER_TRUSTED_PROXIES", "", func(c *Config) any { return c.Server.TrustedProxies }, []string(nil)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.env+"="+tc.value, func(t *testing.T) {
+			t.Setenv(tc.env, tc.value)
+			cfg := Default()
+			if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+				t.Fatalf("applyEnv返回错误：%v", err)
+			}
+			if got := tc.got(cfg); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s=%q：字段值=%v，期望%v", tc.env, tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	cases := []struct {
+		env   string
+		value string
+	}{
+		{"CMS_SERVER_PORT", "80a"},
+		{"CMS_JWT_ACCESS_TTL", "30"},
+		{"CMS_SMTP_TIMEOUT", "ten seconds"},
+		{"CMS_PAYMENT_MOCK_ENABLED", "yes"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.env, func(t *testing.T) {
+			t.Setenv(tc.env, tc.value)
+			err := applyEnv(reflect.ValueOf(Default()).Elem())
+			if err == nil || !strings.Contains(err.Error(), tc.env) {
+				t.Fatalf("%s=%q：返回%v，期望指明环境变量的格式错误", tc.env, tc.value, err)
+			}
+		})
+	}
+}
+
+func TestLoadExampleWithEnvSecrets(t *testing.T) {
+	// 示例配置不含任何密钥：只有通过环境变量注入后才能通过校验
+	path := filepath.Join("..", "..", "config", "example.yaml")
+	if _, err := Load(path); err == nil {
+		t.Fatal("未注入DSN与密钥时示例配置应校验失败")
+	}
+
+	t.Setenv("CMS_DATABASE_DSN", "user:pass@tcp(127.0.0.1:3306)/cms?parseTime=True")
+	t.Setenv("CMS_JWT_SECRET", testSecret)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("注入DSN与密钥后加载示例配置失败：%v", err)
+	}
+	if cfg.TwoFactor.EncryptionKey != testSecret {
+		t.Error("two_factor.encryption_key为空时应使用jwt.secret")
+	}
+}
+
+func TestLoadDatabaseOnlyChecksDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("database:\n  max_open_conns: 5\n  max_idle_conns: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDatabase(path); err == nil || !strings.Contains(err.Error(), "database.dsn") {
+		t.Fatalf("缺少DSN时返回%v，期望提示database.dsn", err)
+	}
+
+	// 只提供DSN：migrate可以运行，完整启动仍因缺少JWT密钥失败
+	t.Setenv("CMS_DATABASE_DSN", "user:pass@tcp(127.0.0.1:3306)/cms?parseTime=True")
+	cfg, err := LoadDatabase(path)
+	if err != nil {
+		t.Fatalf("LoadDatabase返回错误：%v", err)
+	}
+	if cfg.Database.MaxOpenConns != 5 {
+		t.Errorf("max_open_conns=%d，期望取配置文件中的5", cfg.Database.MaxOpenConns)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "jwt.secret") {
+		t.Fatalf("Load返回%v，期望提示jwt.secret", err)
+	}
+}
+
+func TestLoadRejectsUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  prot: 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDatabase(path); err == nil {
+		t.Fatal("配置文件包含未知字段时应报错")
+	}
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"database/sql"
+	"encoding/json"
 
 	"github.com/shopspring/decimal"
 )
@@ -9,23 +10,24 @@ import (
 // RegisterRequest 注册请求参数
 // @Description 用户注册接口的请求参数，用户名/密码为必填，邮箱/手机号二选一（可选），其余字段为可选
 type RegisterRequest struct {
-	Username  string         `json:"username" binding:"required,min=3,max=50" example:"test_user"`   // 用户名（必填，3-50位）
-	Password  string         `json:"password" binding:"required,min=6,max=20" example:"123456Ab"`    // 密码（必填，6-20位）
-	Email     string         `json:"email" binding:"omitempty,email" example:"test@example.com"`     // 邮箱（可选，需符合邮箱格式）
-	Phone     string         `json:"phone" binding:"omitempty,phone" example:"13800138000"`          // 手机号（可选，需符合11位国内手机号格式）
-	Role      string         `json:"role" binding:"omitempty,oneof=candidate hr admin" example:"hr"` // 角色（可选，仅支持candidate/hr/admin）
-	BirthDate sql.NullString `gorm:"column:birth_date" json:"birth_date" example:"1990-01-01"`       // 出生日期（可选，格式YYYY-MM-DD）
-	RealName  string         `json:"real_name" binding:"omitempty,max=10" example:"张三"`              // 真实姓名（可选，最多10位）
-	Gender    string         `json:"gender" binding:"omitempty,oneof=0 1 2" example:"1"`             // 性别（可选，0-未知/1-男/2-女）
-	AvatarURL string         `json:"avatar_url" example:"https://example.com/avatar.jpg"`            // 头像URL（可选）
+	Username  string         `json:"username" binding:"required,min=3,max=50" example:"test_user"` // 用户名（必填，3-50位）
+	Password  string         `json:"password" binding:"required,min=6,max=20" example:"123456Ab"`  // 密码（必填，6-20位）
+	Email     string         `json:"email" binding:"omitempty,email" example:"test@example.com"`   // 邮箱（可选，需符合邮箱格式）
+	Phone     string         `json:"phone" binding:"omitempty,phone" example:"13800138000"`        // 手机号（可选，需符合11位国内手机号格式）
+	Role      string         `json:"role" binding:"omitempty,oneof=candidate hr" example:"hr"`     // 角色（可选，仅支持candidate/hr；管理员不允许自行注册）
+	BirthDate sql.NullString `gorm:"column:birth_date" json:"birth_date" example:"1990-01-01"`     // 出生日期（可选，格式YYYY-MM-DD）
+	RealName  string         `json:"real_name" binding:"omitempty,max=10" example:"张三"`            // 真实姓名（可选，最多10位）
+	Gender    string         `json:"gender" binding:"omitempty,oneof=0 1 2" example:"1"`           // 性别（可选，0-未知/1-男/2-女）
+	AvatarURL string         `json:"avatar_url" example:"https://example.com/avatar.jpg"`          // 头像URL（可选）
 }
 
 // RegisterResponse 注册响应参数
 // @Description 用户注册成功后返回的信息
 type RegisterResponse struct {
-	UserID   uint64 `json:"user_id" example:"10001"`      // 用户主键ID
-	Username string `json:"username" example:"test_user"` // 用户名
-	Role     string `json:"role" example:"hr"`            // 用户角色
+	UserID          uint64 `json:"user_id" example:"10001"`          // 用户主键ID
+	Username        string `json:"username" example:"test_user"`     // 用户名
+	Role            string `json:"role" example:"hr"`                // 用户角色
+	EmailVerifySent bool   `json:"email_verify_sent" example:"true"` // 是否已向注册邮箱发送验证码（登录后调用邮箱验证接口确认）
 }
 
 // LoginRequest 登录请求参数
@@ -40,10 +42,21 @@ type LoginRequest struct {
 // LoginResponse 登录响应参数
 // @Description 用户登录成功后返回的信息，包含登录凭证Token
 type LoginResponse struct {
-	Token    string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // 登录凭证Token
-	UserID   uint64 `json:"user_id" example:"10001"`                                 // 用户主键ID
-	Username string `json:"username" example:"test_user"`                            // 用户名
-	Role     string `json:"role" example:"hr"`                                       // 用户角色
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`     // 登录凭证Token
+	RefreshToken string `json:"refresh_token" example:"q3Vx8yN0c2VjcmV0LXJlZnJlc2gtdG9rZW4"` // 刷新令牌（访问Token过期后调用/staff/refresh换取新Token，每次刷新都会轮换）
+	ExpiresIn    int64  `json:"expires_in" example:"1800"`                                   // 访问Token有效期（秒）
+	UserID       uint64 `json:"user_id" example:"10001"`                                     // 用户主键ID
+	Username     string `json:"username" example:"test_user"`                                // 用户名
+	Role         string `json:"role" example:"hr"`                                           // 用户角色
+
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty" example:"false"`                    // 账号已开启两步验证：本次未签发Token，需凭challenge_token调用/staff/login/2fa
+	ChallengeToken     string `json:"challenge_token,omitempty" example:"bG9naW4tY2hhbGxlbmdlLXRva2Vu"` // 两步验证挑战令牌（仅two_factor_required为true时返回）
+	ChallengeExpiresIn int64  `json:"challenge_expires_in,omitempty" example:"300"`                     // 挑战令牌有效期（秒）
+}
+
+// RefreshTokenReq 刷新Token请求参数
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"q3Vx8yN0c2VjcmV0LXJlZnJlc2gtdG9rZW4"` // 刷新令牌（必填）
 }
 
 // JobsRequest 职位管理请求参数
@@ -68,11 +81,13 @@ type UpdateUserReq struct {
 }
 
 // CommonResponse 通用响应体
-// @Description 接口通用返回格式，所有接口统一使用该结构体返回（除特殊定制接口）
+// @Description 接口通用返回格式，所有接口统一使用该结构体返回；失败时error为机器可读错误码（见apperr包），客户端按错误码分支处理，msg仅用于展示
 type CommonResponse struct {
-	Code int         `json:"code" example:"200"`    // 响应码：200成功/400参数错误/401未登录/404不存在/500系统错误
-	Msg  string      `json:"msg" example:"success"` // 提示信息（成功/失败原因）
-	Data interface{} `json:"data"`                  // 响应数据（成功时返回具体内容，失败时为nil）
+	Code      int         `json:"code" example:"200"`                                                  // 响应码（与HTTP状态码一致）：200成功/400参数错误/401未登录/403无权限/404不存在/409冲突/429过于频繁/500系统错误
+	Msg       string      `json:"msg" example:"success"`                                               // 提示信息（成功/失败原因）
+	Data      interface{} `json:"data"`                                                                // 响应数据（成功时返回具体内容，失败时为nil）
+	Error     string      `json:"error,omitempty" example:"USER_NOT_FOUND"`                            // 错误码（仅失败时返回）
+	RequestID string      `json:"request_id,omitempty" example:"3f1c2a9e-6b1d-4c8e-9a57-1e2f3d4c5b6a"` // 请求ID（仅失败时返回，排查问题时提供给后端）
 }
 
 // UpdateAvatarReq 更新头像请求参数
@@ -87,6 +102,8 @@ type UpdateAvatarReq struct {
 type RechargeRequest struct {
 	UserUUID string          `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 用户UUID（由中间件覆盖，前端无需传）
 	Amount   decimal.Decimal `json:"amount" binding:"required" example:"100.00"`          // 充值金额（必填，必须大于0，保留2位小数）
+	// IdempotencyKey 幂等键（可选，也可通过Idempotency-Key请求头传入；同一键重复提交只充值一次）
+	IdempotencyKey string `json:"idempotency_key" binding:"omitempty,max=64" example:"recharge-20260107-0001"`
 }
 
 // DeductRequest 账户余额扣减请求参数
@@ -94,6 +111,8 @@ type RechargeRequest struct {
 type DeductRequest struct {
 	UserUUID string          `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 用户UUID（由中间件覆盖，前端无需传）
 	Amount   decimal.Decimal `json:"amount" binding:"required" example:"50.00"`           // 扣减金额（必填，必须大于0，保留2位小数）
+	// IdempotencyKey 幂等键（可选，也可通过Idempotency-Key请求头传入；客户端重试时复用同一键不会重复扣款）
+	IdempotencyKey string `json:"idempotency_key" binding:"omitempty,max=64" example:"order-20260107-0001"`
 }
 
 // AccountResponse 账户信息响应参数
@@ -103,14 +122,67 @@ type AccountResponse struct {
 	Balance       decimal.Decimal `json:"balance" example:"950.00"`                            // 当前账户余额（保留2位小数）
 	TotalRecharge decimal.Decimal `json:"total_recharge" example:"1000.00"`                    // 累计充值金额（保留2位小数）
 	TotalConsume  decimal.Decimal `json:"total_consume" example:"50.00"`                       // 累计消费金额（保留2位小数）
+	TransactionID uint64          `json:"transaction_id,omitempty" example:"1"`                // 本次充值/扣减对应的流水ID（仅充值/扣减接口返回）
+	Replayed      bool            `json:"replayed,omitempty" example:"false"`                  // 是否为幂等重放（幂等键已处理过，本次未变动余额）
+}
+
+// RechargeOrderResp 充值单响应参数
+// @Description 充值接口返回待支付充值单，前端跳转/调用pay_url完成支付；支付渠道回调验签通过后才入账
+type RechargeOrderResp struct {
+	OrderNo    string          `json:"order_no" example:"R20260107153000a1b2c3d4e5f6"`               // 充值单号
+	Amount     decimal.Decimal `json:"amount" example:"100.00"`                                      // 充值金额
+	Provider   string          `json:"provider" example:"mock"`                                      // 支付渠道
+	Status     string          `json:"status" example:"pending"`                                     // 状态（pending待支付/paid已支付/failed支付失败）
+	PayURL     string          `json:"pay_url,omitempty" example:"/payment/mock/pay?order_no=R2026"` // 支付链接（仅待支付状态返回）
+	CreateTime string          `json:"create_time" example:"2026-01-07 15:30:00"`                    // 创建时间
+	PaidTime   string          `json:"paid_time,omitempty" example:"2026-01-07 15:31:00"`            // 支付完成时间
+	Replayed   bool            `json:"replayed,omitempty" example:"false"`                           // 是否为幂等重放（幂等键已创建过充值单，本次返回原充值单）
+}
+
+// RechargeOrderReq 充值单查询/模拟支付请求参数
+type RechargeOrderReq struct {
+	OrderNo string `form:"order_no" binding:"required,max=64" example:"R20260107153000a1b2c3d4e5f6"` // 充值单号（必填）
+}
+
+// TransactionListReq 账户流水查询请求参数
+// @Description 分页查询当前用户账户流水（对账单），可按类型过滤
+type TransactionListReq struct {
+	Page int    `form:"page" binding:"omitempty,gte=1" example:"1"`         // 页码（默认1）
+	Size int    `form:"size" binding:"omitempty,gte=1,lte=50" example:"10"` // 每页条数（默认10，最大50）
+	Type string `form:"type" example:"recharge"`                            // 流水类型（可选：recharge/deduct/purchase/sale/adjust）
+}
+
+// TransactionItem 账户流水项
+// @Description 单条账户流水，amount收入为正、支出为负
+type TransactionItem struct {
+	ID           uint64          `json:"id" example:"1"`                            // 流水ID
+	Type         string          `json:"type" example:"purchase"`                   // 流水类型
+	Amount       decimal.Decimal `json:"amount" example:"-9.90"`                    // 变动金额（收入为正、支出为负）
+	BalanceAfter decimal.Decimal `json:"balance_after" example:"90.10"`             // 变动后余额
+	ReferenceID  string          `json:"reference_id" example:"12"`                 // 关联业务ID（如购买记录ID）
+	Remark       string          `json:"remark" example:"购买资源《Go入门教程》"`             // 备注
+	CreateTime   string          `json:"create_time" example:"2026-01-07 15:30:00"` // 流水时间
+}
+
+// TransactionListResp 账户流水分页响应
+// @Description 账户流水列表及分页信息
+type TransactionListResp struct {
+	List  []TransactionItem `json:"list"`                // 流水列表
+	Total int64             `json:"total" example:"100"` // 总条数
+	Page  int               `json:"page" example:"1"`    // 当前页码
+	Size  int               `json:"size" example:"10"`   // 每页条数
 }
 
 // CreateResourceReq 创建资源请求参数
 // @Description 用户创建文本/代码资源接口的请求参数，标题必填，其余字段可选
 type CreateResourceReq struct {
-	Title       string `json:"title" binding:"required,max=255" example:"Go入门教程"`               // 资源标题（必填，最多255位）
-	TextContent string `json:"text_content" example:"Go基础语法讲解..."`                              // 文本内容（可选）
-	CodeContent string `json:"code_content" example:"package main\nimport fmt\nfunc main() {}"` // 代码内容（可选）
+	Title       string          `json:"title" binding:"required,max=255" example:"Go入门教程"`               // 资源标题（必填，最多255位）
+	TextContent string          `json:"text_content" example:"Go基础语法讲解..."`                              // 文本内容（可选）
+	CodeContent string          `json:"code_content" example:"package main\nimport fmt\nfunc main() {}"` // 代码内容（可选）
+	Price       decimal.Decimal `json:"price" example:"9.90"`                                            // 价格（可选，默认0免费，最多两位小数）
+	Language    string          `json:"language" binding:"max=20" example:"go"`                          // 编程语言（可选，不传时按代码内容自动识别）
+	CategoryID  uint64          `json:"category_id" example:"3"`                                         // 所属分类ID（可选，0为未分类）
+	Tags        []string        `json:"tags" example:"gin,入门"`                                           // 标签（可选，最多5个，每个不超过20个字符，不区分大小写）
 	// UserID由中间件从Token解析，不接收前端传参，避免伪造
 }
 
@@ -132,51 +204,262 @@ type ResourceIDReq struct {
 	ID uint64 `json:"id" binding:"required,min=1" example:"1"` // 资源ID（必填，最小为1）
 }
 
+// SetPriceReq 修改资源价格请求参数
+// @Description 作者修改自己资源的价格，0表示免费
+type SetPriceReq struct {
+	ID    uint64          `json:"id" binding:"required,min=1" example:"1"` // 资源ID（必填，最小为1）
+	Price decimal.Decimal `json:"price" example:"9.90"`                    // 新价格（0~9999.99，最多两位小数）
+}
+
+// UpdateResourceMetaReq 修改资源标签/语言/分类请求参数
+// @Description 作者修改自己资源的元信息，标签整体替换；language为空时按代码内容重新识别
+type UpdateResourceMetaReq struct {
+	ID         uint64   `json:"id" binding:"required,min=1" example:"1"` // 资源ID（必填，最小为1）
+	Language   string   `json:"language" binding:"max=20" example:"go"`  // 编程语言（可选，为空时自动识别）
+	CategoryID uint64   `json:"category_id" example:"3"`                 // 所属分类ID（0为未分类）
+	Tags       []string `json:"tags" example:"gin,入门"`                   // 标签（最多5个，为空表示清除全部标签）
+}
+
+// ResourceMetaResp 资源元信息响应参数
+// @Description 发布或修改资源后返回规范化后的标签、识别出的编程语言及分类
+type ResourceMetaResp struct {
+	ID           uint64   `json:"id" example:"1"`             // 资源ID
+	Language     string   `json:"language" example:"go"`      // 编程语言标识（空为未识别）
+	LanguageName string   `json:"language_name" example:"Go"` // 编程语言展示名称
+	CategoryID   uint64   `json:"category_id" example:"3"`    // 所属分类ID（0为未分类）
+	Tags         []string `json:"tags" example:"gin,入门"`      // 标签（小写、去重）
+}
+
+// PurchaseResp 购买资源响应参数
+// @Description 购买成功后返回成交价格、买家最新余额及完整代码
+type PurchaseResp struct {
+	ID          uint64          `json:"id" example:"1"`                                  // 资源ID
+	Price       decimal.Decimal `json:"price" example:"9.90"`                            // 成交价格
+	Balance     decimal.Decimal `json:"balance" example:"90.10"`                         // 买家购买后余额
+	CodeContent string          `json:"code_content" example:"package main\nimport fmt"` // 完整代码内容
+}
+
+// ViewResp 浏览量接口响应参数
+// @Description 返回本次浏览是否计入（去重窗口内重复浏览不计入）
+type ViewResp struct {
+	ID      uint64 `json:"id" example:"1"`         // 资源ID
+	Counted bool   `json:"counted" example:"true"` // 本次浏览是否计入浏览量
+}
+
+// LikeResp 点赞/取消点赞响应参数
+// @Description 返回操作后的点赞状态与最新点赞量
+type LikeResp struct {
+	ID        uint64 `json:"id" example:"1"`          // 资源ID
+	Liked     bool   `json:"liked" example:"true"`    // 当前用户是否已点赞
+	LikeCount uint64 `json:"like_count" example:"51"` // 最新点赞量（由点赞明细计数得出）
+}
+
 // CommentReq 评论请求参数
-// @Description 提交资源评论的请求参数
+// @Description 提交资源评论的请求参数，传parent_id即为回复某条评论；内容中的@用户名会被解析为提及
 type CommentReq struct {
-	ID      uint64 `json:"id" binding:"required,min=1" example:"1"`             // 资源ID（必填，最小为1）
-	Content string `json:"content" binding:"required,min=1" example:"这篇教程很实用！"` // 评论内容（必填，非空）
+	ID       uint64 `json:"id" binding:"required,min=1" example:"1"`                    // 资源ID（必填，最小为1）
+	ParentID uint64 `json:"parent_id" example:"0"`                                      // 被回复的评论ID（可选，0或不传表示直接评论资源）
+	Content  string `json:"content" binding:"required,min=1" example:"@test_user 说得对！"` // 评论内容（必填，非空）
+}
+
+// CommentItem 评论列表项参数
+// @Description 单条评论的展示参数（评论者信息取自JWT，前端无法伪造）
+type CommentItem struct {
+	ID         uint64   `json:"id" example:"1"`                            // 评论主键ID
+	ResourceID uint64   `json:"resource_id" example:"1001"`                // 所属资源ID
+	ParentID   uint64   `json:"parent_id" example:"0"`                     // 父评论ID（0表示直接评论资源）
+	RootID     uint64   `json:"root_id" example:"0"`                       // 所属楼层顶层评论ID（顶层评论为0）
+	Depth      int      `json:"depth" example:"0"`                         // 回复层级（顶层评论为0）
+	UserID     uint64   `json:"user_id" example:"10001"`                   // 评论者用户ID
+	Username   string   `json:"username" example:"test_user"`              // 评论者用户名
+	Content    string   `json:"content" example:"这篇教程很实用！"`                // 评论内容
+	CreateTime string   `json:"create_time" example:"2026-01-07 15:30:00"` // 评论时间（格式YYYY-MM-DD HH:MM:SS）
+	UpdateTime string   `json:"update_time" example:"2026-01-07 15:30:00"` // 最后编辑时间（格式YYYY-MM-DD HH:MM:SS）
+	Mentions   []string `json:"mentions,omitempty" example:"test_user"`    // 成功解析的@用户名（仅创建/编辑时返回）
+}
+
+// CommentNode 评论树节点参数
+// @Description 评论楼层中的单个节点，replies为直接回复（超出查询层级时为空，reply_count仍返回直接回复数）
+type CommentNode struct {
+	CommentItem
+	ReplyCount int            `json:"reply_count" example:"2"` // 直接回复数
+	Replies    []*CommentNode `json:"replies"`                 // 直接回复（按时间正序）
+}
+
+// CommentTreeReq 评论树查询请求参数
+// @Description 按资源ID分页查询顶层评论及其回复树（Query参数），depth控制返回的回复层级
+type CommentTreeReq struct {
+	ID    uint64 `form:"id" binding:"required,min=1" example:"1"`            // 资源ID（必填，最小为1）
+	Page  int    `form:"page" binding:"omitempty,gte=1" example:"1"`         // 页码（可选，按顶层评论分页）
+	Size  int    `form:"size" binding:"omitempty,gte=1,lte=50" example:"10"` // 每页顶层评论条数（可选，1~50之间）
+	Depth int    `form:"depth" binding:"omitempty,gte=1,lte=5" example:"3"`  // 返回的回复层级（可选，1~5，默认3）
+}
+
+// CommentTreeResp 评论树响应参数
+// @Description 评论树分页查询接口返回的参数（total为顶层评论总数）
+type CommentTreeResp struct {
+	List  []*CommentNode `json:"list"`               // 顶层评论及其回复树
+	Total int64          `json:"total" example:"25"` // 顶层评论总条数
+	Page  int            `json:"page" example:"1"`   // 当前页码
+	Size  int            `json:"size" example:"10"`  // 每页条数
+	Depth int            `json:"depth" example:"3"`  // 实际返回的回复层级
+}
+
+// MentionItem @提及提醒项参数
+// @Description 单条@提及提醒，包含提及所在的评论
+type MentionItem struct {
+	ID         uint64      `json:"id" example:"1"`                            // 提及记录ID
+	IsRead     bool        `json:"is_read" example:"false"`                   // 是否已读
+	CreateTime string      `json:"create_time" example:"2026-01-07 15:30:00"` // 提及时间
+	Comment    CommentItem `json:"comment"`                                   // 提及所在的评论
+}
+
+// MentionListReq @提及列表查询请求参数
+// @Description 分页查询@到当前用户的提醒（Query参数）
+type MentionListReq struct {
+	Page int `form:"page" binding:"omitempty,gte=1" example:"1"`         // 页码（可选，默认1）
+	Size int `form:"size" binding:"omitempty,gte=1,lte=50" example:"10"` // 每页条数（可选，默认10）
+}
+
+// MentionListResp @提及列表响应参数
+// @Description @提及提醒分页查询接口返回的参数
+type MentionListResp struct {
+	List   []MentionItem `json:"list"`               // 提醒列表
+	Total  int64         `json:"total" example:"12"` // 提醒总条数
+	Unread int64         `json:"unread" example:"3"` // 未读条数
+	Page   int           `json:"page" example:"1"`   // 当前页码
+	Size   int           `json:"size" example:"10"`  // 每页条数
+}
+
+// CommentListReq 评论列表查询请求参数
+// @Description 按资源ID分页查询评论（Query参数），页码/条数不传时默认第1页、每页10条
+type CommentListReq struct {
+	ID   uint64 `form:"id" binding:"required,min=1" example:"1"`            // 资源ID（必填，最小为1）
+	Page int    `form:"page" binding:"omitempty,gte=1" example:"1"`         // 页码（可选，最小为1）
+	Size int    `form:"size" binding:"omitempty,gte=1,lte=50" example:"10"` // 每页条数（可选，1~50之间）
+}
+
+// CommentListResp 评论列表响应参数
+// @Description 评论列表分页查询接口返回的参数
+type CommentListResp struct {
+	List  []CommentItem `json:"list"`               // 评论列表
+	Total int64         `json:"total" example:"25"` // 评论总条数
+	Page  int           `json:"page" example:"1"`   // 当前页码
+	Size  int           `json:"size" example:"10"`  // 每页条数
+}
+
+// UpdateCommentReq 编辑评论请求参数
+// @Description 评论作者编辑自己的评论内容
+type UpdateCommentReq struct {
+	ID      uint64 `json:"id" binding:"required,min=1" example:"1"`           // 评论ID（必填，最小为1）
+	Content string `json:"content" binding:"required,min=1" example:"更新后的评论"` // 新评论内容（必填，非空）
+}
+
+// CommentIDReq 评论ID请求参数
+// @Description 仅包含评论ID的请求参数（用于删除评论等接口）
+type CommentIDReq struct {
+	ID uint64 `json:"id" binding:"required,min=1" example:"1"` // 评论ID（必填，最小为1）
 }
 
 // ResourceListResp 资源列表响应参数
 // @Description 资源列表分页查询接口返回的参数
 type ResourceListResp struct {
-	List  []ResourceItem `json:"list"`                // 资源列表（包含点赞/浏览/评论量）
-	Total int64          `json:"total" example:"100"` // 资源总条数
-	Page  int            `json:"page" example:"1"`    // 当前页码
-	Size  int            `json:"size" example:"10"`   // 每页条数
+	List   []ResourceItem  `json:"list"`                // 资源列表（包含点赞/浏览/评论量）
+	Total  int64           `json:"total" example:"100"` // 资源总条数
+	Page   int             `json:"page" example:"1"`    // 当前页码
+	Size   int             `json:"size" example:"10"`   // 每页条数
+	Facets *ResourceFacets `json:"facets"`              // 分面统计（语言/分类/标签）
+}
+
+// ResourceFacets 资源列表分面统计
+// @Description 按当前检索/过滤条件统计各维度的资源数；统计某一维度时忽略该维度自身的过滤条件，便于在同一维度内切换选项
+type ResourceFacets struct {
+	Languages  []FacetCount    `json:"languages"`  // 编程语言（按资源数降序，不含未识别语言）
+	Categories []CategoryFacet `json:"categories"` // 分类（按分类树先序排列，资源数包含子孙分类，不含资源数为0的分类）
+	Tags       []FacetCount    `json:"tags"`       // 标签（按资源数降序，最多20个）
 }
 
-// Response 通用响应结构体
-// @Description 接口通用返回格式（替代CommonResponse的另一种格式，字段命名更贴合前端习惯）
-type Response struct {
-	Code    int         `json:"code" example:"200"`        // 响应码：200成功/400参数错误/401未登录/404不存在/500系统错误
-	Message string      `json:"message" example:"success"` // 提示信息（成功/失败原因）
-	Data    interface{} `json:"data"`                      // 响应数据（成功时返回具体内容，失败时为nil）
+// FacetCount 分面统计项
+type FacetCount struct {
+	Value string `json:"value" example:"go"` // 筛选值（作为language/tag参数传入）
+	Label string `json:"label" example:"Go"` // 展示名称
+	Count int64  `json:"count" example:"12"` // 资源数
+}
+
+// CategoryFacet 分类分面统计项
+type CategoryFacet struct {
+	ID       uint64 `json:"id" example:"3"`        // 分类ID（作为category_id参数传入）
+	ParentID uint64 `json:"parent_id" example:"1"` // 父分类ID（0为一级分类）
+	Name     string `json:"name" example:"Web开发"`  // 分类名称
+	Depth    int    `json:"depth" example:"2"`     // 层级（一级分类为1）
+	Count    int64  `json:"count" example:"8"`     // 资源数（含子孙分类）
+}
+
+// CategoryNode 分类树节点
+// @Description 资源分类树，children按sort_order升序排列
+type CategoryNode struct {
+	ID        uint64          `json:"id" example:"1"`         // 分类ID
+	ParentID  uint64          `json:"parent_id" example:"0"`  // 父分类ID（0为一级分类）
+	Name      string          `json:"name" example:"后端开发"`    // 分类名称
+	SortOrder int             `json:"sort_order" example:"0"` // 同级排序
+	Children  []*CategoryNode `json:"children"`               // 子分类
+}
+
+// LanguageItem 支持的编程语言
+type LanguageItem struct {
+	ID   string `json:"id" example:"go"`   // 语言标识
+	Name string `json:"name" example:"Go"` // 展示名称
+}
+
+// TaxonomyResp 资源分类体系响应参数
+// @Description 分类树与支持的编程语言（发布资源、列表筛选时使用）；热门标签见资源列表的facets.tags
+type TaxonomyResp struct {
+	Categories []*CategoryNode `json:"categories"` // 分类树（一级分类列表）
+	Languages  []LanguageItem  `json:"languages"`  // 支持的编程语言
 }
 
 // ResourceItem 资源列表项参数
 // @Description 资源列表中单个资源的展示参数（包含点赞/浏览/评论量）
 type ResourceItem struct {
-	ID           uint64 `json:"id" example:"1001"`                               // 资源主键ID
-	Title        string `json:"title" example:"Go入门教程"`                          // 资源标题
-	TextContent  string `json:"text_content" example:"Go基础语法..."`                // 文本内容
-	CodeContent  string `json:"code_content" example:"package main\nimport fmt"` // 代码内容
-	Author       string `json:"author" example:"test_user"`                      // 发布者用户名
-	PublishTime  string `json:"publish_time" example:"2026-01-07 15:30:00"`      // 发布时间（格式YYYY-MM-DD HH:MM:SS）
-	UserID       uint64 `json:"user_id" example:"10001"`                         // 发布者用户ID
-	LikeCount    uint64 `json:"like_count" example:"50"`                         // 点赞量
-	ViewCount    uint64 `json:"view_count" example:"200"`                        // 浏览量
-	CommentCount uint64 `json:"comment_count" example:"10"`                      // 评论量
+	ID           uint64             `json:"id" example:"1001"`                               // 资源主键ID
+	Title        string             `json:"title" example:"Go入门教程"`                          // 资源标题
+	TextContent  string             `json:"text_content" example:"Go基础语法..."`                // 文本内容
+	CodeContent  string             `json:"code_content" example:"package main\nimport fmt"` // 代码内容
+	Author       string             `json:"author" example:"test_user"`                      // 发布者用户名
+	PublishTime  string             `json:"publish_time" example:"2026-01-07 15:30:00"`      // 发布时间（格式YYYY-MM-DD HH:MM:SS）
+	UserID       uint64             `json:"user_id" example:"10001"`                         // 发布者用户ID
+	LikeCount    uint64             `json:"like_count" example:"50"`                         // 点赞量
+	ViewCount    uint64             `json:"view_count" example:"200"`                        // 浏览量
+	CommentCount uint64             `json:"comment_count" example:"10"`                      // 评论量
+	Liked        bool               `json:"liked" example:"false"`                           // 当前用户是否已点赞（未登录为false）
+	Price        decimal.Decimal    `json:"price" example:"9.90"`                            // 价格（0为免费）
+	Locked       bool               `json:"locked" example:"false"`                          // 代码是否为预览（付费资源且当前用户未购买）
+	Language     string             `json:"language" example:"go"`                           // 编程语言标识（空为未识别）
+	CategoryID   uint64             `json:"category_id" example:"3"`                         // 所属分类ID（0为未分类）
+	Tags         []string           `json:"tags" example:"gin,入门"`                           // 标签
+	Score        float64            `json:"score,omitempty" example:"3.72"`                  // 相关度得分（仅关键词检索时返回，按此降序排列）
+	Highlight    *ResourceHighlight `json:"highlight,omitempty"`                             // 关键词高亮（仅关键词检索时返回）
+}
+
+// ResourceHighlight 资源检索高亮片段
+// @Description HTML片段：除包裹检索词的<mark>标签外，其余内容均已转义，可直接作为innerHTML渲染
+type ResourceHighlight struct {
+	Title   string `json:"title" example:"<mark>Go</mark>入门教程"`         // 高亮后的标题
+	Snippet string `json:"snippet" example:"…本文介绍<mark>Go</mark>基础语法…"` // 命中处附近的正文/代码摘要（已按购买状态截取）
 }
 
 // ResourceListReq 资源列表查询请求参数
 // @Description 资源列表分页查询接口的请求参数
 type ResourceListReq struct {
-	Page    int    `json:"page" binding:"gte=1" example:"1"`         // 页码（必填，最小为1）
-	Size    int    `json:"size" binding:"gte=1,lte=50" example:"10"` // 每页条数（必填，1~50之间）
-	Keyword string `json:"keyword" example:"Go教程"`                   // 搜索关键词（可选，模糊匹配标题/内容）
+	Page       int    `json:"page" binding:"gte=1" example:"1"`            // 页码（必填，最小为1）
+	Size       int    `json:"size" binding:"gte=1,lte=50" example:"10"`    // 每页条数（必填，1~50之间）
+	Keyword    string `json:"keyword" binding:"max=100" example:"Go 教程"`   // 检索关键词（可选，全文检索标题/内容/代码，空格分隔的多个词需同时命中，结果按相关度排序）
+	Author     string `json:"author" binding:"max=50" example:"test_user"` // 作者用户名（可选，精确匹配）
+	StartDate  string `json:"start_date" example:"2026-01-01"`             // 发布日期起（可选，含当天，格式YYYY-MM-DD）
+	EndDate    string `json:"end_date" example:"2026-01-31"`               // 发布日期止（可选，含当天，格式YYYY-MM-DD）
+	Language   string `json:"language" binding:"max=20" example:"go"`      // 编程语言（可选）
+	CategoryID uint64 `json:"category_id" example:"1"`                     // 分类ID（可选，包含全部子孙分类）
+	Tag        string `json:"tag" binding:"max=20" example:"gin"`          // 标签（可选，不区分大小写）
 }
 
 // UploadMdFileReq MD文件上传请求参数
@@ -198,3 +481,223 @@ type EmailVerifyReq struct {
 	Email string `json:"email" binding:"required,email" example:"test@example.com"` // 接收验证码的邮箱
 	Code  string `json:"code" binding:"required,len=6" example:"123456"`            // 6位数字验证码
 }
+
+// PasswordForgotReq 忘记密码-发送重置验证码请求参数
+// @Description 传入账号绑定的邮箱，发送重置密码验证码
+type PasswordForgotReq struct {
+	Email string `json:"email" binding:"required,email" example:"test@example.com"` // 账号绑定的邮箱
+}
+
+// PasswordResetReq 忘记密码-重置密码请求参数
+// @Description 传入邮箱、验证码和新密码完成重置
+type PasswordResetReq struct {
+	Email       string `json:"email" binding:"required,email" example:"test@example.com"`     // 账号绑定的邮箱
+	Code        string `json:"code" binding:"required,len=6" example:"123456"`                // 6位数字验证码
+	NewPassword string `json:"new_password" binding:"required,min=6,max=20" example:"654321"` // 新密码（6-20位）
+}
+
+// PasswordChangeReq 修改密码请求参数
+// @Description 已登录用户校验原密码后修改密码
+type PasswordChangeReq struct {
+	OldPassword string `json:"old_password" binding:"required" example:"123456"`              // 原密码
+	NewPassword string `json:"new_password" binding:"required,min=6,max=20" example:"654321"` // 新密码（6-20位，不能与原密码相同）
+}
+
+// EmailConfirmReq 邮箱验证-确认请求参数
+// @Description 传入发送到当前账号邮箱的验证码，确认邮箱归属
+type EmailConfirmReq struct {
+	Code string `json:"code" binding:"required,len=6" example:"123456"` // 6位数字验证码
+}
+
+// LoginAttemptListReq 登录记录查询请求参数
+// @Description 分页查询当前账号的密码登录记录
+type LoginAttemptListReq struct {
+	Page int `form:"page" binding:"omitempty,gte=1" example:"1"`          // 页码（默认1）
+	Size int `form:"size" binding:"omitempty,gte=1,lte=100" example:"20"` // 每页条数（默认20，最大100）
+}
+
+// LoginAttemptItem 登录记录项
+// @Description 单次密码登录的时间、来源及结果
+type LoginAttemptItem struct {
+	ID         uint64 `json:"id" example:"1"`                            // 记录ID
+	Identifier string `json:"identifier" example:"test_user"`            // 登录时填写的用户名/手机号/邮箱
+	IP         string `json:"ip" example:"127.0.0.1"`                    // 客户端IP
+	UserAgent  string `json:"user_agent" example:"Mozilla/5.0"`          // 客户端User-Agent
+	Result     string `json:"result" example:"success"`                  // 登录结果（success/bad_credentials/locked/disabled）
+	CreateTime string `json:"create_time" example:"2026-01-07 15:30:00"` // 登录时间
+}
+
+// LoginAttemptListResp 登录记录分页响应
+// @Description 登录记录列表及分页信息
+type LoginAttemptListResp struct {
+	List  []LoginAttemptItem `json:"list"`                // 登录记录列表
+	Total int64              `json:"total" example:"100"` // 总条数
+	Page  int                `json:"page" example:"1"`    // 当前页码
+	Size  int                `json:"size" example:"20"`   // 每页条数
+}
+
+// AdminUserListReq 管理后台用户列表请求参数
+// @Description 分页查询用户，支持按用户名/邮箱/手机号模糊搜索及按角色、状态过滤
+type AdminUserListReq struct {
+	Page    int    `form:"page" binding:"omitempty,gte=1" example:"1"`                               // 页码（默认1）
+	Size    int    `form:"size" binding:"omitempty,gte=1,lte=100" example:"20"`                      // 每页条数（默认20，最大100）
+	Keyword string `form:"keyword" binding:"omitempty,max=100" example:"test"`                       // 搜索关键词（可选）
+	Role    string `form:"role" binding:"omitempty,oneof=candidate hr admin" example:"hr"`           // 角色过滤（可选）
+	Status  string `form:"status" binding:"omitempty,oneof=active disabled banned" example:"active"` // 状态过滤（可选）
+}
+
+// AdminUserItem 管理后台用户列表项
+// @Description 用户基础信息及角色、账号状态（不含密码等敏感字段）
+type AdminUserItem struct {
+	ID       uint64  `json:"id" example:"10001"`                                  // 用户ID
+	UUID     string  `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 用户UUID
+	Username string  `json:"username" example:"test_user"`                        // 用户名
+	Email    *string `json:"email" example:"test@example.com"`                    // 邮箱
+	Phone    *string `json:"phone" example:"13800138000"`                         // 手机号
+	RealName *string `json:"real_name" example:"张三"`                              // 真实姓名
+	Role     string  `json:"role" example:"candidate"`                            // 角色
+	Status   string  `json:"status" example:"active"`                             // 账号状态（active/disabled/banned）
+}
+
+// AdminUserListResp 管理后台用户列表响应
+// @Description 用户列表及分页信息
+type AdminUserListResp struct {
+	List  []AdminUserItem `json:"list"`                // 用户列表
+	Total int64           `json:"total" example:"100"` // 总条数
+	Page  int             `json:"page" example:"1"`    // 当前页码
+	Size  int             `json:"size" example:"20"`   // 每页条数
+}
+
+// AdminSetRoleReq 修改用户角色请求参数
+// @Description 修改后该用户已签发的访问Token立即失效，刷新后获得新角色
+type AdminSetRoleReq struct {
+	UUID   string `json:"uuid" binding:"required,max=36" example:"123e4567-e89b-12d3-a456-426614174001"` // 目标用户UUID（必填）
+	Role   string `json:"role" binding:"required,oneof=candidate hr admin" example:"hr"`                 // 新角色（必填）
+	Reason string `json:"reason" binding:"required,max=255" example:"入职HR岗位"`                            // 操作原因（必填，写入审计日志）
+}
+
+// AdminSetStatusReq 停用/封禁/恢复账号请求参数
+// @Description 停用或封禁后该用户全部会话立即失效且无法再登录；active为恢复正常
+type AdminSetStatusReq struct {
+	UUID   string `json:"uuid" binding:"required,max=36" example:"123e4567-e89b-12d3-a456-426614174001"` // 目标用户UUID（必填）
+	Status string `json:"status" binding:"required,oneof=active disabled banned" example:"banned"`       // 新状态（必填）
+	Reason string `json:"reason" binding:"required,max=255" example:"发布违规广告"`                            // 操作原因（必填，写入审计日志）
+}
+
+// AdminHideReq 隐藏/取消隐藏资源或评论请求参数
+// @Description 隐藏评论时其下全部回复一并隐藏；hidden=false为取消隐藏
+type AdminHideReq struct {
+	ID     uint64 `json:"id" binding:"required,min=1" example:"1"`          // 资源/评论ID（必填）
+	Hidden *bool  `json:"hidden" binding:"required" example:"true"`         // 是否隐藏（必填）
+	Reason string `json:"reason" binding:"required,max=255" example:"涉嫌抄袭"` // 操作原因（必填，写入审计日志）
+}
+
+// AdminDeleteReq 删除资源、评论或分类请求参数
+// @Description 删除不可恢复；评论的全部回复一并删除，资源的评论、点赞明细与标签关联一并删除；分类需先清空子分类和资源
+type AdminDeleteReq struct {
+	ID     uint64 `json:"id" binding:"required,min=1" example:"1"`          // 资源/评论/分类ID（必填）
+	Reason string `json:"reason" binding:"required,max=255" example:"违法内容"` // 操作原因（必填，写入审计日志）
+}
+
+// AdminCreateCategoryReq 新增资源分类请求参数
+// @Description parent_id为0时新增一级分类，分类最多3级；同一父分类下名称不能重复
+type AdminCreateCategoryReq struct {
+	ParentID  uint64 `json:"parent_id" example:"0"`                            // 父分类ID（可选，0为一级分类）
+	Name      string `json:"name" binding:"required,max=30" example:"后端开发"`    // 分类名称（必填，最多30个字符）
+	SortOrder int    `json:"sort_order" example:"0"`                           // 同级排序（可选，升序）
+	Reason    string `json:"reason" binding:"required,max=255" example:"新增栏目"` // 操作原因（必填，写入审计日志）
+}
+
+// AdminAdjustBalanceReq 手工调账请求参数
+// @Description 金额为正增加余额、为负扣减余额（扣减后余额不能为负），写入adjust类型流水
+type AdminAdjustBalanceReq struct {
+	UUID   string          `json:"uuid" binding:"required,max=36" example:"123e4567-e89b-12d3-a456-426614174001"` // 目标用户UUID（必填）
+	Amount decimal.Decimal `json:"amount" binding:"required" example:"-20.00"`                                    // 调账金额（必填，非0，最多两位小数）
+	Reason string          `json:"reason" binding:"required,max=255" example:"充值渠道重复扣款退回"`                        // 调账原因（必填，写入流水备注与审计日志）
+}
+
+// AdminAdjustBalanceResp 手工调账响应参数
+// @Description 调账后的余额及对应流水、审计日志ID
+type AdminAdjustBalanceResp struct {
+	UserUUID      string          `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174001"` // 用户UUID
+	Amount        decimal.Decimal `json:"amount" example:"-20.00"`                             // 调账金额
+	BalanceAfter  decimal.Decimal `json:"balance_after" example:"80.00"`                       // 调账后余额
+	TransactionID uint64          `json:"transaction_id" example:"1"`                          // 对应流水ID
+	AuditID       uint64          `json:"audit_id" example:"1"`                                // 对应审计日志ID
+}
+
+// AdminAuditListReq 审计日志查询请求参数
+// @Description 分页查询管理员操作审计日志，可按管理员、操作类型、操作对象过滤
+type AdminAuditListReq struct {
+	Page       int    `form:"page" binding:"omitempty,gte=1" example:"1"`                                              // 页码（默认1）
+	Size       int    `form:"size" binding:"omitempty,gte=1,lte=100" example:"20"`                                     // 每页条数（默认20，最大100）
+	AdminUUID  string `form:"admin_uuid" binding:"omitempty,max=36" example:""`                                        // 管理员UUID（可选）
+	Action     string `form:"action" binding:"omitempty,max=32" example:"user.status"`                                 // 操作类型（可选）
+	TargetType string `form:"target_type" binding:"omitempty,oneof=user resource comment account category" example:""` // 操作对象类型（可选）
+	TargetID   string `form:"target_id" binding:"omitempty,max=64" example:""`                                         // 操作对象ID（可选）
+}
+
+// AdminAuditItem 审计日志项
+// @Description 单条管理员操作记录，detail为操作前后状态的JSON
+type AdminAuditItem struct {
+	ID         uint64          `json:"id" example:"1"`                                            // 审计日志ID
+	AdminUUID  string          `json:"admin_uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 管理员UUID
+	AdminName  string          `json:"admin_name" example:"admin"`                                // 管理员用户名
+	Action     string          `json:"action" example:"user.status"`                              // 操作类型
+	TargetType string          `json:"target_type" example:"user"`                                // 操作对象类型
+	TargetID   string          `json:"target_id" example:"123e4567-e89b-12d3-a456-426614174001"`  // 操作对象ID
+	Reason     string          `json:"reason" example:"发布违规广告"`                                   // 操作原因
+	Detail     json.RawMessage `json:"detail" swaggertype:"object"`                               // 操作详情
+	CreateTime string          `json:"create_time" example:"2026-01-07 15:30:00"`                 // 操作时间
+}
+
+// AdminAuditListResp 审计日志分页响应
+// @Description 审计日志列表及分页信息
+type AdminAuditListResp struct {
+	List  []AdminAuditItem `json:"list"`                // 审计日志列表
+	Total int64            `json:"total" example:"100"` // 总条数
+	Page  int              `json:"page" example:"1"`    // 当前页码
+	Size  int              `json:"size" example:"20"`   // 每页条数
+}
+
+// TwoFactorLoginReq 两步验证登录请求参数
+// @Description 密码登录返回challenge_token后，凭挑战令牌与动态码（或恢复码）换取登录凭证
+type TwoFactorLoginReq struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"bG9naW4tY2hhbGxlbmdlLXRva2Vu"` // 密码登录返回的挑战令牌
+	Code           string `json:"code" binding:"required,max=32" example:"123456"`                           // 验证器App中的6位动态码，或一次性恢复码
+}
+
+// TwoFactorCodeReq 两步验证动态码请求参数
+// @Description 开启两步验证、重新生成恢复码时提交的动态码
+type TwoFactorCodeReq struct {
+	Code string `json:"code" binding:"required,max=32" example:"123456"` // 6位动态码（重新生成恢复码时也可使用恢复码）
+}
+
+// TwoFactorDisableReq 关闭两步验证请求参数
+// @Description 关闭两步验证需同时校验登录密码与动态码（或恢复码）
+type TwoFactorDisableReq struct {
+	Password string `json:"password" binding:"required" example:"123456"`    // 登录密码
+	Code     string `json:"code" binding:"required,max=32" example:"123456"` // 6位动态码或恢复码
+}
+
+// TwoFactorSetupResp 绑定验证器响应
+// @Description 返回TOTP密钥及otpauth URI（前端生成二维码供验证器App扫描），提交动态码确认后才会开启
+type TwoFactorSetupResp struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`                                     // TOTP密钥（Base32，无法扫码时手动输入）
+	OtpauthURI string `json:"otpauth_uri" example:"otpauth://totp/CMS:test_user?secret=JBSWY3DPEHPK3PXP&issuer=CMS"` // 验证器App绑定URI
+}
+
+// TwoFactorRecoveryCodesResp 恢复码响应
+// @Description 一次性恢复码只在生成时返回一次，请提示用户妥善保存
+type TwoFactorRecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"ab3de-fg7hk"` // 一次性恢复码（每个只能使用一次）
+}
+
+// TwoFactorStatusResp 两步验证状态响应
+// @Description 当前账号两步验证开启状态及剩余恢复码个数
+type TwoFactorStatusResp struct {
+	Enabled           bool   `json:"enabled" example:"true"`                    // 是否已开启
+	Pending           bool   `json:"pending" example:"false"`                   // 已生成密钥、待提交动态码确认
+	EnableTime        string `json:"enable_time" example:"2025-01-01 12:00:00"` // 开启时间（未开启为空）
+	RecoveryCodesLeft int    `json:"recovery_codes_left" example:"10"`          // 剩余未使用的恢复码个数
+}
@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"CMS/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminListUsersHandler 管理后台用户列表接口
+// @Summary 查询/搜索用户
+// @Description 管理员分页查询用户，支持按用户名/邮箱/手机号模糊搜索及按角色、状态过滤
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页条数（默认20，最大100）" example(20)
+// @Param keyword query string false "搜索关键词" example(test)
+// @Param role query string false "角色过滤（candidate/hr/admin）" example(hr)
+// @Param status query string false "状态过滤（active/disabled/banned）" example(active)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.AdminUserListResp} "查询成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询用户失败"
+// @Router /admin/users [get]
+func (h *StaffHandler) AdminListUsersHandler(c *gin.Context) {
+	var req dto.AdminUserListReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 20
+	}
+
+	resp, err := h.adminsvc.ListUsers(c.Request.Context(), req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", resp)
+}
+
+// AdminSetUserRoleHandler 修改用户角色接口
+// @Summary 修改用户角色
+// @Description 管理员修改用户角色（不能修改自己），修改后该用户已签发的访问Token立即失效
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminSetRoleReq true "角色修改参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/角色未变化"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改角色失败"
+// @Router /admin/users/role [post]
+func (h *StaffHandler) AdminSetUserRoleHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminSetRoleReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.SetUserRole(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "修改成功", nil)
+}
+
+// AdminSetUserStatusHandler 修改账号状态接口
+// @Summary 停用/封禁/恢复账号
+// @Description 管理员修改账号状态（不能修改自己）；停用或封禁时吊销该用户全部会话
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminSetStatusReq true "状态修改参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/状态未变化"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改账号状态失败"
+// @Router /admin/users/status [post]
+func (h *StaffHandler) AdminSetUserStatusHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminSetStatusReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.SetUserStatus(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "修改成功", nil)
+}
+
+// AdminHideResourceHandler 隐藏/取消隐藏资源接口
+// @Summary 隐藏/取消隐藏资源
+// @Description 隐藏后资源不再出现在列表与详情中，已购买记录保留
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminHideReq true "隐藏参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "操作成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/状态未变化"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "隐藏资源失败"
+// @Router /admin/resources/hide [post]
+func (h *StaffHandler) AdminHideResourceHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminHideReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.SetResourceHidden(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "操作成功", nil)
+}
+
+// AdminDeleteResourceHandler 删除资源接口
+// @Summary 删除资源
+// @Description 删除资源及其评论、点赞明细；已有购买记录的资源不能删除，只能隐藏
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminDeleteReq true "删除参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/已有购买记录"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除资源失败"
+// @Router /admin/resources/delete [post]
+func (h *StaffHandler) AdminDeleteResourceHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminDeleteReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.DeleteResource(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "删除成功", nil)
+}
+
+// AdminHideCommentHandler 隐藏/取消隐藏评论接口
+// @Summary 隐藏/取消隐藏评论
+// @Description 隐藏评论及其全部回复，并重新统计资源评论量；上级评论仍隐藏时不能单独恢复回复
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminHideReq true "隐藏参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "操作成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/状态未变化"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "评论不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "隐藏评论失败"
+// @Router /admin/comments/hide [post]
+func (h *StaffHandler) AdminHideCommentHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminHideReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.SetCommentHidden(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "操作成功", nil)
+}
+
+// AdminDeleteCommentHandler 删除评论接口
+// @Summary 删除评论
+// @Description 管理员删除任意评论及其全部回复，并重新统计资源评论量
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminDeleteReq true "删除参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "评论不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除评论失败"
+// @Router /admin/comments/delete [post]
+func (h *StaffHandler) AdminDeleteCommentHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminDeleteReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.DeleteComment(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "删除成功", nil)
+}
+
+// AdminCreateCategoryHandler 新增资源分类接口
+// @Summary 新增资源分类
+// @Description 管理员新增资源分类（parent_id为0时为一级分类，最多3级，同级名称不能重复）
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminCreateCategoryReq true "分类参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.CategoryNode} "新增成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/超过最大层级"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "父分类不存在"
+// @Failure 409 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "同级分类名称重复"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "新增分类失败"
+// @Router /admin/categories/create [post]
+func (h *StaffHandler) AdminCreateCategoryHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminCreateCategoryReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	category, err := h.adminsvc.CreateCategory(c.Request.Context(), adminUUID, req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "新增成功", category)
+}
+
+// AdminDeleteCategoryHandler 删除资源分类接口
+// @Summary 删除资源分类
+// @Description 管理员删除资源分类，分类下仍有子分类或资源时不允许删除
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminDeleteReq true "删除参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/分类下仍有子分类或资源"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "分类不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除分类失败"
+// @Router /admin/categories/delete [post]
+func (h *StaffHandler) AdminDeleteCategoryHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminDeleteReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.adminsvc.DeleteCategory(c.Request.Context(), adminUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "删除成功", nil)
+}
+
+// AdminAdjustBalanceHandler 手工调账接口
+// @Summary 手工调整余额
+// @Description 管理员按原因手工增减用户余额（正数加、负数减），写入账户流水与审计日志
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param req body dto.AdminAdjustBalanceReq true "调账参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.AdminAdjustBalanceResp} "调账成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/余额不足"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户/账户不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "调账失败"
+// @Router /admin/accounts/adjust [post]
+func (h *StaffHandler) AdminAdjustBalanceHandler(c *gin.Context) {
+	adminUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminAdjustBalanceReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	resp, err := h.adminsvc.AdjustBalance(c.Request.Context(), adminUUID, req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "调账成功", resp)
+}
+
+// AdminAuditLogsHandler 审计日志查询接口
+// @Summary 查询审计日志
+// @Description 分页查询管理员操作审计日志（按时间倒序），可按管理员、操作类型、操作对象过滤
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页条数（默认20，最大100）" example(20)
+// @Param admin_uuid query string false "管理员UUID"
+// @Param action query string false "操作类型" example(user.status)
+// @Param target_type query string false "操作对象类型（user/resource/comment/account）" example(user)
+// @Param target_id query string false "操作对象ID"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.AdminAuditListResp} "查询成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无管理权限"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询审计日志失败"
+// @Router /admin/audit-logs [get]
+func (h *StaffHandler) AdminAuditLogsHandler(c *gin.Context) {
+	var req dto.AdminAuditListReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 20
+	}
+
+	resp, err := h.adminsvc.ListAuditLogs(c.Request.Context(), req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", resp)
+}
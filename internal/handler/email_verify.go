@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"CMS/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendEmailVerifyHandler 邮箱验证-发送验证码接口
+// @Summary 邮箱验证-发送验证码
+// @Description 向当前账号绑定的邮箱发送归属验证码（注册、修改邮箱后需完成验证才能使用邮箱验证码登录）
+// @Tags 邮箱验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "验证码已发送"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未绑定邮箱/邮箱已验证"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 429 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "发送过于频繁（同一邮箱/IP有发送间隔）"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "发送验证码失败"
+// @Router /staff/email/verify/send [post]
+func (h *StaffHandler) SendEmailVerifyHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.SendEmailVerification(c.Request.Context(), userUUID, c.ClientIP()); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "验证码已发送，请查收邮件", nil)
+}
+
+// ConfirmEmailHandler 邮箱验证-确认接口
+// @Summary 邮箱验证-确认
+// @Description 校验发送到当前账号邮箱的验证码，通过后邮箱标记为已验证
+// @Tags 邮箱验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Param req body dto.EmailConfirmReq true "邮箱验证参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "邮箱验证成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/验证码错误或已过期/邮箱已变更"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "邮箱验证失败"
+// @Router /staff/email/verify/confirm [post]
+func (h *StaffHandler) ConfirmEmailHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.EmailConfirmReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.svc.ConfirmEmail(c.Request.Context(), userUUID, req.Code); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "邮箱验证成功", nil)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"CMS/internal/apperr"
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthCheckTimeout 探活时MySQL Ping的超时时间（探针通常1~3秒超时，需在其之内返回）
+const healthCheckTimeout = 2 * time.Second
+
+// HealthHandler 存活/就绪探针处理器
+type HealthHandler struct {
+	db       *sql.DB
+	draining atomic.Bool // 进入优雅关闭后置为true，就绪探针返回503，负载均衡不再转发新请求
+}
+
+// NewHealthHandler 创建探针处理器实例
+func NewHealthHandler(db *sql.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// SetDraining 标记服务进入优雅关闭（就绪探针随即返回503）
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Healthz 存活探针
+// @Summary 存活探针
+// @Description 进程存活且MySQL可连通时返回200，否则返回503
+// @Tags 系统
+// @Produce json
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "服务正常"
+// @Failure 503 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "MySQL不可用"
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	if err := h.pingDB(c.Request.Context()); err != nil {
+		fail(c, err)
+		return
+	}
+	Success(c, "ok", nil)
+}
+
+// Readyz 就绪探针
+// @Summary 就绪探针
+// @Description MySQL可连通且服务未进入优雅关闭时返回200；关闭过程中返回503，便于负载均衡摘除流量
+// @Tags 系统
+// @Produce json
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "可以接收流量"
+// @Failure 503 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "MySQL不可用/服务正在关闭"
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.draining.Load() {
+		fail(c, apperr.ErrUnavailable.WithMsg("服务正在关闭"))
+		return
+	}
+	if err := h.pingDB(c.Request.Context()); err != nil {
+		fail(c, err)
+		return
+	}
+	Success(c, "ready", nil)
+}
+
+// pingDB 带超时的MySQL连通性检查
+func (h *HealthHandler) pingDB(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if err := h.db.PingContext(ctx); err != nil {
+		return apperr.ErrUnavailable.WithMsg("MySQL不可用").Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"CMS/internal/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginAttemptsHandler 登录记录接口
+// @Summary 查询登录记录
+// @Description 个人中心分页查询当前账号的密码登录记录（时间、IP、设备及结果），用于发现异常登录
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页条数（默认20，最大100）" example(20)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.LoginAttemptListResp} "查询成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询登录记录失败"
+// @Router /staff/login-attempts [get]
+func (h *StaffHandler) LoginAttemptsHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.LoginAttemptListReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 20
+	}
+
+	resp, err := h.svc.LoginHistory(c.Request.Context(), userUUID, req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", resp)
+}
@@ -1,181 +1,127 @@
 package handler
 
 import (
+	"CMS/internal/apperr"
 	"CMS/internal/dto"
-	"errors"
-	"fmt"
-	"net/http"
+	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shopspring/decimal"
 )
 
-// 补充缺失的结构体定义（保证代码可编译）
-
-// Success 成功响应（内部工具函数，无需Swagger注释）
-func Success(c *gin.Context, data interface{}) {
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "success",
-		Data:    data,
-	})
-}
-
-// Fail 失败响应（内部工具函数，无需Swagger注释）
-func Fail(c *gin.Context, code int, msg string) {
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    code,
-		Message: msg,
-		Data:    nil,
-	})
-}
-
-// Error 系统错误响应（HTTP 500）（内部工具函数，无需Swagger注释）
-func Error(c *gin.Context, msg string) {
-	c.JSON(http.StatusInternalServerError, dto.Response{
-		Code:    500,
-		Message: msg,
-		Data:    nil,
-	})
-}
-
 // Recharge 账户充值接口
-// @Summary 账户充值
-// @Description 登录用户为自身账户充值（UUID从中间件获取，自动关联用户，仅需传入充值金额）
+// @Summary 账户充值（创建充值单）
+// @Description 登录用户为自身账户发起充值：创建待支付充值单并返回支付链接pay_url，余额在支付渠道回调验签通过后才入账；可传幂等键，重试请求返回同一充值单
 // @Tags 账户管理
 // @Accept json
 // @Produce json
-// @Param req body dto.RechargeRequest true "充值请求参数" example({"amount":100.00})
-// @Success 200 {object} dto.Response{Code=int,Message=string,Data=dto.AccountResponse} "充值成功，Data返回账户充值后信息"
-// @Failure 401 {object} dto.Response{Code=int,Message=string,Data=nil} "未获取到用户UUID/UUID无效"
-// @Failure 400 {object} dto.Response{Code=int,Message=string,Data=nil} "参数校验失败/充值金额格式错误/充值金额≤0/用户不存在"
-// @Failure 500 {object} dto.Response{Code=int,Message=string,Data=nil} "充值失败（数据库异常等系统错误）"
+// @Param req body dto.RechargeRequest true "充值请求参数" example({"amount":100.00,"idempotency_key":"recharge-20260107-0001"})
+// @Param Idempotency-Key header string false "幂等键（请求体未传idempotency_key时使用）"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.RechargeOrderResp} "充值单创建成功，Data返回充值单及支付链接"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到用户UUID/UUID无效"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/充值金额格式错误/充值金额≤0或超过上限"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户不存在"
+// @Failure 409 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "幂等键已被用于其他请求（金额不同）"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "创建充值单失败（数据库异常/支付渠道异常等系统错误）"
 // @Router /account/recharge [post]
 func (h *StaffHandler) Recharge(c *gin.Context) {
 	// ========== 从上下文获取登录用户的UUID ==========
-	rawUUID, exists := c.Get("uuid")
-	if !exists {
-		Fail(c, 401, "未获取到用户身份信息，请先登录")
-		return
-	}
-	// 类型断言 + 空值校验
-	realUUID, ok := rawUUID.(string)
-	if !ok || strings.TrimSpace(realUUID) == "" {
-		Fail(c, 401, "用户 UUID 无效")
+	realUUID, ok := requireUserUUID(c)
+	if !ok {
 		return
 	}
 
 	// 1. 绑定并校验请求参数（仅绑定amount，无需user_uuid）
 	var req dto.RechargeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Fail(c, 400, fmt.Sprintf("参数校验失败：%v", err))
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
 	// 2. 校验decimal金额（格式+数值合法性）
 	if req.Amount.IsZero() && req.Amount.String() != "0" {
-		Fail(c, 400, "充值金额格式错误（请传入合法数字，如100.00）")
+		fail(c, apperr.InvalidArgument("充值金额格式错误（请传入合法数字，如100.00）"))
 		return
 	}
 	// 新增：校验充值金额必须大于0
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		Fail(c, 400, "充值金额必须大于0")
+		fail(c, apperr.InvalidArgument("充值金额必须大于0"))
 		return
 	}
 
 	// ========== 将上下文的UUID赋值给请求体 ==========
 	req.UserUUID = realUUID
+	// 幂等键：请求体未传时取Idempotency-Key请求头
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	}
 
 	// 3. 调用Service层处理业务逻辑
 	resp, err := h.accsvc.Recharge(c.Request.Context(), req)
 	if err != nil {
-		// 区分业务错误和系统错误
-		switch {
-		// 业务错误（参数/逻辑问题，返回400）
-		case errors.Is(err, errors.New("充值金额必须大于0")):
-			fallthrough
-		case strings.Contains(err.Error(), "用户不存在"):
-			Fail(c, 400, err.Error())
-		// 系统错误（如数据库异常，返回500）
-		default:
-			Error(c, fmt.Sprintf("充值失败：%v", err))
-		}
+		fail(c, err)
 		return
 	}
 
 	// 4. 返回成功响应
-	Success(c, resp)
+	Success(c, "充值单创建成功", resp)
 }
 
 // Deduct 账户余额扣减接口
 // @Summary 扣减账户余额
-// @Description 登录用户扣减自身账户余额（UUID从中间件获取，自动关联用户，仅需传入扣减金额）
+// @Description 登录用户扣减自身账户余额（UUID从中间件获取，自动关联用户，仅需传入扣减金额）；余额校验与扣减在行锁内原子执行，并发扣减不会扣成负数；传入幂等键时，客户端重试不会重复扣款（返回replayed=true）
 // @Tags 账户管理
 // @Accept json
 // @Produce json
-// @Param req body dto.DeductRequest true "扣减请求参数" example({"amount":50.00})
-// @Success 200 {object} dto.Response{Code=int,Message=string,Data=dto.AccountResponse} "扣减成功，Data返回账户扣减后信息"
-// @Failure 401 {object} dto.Response{Code=int,Message=string,Data=nil} "未获取到用户UUID/UUID无效"
-// @Failure 400 {object} dto.Response{Code=int,Message=string,Data=nil} "参数校验失败/扣减金额格式错误/扣减金额≤0/用户不存在/账户余额不足"
-// @Failure 500 {object} dto.Response{Code=int,Message=string,Data=nil} "扣减余额失败（数据库异常等系统错误）"
+// @Param req body dto.DeductRequest true "扣减请求参数" example({"amount":50.00,"idempotency_key":"order-20260107-0001"})
+// @Param Idempotency-Key header string false "幂等键（请求体未传idempotency_key时使用）"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.AccountResponse} "扣减成功，Data返回账户扣减后信息"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到用户UUID/UUID无效"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/扣减金额格式错误/扣减金额≤0/账户余额不足"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户或账户不存在"
+// @Failure 409 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "幂等键已被用于其他请求（金额或类型不同）"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "扣减余额失败（数据库异常等系统错误）"
 // @Router /account/deduct [post]
 func (h *StaffHandler) Deduct(c *gin.Context) {
 	// ========== 从上下文获取登录用户的UUID ==========
-	rawUUID, exists := c.Get("uuid")
-	if !exists {
-		Fail(c, 401, "未获取到用户身份信息，请先登录")
-		return
-	}
-	// 类型断言 + 空值校验
-	realUUID, ok := rawUUID.(string)
-	if !ok || strings.TrimSpace(realUUID) == "" {
-		Fail(c, 401, "用户 UUID 无效")
+	realUUID, ok := requireUserUUID(c)
+	if !ok {
 		return
 	}
 
 	// 1. 绑定并校验请求参数（仅绑定amount，无需user_uuid）
 	var req dto.DeductRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		Fail(c, 400, fmt.Sprintf("参数校验失败：%v", err))
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
 	// 2. 校验decimal金额（格式+数值合法性）
 	if req.Amount.IsZero() && req.Amount.String() != "0" {
-		Fail(c, 400, "扣减金额格式错误（请传入合法数字，如100.00）")
+		fail(c, apperr.InvalidArgument("扣减金额格式错误（请传入合法数字，如100.00）"))
 		return
 	}
 	// 新增：校验扣减金额必须大于0
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		Fail(c, 400, "扣减金额必须大于0")
+		fail(c, apperr.InvalidArgument("扣减金额必须大于0"))
 		return
 	}
 
 	// ========== 将上下文的UUID赋值给请求体 ==========
 	req.UserUUID = realUUID
+	// 幂等键：请求体未传时取Idempotency-Key请求头
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	}
 
 	// 3. 调用Service层处理业务逻辑
 	resp, err := h.accsvc.Deduct(c.Request.Context(), req)
 	if err != nil {
-		// 区分业务错误和系统错误
-		switch {
-		// 业务错误（400）
-		case errors.Is(err, errors.New("扣减金额必须大于0")):
-			fallthrough
-		case strings.Contains(err.Error(), "用户不存在"):
-			fallthrough
-		case strings.Contains(err.Error(), "账户余额不足"):
-			Fail(c, 400, err.Error())
-		// 系统错误（500）
-		default:
-			Error(c, fmt.Sprintf("扣减余额失败：%v", err))
-		}
+		fail(c, err)
 		return
 	}
 
 	// 4. 返回成功响应
-	Success(c, resp)
+	Success(c, "扣减成功", resp)
 }
 
 // GetAccountByUserUUID 查询账户信息接口
@@ -184,40 +130,145 @@ func (h *StaffHandler) Deduct(c *gin.Context) {
 // @Tags 账户管理
 // @Accept json
 // @Produce json
-// @Success 200 {object} dto.Response{Code=int,Message=string,Data=dto.AccountResponse} "查询成功，Data返回账户余额、UUID等信息"
-// @Failure 401 {object} dto.Response{Code=int,Message=string,Data=nil} "未获取到用户UUID/UUID无效"
-// @Failure 404 {object} dto.Response{Code=int,Message=string,Data=nil} "未查询到用户账户信息"
-// @Failure 500 {object} dto.Response{Code=int,Message=string,Data=nil} "查询账户信息失败（数据库异常等系统错误）"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.AccountResponse} "查询成功，Data返回账户余额、UUID等信息"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到用户UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未查询到用户账户信息"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询账户信息失败（数据库异常等系统错误）"
 // @Router /account/get-account [get]
 func (h *StaffHandler) GetAccountByUserUUID(c *gin.Context) {
 	// ========== 从上下文获取登录用户的UUID ==========
-	rawUUID, exists := c.Get("uuid")
-	if !exists {
-		Fail(c, 401, "未获取到用户身份信息，请先登录")
-		return
-	}
-	// 类型断言 + 空值校验
-	realUUID, ok := rawUUID.(string)
-	if !ok || strings.TrimSpace(realUUID) == "" {
-		Fail(c, 401, "用户 UUID 无效")
+	realUUID, ok := requireUserUUID(c)
+	if !ok {
 		return
 	}
 
 	// 2. 调用Service层查询账户信息（直接用上下文的UUID）
 	resp, err := h.accsvc.GetAccountByUserUUID(c.Request.Context(), realUUID)
 	if err != nil {
-		// 区分业务错误和系统错误
-		switch {
-		// 业务错误（404）
-		case errors.Is(err, errors.New("未查询到用户账户信息")):
-			Fail(c, 404, err.Error())
-		// 系统错误（500）
-		default:
-			Error(c, fmt.Sprintf("查询账户信息失败：%v", err))
-		}
+		fail(c, err)
 		return
 	}
 
 	// 3. 返回成功响应
-	Success(c, resp)
+	Success(c, "查询成功", resp)
+}
+
+// GetTransactions 账户流水查询接口
+// @Summary 查询账户流水
+// @Description 登录用户分页查询自身账户流水（对账单），每次余额变动对应一条流水，可按类型过滤
+// @Tags 账户管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页条数（默认10，最大50）" example(10)
+// @Param type query string false "流水类型（recharge/deduct/purchase/sale）" example(recharge)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.TransactionListResp} "查询成功"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到用户UUID/UUID无效"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/流水类型无效"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询账户流水失败"
+// @Router /account/transactions [get]
+func (h *StaffHandler) GetTransactions(c *gin.Context) {
+	// ========== 从上下文获取登录用户的UUID ==========
+	realUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	// 1. 绑定分页参数（未传时默认第1页、每页10条）
+	var req dto.TransactionListReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 10
+	}
+
+	// 2. 调用Service层查询流水
+	resp, err := h.accsvc.GetTransactions(c.Request.Context(), realUUID, req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	// 3. 返回成功响应
+	Success(c, "查询成功", resp)
+}
+
+// GetRechargeOrder 充值单查询接口
+// @Summary 查询充值单
+// @Description 登录用户查询自身充值单状态（前端支付后轮询，status为paid表示已入账）
+// @Tags 账户管理
+// @Accept json
+// @Produce json
+// @Param order_no query string true "充值单号" example(R20260107153000a1b2c3d4e5f6)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.RechargeOrderResp} "查询成功"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到用户UUID/UUID无效"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "充值单不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询充值单失败"
+// @Router /account/recharge/order [get]
+func (h *StaffHandler) GetRechargeOrder(c *gin.Context) {
+	h.handleRechargeOrder(c, h.accsvc.GetRechargeOrder)
+}
+
+// SimulatePayment Mock渠道模拟支付接口
+// @Summary 模拟支付（Mock渠道）
+// @Description 本地联调用：模拟用户在Mock渠道完成支付，由Mock渠道向/payment/callback/mock发送签名回调，走真实回调入账流程
+// @Tags 账户管理
+// @Accept json
+// @Produce json
+// @Param order_no query string true "充值单号" example(R20260107153000a1b2c3d4e5f6)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.RechargeOrderResp} "支付完成，Data返回最新充值单状态"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到用户UUID/UUID无效"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/充值单不是待支付状态/渠道不支持模拟支付"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "充值单不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "模拟支付失败"
+// @Router /payment/mock/pay [post]
+func (h *StaffHandler) SimulatePayment(c *gin.Context) {
+	h.handleRechargeOrder(c, h.accsvc.SimulatePayment)
+}
+
+// handleRechargeOrder 充值单查询/模拟支付共用流程：取登录用户 → 绑定order_no → 调用Service
+func (h *StaffHandler) handleRechargeOrder(c *gin.Context, fn func(ctx context.Context, userUUID, orderNo string) (*dto.RechargeOrderResp, error)) {
+	realUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RechargeOrderReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+
+	resp, err := fn(c.Request.Context(), realUUID, req.OrderNo)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	Success(c, "操作成功", resp)
+}
+
+// PaymentCallback 支付渠道回调接口
+// @Summary 支付渠道回调
+// @Description 支付渠道异步通知支付结果（无需登录，由渠道签名保证真实性）；验签通过且金额一致才入账，重复通知不会重复入账。返回非200时渠道应重试
+// @Tags 账户管理
+// @Accept json
+// @Produce json
+// @Param provider path string true "支付渠道标识" example(mock)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "处理成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "验签失败/金额不一致/充值单已关闭"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "不支持的支付渠道/充值单不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "处理失败（渠道应重试）"
+// @Router /payment/callback/{provider} [post]
+func (h *StaffHandler) PaymentCallback(c *gin.Context) {
+	err := h.accsvc.HandlePaymentCallback(c.Request.Context(), c.Param("provider"), c.Request)
+	if err != nil {
+		// 回调方是支付渠道而非前端，错误同样以HTTP状态码表达结果（渠道按状态码判断是否重试）
+		fail(c, err)
+		return
+	}
+	Success(c, "处理成功", nil)
 }
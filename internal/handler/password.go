@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"CMS/internal/dto"
+	"CMS/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPasswordHandler 忘记密码-发送重置验证码接口
+// @Summary 忘记密码-发送重置验证码
+// @Description 向账号绑定且已验证的邮箱发送重置密码验证码（邮箱未注册或未验证同样返回成功并占用发送间隔，不提示账号是否存在）
+// @Tags 密码管理
+// @Accept json
+// @Produce json
+// @Param req body dto.PasswordForgotReq true "忘记密码参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "验证码已发送"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 429 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "发送过于频繁（同一邮箱/IP有发送间隔）"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "发送验证码失败"
+// @Router /staff/password/forgot [post]
+func (h *StaffHandler) ForgotPasswordHandler(c *gin.Context) {
+	var req dto.PasswordForgotReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.svc.ForgotPassword(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[忘记密码接口] 业务处理失败", "email", req.Email, "error", err)
+		fail(c, err)
+		return
+	}
+
+	Success(c, "若该邮箱已注册，重置验证码已发送，请查收邮件", nil)
+}
+
+// ResetPasswordHandler 忘记密码-重置密码接口
+// @Summary 忘记密码-重置密码
+// @Description 凭邮箱验证码设置新密码，成功后该账号所有设备的登录状态全部失效
+// @Tags 密码管理
+// @Accept json
+// @Produce json
+// @Param req body dto.PasswordResetReq true "重置密码参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "重置成功，请重新登录"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/验证码错误或已过期"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "邮箱未验证"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "重置密码失败"
+// @Router /staff/password/reset [post]
+func (h *StaffHandler) ResetPasswordHandler(c *gin.Context) {
+	var req dto.PasswordResetReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.svc.ResetPassword(c.Request.Context(), req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "密码已重置，请重新登录", nil)
+}
+
+// ChangePasswordHandler 修改密码接口
+// @Summary 修改密码
+// @Description 已登录用户校验原密码后修改密码，成功后所有设备（含当前设备）需重新登录
+// @Tags 密码管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Param req body dto.PasswordChangeReq true "修改密码参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改成功，请重新登录"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/原密码错误/新旧密码相同"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改密码失败"
+// @Router /staff/password/change [post]
+func (h *StaffHandler) ChangePasswordHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.PasswordChangeReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.svc.ChangePassword(c.Request.Context(), userUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "密码已修改，请重新登录", nil)
+}
@@ -1,50 +1,33 @@
 package handler
 
 import (
+	"CMS/internal/apperr"
 	"CMS/internal/dto"
+	"CMS/internal/pkg/langdetect"
 	"CMS/internal/service"
-	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CreateResourceHandler 创建资源接口
 // @Summary 创建资源
-// @Description 登录用户创建文本/代码类资源（需先通过Token获取UUID，关联用户ID）
+// @Description 登录用户创建文本/代码类资源（需先通过Token获取UUID，关联用户ID）；可指定标签（最多5个）与分类，未指定编程语言时按代码内容自动识别
 // @Tags 资源管理
 // @Accept json
 // @Produce json
-// @Param req body dto.CreateResourceReq true "创建资源请求参数" example({"title":"Go入门教程","text_content":"基础语法讲解","code_content":"package main\nimport fmt\nfunc main() {fmt.Println(\"hello\")}"})
-// @Success 200 {object} dto.Response{Code=int,Message=string,Data=dto.ResourceItem} "创建成功"
-// @Failure 401 {object} dto.Response{Code=int,Message=string,Data=nil} "未获取到UUID/UUID无效"
-// @Failure 404 {object} dto.Response{Code=int,Message=string,Data=nil} "用户不存在"
-// @Failure 400 {object} dto.Response{Code=int,Message=string,Data=nil} "参数校验失败"
-// @Failure 500 {object} dto.Response{Code=int,Message=string,Data=nil} "查询用户失败/创建资源失败"
+// @Param req body dto.CreateResourceReq true "创建资源请求参数" example({"title":"Go入门教程","text_content":"基础语法讲解","code_content":"package main\nimport fmt\nfunc main() {fmt.Println(\"hello\")}","category_id":3,"tags":["gin","入门"]})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.ResourceItem} "创建成功"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "用户不存在/分类不存在"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（含标签过多/不支持的编程语言）"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询用户失败/创建资源失败"
 // @Router /resource/create [post]
 func (h *StaffHandler) CreateResourceHandler(c *gin.Context) {
 	// ========== 步骤1：从Context中获取用户UUID（认证中间件解析Token后注入） ==========
-	rawUserUUID, exists := c.Get("uuid")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    401,
-			"message": "未获取到用户UUID，请先登录",
-			"data":    nil,
-		})
-		return
-	}
-
-	// 转换UUID为字符串（Token解析的uuid是string类型）
-	userUUID, ok := rawUserUUID.(string)
-	if !ok || strings.TrimSpace(userUUID) == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"code":    401,
-			"message": "用户UUID无效",
-			"data":    nil,
-		})
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
 		return
 	}
 
@@ -52,19 +35,11 @@ func (h *StaffHandler) CreateResourceHandler(c *gin.Context) {
 	ctx := c.Request.Context() // 复用HTTP请求的Context（支持超时/取消）
 	user, err := h.svc.GetUserByUuid(ctx, userUUID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("通过UUID查询用户失败：%v", err),
-			"data":    nil,
-		})
+		fail(c, err)
 		return
 	}
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    404,
-			"message": "用户不存在（UUID错误）",
-			"data":    nil,
-		})
+		fail(c, apperr.ErrUserNotFound.WithMsg("用户不存在（UUID错误）"))
 		return
 	}
 	// 拿到用户主键ID（uint64类型，匹配数据库）
@@ -72,198 +47,168 @@ func (h *StaffHandler) CreateResourceHandler(c *gin.Context) {
 
 	// ========== 步骤3：绑定并校验前端请求参数 ==========
 	var req dto.CreateResourceReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": fmt.Sprintf("参数校验失败：%v", err),
-			"data":    nil,
-		})
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
 	// ========== 步骤4：调用Service层创建资源 ==========
-	err = h.resourcesvc.(*service.ResourceServiceImpl).CreateResource(
-		ctx,
-		userID, // 传入查询到的用户主键ID
-		req.Title,
-		req.TextContent,
-		req.CodeContent,
-	)
+	resource, err := h.resourcesvc.CreateResource(ctx, userID, req)
 	if err != nil {
-		// 区分不同错误类型，返回对应状态码
-		switch {
-		case strings.Contains(err.Error(), "查询用户失败"):
-			c.JSON(http.StatusNotFound, gin.H{
-				"code":    404,
-				"message": err.Error(),
-				"data":    nil,
-			})
-		case strings.Contains(err.Error(), "创建资源失败"):
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": err.Error(),
-				"data":    nil,
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": fmt.Sprintf("创建资源异常：%v", err),
-				"data":    nil,
-			})
-		}
+		fail(c, err)
 		return
 	}
 
 	// ========== 步骤5：构造成功响应（新增：点赞/浏览/评论量初始值0） ==========
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "资源创建成功",
-		"data": gin.H{
-			"user_id":       userID,
-			"user_uuid":     userUUID, // 可选：返回uuid
-			"title":         req.Title,
-			"publish_time":  time.Now().Format("2006-01-02 15:04:05"),
-			"like_count":    0, // 新增：点赞量初始值
-			"view_count":    0, // 新增：浏览量初始值
-			"comment_count": 0, // 新增：评论量初始值
-		},
+	Success(c, "资源创建成功", gin.H{
+		"id":            resource.ID,
+		"user_id":       userID,
+		"user_uuid":     userUUID, // 可选：返回uuid
+		"title":         resource.Title,
+		"publish_time":  resource.PublishTime.Format("2006-01-02 15:04:05"),
+		"like_count":    0, // 新增：点赞量初始值
+		"view_count":    0, // 新增：浏览量初始值
+		"comment_count": 0, // 新增：评论量初始值
+		"price":         resource.Price,
+		"language":      resource.Language, // 指定或自动识别的编程语言（空为未识别）
+		"language_name": langdetect.Name(resource.Language),
+		"category_id":   resource.CategoryID,
+		"tags":          resource.Tags,
 	})
 }
 
 // ResourceListHandler 查询资源列表接口
 // @Summary 查询资源列表
-// @Description 登录用户分页查询资源列表，支持关键词模糊搜索（需先登录验证UUID）
+// @Description 登录用户分页查询资源列表（需先登录验证UUID）；传keyword时全文检索标题/内容/代码，结果按相关度排序并返回高亮片段（highlight），否则按发布时间倒序；均支持按作者、发布日期范围、编程语言、分类（含子孙分类）、标签过滤，并返回各维度的分面统计（facets）
 // @Tags 资源管理
 // @Accept json
 // @Produce json
-// @Param req body dto.ResourceListReq true "资源列表查询参数" example({"page":1,"size":10,"keyword":"Go教程"})
-// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.ResourceListResp} "查询成功，返回资源列表及分页信息"
+// @Param req body dto.ResourceListReq true "资源列表查询参数" example({"page":1,"size":10,"keyword":"Go 教程","author":"test_user","start_date":"2026-01-01","end_date":"2026-01-31","language":"go","category_id":1,"tag":"gin"})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.ResourceListResp} "查询成功，返回资源列表、分页信息及分面统计"
 // @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未检测到登录状态"
 // @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
 // @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询资源列表失败"
 // @Router /resource/list [post]
 func (h *StaffHandler) ResourceListHandler(c *gin.Context) {
 	// ========== 步骤1：认证校验 ==========
-	_, exists := c.Get("uuid") // 确保与认证中间件的Key一致（如user_uuid）
+	rawUserUUID, exists := c.Get("uuid") // 确保与认证中间件的Key一致（如user_uuid）
 	if !exists {
-		c.JSON(http.StatusUnauthorized, dto.CommonResponse{
-			Code: 401,
-			Msg:  "未检测到登录状态，请先登录",
-			Data: nil,
-		})
+		fail(c, apperr.ErrUnauthenticated.WithMsg("未检测到登录状态，请先登录"))
 		return
 	}
 
 	// ========== 步骤2：绑定并校验POST JSON参数（使用ResourceListReq） ==========
 	var req dto.ResourceListReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// 参数校验失败（如page<1、size>50），返回标准化400响应
-		c.JSON(http.StatusBadRequest, dto.CommonResponse{
-			Code: 400,
-			Msg:  fmt.Sprintf("参数校验失败：%v", err),
-			Data: nil,
-		})
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
 	// ========== 步骤3：调用Service层查询资源列表 ==========
 	ctx := c.Request.Context()
 	// Service层返回 []dto.ResourceItem + 总条数（已包含新字段）
-	resourceItems, total, err := h.resourcesvc.GetResourceList(ctx, req.Page, req.Size, req.Keyword)
+	userUUID, _ := rawUserUUID.(string) // 用于标记列表中当前用户已点赞的资源
+	resourceItems, total, err := h.resourcesvc.GetResourceList(ctx, userUUID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.CommonResponse{
-			Code: 500,
-			Msg:  fmt.Sprintf("查询资源列表失败：%v", err),
-			Data: nil,
-		})
+		fail(c, err)
+		return
+	}
+	facets, err := h.resourcesvc.GetResourceFacets(ctx, req)
+	if err != nil {
+		fail(c, err)
 		return
 	}
 
 	// ========== 步骤4：封装响应数据（使用ResourceListResp） ==========
 	respData := dto.ResourceListResp{
-		List:  resourceItems, // 资源列表（自动包含点赞/浏览/评论量）
-		Total: total,         // 总条数
-		Page:  req.Page,      // 当前页（与请求一致）
-		Size:  req.Size,      // 每页条数（与请求一致）
+		List:   resourceItems, // 资源列表（自动包含点赞/浏览/评论量）
+		Total:  total,         // 总条数
+		Page:   req.Page,      // 当前页（与请求一致）
+		Size:   req.Size,      // 每页条数（与请求一致）
+		Facets: facets,        // 语言/分类/标签分面统计
 	}
 
 	// ========== 步骤5：返回标准化成功响应 ==========
-	c.JSON(http.StatusOK, dto.CommonResponse{
-		Code: 200,
-		Msg:  "查询成功",
-		Data: respData, // Data字段为ResourceListResp结构体
-	})
+	Success(c, "查询成功", respData)
 }
 
 // ResourceDetailHandler 查询资源详情接口
 // @Summary 查询资源详情
-// @Description 根据资源ID（Query参数）查询资源完整信息，包含点赞/浏览/评论量
+// @Description 根据资源ID（Query参数）查询资源完整信息，包含点赞/浏览/评论量；携带Token时返回当前用户是否已点赞；付费资源仅作者和购买者返回完整代码，其余用户返回代码预览（locked=true）
 // @Tags 资源管理
 // @Accept json
 // @Produce json
 // @Param id query string true "资源ID" example(1)
-// @Success 200 {object} dto.Response{Code=int,Message=string,Data=model.Resource} "查询成功，返回资源详情"
-// @Failure 400 {object} dto.Response{Code=int,Message=string,Data=nil} "资源ID为空/格式错误"
-// @Failure 404 {object} dto.Response{Code=int,Message=string,Data=nil} "资源不存在"
-// @Failure 500 {object} dto.Response{Code=int,Message=string,Data=nil} "查询资源失败"
+// @Param Authorization header string false "Bearer Token（可选）"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=model.Resource} "查询成功，返回资源详情"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源ID为空/格式错误"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询资源失败"
 // @Router /resource/detail [get]
 func (h *StaffHandler) ResourceDetailHandler(c *gin.Context) {
 	// 1. 获取URL中的id参数（query参数）
 	idStr := c.Query("id")
 	if idStr == "" {
 		// 参数缺失，返回400错误
-		c.JSON(http.StatusOK, dto.Response{
-			Code:    400,
-			Message: "参数错误：资源ID不能为空",
-			Data:    nil,
-		})
+		fail(c, apperr.InvalidArgument("参数错误：资源ID不能为空"))
 		return
 	}
 
 	// 2. 转换ID为整数并校验（适配uint64类型）
 	idUint64, err := strconv.ParseUint(idStr, 10, 64)
 	if err != nil || idUint64 <= 0 {
-		c.JSON(http.StatusOK, dto.Response{
-			Code:    400,
-			Message: "参数错误：资源ID必须为正整数",
-			Data:    nil,
-		})
+		fail(c, apperr.InvalidArgument("参数错误：资源ID必须为正整数"))
 		return
 	}
 
 	// 3. 调用服务层查询资源详情（真实DB查询）
 	resource, err := h.resourcesvc.GetResourceByID(c.Request.Context(), idUint64)
 	if err != nil {
-		// 数据库查询出错（如连接失败、SQL错误等）
-		c.JSON(http.StatusOK, dto.Response{
-			Code:    500,
-			Message: "服务器内部错误：查询资源失败 - " + err.Error(),
-			Data:    nil,
-		})
+		fail(c, err)
 		return
 	}
 
 	// 4. 检查资源是否存在
 	if resource == nil {
-		c.JSON(http.StatusOK, dto.Response{
-			Code:    404,
-			Message: "资源不存在：未找到ID为" + idStr + "的资源",
-			Data:    nil,
-		})
+		fail(c, apperr.ErrResourceNotFound.WithMsg("资源不存在：未找到ID为"+idStr+"的资源"))
 		return
 	}
 
-	// 5. 格式化响应数据（新增：点赞/浏览/评论量字段）
+	// 5. 查询当前用户点赞状态（OptionalJWTMiddleware注入uuid，游客为false）
+	userUUID := c.GetString("uuid")
+	liked, err := h.resourcesvc.IsLiked(c.Request.Context(), userUUID, idUint64)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	// 6. 付费资源权限：非作者/未购买只返回代码预览
+	canView, err := h.resourcesvc.CanViewCode(c.Request.Context(), userUUID, resource)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	codeContent := resource.CodeContent
+	if !canView {
+		codeContent = service.CodePreview(resource.CodeContent)
+	}
+
+	// 7. 格式化响应数据（新增：点赞/浏览/评论量字段）
 	responseData := struct {
-		ID           uint64 `json:"id"`
-		Title        string `json:"title"`
-		Author       string `json:"author"`
-		PublishTime  string `json:"publish_time"` // 转为字符串格式
-		TextContent  string `json:"text_content"`
-		CodeContent  string `json:"code_content"`
-		LikeCount    uint64 `json:"like_count"`    // 新增：点赞量
-		ViewCount    uint64 `json:"view_count"`    // 新增：浏览量
-		CommentCount uint64 `json:"comment_count"` // 新增：评论量
+		ID           uint64   `json:"id"`
+		Title        string   `json:"title"`
+		Author       string   `json:"author"`
+		PublishTime  string   `json:"publish_time"` // 转为字符串格式
+		TextContent  string   `json:"text_content"`
+		CodeContent  string   `json:"code_content"`
+		LikeCount    uint64   `json:"like_count"`    // 新增：点赞量
+		ViewCount    uint64   `json:"view_count"`    // 新增：浏览量
+		CommentCount uint64   `json:"comment_count"` // 新增：评论量
+		Liked        bool     `json:"liked"`         // 当前用户是否已点赞
+		Price        string   `json:"price"`         // 价格（0.00为免费）
+		Locked       bool     `json:"locked"`        // 代码是否为预览（未购买）
+		Language     string   `json:"language"`      // 编程语言标识（空为未识别）
+		LanguageName string   `json:"language_name"` // 编程语言展示名称
+		CategoryID   uint64   `json:"category_id"`   // 所属分类ID（0为未分类）
+		Tags         []string `json:"tags"`          // 标签
 		// 如需返回user_id可添加，前端没要求则可省略
 	}{
 		ID:           resource.ID,
@@ -271,147 +216,516 @@ func (h *StaffHandler) ResourceDetailHandler(c *gin.Context) {
 		Author:       resource.Author,
 		PublishTime:  resource.PublishTime.Format("2006-01-02 15:04:05"), // 关键：时间格式化
 		TextContent:  resource.TextContent,
-		CodeContent:  resource.CodeContent,
+		CodeContent:  codeContent,
 		LikeCount:    resource.LikeCount,    // 新增：赋值点赞量
 		ViewCount:    resource.ViewCount,    // 新增：赋值浏览量
 		CommentCount: resource.CommentCount, // 新增：赋值评论量
+		Liked:        liked,
+		Price:        resource.Price.StringFixed(2),
+		Locked:       !canView,
+		Language:     resource.Language,
+		LanguageName: langdetect.Name(resource.Language),
+		CategoryID:   resource.CategoryID,
+		Tags:         resource.Tags,
 	}
 
-	// 6. 返回成功响应（完全匹配前端要求的格式）
-	c.JSON(http.StatusOK, dto.Response{
-		Code:    200,
-		Message: "success", // 对应前端的msg字段
-		Data:    responseData,
-	})
+	// 8. 返回成功响应（完全匹配前端要求的格式）
+	Success(c, "success", responseData)
+}
+
+// UpdateResourceMetaHandler 修改资源标签/语言/分类接口
+// @Summary 修改资源标签/语言/分类
+// @Description 作者修改自己资源的元信息：标签整体替换（传空数组清除全部标签），language为空时按代码内容重新识别，category_id为0表示未分类
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param req body dto.UpdateResourceMetaReq true "资源元信息" example({"id":1,"language":"go","category_id":3,"tags":["gin","入门"]})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.ResourceMetaResp} "修改成功，返回规范化后的元信息"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（标签过多/格式错误、不支持的编程语言）"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "只能修改自己发布的资源"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在/分类不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改资源信息失败"
+// @Router /resource/meta [post]
+func (h *StaffHandler) UpdateResourceMetaHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateResourceMetaReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	resp, err := h.resourcesvc.UpdateResourceMeta(c.Request.Context(), userUUID, req)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	Success(c, "资源信息修改成功", resp)
+}
+
+// TaxonomyHandler 查询资源分类体系接口
+// @Summary 查询分类树与编程语言
+// @Description 返回资源分类树（最多3级）及支持的编程语言列表，供发布资源和列表筛选使用，无需登录
+// @Tags 资源管理
+// @Produce json
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.TaxonomyResp} "查询成功"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询分类失败"
+// @Router /resource/taxonomy [get]
+func (h *StaffHandler) TaxonomyHandler(c *gin.Context) {
+	resp, err := h.resourcesvc.GetTaxonomy(c.Request.Context())
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	Success(c, "查询成功", resp)
 }
 
 // IncrViewCountHandler 增加资源浏览量接口
 // @Summary 增加资源浏览量
-// @Description 传入资源ID，将该资源的浏览量+1
+// @Description 传入资源ID记录一次浏览：登录用户按UUID、游客按客户端IP去重，窗口期内重复浏览不计数；不存在或已隐藏的资源返回404；浏览量由后台批量写入，存在短暂延迟
 // @Tags 资源管理
 // @Accept json
 // @Produce json
 // @Param req body dto.ResourceIDReq true "资源ID参数" example({"id":1})
-// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "浏览量更新成功"
+// @Param Authorization header string false "Bearer Token（可选）"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.ViewResp} "浏览量更新成功"
 // @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（ID为空/小于1）"
 // @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "增加浏览量失败"
-// @Router /resource/incr-view [post]
+// @Router /resource/incr-view-count [post]
 func (h *StaffHandler) IncrViewCountHandler(c *gin.Context) {
 	// 1. 绑定请求参数
 	var req dto.ResourceIDReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.CommonResponse{
-			Code: 400,
-			Msg:  fmt.Sprintf("参数校验失败：%v", err),
-			Data: nil,
-		})
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
-	// 2. 调用Service层增加浏览量
+	// 2. 调用Service层记录浏览（去重后在内存聚合，不直接写库）
 	ctx := c.Request.Context()
-	err := h.resourcesvc.IncrViewCount(ctx, req.ID)
+	counted, err := h.resourcesvc.IncrViewCount(ctx, req.ID, viewerKey(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.CommonResponse{
-			Code: 500,
-			Msg:  fmt.Sprintf("增加浏览量失败：%v", err),
-			Data: nil,
-		})
+		fail(c, err)
 		return
-
 	}
 
 	// 3. 返回成功响应
-	c.JSON(http.StatusOK, dto.CommonResponse{
-		Code: 200,
-		Msg:  "浏览量更新成功",
-		Data: nil,
-	})
+	Success(c, "浏览量更新成功", dto.ViewResp{ID: req.ID, Counted: counted})
+}
+
+// viewerKey 生成浏览者标识：登录用户取UUID（OptionalJWTMiddleware注入），游客取客户端IP
+// 游客不叠加User-Agent等可由客户端任意修改的请求头，否则更换请求头即可绕过去重刷浏览量
+// ClientIP只采信server.trusted_proxies中代理转发的X-Forwarded-For/X-Real-IP（见router.newEngine），直连时伪造转发头无效
+func viewerKey(c *gin.Context) string {
+	if userUUID := c.GetString("uuid"); userUUID != "" {
+		return "u:" + userUUID
+	}
+	return "ip:" + c.ClientIP()
 }
 
-// IncrLikeCountHandler 点赞接口：增加资源点赞数
+// LikeHandler 点赞接口：当前用户点赞资源
 // @Summary 资源点赞
-// @Description 传入资源ID，将该资源的点赞量+1
+// @Description 登录用户点赞资源（身份取自Token），每个用户对同一资源只计一次，重复点赞不会增加点赞量
 // @Tags 资源管理
 // @Accept json
 // @Produce json
 // @Param req body dto.ResourceIDReq true "资源ID参数" example({"id":1})
-// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "点赞成功"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.LikeResp} "点赞成功，返回最新点赞量"
 // @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（ID为空/小于1）"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在"
 // @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "点赞失败"
 // @Router /resource/like [post]
-func (h *StaffHandler) IncrLikeCountHandler(c *gin.Context) {
-	// 1. 绑定并校验请求参数（和浏览量接口格式一致）
+func (h *StaffHandler) LikeHandler(c *gin.Context) {
+	h.handleLike(c, true)
+}
+
+// UnlikeHandler 取消点赞接口：当前用户取消对资源的点赞
+// @Summary 取消资源点赞
+// @Description 登录用户取消点赞（身份取自Token），未点赞时直接返回当前点赞量
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param req body dto.ResourceIDReq true "资源ID参数" example({"id":1})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.LikeResp} "取消点赞成功，返回最新点赞量"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（ID为空/小于1）"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "取消点赞失败"
+// @Router /resource/unlike [post]
+func (h *StaffHandler) UnlikeHandler(c *gin.Context) {
+	h.handleLike(c, false)
+}
+
+// handleLike 点赞/取消点赞共用处理逻辑
+func (h *StaffHandler) handleLike(c *gin.Context, like bool) {
+	action := "点赞"
+	if !like {
+		action = "取消点赞"
+	}
+
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	// 1. 绑定并校验请求参数
 	var req dto.ResourceIDReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.CommonResponse{
-			Code: 400,
-			Msg:  fmt.Sprintf("参数校验失败：%v", err),
-			Data: nil,
-		})
-		println(req.ID)
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
-	// 2. 调用Service层增加点赞量（需确保Service已实现IncrLikeCount方法）
+	// 2. 调用Service层（点赞明细与点赞量在同一事务内更新）
 	ctx := c.Request.Context()
-	err := h.resourcesvc.IncrLikeCount(ctx, req.ID)
+	var (
+		resp *dto.LikeResp
+		err  error
+	)
+	if like {
+		resp, err = h.resourcesvc.LikeResource(ctx, userUUID, req.ID)
+	} else {
+		resp, err = h.resourcesvc.UnlikeResource(ctx, userUUID, req.ID)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.CommonResponse{
-			Code: 500,
-			Msg:  "点赞失败", // 严格匹配你要求的返回文案
-			Data: nil,
-		})
+		fail(c, err)
 		return
 	}
 
-	// 3. 返回成功响应（匹配你要求的格式）
-	c.JSON(http.StatusOK, dto.CommonResponse{
-		Code: 200,
-		Msg:  "点赞成功", // 严格匹配你要求的返回文案
-		Data: nil,
-	})
+	// 3. 返回成功响应
+	Success(c, action+"成功", resp)
+}
+
+// PurchaseResourceHandler 购买资源接口
+// @Summary 购买付费资源
+// @Description 登录用户使用账户余额购买付费资源：扣减买家余额、作者入账、记录购买关系在同一事务内完成，成功后返回完整代码
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param req body dto.ResourceIDReq true "资源ID参数" example({"id":1})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.PurchaseResp} "购买成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/免费资源/自己的资源/已购买/余额不足"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "购买失败"
+// @Router /resource/purchase [post]
+func (h *StaffHandler) PurchaseResourceHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	// 1. 绑定并校验请求参数
+	var req dto.ResourceIDReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	// 2. 调用Service层购买（扣款、入账、购买记录同一事务）
+	resp, err := h.resourcesvc.PurchaseResource(c.Request.Context(), userUUID, req.ID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "购买成功", resp)
+}
+
+// SetPriceHandler 修改资源价格接口
+// @Summary 修改资源价格
+// @Description 作者修改自己发布的资源价格，0表示免费（已购买用户不受影响）
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param req body dto.SetPriceReq true "价格参数" example({"id":1,"price":"9.90"})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/价格不合法"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在或非本人资源"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "修改失败"
+// @Router /resource/price [post]
+func (h *StaffHandler) SetPriceHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetPriceReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.resourcesvc.SetResourcePrice(c.Request.Context(), userUUID, req.ID, req.Price); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "修改成功", nil)
 }
 
 // CreateCommentHandler 评论接口：提交资源评论并增加评论数
 // @Summary 提交资源评论
-// @Description 传入资源ID和评论内容，创建评论并将该资源的评论量+1
+// @Description 登录用户传入资源ID和评论内容，创建评论并将该资源的评论量+1（评论者身份取自Token）；传parent_id为回复指定评论，内容中的@用户名会通知对应用户
 // @Tags 资源管理
 // @Accept json
 // @Produce json
-// @Param req body dto.CommentReq true "评论请求参数" example({"id":1,"content":"这篇教程很实用！"})
-// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "评论成功"
-// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（ID为空/小于1或评论内容为空）"
+// @Param req body dto.CommentReq true "评论请求参数" example({"id":1,"parent_id":0,"content":"@test_user 这篇教程很实用！"})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.CommentItem} "评论成功，返回新评论"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败（ID为空/小于1、评论内容为空/过长、回复层级超限）"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "资源不存在/被回复的评论不存在"
 // @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "评论失败"
 // @Router /resource/comment [post]
 func (h *StaffHandler) CreateCommentHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
 	// 1. 绑定并校验请求参数（新增content字段，必填且非空）
 	var req dto.CommentReq
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.CommonResponse{
-			Code: 400,
-			Msg:  fmt.Sprintf("参数校验失败：%v", err),
-			Data: nil,
-		})
+	if !bindReq(c, &req, c.ShouldBindJSON) {
 		return
 	}
 
-	// 2. 调用Service层创建评论（需确保Service已实现CreateComment方法）
+	// 2. 调用Service层创建评论（评论写入与评论量+1在同一事务）
 	ctx := c.Request.Context()
-	err := h.resourcesvc.CreateComment(ctx, req.ID, req.Content)
+	item, err := h.resourcesvc.CreateComment(ctx, userUUID, req.ID, req.ParentID, req.Content)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.CommonResponse{
-			Code: 500,
-			Msg:  "评论失败", // 严格匹配你要求的返回文案
-			Data: nil,
-		})
+		fail(c, err)
 		return
 	}
 
 	// 3. 返回成功响应（匹配你要求的格式）
-	c.JSON(http.StatusOK, dto.CommonResponse{
-		Code: 200,
-		Msg:  "评论成功", // 严格匹配你要求的返回文案
-		Data: nil,
+	Success(c, "评论成功", item)
+}
+
+// CommentListHandler 评论列表接口
+// @Summary 分页查询资源评论
+// @Description 根据资源ID（Query参数）分页查询评论，按评论时间倒序
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param id query int true "资源ID" example(1)
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页条数（默认10，最大50）" example(10)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.CommentListResp} "查询成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询评论失败"
+// @Router /resource/comments [get]
+func (h *StaffHandler) CommentListHandler(c *gin.Context) {
+	var req dto.CommentListReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	// 分页参数兜底
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 10
+	}
+
+	items, total, err := h.resourcesvc.GetCommentList(c.Request.Context(), req.ID, req.Page, req.Size)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", dto.CommentListResp{
+		List:  items,
+		Total: total,
+		Page:  req.Page,
+		Size:  req.Size,
+	})
+}
+
+// UpdateCommentHandler 编辑评论接口
+// @Summary 编辑评论
+// @Description 评论作者修改自己的评论内容，@提及随内容重新解析
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param req body dto.UpdateCommentReq true "编辑评论参数" example({"id":1,"content":"更新后的评论"})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.CommentItem} "编辑成功，返回编辑后的评论"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无权操作他人的评论"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "评论不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "编辑评论失败"
+// @Router /resource/comment/update [post]
+func (h *StaffHandler) UpdateCommentHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateCommentReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	item, err := h.resourcesvc.UpdateComment(c.Request.Context(), userUUID, req.ID, req.Content)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "编辑成功", item)
+}
+
+// DeleteCommentHandler 删除评论接口
+// @Summary 删除评论
+// @Description 评论作者删除自己的评论（其下回复一并删除），并同步扣减资源评论量
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param req body dto.CommentIDReq true "评论ID参数" example({"id":1})
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "无权操作他人的评论"
+// @Failure 404 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "评论不存在"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "删除评论失败"
+// @Router /resource/comment/delete [post]
+func (h *StaffHandler) DeleteCommentHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CommentIDReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.resourcesvc.DeleteComment(c.Request.Context(), userUUID, req.ID); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "删除成功", nil)
+}
+
+// CommentTreeHandler 评论树接口
+// @Summary 分页查询资源评论树
+// @Description 按顶层评论分页，返回每条顶层评论及其回复树；depth控制返回的回复层级（默认3，最大5）
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param id query int true "资源ID" example(1)
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页顶层评论条数（默认10，最大50）" example(10)
+// @Param depth query int false "回复层级（默认3，1~5）" example(3)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.CommentTreeResp} "查询成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询评论失败"
+// @Router /resource/comments/tree [get]
+func (h *StaffHandler) CommentTreeHandler(c *gin.Context) {
+	var req dto.CommentTreeReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	// 分页/层级参数兜底
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 10
+	}
+	if req.Depth == 0 {
+		req.Depth = service.DefaultCommentTreeDepth
+	}
+
+	nodes, total, err := h.resourcesvc.GetCommentTree(c.Request.Context(), req.ID, req.Page, req.Size, req.Depth)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", dto.CommentTreeResp{
+		List:  nodes,
+		Total: total,
+		Page:  req.Page,
+		Size:  req.Size,
+		Depth: req.Depth,
 	})
 }
+
+// MentionListHandler @我的提醒列表接口
+// @Summary 查询@我的提醒
+// @Description 登录用户分页查询评论中@到自己的提醒，返回未读数
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Param page query int false "页码（默认1）" example(1)
+// @Param size query int false "每页条数（默认10，最大50）" example(10)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.MentionListResp} "查询成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询提醒失败"
+// @Router /resource/comment/mentions [get]
+func (h *StaffHandler) MentionListHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MentionListReq
+	if !bindReq(c, &req, c.ShouldBindQuery) {
+		return
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.Size == 0 {
+		req.Size = 10
+	}
+
+	resp, err := h.resourcesvc.GetMentions(c.Request.Context(), userUUID, req.Page, req.Size)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", resp)
+}
+
+// MarkMentionsReadHandler @我的提醒全部已读接口
+// @Summary @我的提醒全部标记已读
+// @Description 登录用户将所有@提醒标记为已读
+// @Tags 资源管理
+// @Accept json
+// @Produce json
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "标记成功"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未获取到UUID/UUID无效"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "标记失败"
+// @Router /resource/comment/mentions/read [post]
+func (h *StaffHandler) MarkMentionsReadHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.resourcesvc.MarkMentionsRead(c.Request.Context(), userUUID); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "标记成功", nil)
+}
+
+// requireUserUUID 从上下文获取当前登录用户UUID（失败时已上报401错误）
+func requireUserUUID(c *gin.Context) (string, bool) {
+	rawUUID, exists := c.Get("uuid")
+	if !exists {
+		fail(c, apperr.ErrUnauthenticated.WithMsg("未获取到用户UUID，请先登录"))
+		return "", false
+	}
+	userUUID, ok := rawUUID.(string)
+	if !ok || strings.TrimSpace(userUUID) == "" {
+		fail(c, apperr.ErrUnauthenticated.WithMsg("用户UUID无效"))
+		return "", false
+	}
+	return userUUID, true
+}
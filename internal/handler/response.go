@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Success 成功响应（所有接口的成功返回统一经此输出，失败返回统一经fail输出）
+func Success(c *gin.Context, msg string, data interface{}) {
+	c.JSON(http.StatusOK, dto.CommonResponse{
+		Code: 200,
+		Msg:  msg,
+		Data: data,
+	})
+}
+
+// fail 上报错误并终止后续处理，由middleware.ErrorHandler按错误类型输出统一响应
+// （*apperr.Error按其状态码/错误码返回，其余错误返回500）
+func fail(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// bindReq 按bind（ShouldBindJSON/ShouldBindQuery等）绑定并校验请求参数，失败时已上报400错误
+func bindReq(c *gin.Context, req interface{}, bind func(interface{}) error) bool {
+	if err := bind(req); err != nil {
+		fail(c, apperr.InvalidArgumentf("参数校验失败：%v", err))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"CMS/internal/dto"
+	"CMS/internal/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginTwoFactorHandler 两步验证登录接口
+// @Summary 两步验证登录
+// @Description 密码登录返回two_factor_required时，凭challenge_token与验证器动态码（或一次性恢复码）换取登录凭证；
+// @Description 挑战令牌短期有效，错误次数达到上限后需重新输入密码登录
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param req body dto.TwoFactorLoginReq true "两步验证登录参数"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.LoginResponse} "登录成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/动态码错误"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "挑战令牌无效或已过期/错误次数过多"
+// @Failure 403 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "账号已停用或封禁"
+// @Failure 429 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "登录失败次数过多，已临时锁定"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "登录失败"
+// @Router /staff/login/2fa [post]
+func (h *StaffHandler) LoginTwoFactorHandler(c *gin.Context) {
+	var req dto.TwoFactorLoginReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	resp, err := h.svc.LoginTwoFactor(c.Request.Context(), req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("[两步验证登录接口] 业务处理失败", "error", err)
+		fail(c, err)
+		return
+	}
+
+	Success(c, "登录成功", resp)
+}
+
+// TwoFactorStatusHandler 两步验证状态接口
+// @Summary 查询两步验证状态
+// @Description 查询当前账号是否已开启两步验证及剩余恢复码个数
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.TwoFactorStatusResp} "查询成功"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "查询两步验证状态失败"
+// @Router /staff/2fa/status [get]
+func (h *StaffHandler) TwoFactorStatusHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.TwoFactorStatus(c.Request.Context(), userUUID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "查询成功", resp)
+}
+
+// TwoFactorSetupHandler 绑定验证器接口
+// @Summary 绑定验证器
+// @Description 生成TOTP密钥及otpauth URI（前端生成二维码供验证器App扫描），调用/staff/2fa/enable提交动态码后才会开启；
+// @Description 未开启前重复调用会生成新密钥，旧密钥作废
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.TwoFactorSetupResp} "密钥已生成"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "两步验证已开启"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "生成密钥失败"
+// @Router /staff/2fa/setup [post]
+func (h *StaffHandler) TwoFactorSetupHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.svc.SetupTwoFactor(c.Request.Context(), userUUID)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "密钥已生成，请使用验证器App扫码后提交动态码完成开启", resp)
+}
+
+// TwoFactorEnableHandler 开启两步验证接口
+// @Summary 开启两步验证
+// @Description 提交验证器App中的动态码确认绑定，开启后返回一次性恢复码（只返回这一次，请妥善保存）
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Param req body dto.TwoFactorCodeReq true "动态码"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.TwoFactorRecoveryCodesResp} "开启成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/未绑定验证器/动态码错误/已开启"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "开启两步验证失败"
+// @Router /staff/2fa/enable [post]
+func (h *StaffHandler) TwoFactorEnableHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.TwoFactorCodeReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	resp, err := h.svc.EnableTwoFactor(c.Request.Context(), userUUID, req.Code)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "两步验证已开启，请妥善保存恢复码", resp)
+}
+
+// TwoFactorDisableHandler 关闭两步验证接口
+// @Summary 关闭两步验证
+// @Description 校验登录密码与动态码（或恢复码）后关闭两步验证，密钥与恢复码全部删除
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Param req body dto.TwoFactorDisableReq true "登录密码与动态码"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "关闭成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/登录密码错误/动态码错误/未开启"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "关闭两步验证失败"
+// @Router /staff/2fa/disable [post]
+func (h *StaffHandler) TwoFactorDisableHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.TwoFactorDisableReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	if err := h.svc.DisableTwoFactor(c.Request.Context(), userUUID, req); err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "两步验证已关闭", nil)
+}
+
+// TwoFactorRecoveryCodesHandler 重新生成恢复码接口
+// @Summary 重新生成恢复码
+// @Description 校验动态码（或恢复码）后重新生成一次性恢复码，旧恢复码全部作废
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "登录凭证，格式：Bearer {token}" example(Bearer eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9)
+// @Param req body dto.TwoFactorCodeReq true "动态码或恢复码"
+// @Success 200 {object} dto.CommonResponse{Code=int,Msg=string,Data=dto.TwoFactorRecoveryCodesResp} "生成成功"
+// @Failure 400 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "参数校验失败/动态码错误/未开启"
+// @Failure 401 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "未登录"
+// @Failure 500 {object} dto.CommonResponse{Code=int,Msg=string,Data=nil} "生成恢复码失败"
+// @Router /staff/2fa/recovery-codes [post]
+func (h *StaffHandler) TwoFactorRecoveryCodesHandler(c *gin.Context) {
+	userUUID, ok := requireUserUUID(c)
+	if !ok {
+		return
+	}
+	var req dto.TwoFactorCodeReq
+	if !bindReq(c, &req, c.ShouldBindJSON) {
+		return
+	}
+
+	resp, err := h.svc.RegenerateRecoveryCodes(c.Request.Context(), userUUID, req.Code)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+
+	Success(c, "恢复码已重新生成，旧恢复码已作废", resp)
+}
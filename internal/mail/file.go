@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer 文件邮件发送器：每封邮件写成一个.eml文件（本地开发时用邮件客户端直接打开查看）
+type FileMailer struct {
+	dir  string
+	from netmail.Address
+}
+
+// NewFileMailer 创建FileMailer实例（目录不存在时自动创建）
+func NewFileMailer(dir string, from netmail.Address) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建邮件保存目录失败：%w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send 生成完整邮件并写入{dir}/{时间}_{随机ID}.eml（先写临时文件再改名，避免读到半封邮件）
+func (m *FileMailer) Send(_ context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	now := time.Now()
+	raw, err := buildMessage(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s_%s.eml", now.Format("20060102-150405.000"), uuid.NewString()[:8])
+	path := filepath.Join(m.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return fmt.Errorf("写入邮件文件失败：%w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("保存邮件文件失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"CMS/internal/config"
+	"CMS/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"strings"
+)
+
+// Message 待发送的邮件（HTML正文必填，Text为纯文本备用正文，为空时只发送HTML）
+type Message struct {
+	To      []string // 收件人地址
+	Subject string   // 主题（可含中文，发送时按RFC 2047编码）
+	HTML    string   // HTML正文
+	Text    string   // 纯文本正文（不支持HTML的客户端显示）
+}
+
+// Mailer 邮件发送接口（SMTP/文件/内存三种实现，由config.mail.driver选择）
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// ErrDisabled 未启用邮件发送（mail.driver为空）
+var ErrDisabled = errors.New("邮件服务未配置")
+
+// New 按配置创建Mailer（mail.driver为空时返回的Mailer发送即报ErrDisabled）
+// 返回的Mailer每次发送都会计入cms_email_sends_total
+func New(mailCfg config.MailConfig, smtpCfg config.SMTPConfig) (Mailer, error) {
+	from := netmail.Address{Name: mailCfg.FromName, Address: mailCfg.From}
+	var m Mailer
+	driver := mailCfg.Driver
+	switch mailCfg.Driver {
+	case "":
+		m, driver = disabledMailer{}, "none"
+	case config.MailDriverSMTP:
+		m = NewSMTPMailer(smtpCfg, from)
+	case config.MailDriverFile:
+		fm, err := NewFileMailer(mailCfg.DropDir, from)
+		if err != nil {
+			return nil, err
+		}
+		m = fm
+	case config.MailDriverMemory:
+		m = NewMemoryMailer(from)
+	default:
+		return nil, fmt.Errorf("不支持的邮件发送方式：%s", mailCfg.Driver)
+	}
+	return &countingMailer{Mailer: m, driver: driver}, nil
+}
+
+// countingMailer 按发送方式与结果统计邮件发送次数
+type countingMailer struct {
+	Mailer
+	driver string
+}
+
+func (m *countingMailer) Send(ctx context.Context, msg *Message) error {
+	err := m.Mailer.Send(ctx, msg)
+	result := metrics.EmailResultSuccess
+	switch {
+	case errors.Is(err, ErrDisabled):
+		result = metrics.EmailResultDisabled
+	case err != nil:
+		result = metrics.EmailResultFailure
+	}
+	metrics.EmailSends.WithLabelValues(m.driver, result).Inc()
+	return err
+}
+
+// disabledMailer 未启用邮件发送时的占位实现
+type disabledMailer struct{}
+
+func (disabledMailer) Send(context.Context, *Message) error {
+	return ErrDisabled
+}
+
+// validate 发送前校验邮件内容（收件人地址格式、主题与正文非空）
+func (m *Message) validate() error {
+	if len(m.To) == 0 {
+		return errors.New("收件人不能为空")
+	}
+	for _, to := range m.To {
+		addr, err := netmail.ParseAddress(to)
+		if err != nil || addr.Address != strings.TrimSpace(to) {
+			return fmt.Errorf("收件人地址无效：%q", to)
+		}
+	}
+	if strings.TrimSpace(m.Subject) == "" {
+		return errors.New("邮件主题不能为空")
+	}
+	if strings.TrimSpace(m.HTML) == "" {
+		return errors.New("邮件正文不能为空")
+	}
+	return nil
+}
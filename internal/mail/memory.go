@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"context"
+	netmail "net/mail"
+	"sync"
+	"time"
+)
+
+// SentMessage 已发送邮件的记录（MemoryMailer保存）
+type SentMessage struct {
+	Message
+	From   string    // 发件人（含显示名称）
+	Raw    []byte    // 完整MIME邮件
+	SentAt time.Time // 发送时间
+}
+
+// MemoryMailer 内存邮件发送器：只保存邮件不实际投递（联调/测试时读取验证码等内容）
+type MemoryMailer struct {
+	from     netmail.Address
+	mu       sync.Mutex
+	messages []*SentMessage
+}
+
+// NewMemoryMailer 创建MemoryMailer实例
+func NewMemoryMailer(from netmail.Address) *MemoryMailer {
+	return &MemoryMailer{from: from}
+}
+
+// Send 生成完整邮件并保存到内存
+func (m *MemoryMailer) Send(_ context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	now := time.Now()
+	raw, err := buildMessage(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	sent := &SentMessage{Message: *msg, From: m.from.String(), Raw: raw, SentAt: now}
+	sent.To = append([]string(nil), msg.To...)
+	m.mu.Lock()
+	m.messages = append(m.messages, sent)
+	m.mu.Unlock()
+	return nil
+}
+
+// Messages 返回已发送邮件（按发送顺序）
+func (m *MemoryMailer) Messages() []*SentMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*SentMessage(nil), m.messages...)
+}
+
+// Last 返回发给指定收件人的最后一封邮件（没有返回nil）
+func (m *MemoryMailer) Last(to string) *SentMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		for _, addr := range m.messages[i].To {
+			if addr == to {
+				return m.messages[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Reset 清空已发送邮件
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	m.messages = nil
+	m.mu.Unlock()
+}
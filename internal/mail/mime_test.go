@@ -0,0 +1,176 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseBuilt 生成邮件并解析，同时检查原始报文只含ASCII且按CRLF换行、每行不超过998字节
+func parseBuilt(t *testing.T, from netmail.Address, msg *Message) *netmail.Message {
+	t.Helper()
+	raw, err := buildMessage(from, msg, time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("buildMessage返回错误：%v", err)
+	}
+	for i, line := range bytes.Split(raw, []byte("\r\n")) {
+		if bytes.IndexByte(line, '\n') >= 0 || bytes.IndexByte(line, '\r') >= 0 {
+			t.Fatalf("第%d行存在非CRLF换行：%q", i+1, line)
+		}
+		if len(line) > 998 {
+			t.Fatalf("第%d行超过998字节", i+1)
+		}
+		for _, b := range line {
+			if b > 0x7e {
+				t.Fatalf("第%d行含非ASCII字节：%q", i+1, line)
+			}
+		}
+	}
+	parsed, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("解析生成的邮件失败：%v", err)
+	}
+	return parsed
+}
+
+// decodeQP 解码quoted-printable正文，并检查编码后每行不超过76字符
+func decodeQP(t *testing.T, r io.Reader) string {
+	t.Helper()
+	encoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(encoded), "\r\n") {
+		if len(line) > 76 {
+			t.Fatalf("quoted-printable行超过76字符：%q", line)
+		}
+	}
+	plain, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(encoded)))
+	if err != nil {
+		t.Fatalf("quoted-printable解码失败：%v", err)
+	}
+	return string(plain)
+}
+
+// crlf 正文换行统一转为CRLF后的内容
+func crlf(s string) string {
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+func TestBuildMessageHeaders(t *testing.T) {
+	dec := new(mime.WordDecoder)
+	cases := []struct {
+		name     string
+		fromName string
+		subject  string
+		encoded  bool // 主题是否需要RFC 2047编码
+	}{
+		{"ASCII主题与发件人", "CMS", "Verify your email", false},
+		{"中文主题", "CMS", "【CMS】邮箱验证码", true},
+		{"中文发件人名称", "面试资料平台", "Your code", false},
+		{"长中文主题", "面试资料平台", strings.Repeat("资源《Go并发编程》购买成功", 5), true},
+		{"主题中的换行不会注入头部", "CMS", "hi\r\nBcc: evil@example.com", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			from := netmail.Address{Name: tc.fromName, Address: "noreply@example.com"}
+			parsed := parseBuilt(t, from, &Message{To: []string{"a@example.com"}, Subject: tc.subject, HTML: "<p>hi</p>"})
+
+			rawSubject := parsed.Header.Get("Subject")
+			if got := strings.HasPrefix(rawSubject, "=?UTF-8?b?"); got != tc.encoded {
+				t.Errorf("Subject=%q，是否编码=%v，期望%v", rawSubject, got, tc.encoded)
+			}
+			subject, err := dec.DecodeHeader(rawSubject)
+			if err != nil || subject != tc.subject {
+				t.Errorf("Subject解码为%q, %v，期望%q", subject, err, tc.subject)
+			}
+			if parsed.Header.Get("Bcc") != "" {
+				t.Error("主题内容不应产生额外的头部")
+			}
+
+			addr, err := (&netmail.AddressParser{WordDecoder: dec}).Parse(parsed.Header.Get("From"))
+			if err != nil {
+				t.Fatalf("解析From失败：%v", err)
+			}
+			if addr.Name != tc.fromName || addr.Address != from.Address {
+				t.Errorf("From解码为%q <%s>，期望%q <%s>", addr.Name, addr.Address, tc.fromName, from.Address)
+			}
+
+			if id := parsed.Header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+				t.Errorf("Message-ID=%q，期望使用发件域名", id)
+			}
+			if parsed.Header.Get("Date") != "Wed, 01 May 2024 08:00:00 +0000" {
+				t.Errorf("Date=%q", parsed.Header.Get("Date"))
+			}
+		})
+	}
+}
+
+func TestBuildMessageBody(t *testing.T) {
+	from := netmail.Address{Name: "CMS", Address: "noreply@example.com"}
+	longLine := strings.Repeat("验证码将在5分钟后失效，请勿泄露给他人。", 6)
+	cases := []struct {
+		name string
+		html string
+		text string
+	}{
+		{"仅HTML", `<p style="color:red">验证码：<b>123456</b></p>`, ""},
+		{"HTML与纯文本", "<p>你好</p>\n<p>a=b</p>", "你好\na=b"},
+		{"超长中文行", "<p>" + longLine + "</p>", longLine},
+		{"行尾空格与等号", "<p>price = 9.90 </p>", "price = 9.90 \nend="},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed := parseBuilt(t, from, &Message{To: []string{"a@example.com"}, Subject: "测试", HTML: tc.html, Text: tc.text})
+			mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatalf("解析Content-Type失败：%v", err)
+			}
+
+			if tc.text == "" {
+				if mediaType != "text/html" || params["charset"] != "UTF-8" {
+					t.Fatalf("Content-Type=%q，期望text/html; charset=UTF-8", parsed.Header.Get("Content-Type"))
+				}
+				if cte := parsed.Header.Get("Content-Transfer-Encoding"); cte != "quoted-printable" {
+					t.Fatalf("Content-Transfer-Encoding=%q，期望quoted-printable", cte)
+				}
+				if got := decodeQP(t, parsed.Body); got != crlf(tc.html) {
+					t.Errorf("正文解码为%q，期望%q", got, crlf(tc.html))
+				}
+				return
+			}
+
+			if mediaType != "multipart/alternative" {
+				t.Fatalf("Content-Type=%q，期望multipart/alternative", mediaType)
+			}
+			mr := multipart.NewReader(parsed.Body, params["boundary"])
+			for _, want := range []struct {
+				contentType string
+				content     string
+			}{
+				{"text/plain; charset=UTF-8", tc.text}, // 纯文本在前，HTML优先展示
+				{"text/html; charset=UTF-8", tc.html},
+			} {
+				part, err := mr.NextRawPart()
+				if err != nil {
+					t.Fatalf("读取%s部分失败：%v", want.contentType, err)
+				}
+				if part.Header.Get("Content-Type") != want.contentType || part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+					t.Fatalf("部分头部为%v，期望%s + quoted-printable", part.Header, want.contentType)
+				}
+				if got := decodeQP(t, part); got != crlf(want.content) {
+					t.Errorf("%s解码为%q，期望%q", want.contentType, got, crlf(want.content))
+				}
+			}
+			if _, err := mr.NextRawPart(); err != io.EOF {
+				t.Errorf("multipart应只有两部分，读取第三部分返回%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"CMS/internal/config"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPMailer 通过SMTP服务器发送邮件（支持SSL直连、STARTTLS及本地测试用的明文连接）
+type SMTPMailer struct {
+	cfg  config.SMTPConfig
+	from netmail.Address
+}
+
+// NewSMTPMailer 创建SMTPMailer实例
+func NewSMTPMailer(cfg config.SMTPConfig, from netmail.Address) *SMTPMailer {
+	return &SMTPMailer{cfg: cfg, from: from}
+}
+
+// Send 发送邮件：建立连接 → （STARTTLS）→ 登录 → 投递（每封邮件单独建立连接）
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+	raw, err := buildMessage(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// 超时取ctx截止时间与smtp.timeout中较早者
+	deadline := time.Now().Add(m.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn, err := m.dial(ctx, deadline)
+	if err != nil {
+		return fmt.Errorf("连接邮件服务器失败：%w", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("设置邮件发送超时失败：%w", err)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("创建SMTP客户端失败：%w", err)
+	}
+	defer client.Close()
+
+	if m.cfg.Security == config.SMTPSecurityStartTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return fmt.Errorf("启用STARTTLS失败：%w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+				return fmt.Errorf("邮箱登录失败（请检查账号与授权码）：%w", err)
+			}
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("设置发件人失败：%w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("设置收件人失败（%s）：%w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("准备发送邮件内容失败：%w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("写入邮件内容失败：%w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("发送邮件失败：%w", err)
+	}
+	// 邮件已被服务器接收，QUIT失败不影响结果
+	_ = client.Quit()
+	return nil
+}
+
+// dial 按加密方式建立连接（ssl直接TLS握手，starttls/none先建立明文连接）
+func (m *SMTPMailer) dial(ctx context.Context, deadline time.Time) (net.Conn, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	dialer := &net.Dialer{Deadline: deadline}
+	if m.cfg.Security == config.SMTPSecuritySSL {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.cfg.Host}}
+		return tlsDialer.DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
@@ -0,0 +1,288 @@
+// Package smtptest 提供本地SMTP测试服务器：监听127.0.0.1随机端口，接收并保存邮件而不投递，
+// 配合 mail.driver=smtp、smtp.security=none 使用，用于联调/测试完整的邮件发送流程
+package smtptest
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 服务器收到的邮件
+type Message struct {
+	From string    // MAIL FROM地址
+	To   []string  // RCPT TO地址
+	Data []byte    // 完整邮件内容（已去除点转义）
+	Auth string    // AUTH PLAIN登录账号（未登录为空）
+	At   time.Time // 接收时间
+}
+
+// Server 本地SMTP测试服务器（只支持EHLO/HELO、AUTH PLAIN、MAIL、RCPT、DATA、RSET、NOOP、QUIT）
+type Server struct {
+	Addr string // 监听地址（host:port）
+
+	ln       net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+	messages []*Message
+	notify   chan struct{}
+	closed   bool
+}
+
+// NewServer 在127.0.0.1随机端口启动测试服务器
+func NewServer() (*Server, error) {
+	return Listen("127.0.0.1:0")
+}
+
+// Listen 在指定地址启动测试服务器
+func Listen(addr string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("启动SMTP测试服务器失败：%w", err)
+	}
+	s := &Server{
+		Addr:   ln.Addr().String(),
+		ln:     ln,
+		conns:  make(map[net.Conn]struct{}),
+		notify: make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host 监听主机名
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return host
+}
+
+// Port 监听端口
+func (s *Server) Port() int {
+	addr, _ := s.ln.Addr().(*net.TCPAddr)
+	if addr == nil {
+		return 0
+	}
+	return addr.Port
+}
+
+// Messages 返回已收到的邮件（按接收顺序）
+func (s *Server) Messages() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Message(nil), s.messages...)
+}
+
+// WaitForMessages 等待收到至少n封邮件（超时返回错误）
+func (s *Server) WaitForMessages(n int, timeout time.Duration) ([]*Message, error) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		if len(s.messages) >= n {
+			msgs := append([]*Message(nil), s.messages...)
+			s.mu.Unlock()
+			return msgs, nil
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return nil, fmt.Errorf("等待邮件超时（期望%d封）", n)
+		}
+	}
+}
+
+// Close 停止服务器并断开所有连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.ln.Close()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// serve 接受连接，每个连接一个协程处理
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+// session 单个连接的会话状态
+type session struct {
+	from string
+	to   []string
+	auth string
+}
+
+// handle 处理单个SMTP会话
+func (s *Server) handle(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	defer tp.Close()
+	if err := tp.PrintfLine("220 smtptest ESMTP ready"); err != nil {
+		return
+	}
+
+	var sess session
+	for {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			err = printLines(tp, "250-smtptest", "250-8BITMIME", "250 AUTH PLAIN")
+		case "HELO":
+			err = tp.PrintfLine("250 smtptest")
+		case "AUTH":
+			sess.auth, err = authPlain(tp, arg)
+		case "MAIL":
+			sess.from, sess.to = extractAddr(arg, "FROM:"), nil
+			err = tp.PrintfLine("250 OK")
+		case "RCPT":
+			if sess.from == "" {
+				err = tp.PrintfLine("503 MAIL first")
+				break
+			}
+			sess.to = append(sess.to, extractAddr(arg, "TO:"))
+			err = tp.PrintfLine("250 OK")
+		case "DATA":
+			err = s.receive(tp, &sess)
+		case "RSET":
+			sess.from, sess.to = "", nil
+			err = tp.PrintfLine("250 OK")
+		case "NOOP":
+			err = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 Bye")
+			return
+		default:
+			err = tp.PrintfLine("502 Command not implemented")
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// receive 处理DATA命令：读取点结尾的邮件内容并保存
+func (s *Server) receive(tp *textproto.Conn, sess *session) error {
+	if sess.from == "" || len(sess.to) == 0 {
+		return tp.PrintfLine("503 MAIL and RCPT first")
+	}
+	if err := tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>"); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(tp.DotReader())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, &Message{From: sess.from, To: sess.to, Data: data, Auth: sess.auth, At: time.Now()})
+	close(s.notify)
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+
+	sess.from, sess.to = "", nil
+	return tp.PrintfLine("250 OK: queued")
+}
+
+// authPlain 处理AUTH PLAIN（接受任意账号密码，返回登录账号）
+func authPlain(tp *textproto.Conn, arg string) (string, error) {
+	mech, initial, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mech, "PLAIN") {
+		return "", tp.PrintfLine("504 Unrecognized authentication type")
+	}
+	if initial == "" {
+		if err := tp.PrintfLine("334 "); err != nil {
+			return "", err
+		}
+		line, err := tp.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		initial = line
+	}
+	user, err := decodePlain(initial)
+	if err != nil {
+		return "", tp.PrintfLine("501 %v", err)
+	}
+	return user, tp.PrintfLine("235 Authentication successful")
+}
+
+// extractAddr 从"FROM:<addr>"/"TO:<addr>"参数中取出地址
+func extractAddr(arg, prefix string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = arg[len(prefix):]
+	}
+	if i := strings.Index(arg, ">"); i >= 0 {
+		arg = arg[:i]
+	}
+	return strings.TrimPrefix(strings.TrimSpace(arg), "<")
+}
+
+// decodePlain 解析AUTH PLAIN凭据（base64("authzid\x00user\x00password")），返回登录账号
+func decodePlain(encoded string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", errors.New("invalid base64 credentials")
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 || parts[1] == "" {
+		return "", errors.New("invalid PLAIN credentials")
+	}
+	return parts[1], nil
+}
+
+// printLines 逐行写入多行响应
+func printLines(tp *textproto.Conn, lines ...string) error {
+	for _, line := range lines {
+		if err := tp.PrintfLine("%s", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// 邮件模板文件名（templates目录下，每个模板定义content块，由layout.html统一包裹）
+const (
+	TemplateVerifyCode      = "verify_code.html"
+	TemplatePasswordReset   = "password_reset.html"
+	TemplatePurchaseReceipt = "purchase_receipt.html"
+)
+
+// templates 启动时解析全部邮件模板（模板有误直接panic，避免运行时才发现）
+var templates = func() map[string]*template.Template {
+	m := make(map[string]*template.Template)
+	for _, name := range []string{TemplateVerifyCode, TemplatePasswordReset, TemplatePurchaseReceipt} {
+		m[name] = template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/"+name))
+	}
+	return m
+}()
+
+// render 渲染HTML正文（data中的内容会被自动转义）
+func render(name, subject string, data interface{}) (string, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return "", fmt.Errorf("邮件模板不存在：%s", name)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", map[string]interface{}{
+		"Subject": subject,
+		"Data":    data,
+	}); err != nil {
+		return "", fmt.Errorf("渲染邮件模板失败（%s）：%w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// VerifyCodeData 邮箱验证码邮件参数
+type VerifyCodeData struct {
+	Code          string // 验证码
+	ExpireMinutes int    // 有效期（分钟）
+}
+
+// NewVerifyCodeMessage 生成邮箱验证码邮件
+func NewVerifyCodeMessage(to, code string, expire time.Duration) (*Message, error) {
+	const subject = "您的邮箱验证码"
+	data := VerifyCodeData{Code: code, ExpireMinutes: int(expire / time.Minute)}
+	html, err := render(TemplateVerifyCode, subject, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:      []string{to},
+		Subject: subject,
+		HTML:    html,
+		Text:    fmt.Sprintf("您的邮箱验证码为：%s\n验证码有效期为%d分钟，若您未发起此操作，请忽略此邮件。\n", data.Code, data.ExpireMinutes),
+	}, nil
+}
+
+// PasswordResetData 重置密码邮件参数
+type PasswordResetData struct {
+	Username      string // 用户名（可为空）
+	Code          string // 重置验证码
+	ExpireMinutes int    // 有效期（分钟）
+}
+
+// NewPasswordResetMessage 生成重置密码邮件
+func NewPasswordResetMessage(to string, data PasswordResetData) (*Message, error) {
+	const subject = "重置密码验证码"
+	html, err := render(TemplatePasswordReset, subject, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		To:      []string{to},
+		Subject: subject,
+		HTML:    html,
+		Text:    fmt.Sprintf("您正在重置账号密码，验证码为：%s\n验证码有效期为%d分钟，若您未申请重置密码，请忽略此邮件。\n", data.Code, data.ExpireMinutes),
+	}, nil
+}
+
+// PurchaseReceiptData 购买回执邮件参数（金额为已格式化的两位小数）
+type PurchaseReceiptData struct {
+	Username      string // 买家用户名
+	PurchaseID    uint64 // 购买记录ID
+	ResourceTitle string // 资源标题
+	ResourceURL   string // 资源详情页地址（可为空）
+	Price         string // 支付金额
+	Balance       string // 购买后余额
+	PurchaseTime  string // 购买时间
+}
+
+// NewPurchaseReceiptMessage 生成购买回执邮件
+func NewPurchaseReceiptMessage(to string, data PurchaseReceiptData) (*Message, error) {
+	subject := fmt.Sprintf("购买成功：《%s》", truncateRunes(data.ResourceTitle, 40))
+	html, err := render(TemplatePurchaseReceipt, subject, data)
+	if err != nil {
+		return nil, err
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "您已成功购买资源《%s》\n", data.ResourceTitle)
+	fmt.Fprintf(&text, "订单编号：%d\n支付金额：¥%s\n账户余额：¥%s\n购买时间：%s\n", data.PurchaseID, data.Price, data.Balance, data.PurchaseTime)
+	if data.ResourceURL != "" {
+		fmt.Fprintf(&text, "查看资源：%s\n", data.ResourceURL)
+	}
+	return &Message{
+		To:      []string{to},
+		Subject: subject,
+		HTML:    html,
+		Text:    text.String(),
+	}, nil
+}
+
+// truncateRunes 按字符截断（超长时追加省略号）
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,58 @@
+package metrics
+
+// 登录方式（cms_logins_total的method标签）
+const (
+	LoginMethodPassword  = "password"   // 用户名/手机号/邮箱+密码
+	LoginMethodEmailCode = "email_code" // 邮箱验证码
+	LoginMethodTwoFactor = "two_factor" // 两步验证第二步（提交动态码/恢复码）
+)
+
+// 登录结果（cms_logins_total的result标签）
+const (
+	LoginResultSuccess   = "success"   // 已签发会话
+	LoginResultChallenge = "challenge" // 密码/验证码正确，等待两步验证
+	LoginResultFailure   = "failure"   // 凭证错误、账号锁定/禁用等
+)
+
+// 邮件发送结果（cms_email_sends_total的result标签）
+const (
+	EmailResultSuccess  = "success"
+	EmailResultFailure  = "failure"
+	EmailResultDisabled = "disabled" // 未配置mail.driver
+)
+
+var (
+	// HTTPRequestDuration 接口耗时（按路由模板统计，未匹配路由的请求统一记为route="unmatched"）
+	HTTPRequestDuration = NewHistogramVec("cms_http_request_duration_seconds", "HTTP请求耗时（秒）", DefaultBuckets, "method", "route", "status")
+
+	// Registrations 注册成功的用户数
+	Registrations = NewCounter("cms_user_registrations_total", "注册成功的用户数")
+	// Logins 登录次数（按登录方式与结果）
+	Logins = NewCounterVec("cms_logins_total", "登录次数（按登录方式与结果）", "method", "result")
+	// RechargeOrders 创建的充值单数（同一幂等键重放不计）
+	RechargeOrders = NewCounter("cms_recharge_orders_total", "创建的待支付充值单数")
+	// Recharges 支付回调处理结果（paid为已入账，failed为支付失败关闭充值单）
+	Recharges = NewCounterVec("cms_recharges_total", "支付回调处理的充值单数（按结果）", "status")
+	// RechargeAmount 已入账的充值金额
+	RechargeAmount = NewCounter("cms_recharge_amount_total", "已入账的充值金额（元）")
+	// Deductions 余额扣减成功次数（同一幂等键重放不计）
+	Deductions = NewCounter("cms_deductions_total", "余额扣减成功次数")
+	// DeductionAmount 已扣减的金额
+	DeductionAmount = NewCounter("cms_deduction_amount_total", "已扣减的余额（元）")
+	// ResourcesCreated 发布成功的资源数
+	ResourcesCreated = NewCounter("cms_resources_created_total", "发布成功的资源数")
+	// EmailSends 邮件发送次数（按发送方式与结果）
+	EmailSends = NewCounterVec("cms_email_sends_total", "邮件发送次数（按发送方式与结果）", "driver", "result")
+)
+
+func init() {
+	// 预先创建全部登录标签组合，未发生过的组合也输出0，便于按比例计算失败率
+	for _, method := range []string{LoginMethodPassword, LoginMethodEmailCode, LoginMethodTwoFactor} {
+		for _, result := range []string{LoginResultSuccess, LoginResultChallenge, LoginResultFailure} {
+			if method == LoginMethodTwoFactor && result == LoginResultChallenge {
+				continue
+			}
+			Logins.WithLabelValues(method, result)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"sync/atomic"
+)
+
+// dbStatsCollector 抓取时读取sql.DBStats，输出MySQL连接池状态（未调用ObserveDB时不输出）
+type dbStatsCollector struct {
+	db atomic.Pointer[sql.DB]
+}
+
+var dbStats = &dbStatsCollector{}
+
+func init() {
+	Default.MustRegister(dbStats)
+}
+
+// ObserveDB 指定需要暴露连接池状态的数据库（pkg.InitMySQL打开连接池后调用，重复调用以最后一次为准）
+func ObserveDB(db *sql.DB) {
+	dbStats.db.Store(db)
+}
+
+func (c *dbStatsCollector) Name() string { return "cms_db" }
+
+func (c *dbStatsCollector) Write(w io.Writer) {
+	db := c.db.Load()
+	if db == nil {
+		return
+	}
+	s := db.Stats()
+	write := func(typ string) func(name, help string, v float64) {
+		return func(name, help string, v float64) {
+			(&desc{name: name, help: help, typ: typ}).writeHeader(w)
+			writeSample(w, name, nil, nil, "", "", v)
+		}
+	}
+	gauge, counter := write("gauge"), write("counter")
+
+	gauge("cms_db_max_open_connections", "连接池最大打开连接数（database.max_open_conns，0为不限）", float64(s.MaxOpenConnections))
+	gauge("cms_db_open_connections", "当前打开的连接数（使用中+空闲）", float64(s.OpenConnections))
+	gauge("cms_db_in_use_connections", "当前使用中的连接数", float64(s.InUse))
+	gauge("cms_db_idle_connections", "当前空闲的连接数", float64(s.Idle))
+	counter("cms_db_wait_count_total", "因连接池已满而等待连接的累计次数", float64(s.WaitCount))
+	counter("cms_db_wait_duration_seconds_total", "等待连接的累计耗时（秒）", s.WaitDuration.Seconds())
+	counter("cms_db_max_idle_closed_total", "因超过最大空闲连接数而关闭的连接数", float64(s.MaxIdleClosed))
+	counter("cms_db_max_idle_time_closed_total", "因超过最大空闲时间而关闭的连接数", float64(s.MaxIdleTimeClosed))
+	counter("cms_db_max_lifetime_closed_total", "因超过最大存活时间而关闭的连接数", float64(s.MaxLifetimeClosed))
+}
@@ -0,0 +1,345 @@
+// Package metrics 进程内指标（计数器/直方图/连接池状态），以Prometheus文本格式（0.0.4）通过/metrics暴露
+// 仅依赖标准库：指标数量少、类型固定，无需引入完整的Prometheus客户端
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// contentType Prometheus文本格式的响应类型
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector 指标采集接口：Name为指标名（注册时去重），Write按文本格式输出HELP/TYPE及全部样本
+type Collector interface {
+	Name() string
+	Write(w io.Writer)
+}
+
+// Registry 指标注册表（按注册顺序输出）
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+	names      map[string]bool
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// Default 默认注册表（业务指标、HTTP指标与连接池指标均注册于此，由Handler输出）
+var Default = NewRegistry()
+
+// MustRegister 注册指标，指标名重复时panic（属于编码错误，应在启动阶段暴露）
+func (r *Registry) MustRegister(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range cs {
+		if r.names[c.Name()] {
+			panic("metrics: 指标名重复注册：" + c.Name())
+		}
+		r.names[c.Name()] = true
+		r.collectors = append(r.collectors, c)
+	}
+}
+
+// WriteText 按Prometheus文本格式输出全部指标
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.collectors {
+		c.Write(w)
+	}
+}
+
+// Handler 输出默认注册表的HTTP处理器（供Prometheus抓取）
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var buf bytes.Buffer
+		Default.WriteText(&buf)
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+// desc 指标描述（名称、说明、类型、标签名）
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+// writeHeader 输出HELP/TYPE行
+func (d *desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+}
+
+// Counter 单调递增计数器（无锁，float64以位模式存储）
+type Counter struct {
+	desc        *desc
+	labelValues []string
+	bits        atomic.Uint64
+}
+
+// NewCounter 创建并注册无标签计数器
+func NewCounter(name, help string) *Counter {
+	c := &Counter{desc: &desc{name: name, help: help, typ: "counter"}}
+	Default.MustRegister(c)
+	return c
+}
+
+// Inc 计数加1
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add 计数增加v（v为负数时忽略，计数器只增不减）
+func (c *Counter) Add(v float64) {
+	if v < 0 || math.IsNaN(v) {
+		return
+	}
+	for {
+		old := c.bits.Load()
+		if c.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Value 当前计数
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+func (c *Counter) Name() string { return c.desc.name }
+
+func (c *Counter) Write(w io.Writer) {
+	c.desc.writeHeader(w)
+	c.writeSample(w)
+}
+
+func (c *Counter) writeSample(w io.Writer) {
+	writeSample(w, c.desc.name, c.desc.labels, c.labelValues, "", "", c.Value())
+}
+
+// CounterVec 带标签的计数器组（每组标签值对应一个Counter，首次使用时创建）
+type CounterVec struct {
+	desc     *desc
+	mu       sync.RWMutex
+	counters map[string]*Counter
+}
+
+// NewCounterVec 创建并注册带标签的计数器组
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{
+		desc:     &desc{name: name, help: help, typ: "counter", labels: labels},
+		counters: make(map[string]*Counter),
+	}
+	Default.MustRegister(v)
+	return v
+}
+
+// WithLabelValues 按标签值（顺序与创建时的标签名一致）取计数器，数量不符时panic
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	key := labelKey(v.desc, values)
+	v.mu.RLock()
+	c, ok := v.counters[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok := v.counters[key]; ok {
+		return c
+	}
+	c = &Counter{desc: v.desc, labelValues: slices.Clone(values)}
+	v.counters[key] = c
+	return c
+}
+
+func (v *CounterVec) Name() string { return v.desc.name }
+
+func (v *CounterVec) Write(w io.Writer) {
+	v.mu.RLock()
+	counters := make([]*Counter, 0, len(v.counters))
+	for _, c := range v.counters {
+		counters = append(counters, c)
+	}
+	v.mu.RUnlock()
+	sortByLabels(counters, func(c *Counter) []string { return c.labelValues })
+
+	v.desc.writeHeader(w)
+	for _, c := range counters {
+		c.writeSample(w)
+	}
+}
+
+// DefaultBuckets 默认耗时分桶（秒），覆盖5ms~10s的接口耗时
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram 单组标签值的直方图数据
+type histogram struct {
+	labelValues []string
+	mu          sync.Mutex
+	counts      []uint64 // 与buckets一一对应，非累积（输出时累加）
+	count       uint64
+	sum         float64
+}
+
+// HistogramVec 带标签的直方图组
+type HistogramVec struct {
+	desc       *desc
+	buckets    []float64
+	mu         sync.RWMutex
+	histograms map[string]*histogram
+}
+
+// NewHistogramVec 创建并注册带标签的直方图组（buckets为空时使用DefaultBuckets）
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	sort.Float64s(buckets)
+	v := &HistogramVec{
+		desc:       &desc{name: name, help: help, typ: "histogram", labels: labels},
+		buckets:    buckets,
+		histograms: make(map[string]*histogram),
+	}
+	Default.MustRegister(v)
+	return v
+}
+
+// Observe 记录一次观测值（标签值顺序与创建时的标签名一致）
+func (v *HistogramVec) Observe(value float64, labelValues ...string) {
+	h := v.get(labelValues)
+	i := sort.SearchFloat64s(v.buckets, value) // 第一个>=value的分桶（le为上界，含等于）
+	h.mu.Lock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += value
+	h.mu.Unlock()
+}
+
+func (v *HistogramVec) get(values []string) *histogram {
+	key := labelKey(v.desc, values)
+	v.mu.RLock()
+	h, ok := v.histograms[key]
+	v.mu.RUnlock()
+	if ok {
+		return h
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if h, ok := v.histograms[key]; ok {
+		return h
+	}
+	h = &histogram{labelValues: slices.Clone(values), counts: make([]uint64, len(v.buckets))}
+	v.histograms[key] = h
+	return h
+}
+
+func (v *HistogramVec) Name() string { return v.desc.name }
+
+func (v *HistogramVec) Write(w io.Writer) {
+	v.mu.RLock()
+	histograms := make([]*histogram, 0, len(v.histograms))
+	for _, h := range v.histograms {
+		histograms = append(histograms, h)
+	}
+	v.mu.RUnlock()
+	sortByLabels(histograms, func(h *histogram) []string { return h.labelValues })
+
+	v.desc.writeHeader(w)
+	for _, h := range histograms {
+		h.mu.Lock()
+		counts := slices.Clone(h.counts)
+		count, sum := h.count, h.sum
+		h.mu.Unlock()
+
+		var cumulative uint64
+		for i, upper := range v.buckets {
+			cumulative += counts[i]
+			writeSample(w, v.desc.name+"_bucket", v.desc.labels, h.labelValues, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, v.desc.name+"_bucket", v.desc.labels, h.labelValues, "le", "+Inf", float64(count))
+		writeSample(w, v.desc.name+"_sum", v.desc.labels, h.labelValues, "", "", sum)
+		writeSample(w, v.desc.name+"_count", v.desc.labels, h.labelValues, "", "", float64(count))
+	}
+}
+
+// labelKey 标签值拼接为map键，数量与标签名不符时panic
+func labelKey(d *desc, values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s需要%d个标签值，实际%d个", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sortByLabels 按标签值排序，保证每次输出顺序稳定
+func sortByLabels[T any](items []T, labels func(T) []string) {
+	slices.SortFunc(items, func(a, b T) int {
+		return slices.Compare(labels(a), labels(b))
+	})
+}
+
+// writeSample 输出一行样本：name{label="value",...,extraName="extraValue"} value
+func writeSample(w io.Writer, name string, labels, values []string, extraName, extraValue string, v float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", l, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", extraName, extraValue)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(v))
+	b.WriteByte('\n')
+	_, _ = io.WriteString(w, b.String())
+}
+
+// formatFloat 按Prometheus格式输出数值（±Inf/NaN使用规定写法）
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -1,8 +1,9 @@
 package middleware
 
 import (
+	"CMS/internal/apperr"
 	pkg "CMS/internal/pkg/jwt"
-	"net/http"
+	"errors"
 	"strings"
 	"time"
 
@@ -16,28 +17,62 @@ func Cors() gin.HandlerFunc {
 	return cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // 生产环境替换为前端域名（如http://localhost:8081）
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key", HeaderRequestID},
+		ExposeHeaders:    []string{"Content-Length", HeaderRequestID},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	})
 }
 
+// jwtCfg Token校验配置（启动时由SetJWTConfig注入，与签发Token使用同一密钥）
+var jwtCfg pkg.JWTConfig
+
+// SetJWTConfig 注入Token校验配置
+func SetJWTConfig(cfg pkg.JWTConfig) {
+	jwtCfg = cfg
+}
+
+// signingKey 校验Token签名的密钥回调（只接受HMAC签名）
+func signingKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	if len(jwtCfg.Secret) == 0 {
+		return nil, errors.New("JWT签名密钥未配置")
+	}
+	return jwtCfg.Secret, nil
+}
+
+// RevocationChecker 访问Token吊销检查（由service.TokenDenylist实现，启动时通过SetRevocationChecker注入）
+type RevocationChecker interface {
+	IsRevoked(claims *pkg.UserClaims) bool
+}
+
+var revocationChecker RevocationChecker
+
+// SetRevocationChecker 注入Token吊销检查（未注入时不做吊销检查）
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
+// isRevoked 判断Token是否已被吊销（退出登录/退出所有设备）
+func isRevoked(claims *pkg.UserClaims) bool {
+	return revocationChecker != nil && revocationChecker.IsRevoked(claims)
+}
+
 func JWTMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 1. 从请求头中获取 Authorization 字段
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "请求头中缺少 Authorization"})
-			c.Abort() // 终止请求，不再执行后续处理
+			abortWithError(c, apperr.ErrUnauthenticated.WithMsg("请求头中缺少 Authorization")) // 终止请求，不再执行后续处理
 			return
 		}
 
 		// 2. 检查 Authorization 格式是否为 "Bearer <token>"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization 格式错误（应为 Bearer <token>）"})
-			c.Abort()
+			abortWithError(c, apperr.ErrUnauthenticated.WithMsg("Authorization 格式错误（应为 Bearer <token>）"))
 			return
 		}
 
@@ -47,33 +82,55 @@ func JWTMiddleware() gin.HandlerFunc {
 		token, err := jwt.ParseWithClaims(
 			tokenString,
 			&pkg.UserClaims{}, // 与生成时的载荷结构一致
-			func(token *jwt.Token) (interface{}, error) {
-				// 验证签名算法是否为预期的 HS256
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte("aiuegfiuewgfiuwfeiuwheqowhfoiqfiifenfeqnfeq"), nil // 使用相同的密钥验证签名
-			},
+			signingKey,        // 验证签名算法为HMAC并使用相同的密钥验证签名
 		)
 
 		// 4. 处理验证错误
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的 Token：" + err.Error()})
-			c.Abort()
+			abortWithError(c, apperr.ErrTokenInvalid.WithMsg("无效的 Token").Wrap(err))
 			return
 		}
 
 		// 5. 验证通过，提取用户信息并存入上下文
 		if claims, ok := token.Claims.(*pkg.UserClaims); ok && token.Valid {
-			// 将 sid 存入上下文，后续接口可通过 c.Get("sid") 获取
-			c.Set("uuid", claims.UserID)
-			println(claims.UserID)
+			// 已退出登录的Token（jti或用户级吊销）视为无效
+			if isRevoked(claims) {
+				abortWithError(c, apperr.ErrTokenInvalid.WithMsg("Token 已失效，请重新登录"))
+				return
+			}
+			// 将用户信息存入上下文：uuid/role供接口直接读取，完整声明供RequireRole/RequirePermission使用
+			c.Set(ContextKeyUUID, claims.UserID)
+			c.Set(ContextKeyRole, claims.Role)
+			c.Set(ContextKeyClaims, claims)
+			withUserLogger(c, claims.UserID)
 		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token 验证失败"})
-			c.Abort()
+			abortWithError(c, apperr.ErrTokenInvalid.WithMsg("Token 验证失败"))
 			return
 		}
 		// 继续执行后续的接口处理函数
 		c.Next()
 	}
 }
+
+// OptionalJWTMiddleware 可选登录中间件：携带有效Token时注入uuid，未携带或Token无效时按游客继续处理（用于公开接口展示个性化字段，如是否已点赞）
+func OptionalJWTMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			token, err := jwt.ParseWithClaims(
+				parts[1],
+				&pkg.UserClaims{},
+				signingKey, // 与JWTMiddleware使用相同的密钥
+			)
+			if err == nil {
+				if claims, ok := token.Claims.(*pkg.UserClaims); ok && token.Valid && !isRevoked(claims) {
+					c.Set(ContextKeyUUID, claims.UserID)
+					c.Set(ContextKeyRole, claims.Role)
+					c.Set(ContextKeyClaims, claims)
+					withUserLogger(c, claims.UserID)
+				}
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/pkg/logger"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler 错误响应中间件：接口/中间件通过c.Error(err)+c.Abort()上报错误，请求结束后由这里统一转换为响应
+// （*apperr.Error按其状态码/错误码/提示返回；其余错误一律500，原因只记录日志，不暴露给客户端）
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+
+		appErr, ok := apperr.As(err)
+		if !ok {
+			logger.FromContext(c.Request.Context()).Error("[错误响应] 未归类的系统错误", "error", err)
+			appErr = apperr.ErrInternal
+		} else if appErr.Status >= 500 {
+			logger.FromContext(c.Request.Context()).Error("[错误响应] 系统错误", "code", appErr.Code, "error", err)
+		}
+
+		c.JSON(appErr.Status, dto.CommonResponse{
+			Code:      appErr.Status,
+			Msg:       appErr.Msg,
+			Data:      nil,
+			Error:     string(appErr.Code),
+			RequestID: c.GetString(ContextKeyRequestID),
+		})
+	}
+}
+
+// RecoveryHandler gin.CustomRecovery的回调：panic转为系统错误，同样由ErrorHandler输出统一响应
+func RecoveryHandler(c *gin.Context, recovered any) {
+	abortWithError(c, fmt.Errorf("panic：%v", recovered))
+}
+
+// abortWithError 中间件内上报错误并终止后续处理（响应由ErrorHandler输出）
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
@@ -1 +1,90 @@
 package middleware
+
+import (
+	pkg "CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRequestID 请求ID请求头/响应头（客户端或网关传入合法值时沿用，否则生成新的）
+const HeaderRequestID = "X-Request-ID"
+
+// ContextKeyRequestID 请求ID在gin上下文中的key（string）
+const ContextKeyRequestID = "request_id"
+
+// probePaths 存活/就绪探针与指标抓取路径（成功的请求只输出debug级访问日志）
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// RequestLogger 请求日志中间件：分配请求ID → 将带request_id的日志注入请求ctx（Service/Repo层通过logger.FromContext取用）→
+// 请求结束后输出一条访问日志（方法/路径/状态码/耗时/用户UUID，查询参数中的敏感字段脱敏）
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(HeaderRequestID)
+		if !validRequestID(requestID) {
+			requestID = pkg.GenerateUUID()
+		}
+		c.Set(ContextKeyRequestID, requestID)
+		c.Header(HeaderRequestID, requestID)
+
+		ctx := logger.WithContext(c.Request.Context(), slog.Default().With("request_id", requestID))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		case probePaths[c.Request.URL.Path]:
+			// 探针/指标抓取请求频繁且成功时无排查价值，降为debug避免刷屏
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.ClientIP()),
+			slog.String("user_uuid", c.GetString(ContextKeyUUID)),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if q := logger.RedactQuery(c.Request.URL.RawQuery); q != "" {
+			attrs = append(attrs, slog.String("query", q))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		// 用注入时的日志输出（JWTMiddleware追加的user_uuid已作为单独字段输出，避免重复）
+		logger.FromContext(ctx).LogAttrs(ctx, level, "HTTP请求", attrs...)
+	}
+}
+
+// withUserLogger JWT校验通过后在请求ctx的日志上追加user_uuid，后续业务日志自动携带
+func withUserLogger(c *gin.Context, userUUID string) {
+	c.Request = c.Request.WithContext(logger.With(c.Request.Context(), "user_uuid", userUUID))
+}
+
+// validRequestID 外部传入的请求ID只接受1~64位字母、数字、-、_、.（防止日志注入）
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"CMS/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 接口耗时统计中间件：按 方法/路由模板/状态码 记录到cms_http_request_duration_seconds
+// 路由取c.FullPath()（如/resource/detail），未匹配的路径统一记为unmatched，避免扫描请求撑爆标签基数
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+	}
+}
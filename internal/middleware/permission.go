@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	pkg "CMS/internal/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 上下文键（JWTMiddleware写入，后续中间件/接口读取）
+const (
+	ContextKeyUUID   = "uuid"   // 用户UUID（string）
+	ContextKeyRole   = "role"   // 用户角色（string）
+	ContextKeyClaims = "claims" // 完整JWT声明（*pkg.UserClaims）
+)
+
+// Permission 接口权限标识（路由通过RequirePermission声明所需权限）
+type Permission string
+
+const (
+	PermProfile         Permission = "profile"          // 个人资料、退出所有设备
+	PermAccountView     Permission = "account:view"     // 查询账户、流水、充值单
+	PermAccountRecharge Permission = "account:recharge" // 充值、模拟支付
+	PermAccountDeduct   Permission = "account:deduct"   // 账户余额扣减
+	PermResourceView    Permission = "resource:view"    // 资源列表、点赞、购买
+	PermResourcePublish Permission = "resource:publish" // 发布资源、修改价格/标签/分类
+	PermCommentWrite    Permission = "comment:write"    // 发表/编辑/删除自己的评论、查看@提及
+	PermAdminUsers      Permission = "admin:users"      // 管理后台：查询用户、修改角色、停用/封禁账号
+	PermAdminContent    Permission = "admin:content"    // 管理后台：隐藏/删除资源和评论、维护资源分类
+	PermAdminBalance    Permission = "admin:balance"    // 管理后台：手工调账
+	PermAdminAudit      Permission = "admin:audit"      // 管理后台：查看审计日志
+)
+
+// rolePermissions 权限矩阵：角色 → 拥有的权限（未列出的角色没有任何权限）
+var rolePermissions = map[string]map[Permission]bool{
+	model.RoleCandidate: permissionSet(
+		PermProfile, PermAccountView, PermAccountRecharge, PermAccountDeduct,
+		PermResourceView, PermResourcePublish, PermCommentWrite,
+	),
+	model.RoleHR: permissionSet(
+		PermProfile, PermAccountView, PermAccountRecharge, PermAccountDeduct,
+		PermResourceView, PermResourcePublish, PermCommentWrite,
+	),
+	model.RoleAdmin: permissionSet(
+		PermProfile, PermAccountView, PermAccountRecharge, PermAccountDeduct,
+		PermResourceView, PermResourcePublish, PermCommentWrite,
+		PermAdminUsers, PermAdminContent, PermAdminBalance, PermAdminAudit,
+	),
+}
+
+// permissionSet 权限列表转集合
+func permissionSet(perms ...Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(perms))
+	for _, p := range perms {
+		set[p] = true
+	}
+	return set
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(role string, perm Permission) bool {
+	return rolePermissions[role][perm]
+}
+
+// GetClaims 从上下文读取JWTMiddleware写入的完整声明
+func GetClaims(c *gin.Context) (*pkg.UserClaims, bool) {
+	raw, exists := c.Get(ContextKeyClaims)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := raw.(*pkg.UserClaims)
+	return claims, ok && claims != nil
+}
+
+// RequireRole 角色校验中间件（需放在JWTMiddleware之后）：当前用户角色不在roles中时返回403
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			abortWithError(c, apperr.ErrUnauthenticated)
+			return
+		}
+		if !allowed[claims.Role] {
+			abortWithError(c, apperr.PermissionDenied("当前角色无权访问该接口"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 权限校验中间件（需放在JWTMiddleware之后）：按权限矩阵判断当前角色是否拥有perm
+func RequirePermission(perm Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			abortWithError(c, apperr.ErrUnauthenticated)
+			return
+		}
+		if !HasPermission(claims.Role, perm) {
+			abortWithError(c, apperr.PermissionDenied("权限不足（缺少权限："+string(perm)+"）"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,87 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Usage migrate子命令用法
+const Usage = `用法：go run . migrate <up|down|status> [步数]（编译后为 ./CMS migrate ...）
+  up [N]     执行未执行的迁移（不传N执行全部）
+  down [N]   回滚最近执行的N个迁移（默认1）
+  status     查看各迁移版本的执行状态`
+
+// Run 执行migrate子命令（args为migrate之后的参数），结果输出到out
+func Run(ctx context.Context, db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New(Usage)
+	}
+	steps := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("步数必须为正整数：%s\n%s", args[1], Usage)
+		}
+		steps = n
+	}
+
+	m, err := New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx, steps)
+		for _, mig := range done {
+			fmt.Fprintf(out, "已执行 %s\n", mig.ID())
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(out, "数据库结构已是最新版本，无需执行")
+		}
+		return nil
+	case "down":
+		done, err := m.Down(ctx, steps)
+		for _, mig := range done {
+			fmt.Fprintf(out, "已回滚 %s\n", mig.ID())
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Fprintln(out, "没有可回滚的迁移")
+		}
+		return nil
+	case "status":
+		if steps != 0 {
+			return errors.New(Usage)
+		}
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名称\t状态\t执行时间")
+		for _, s := range statuses {
+			state, appliedAt := "待执行", "-"
+			if s.Applied {
+				state, appliedAt = "已执行", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Missing {
+				state = "已执行（程序未包含）"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("未知的migrate子命令：%s\n%s", args[0], Usage)
+	}
+}
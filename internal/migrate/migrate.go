@@ -0,0 +1,361 @@
+// Package migrate 数据库结构版本管理：migrations目录下编号的up/down SQL脚本随二进制嵌入，
+// 已执行的版本记录在schema_migrations表；服务启动时校验结构版本，落后则拒绝启动
+package migrate
+
+import (
+	"CMS/internal/pkg/logger"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+const (
+	// lockName 迁移互斥锁（MySQL GET_LOCK），多实例同时执行迁移时排队
+	lockName = "cms_schema_migrations"
+	// lockTimeoutSeconds 等待迁移锁的最长时间
+	lockTimeoutSeconds = 30
+)
+
+// 迁移文件名：{版本号}_{名称}.up.sql / {版本号}_{名称}.down.sql，如 0001_create_users.up.sql
+var fileNameRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrSchemaBehind 数据库结构版本落后（存在未执行的迁移）
+var ErrSchemaBehind = errors.New("数据库结构版本落后")
+
+// Migration 单个迁移版本
+type Migration struct {
+	Version int    // 版本号（文件名前缀）
+	Name    string // 迁移名称
+	Up      string // 升级脚本
+	Down    string // 回滚脚本
+}
+
+// ID 迁移标识，如 0001_create_users
+func (m Migration) ID() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status 迁移执行状态（status子命令输出）
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool // 数据库中已执行、但当前程序未包含的版本（数据库比程序新）
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New 创建迁移执行器（加载嵌入的迁移脚本）
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load 从目录加载迁移脚本，按版本号升序返回（每个版本必须同时有up和down脚本）
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败：%w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名不合法：%s（应为 0001_name.up.sql / 0001_name.down.sql）", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		if version <= 0 {
+			return nil, fmt.Errorf("迁移版本号必须大于0：%s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件%s失败：%w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本号重复：%04d（%s 与 %s）", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("迁移%s缺少up或down脚本", m.ID())
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up 按版本号升序执行未执行的迁移（steps<=0表示全部），返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if steps > 0 && len(done) >= steps {
+				break
+			}
+			if err := execScript(ctx, conn, mig.Up); err != nil {
+				return fmt.Errorf("执行迁移%s失败：%w", mig.ID(), err)
+			}
+			if _, err := conn.ExecContext(ctx,
+				`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("记录迁移版本%s失败：%w", mig.ID(), err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本号降序回滚最近执行的steps个迁移（steps<=0按1处理），返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	byVersion := make(map[int]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		byVersion[mig.Version] = mig
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for _, v := range versions {
+			if len(done) >= steps {
+				break
+			}
+			mig, ok := byVersion[v]
+			if !ok {
+				return fmt.Errorf("版本%04d已在数据库中执行，但当前程序未包含其回滚脚本", v)
+			}
+			if err := execScript(ctx, conn, mig.Down); err != nil {
+				return fmt.Errorf("回滚迁移%s失败：%w", mig.ID(), err)
+			}
+			if _, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, v); err != nil {
+				return fmt.Errorf("删除迁移版本记录%s失败：%w", mig.ID(), err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 查询全部迁移的执行状态（按版本号升序，含数据库中存在但程序未包含的版本）
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败：%w", err)
+	}
+	defer conn.Close()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	known := make(map[int]bool, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if rec, ok := applied[mig.Version]; ok {
+			s.Applied, s.AppliedAt = true, rec.appliedAt
+		}
+		statuses = append(statuses, s)
+	}
+	for v, rec := range applied {
+		if !known[v] {
+			statuses = append(statuses, Status{Version: v, Name: rec.name, Applied: true, AppliedAt: rec.appliedAt, Missing: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check 校验数据库结构是否为最新版本：存在未执行的迁移时返回ErrSchemaBehind；
+// 数据库中存在程序未包含的版本（数据库比程序新）只记录警告，不阻止启动
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending, missing []string
+	for _, s := range statuses {
+		id := fmt.Sprintf("%04d_%s", s.Version, s.Name)
+		switch {
+		case s.Missing:
+			missing = append(missing, id)
+		case !s.Applied:
+			pending = append(pending, id)
+		}
+	}
+	if len(missing) > 0 {
+		logger.FromContext(ctx).Warn("[数据库迁移] 数据库中存在当前程序未包含的迁移版本", "versions", strings.Join(missing, ","))
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w：待执行迁移 %s，请先执行 migrate up", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// withLock 在同一连接上持有迁移锁执行fn（GET_LOCK与连接绑定，迁移语句必须使用同一连接）
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败：%w", err)
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, lockName, lockTimeoutSeconds).Scan(&locked); err != nil {
+		return fmt.Errorf("获取迁移锁失败：%w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("等待迁移锁超时（%d秒），可能有其他实例正在执行迁移", lockTimeoutSeconds)
+	}
+	// 使用独立Context释放锁，避免ctx已取消时锁残留到连接关闭
+	defer conn.ExecContext(context.Background(), `SELECT RELEASE_LOCK(?)`, lockName)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// ensureTable 创建迁移版本表（已存在则跳过）
+func ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL COMMENT '迁移版本号',
+		name VARCHAR(128) NOT NULL COMMENT '迁移名称',
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '执行时间',
+		PRIMARY KEY (version)
+	) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '数据库迁移版本表'
+	`)
+	if err != nil {
+		return fmt.Errorf("创建迁移版本表失败：%w", err)
+	}
+	return nil
+}
+
+// appliedRecord 已执行的迁移记录
+type appliedRecord struct {
+	name      string
+	appliedAt time.Time
+}
+
+// appliedVersions 查询已执行的迁移版本
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int]appliedRecord, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("查询迁移版本失败：%w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedRecord)
+	for rows.Next() {
+		var (
+			version int
+			rec     appliedRecord
+		)
+		if err := rows.Scan(&version, &rec.name, &rec.appliedAt); err != nil {
+			return nil, fmt.Errorf("扫描迁移版本失败：%w", err)
+		}
+		applied[version] = rec
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历迁移版本失败：%w", err)
+	}
+	return applied, nil
+}
+
+// execScript 逐条执行脚本中的SQL语句
+// 注意：MySQL的DDL会隐式提交、无法回滚，脚本中途失败时已执行的语句不会撤销，需按错误信息手动处理后重试
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for i, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("第%d条语句执行失败（已执行的语句不会回滚）：%w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// splitStatements 按行尾分号拆分SQL语句（忽略--注释行；语句内的字符串不能以分号结尾换行）
+func splitStatements(script string) []string {
+	var (
+		stmts []string
+		buf   strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(buf.String()), ";"))
+			buf.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,35 @@
+-- 用户表（status/email_verified供管理后台与邮箱归属验证使用；word为用户信件内容，/staff/word-text读取）
+CREATE TABLE IF NOT EXISTS users (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '用户主键ID',
+    `uuid` VARCHAR(36) NOT NULL COMMENT '用户UUID（全局唯一标识）',
+    `username` VARCHAR(50) NOT NULL COMMENT '用户名（登录用）',
+    `email` VARCHAR(100) DEFAULT NULL COMMENT '用户邮箱',
+    `phone` VARCHAR(20) DEFAULT NULL COMMENT '用户手机号',
+    `password_hash` VARCHAR(255) NOT NULL COMMENT '密码哈希（bcrypt加密后）',
+    `role` VARCHAR(20) NOT NULL DEFAULT 'candidate' COMMENT '用户角色：candidate（候选人）、hr（HR）、admin（管理员）',
+    `avatar_url` VARCHAR(500) DEFAULT NULL COMMENT '用户头像URL',
+    `real_name` VARCHAR(50) DEFAULT NULL COMMENT '用户真实姓名',
+    `gender` VARCHAR(10) DEFAULT NULL COMMENT '性别：male（男）、female（女）、other（其他）',
+    `birth_date` DATE DEFAULT NULL COMMENT '出生日期（格式：YYYY-MM-DD）',
+    `status` VARCHAR(16) NOT NULL DEFAULT 'active' COMMENT '账号状态：active正常/disabled停用/banned封禁',
+    `email_verified` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '邮箱是否已验证（修改邮箱后重置为0）',
+    `word` VARCHAR(2000) NOT NULL DEFAULT '' COMMENT '用户信件内容',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_uuid` (`uuid`),
+    UNIQUE INDEX `idx_username` (`username`),
+    UNIQUE INDEX `idx_email` (`email`),
+    UNIQUE INDEX `idx_phone` (`phone`),
+    INDEX `idx_status` (`status`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '用户信息表';
+
+-- 用户账户表（注册时与用户在同一事务内创建；余额变动与account_transactions流水在同一事务内写入）
+CREATE TABLE IF NOT EXISTS user_account (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '账户主键ID',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `balance` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '账户余额',
+    `total_recharge` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '累计充值金额',
+    `total_consume` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '累计消费金额',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '开户时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_user_uuid` (`user_uuid`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '用户账户表';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS resource_purchases;
+DROP TABLE IF EXISTS resource_likes;
+DROP TABLE IF EXISTS comment_mentions;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS resources;
@@ -0,0 +1,80 @@
+-- 资源表（like_count/view_count/comment_count为冗余计数，由明细表在同一事务内维护；价格0为免费资源）
+CREATE TABLE IF NOT EXISTS resources (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '资源主键ID',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '发布者users.id',
+    `title` VARCHAR(100) NOT NULL COMMENT '资源标题',
+    `text_content` MEDIUMTEXT NOT NULL COMMENT '文本内容',
+    `code_content` MEDIUMTEXT NOT NULL COMMENT '代码内容',
+    `author` VARCHAR(50) NOT NULL COMMENT '作者用户名（冗余存储）',
+    `publish_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '发布时间',
+    `like_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '点赞量',
+    `view_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '浏览量',
+    `comment_count` INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '评论量（只统计未隐藏评论）',
+    `price` DECIMAL(10,2) NOT NULL DEFAULT 0.00 COMMENT '资源价格（元）',
+    `hidden` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否被管理员隐藏',
+    `updated_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后更新时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_id` (`user_id`),
+    INDEX `idx_hidden_publish` (`hidden`, `publish_time`),
+    CONSTRAINT `fk_resources_user` FOREIGN KEY (`user_id`) REFERENCES users (`id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '资源表';
+
+-- 资源评论表（评论者身份取自JWT，comment_count与本表行数在同一事务内维护）
+CREATE TABLE IF NOT EXISTS comments (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '评论主键ID',
+    `resource_id` BIGINT UNSIGNED NOT NULL COMMENT '关联resources.id',
+    `parent_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '父评论ID（0表示直接评论资源）',
+    `root_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '所属楼层顶层评论ID（顶层评论为0）',
+    `depth` TINYINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '回复层级（顶层为0，最大5）',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '评论者users.id',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '评论者users.uuid',
+    `username` VARCHAR(50) NOT NULL COMMENT '评论者用户名（冗余存储）',
+    `content` TEXT NOT NULL COMMENT '评论内容',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '评论时间',
+    `update_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '最后编辑时间',
+    `hidden` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否被管理员隐藏',
+    PRIMARY KEY (`id`),
+    INDEX `idx_resource_time` (`resource_id`, `create_time`),
+    INDEX `idx_root_depth` (`root_id`, `depth`),
+    INDEX `idx_parent` (`parent_id`),
+    INDEX `idx_user_uuid` (`user_uuid`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '资源评论表';
+
+-- 评论@提及表（被@用户的提醒，与评论在同一事务内写入）
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '提及记录主键ID',
+    `comment_id` BIGINT UNSIGNED NOT NULL COMMENT '关联comments.id',
+    `mentioned_user_id` BIGINT UNSIGNED NOT NULL COMMENT '被提及用户users.id',
+    `mentioned_uuid` VARCHAR(36) NOT NULL COMMENT '被提及用户users.uuid',
+    `is_read` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已读（0未读/1已读）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '提及时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_comment_user` (`comment_id`, `mentioned_user_id`),
+    INDEX `idx_mentioned_read` (`mentioned_uuid`, `is_read`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '评论@提及表';
+
+-- 资源点赞明细表（每个用户对同一资源仅一条记录，resources.like_count由本表计数得出）
+CREATE TABLE IF NOT EXISTS resource_likes (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '点赞记录主键ID',
+    `resource_id` BIGINT UNSIGNED NOT NULL COMMENT '关联resources.id',
+    `user_id` BIGINT UNSIGNED NOT NULL COMMENT '点赞用户users.id',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '点赞时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_resource_user` (`resource_id`, `user_id`),
+    INDEX `idx_user_id` (`user_id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '资源点赞明细表';
+
+-- 资源购买记录表（记录所有权，与扣款/入账在同一事务内写入；已售出的资源不能删除）
+CREATE TABLE IF NOT EXISTS resource_purchases (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '购买记录主键ID',
+    `resource_id` BIGINT UNSIGNED NOT NULL COMMENT '关联resources.id',
+    `buyer_id` BIGINT UNSIGNED NOT NULL COMMENT '购买者users.id',
+    `buyer_uuid` VARCHAR(36) NOT NULL COMMENT '购买者users.uuid',
+    `author_id` BIGINT UNSIGNED NOT NULL COMMENT '作者users.id（收款方）',
+    `price` DECIMAL(10,2) NOT NULL COMMENT '成交价格',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '购买时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_resource_buyer` (`resource_id`, `buyer_id`),
+    INDEX `idx_buyer_id` (`buyer_id`),
+    CONSTRAINT `fk_purchases_resource` FOREIGN KEY (`resource_id`) REFERENCES resources (`id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '资源购买记录表';
@@ -0,0 +1,34 @@
+-- 账户流水表（只增不改；每次余额变动在同一事务内写入一条，amount收入为正、支出为负）
+CREATE TABLE IF NOT EXISTS account_transactions (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '流水主键ID',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联user_account.user_uuid',
+    `type` VARCHAR(20) NOT NULL COMMENT '流水类型：recharge充值/deduct消费/purchase购买资源/sale资源售出/adjust管理员调账',
+    `amount` DECIMAL(10,2) NOT NULL COMMENT '变动金额（收入为正、支出为负）',
+    `balance_after` DECIMAL(10,2) NOT NULL COMMENT '变动后余额',
+    `reference_id` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '关联业务ID（如resource_purchases.id）',
+    `idempotency_key` VARCHAR(64) DEFAULT NULL COMMENT '幂等键（同一用户唯一，NULL表示未指定）',
+    `remark` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '备注',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '流水时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_user_idempotency` (`user_uuid`, `idempotency_key`),
+    INDEX `idx_user_type` (`user_uuid`, `type`),
+    INDEX `idx_reference` (`type`, `reference_id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '账户流水表';
+
+-- 充值单表（充值先建待支付单，支付渠道回调验签通过后才入账；reference_id为order_no的充值流水与之对应）
+CREATE TABLE IF NOT EXISTS recharge_orders (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '充值单主键ID',
+    `order_no` VARCHAR(64) NOT NULL COMMENT '充值单号（商户订单号）',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联user_account.user_uuid',
+    `amount` DECIMAL(10,2) NOT NULL COMMENT '充值金额',
+    `provider` VARCHAR(32) NOT NULL COMMENT '支付渠道标识（如mock）',
+    `status` VARCHAR(16) NOT NULL DEFAULT 'pending' COMMENT '状态：pending待支付/paid已支付/failed支付失败',
+    `provider_trade_no` VARCHAR(128) DEFAULT NULL COMMENT '渠道交易号（回调时写入）',
+    `idempotency_key` VARCHAR(64) DEFAULT NULL COMMENT '幂等键（同一用户唯一，NULL表示未指定）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `paid_time` DATETIME DEFAULT NULL COMMENT '支付完成时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_order_no` (`order_no`),
+    UNIQUE INDEX `uk_user_idempotency` (`user_uuid`, `idempotency_key`),
+    INDEX `idx_user_status` (`user_uuid`, `status`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '充值单表';
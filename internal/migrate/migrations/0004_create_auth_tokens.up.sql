@@ -0,0 +1,32 @@
+-- 刷新令牌表（只存SHA-256哈希；每次刷新吊销旧令牌并签发同族新令牌，family_id即登录会话ID）
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `token_hash` CHAR(64) NOT NULL COMMENT '刷新令牌SHA-256哈希（hex）',
+    `family_id` VARCHAR(36) NOT NULL COMMENT '令牌族ID（登录会话ID，对应访问Token的sid）',
+    `expires_at` DATETIME NOT NULL COMMENT '过期时间',
+    `revoked_at` DATETIME DEFAULT NULL COMMENT '吊销时间（NULL表示有效）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '签发时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_token_hash` (`token_hash`),
+    INDEX `idx_family_id` (`family_id`),
+    INDEX `idx_user_uuid` (`user_uuid`),
+    INDEX `idx_expires_at` (`expires_at`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '刷新令牌表';
+
+-- 访问Token吊销表（jti黑名单，记录保留到Token过期）
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    `jti` VARCHAR(64) NOT NULL COMMENT '访问Token的jti',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `expires_at` DATETIME NOT NULL COMMENT 'Token过期时间（过期后可清理）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '吊销时间',
+    PRIMARY KEY (`jti`),
+    INDEX `idx_expires_at` (`expires_at`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '访问Token吊销表';
+
+-- 用户级Token吊销表（退出所有设备：签发时间不晚于revoked_before的访问Token全部失效）
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `revoked_before` DATETIME NOT NULL COMMENT '吊销时间点',
+    PRIMARY KEY (`user_uuid`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '用户级Token吊销表';
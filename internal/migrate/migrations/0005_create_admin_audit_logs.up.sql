@@ -0,0 +1,16 @@
+-- 管理员操作审计日志（只增不改；detail记录操作前后状态）
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `admin_uuid` VARCHAR(36) NOT NULL COMMENT '操作管理员users.uuid',
+    `admin_name` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '操作管理员用户名（冗余，便于查阅）',
+    `action` VARCHAR(32) NOT NULL COMMENT '操作类型：user.role/user.status/resource.hide/resource.delete/comment.hide/comment.delete/balance.adjust',
+    `target_type` VARCHAR(16) NOT NULL COMMENT '操作对象类型：user/resource/comment/account',
+    `target_id` VARCHAR(64) NOT NULL COMMENT '操作对象ID（用户UUID或资源/评论ID）',
+    `reason` VARCHAR(255) NOT NULL COMMENT '操作原因',
+    `detail` JSON DEFAULT NULL COMMENT '操作详情（操作前后状态）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '操作时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_target` (`target_type`, `target_id`),
+    INDEX `idx_admin_uuid` (`admin_uuid`),
+    INDEX `idx_create_time` (`create_time`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '管理员操作审计日志表';
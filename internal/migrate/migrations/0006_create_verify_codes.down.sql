@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS verify_code_cooldowns;
+DROP TABLE IF EXISTS verify_codes;
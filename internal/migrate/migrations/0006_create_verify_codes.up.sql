@@ -0,0 +1,19 @@
+-- 邮箱验证码表（同一用途+邮箱只保留最新一条；只存SHA-256哈希，校验失败次数达到上限即作废）
+CREATE TABLE IF NOT EXISTS verify_codes (
+    `purpose` VARCHAR(32) NOT NULL COMMENT '用途：login邮箱登录/reset_password重置密码/verify_email邮箱验证',
+    `email` VARCHAR(100) NOT NULL COMMENT '邮箱（小写）',
+    `code_hash` CHAR(64) NOT NULL COMMENT '验证码SHA-256哈希（hex，绑定用途与邮箱）',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '已校验失败次数',
+    `expires_at` DATETIME NOT NULL COMMENT '过期时间',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '签发时间',
+    PRIMARY KEY (`purpose`, `email`),
+    INDEX `idx_expires_at` (`expires_at`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '邮箱验证码表';
+
+-- 验证码发送冷却表（按邮箱、IP分别限制发送间隔，过期记录由后台定期清理）
+CREATE TABLE IF NOT EXISTS verify_code_cooldowns (
+    `cooldown_key` VARCHAR(160) NOT NULL COMMENT '冷却key：用途|email|邮箱 或 用途|ip|IP',
+    `until` DATETIME NOT NULL COMMENT '冷却结束时间',
+    PRIMARY KEY (`cooldown_key`),
+    INDEX `idx_until` (`until`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '验证码发送冷却表';
@@ -0,0 +1,23 @@
+-- 密码登录记录（成功/失败都记录，用户可在个人中心查看；超过保留期由后台定期清理）
+CREATE TABLE IF NOT EXISTS login_attempts (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '记录主键ID',
+    `user_uuid` VARCHAR(36) DEFAULT NULL COMMENT '账号UUID（账号不存在时为NULL）',
+    `identifier` VARCHAR(100) NOT NULL COMMENT '登录时填写的用户名/手机号/邮箱',
+    `ip` VARCHAR(64) NOT NULL COMMENT '客户端IP',
+    `user_agent` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '客户端User-Agent',
+    `result` VARCHAR(16) NOT NULL COMMENT '登录结果：success/bad_credentials/locked/disabled',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '登录时间',
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_time` (`user_uuid`, `create_time`),
+    INDEX `idx_create_time` (`create_time`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '密码登录记录表';
+
+-- 登录失败计数与锁定（按账号、IP分别计数；达到阈值后临时锁定，锁定时长指数增长）
+CREATE TABLE IF NOT EXISTS login_lockouts (
+    `lock_key` VARCHAR(160) NOT NULL COMMENT '计数key：account|账号UUID（账号不存在时为登录标识） 或 ip|IP',
+    `failures` INT NOT NULL DEFAULT 0 COMMENT '连续失败次数',
+    `last_failure` DATETIME NOT NULL COMMENT '最近一次失败时间',
+    `locked_until` DATETIME DEFAULT NULL COMMENT '锁定结束时间（未锁定为NULL）',
+    PRIMARY KEY (`lock_key`),
+    INDEX `idx_last_failure` (`last_failure`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '登录失败计数与锁定表';
@@ -0,0 +1,34 @@
+-- TOTP两步验证设置（secret_enc为AES-GCM加密后的密钥；enabled=0为已生成密钥、待提交动态码确认）
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `secret_enc` VARCHAR(255) NOT NULL COMMENT '加密后的TOTP密钥（Base64）',
+    `enabled` TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已开启',
+    `last_step` BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次校验通过的时间步（防止动态码重放）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '生成密钥时间',
+    `enable_time` DATETIME DEFAULT NULL COMMENT '开启时间',
+    PRIMARY KEY (`user_uuid`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = 'TOTP两步验证设置表';
+
+-- 两步验证一次性恢复码（只存SHA-256哈希；重新生成时整体替换）
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `code_hash` CHAR(64) NOT NULL COMMENT '恢复码SHA-256哈希（hex）',
+    `used_at` DATETIME DEFAULT NULL COMMENT '使用时间（未使用为NULL）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '生成时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_user_code` (`user_uuid`, `code_hash`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '两步验证恢复码表';
+
+-- 两步验证登录挑战（密码校验通过后签发，凭挑战令牌+动态码换取登录凭证；只存令牌哈希）
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+    `token_hash` CHAR(64) NOT NULL COMMENT '挑战令牌SHA-256哈希（hex）',
+    `user_uuid` VARCHAR(36) NOT NULL COMMENT '关联users.uuid',
+    `ip` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '发起登录的客户端IP',
+    `attempts` INT NOT NULL DEFAULT 0 COMMENT '已校验失败次数',
+    `expires_at` DATETIME NOT NULL COMMENT '过期时间',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '签发时间',
+    PRIMARY KEY (`token_hash`),
+    INDEX `idx_user_uuid` (`user_uuid`),
+    INDEX `idx_expires_at` (`expires_at`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '两步验证登录挑战表';
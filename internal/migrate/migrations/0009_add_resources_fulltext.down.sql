@@ -0,0 +1,3 @@
+ALTER TABLE resources DROP INDEX `idx_author_publish`;
+ALTER TABLE resources DROP INDEX `ft_content`;
+ALTER TABLE resources DROP INDEX `ft_title`;
@@ -0,0 +1,10 @@
+-- 资源全文检索（search.driver=mysql）：ngram分词的FULLTEXT索引，需MySQL 5.7.6+（中文按ngram_token_size切分，默认2）
+-- 标题单独建索引用于相关度加权；InnoDB每条ALTER只能新增一个FULLTEXT索引，首次新增会重建表，大表请在低峰期执行
+-- 默认停用词表含a、i等单字母，ngram分词会丢弃包含停用词的词元（如"ab"），建索引时关闭停用词
+SET SESSION innodb_ft_enable_stopword = OFF;
+ALTER TABLE resources ADD FULLTEXT INDEX `ft_title` (`title`) WITH PARSER ngram;
+ALTER TABLE resources ADD FULLTEXT INDEX `ft_content` (`title`, `text_content`, `code_content`) WITH PARSER ngram;
+SET SESSION innodb_ft_enable_stopword = ON;
+
+-- 资源列表按作者筛选
+ALTER TABLE resources ADD INDEX `idx_author_publish` (`author`, `publish_time`);
@@ -0,0 +1,8 @@
+ALTER TABLE resources
+    DROP INDEX `idx_category`,
+    DROP INDEX `idx_language`,
+    DROP COLUMN `category_id`,
+    DROP COLUMN `language`;
+DROP TABLE IF EXISTS resource_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS categories;
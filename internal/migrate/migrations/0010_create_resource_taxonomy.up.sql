@@ -0,0 +1,34 @@
+-- 资源分类（树形结构：parent_id为0表示一级分类，最多3级；同一父分类下名称唯一）
+CREATE TABLE IF NOT EXISTS categories (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '分类主键ID',
+    `parent_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '父分类ID（0表示一级分类）',
+    `name` VARCHAR(30) NOT NULL COMMENT '分类名称',
+    `sort_order` INT NOT NULL DEFAULT 0 COMMENT '同级排序（升序）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_parent_name` (`parent_id`, `name`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '资源分类表';
+
+-- 标签（名称统一转小写后存储，按二进制排序规则保证唯一）
+CREATE TABLE IF NOT EXISTS tags (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT COMMENT '标签主键ID',
+    `name` VARCHAR(20) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL COMMENT '标签名（小写）',
+    `create_time` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '首次使用时间',
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `uk_name` (`name`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '标签表';
+
+-- 资源-标签关联（发布/编辑资源时整体替换，删除资源时在同一事务内清理）
+CREATE TABLE IF NOT EXISTS resource_tags (
+    `resource_id` BIGINT UNSIGNED NOT NULL COMMENT '关联resources.id',
+    `tag_id` BIGINT UNSIGNED NOT NULL COMMENT '关联tags.id',
+    PRIMARY KEY (`resource_id`, `tag_id`),
+    INDEX `idx_tag_id` (`tag_id`)
+    ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci COMMENT = '资源标签关联表';
+
+-- 资源编程语言（未指定时由代码内容自动识别，识别不出为空）与所属分类（0表示未分类）
+ALTER TABLE resources
+    ADD COLUMN `language` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '编程语言标识（如go/python，空为未知）' AFTER `code_content`,
+    ADD COLUMN `category_id` BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '所属分类categories.id（0为未分类）' AFTER `language`,
+    ADD INDEX `idx_language` (`language`),
+    ADD INDEX `idx_category` (`category_id`);
@@ -13,25 +13,53 @@ import (
 // User 数据库`users`表映射模型（用户核心信息表）
 // @Description 存储用户的基础信息，包含登录标识、个人信息等，适配DATE字段NULL值场景
 type User struct {
-	ID           uint64         `gorm:"column:id;primaryKey;autoIncrement" json:"id" example:"10001"`                                // 用户主键ID（自增）
-	UUID         string         `gorm:"column:uuid;uniqueIndex;not null" json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 用户唯一标识（UUID，用于外部交互）
-	Username     string         `gorm:"column:username;uniqueIndex;not null" json:"username" example:"test_user"`                    // 用户名（唯一，登录标识）
-	Email        *string        `gorm:"column:email;uniqueIndex" json:"email" example:"test@example.com"`                            // 邮箱（可选，唯一，指针类型支持NULL）
-	Phone        *string        `gorm:"column:phone;uniqueIndex" json:"phone" example:"13800138000"`                                 // 手机号（可选，唯一，指针类型支持NULL）
-	PasswordHash string         `gorm:"column:password_hash;not null" json:"-"`                                                      // 密码哈希值（bcrypt加密，json:"-"避免返回给前端）
-	Role         string         `gorm:"column:role;default:candidate" json:"role" example:"hr"`                                      // 用户角色（默认candidate候选人，可选hr/admin）
-	AvatarURL    *string        `gorm:"column:avatar_url" json:"avatar_url" example:"https://example.com/avatar.jpg"`                // 头像URL（可选，指针类型支持NULL）
-	RealName     *string        `gorm:"column:real_name" json:"real_name" example:"张三"`                                              // 真实姓名（可选，指针类型支持NULL）
-	Gender       *string        `gorm:"column:gender" json:"gender" example:"1"`                                                     // 性别（可选，0-未知/1-男/2-女，指针类型支持NULL）
-	Wod          string         `json:"wod" example:""`                                                                              // 注：字段名疑似笔误（建议确认业务含义，如word/wechat_openid等）
-	BirthDate    sql.NullString `gorm:"column:birth_date" json:"birth_date" example:"1990-01-01"`                                    // 出生日期（DATE类型，sql.NullString支持数据库NULL值）
+	ID            uint64         `gorm:"column:id;primaryKey;autoIncrement" json:"id" example:"10001"`                                // 用户主键ID（自增）
+	UUID          string         `gorm:"column:uuid;uniqueIndex;not null" json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 用户唯一标识（UUID，用于外部交互）
+	Username      string         `gorm:"column:username;uniqueIndex;not null" json:"username" example:"test_user"`                    // 用户名（唯一，登录标识）
+	Email         *string        `gorm:"column:email;uniqueIndex" json:"email" example:"test@example.com"`                            // 邮箱（可选，唯一，指针类型支持NULL）
+	Phone         *string        `gorm:"column:phone;uniqueIndex" json:"phone" example:"13800138000"`                                 // 手机号（可选，唯一，指针类型支持NULL）
+	PasswordHash  string         `gorm:"column:password_hash;not null" json:"-"`                                                      // 密码哈希值（bcrypt加密，json:"-"避免返回给前端）
+	Role          string         `gorm:"column:role;default:candidate" json:"role" example:"hr"`                                      // 用户角色（默认candidate候选人，可选hr/admin）
+	AvatarURL     *string        `gorm:"column:avatar_url" json:"avatar_url" example:"https://example.com/avatar.jpg"`                // 头像URL（可选，指针类型支持NULL）
+	RealName      *string        `gorm:"column:real_name" json:"real_name" example:"张三"`                                              // 真实姓名（可选，指针类型支持NULL）
+	Gender        *string        `gorm:"column:gender" json:"gender" example:"1"`                                                     // 性别（可选，0-未知/1-男/2-女，指针类型支持NULL）
+	Wod           string         `json:"wod" example:""`                                                                              // 注：字段名疑似笔误（建议确认业务含义，如word/wechat_openid等）
+	BirthDate     sql.NullString `gorm:"column:birth_date" json:"birth_date" example:"1990-01-01"`                                    // 出生日期（DATE类型，sql.NullString支持数据库NULL值）
+	Status        string         `gorm:"column:status;default:active" json:"status" example:"active"`                                 // 账号状态（active正常/disabled停用/banned封禁，非active不允许登录）
+	EmailVerified bool           `gorm:"column:email_verified;default:false" json:"email_verified" example:"true"`                    // 邮箱是否已验证（未验证不能使用邮箱验证码登录）
 }
 
+// 用户角色（users.role，同时写入JWT的role声明）
+const (
+	RoleCandidate = "candidate" // 候选人（默认角色）
+	RoleHR        = "hr"        // HR
+	RoleAdmin     = "admin"     // 管理员（不允许自行注册）
+)
+
+// 账号状态（users.status，由管理员修改）
+const (
+	UserStatusActive   = "active"   // 正常
+	UserStatusDisabled = "disabled" // 停用（可恢复）
+	UserStatusBanned   = "banned"   // 封禁（违规处理）
+)
+
 // TableName 指定User模型对应的数据库表名
 func (u *User) TableName() string {
 	return "users"
 }
 
+// RefreshToken 刷新令牌模型（refresh_tokens表，只存令牌哈希）
+// @Description 每次刷新都会吊销旧令牌并签发同族新令牌（轮换）；已吊销的令牌再次使用视为泄露，整族吊销
+type RefreshToken struct {
+	ID         uint64     `json:"id" example:"1"`                                      // 主键ID
+	UserUUID   string     `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 关联users.uuid
+	TokenHash  string     `json:"-"`                                                   // 令牌SHA-256哈希（明文不入库）
+	FamilyID   string     `json:"family_id" example:"5b0c1c7e-..."`                    // 令牌族ID（即登录会话ID，对应访问Token的sid）
+	ExpiresAt  time.Time  `json:"expires_at" example:"2026-01-14T15:30:00+08:00"`      // 过期时间
+	RevokedAt  *time.Time `json:"revoked_at" example:"2026-01-07T16:00:00+08:00"`      // 吊销时间（未吊销为空）
+	CreateTime time.Time  `json:"create_time" example:"2026-01-07T15:30:00+08:00"`     // 签发时间
+}
+
 // WordResponse 文本类接口响应结构体
 // @Description 适配前端文本展示需求的响应格式，包含文本内容、日期、落款等字段
 type WordResponse struct {
@@ -58,17 +86,223 @@ type UserAccount struct {
 	TotalConsume  decimal.Decimal `json:"total_consume" example:"50.00"`                       // 累计消费金额（所有消费记录总和）
 }
 
+// 账户流水类型（account_transactions.type）
+const (
+	TxTypeRecharge = "recharge" // 充值（+）
+	TxTypeDeduct   = "deduct"   // 消费扣减（-）
+	TxTypePurchase = "purchase" // 购买资源付款（-），reference_id为购买记录ID
+	TxTypeSale     = "sale"     // 资源售出收入（+），reference_id为购买记录ID
+	TxTypeAdjust   = "adjust"   // 管理员手工调账（±），reference_id为审计日志ID，remark为调账原因
+)
+
+// AccountTransaction 账户流水模型（account_transactions表，只增不改）
+// @Description 每次余额变动在同一事务内写入一条流水，amount带符号（收入为正、支出为负），balance_after为变动后余额
+type AccountTransaction struct {
+	ID             uint64          `json:"id" example:"1"`                                      // 流水主键ID
+	UserUUID       string          `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 关联user_account.user_uuid
+	Type           string          `json:"type" example:"recharge"`                             // 流水类型（recharge/deduct/purchase/sale/adjust）
+	Amount         decimal.Decimal `json:"amount" example:"100.00"`                             // 变动金额（收入为正、支出为负）
+	BalanceAfter   decimal.Decimal `json:"balance_after" example:"1100.00"`                     // 变动后余额
+	ReferenceID    string          `json:"reference_id" example:"12"`                           // 关联业务ID（如购买记录ID，可为空）
+	IdempotencyKey string          `json:"idempotency_key" example:"7f1c2a9e-recharge-0001"`    // 幂等键（同一用户唯一，可为空）
+	Remark         string          `json:"remark" example:"购买资源《Go入门教程》"`                       // 备注
+	CreateTime     time.Time       `json:"create_time" example:"2026-01-07T15:30:00+08:00"`     // 流水时间
+}
+
+// 充值单状态（recharge_orders.status）
+const (
+	RechargeStatusPending = "pending" // 待支付（已创建，等待渠道回调）
+	RechargeStatusPaid    = "paid"    // 已支付（回调验签通过，余额已入账）
+	RechargeStatusFailed  = "failed"  // 支付失败/关闭
+)
+
+// RechargeOrder 充值单模型（recharge_orders表）
+// @Description 充值先创建待支付充值单，支付渠道回调验签通过后才入账；order_no全局唯一，作为渠道侧商户订单号
+type RechargeOrder struct {
+	ID              uint64          `json:"id" example:"1"`                                      // 充值单主键ID
+	OrderNo         string          `json:"order_no" example:"R20260107153000a1b2c3d4"`          // 充值单号（全局唯一）
+	UserUUID        string          `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 关联user_account.user_uuid
+	Amount          decimal.Decimal `json:"amount" example:"100.00"`                             // 充值金额
+	Provider        string          `json:"provider" example:"mock"`                             // 支付渠道标识
+	Status          string          `json:"status" example:"pending"`                            // 状态（pending/paid/failed）
+	ProviderTradeNo string          `json:"provider_trade_no" example:"MOCK5f0c..."`             // 渠道交易号（回调时写入）
+	IdempotencyKey  string          `json:"idempotency_key" example:"recharge-20260107-0001"`    // 幂等键（同一用户唯一，可为空）
+	CreateTime      time.Time       `json:"create_time" example:"2026-01-07T15:30:00+08:00"`     // 创建时间
+	PaidTime        *time.Time      `json:"paid_time" example:"2026-01-07T15:31:00+08:00"`       // 支付完成时间（未支付为空）
+}
+
 // Resource 资源信息模型（文本/代码资源表）
 // @Description 存储用户发布的文本、代码类资源信息，包含点赞、浏览、评论等统计字段
 type Resource struct {
-	ID           uint64    `json:"id" example:"10001"`                                              // 资源主键ID（自增）
-	UserID       uint64    `json:"user_id" example:"10001"`                                         // 关联users表的主键ID（资源发布者）
-	Title        string    `json:"title" example:"Go入门教程"`                                          // 资源标题（必填）
-	TextContent  string    `json:"text_content" example:"Go基础语法讲解..."`                              // 文本内容（纯文本资源）
-	CodeContent  string    `json:"code_content" example:"package main\nimport fmt\nfunc main() {}"` // 代码内容（代码类资源）
-	Author       string    `json:"author" example:"test_user"`                                      // 作者（冗余users表的username，避免联表查询）
-	PublishTime  time.Time `json:"publish_time" example:"2026-01-07T15:30:00+08:00"`                // 发布时间（RFC3339格式）
-	LikeCount    uint64    `json:"like_count" example:"50"`                                         // 点赞量（数据库int unsigned类型）
-	ViewCount    uint64    `json:"view_count" example:"200"`                                        // 浏览量（数据库int unsigned类型）
-	CommentCount uint64    `json:"comment_count" example:"10"`                                      // 评论量（数据库int unsigned类型）
+	ID           uint64          `json:"id" example:"10001"`                                              // 资源主键ID（自增）
+	UserID       uint64          `json:"user_id" example:"10001"`                                         // 关联users表的主键ID（资源发布者）
+	Title        string          `json:"title" example:"Go入门教程"`                                          // 资源标题（必填）
+	TextContent  string          `json:"text_content" example:"Go基础语法讲解..."`                              // 文本内容（纯文本资源）
+	CodeContent  string          `json:"code_content" example:"package main\nimport fmt\nfunc main() {}"` // 代码内容（代码类资源）
+	Language     string          `json:"language" example:"go"`                                           // 编程语言标识（未指定时按代码内容自动识别，空为未知）
+	CategoryID   uint64          `json:"category_id" example:"3"`                                         // 所属分类ID（0为未分类）
+	Tags         []string        `json:"tags" example:"[\"gin\",\"入门\"]"`                                 // 标签（resource_tags表，查询资源时批量填充）
+	Author       string          `json:"author" example:"test_user"`                                      // 作者（冗余users表的username，避免联表查询）
+	PublishTime  time.Time       `json:"publish_time" example:"2026-01-07T15:30:00+08:00"`                // 发布时间（RFC3339格式）
+	LikeCount    uint64          `json:"like_count" example:"50"`                                         // 点赞量（数据库int unsigned类型）
+	ViewCount    uint64          `json:"view_count" example:"200"`                                        // 浏览量（数据库int unsigned类型）
+	CommentCount uint64          `json:"comment_count" example:"10"`                                      // 评论量（数据库int unsigned类型）
+	Price        decimal.Decimal `json:"price" example:"9.90"`                                            // 价格（DECIMAL(10,2)，0表示免费；付费资源仅作者和购买者可查看完整代码）
+	Hidden       bool            `json:"hidden" example:"false"`                                          // 是否被管理员隐藏（隐藏后不出现在列表和详情中）
+}
+
+// ResourcePurchase 资源购买记录模型（resource_purchases表）
+// @Description 记录用户购买付费资源的所有权，同一用户对同一资源仅一条记录
+type ResourcePurchase struct {
+	ID         uint64          `json:"id" example:"1"`                                            // 购买记录主键ID
+	ResourceID uint64          `json:"resource_id" example:"1001"`                                // 关联resources.id
+	BuyerID    uint64          `json:"buyer_id" example:"10002"`                                  // 购买者users.id
+	BuyerUUID  string          `json:"buyer_uuid" example:"123e4567-e89b-12d3-a456-426614174001"` // 购买者UUID
+	AuthorID   uint64          `json:"author_id" example:"10001"`                                 // 作者users.id（收款方）
+	Price      decimal.Decimal `json:"price" example:"9.90"`                                      // 成交价格（购买时的资源价格）
+	CreateTime time.Time       `json:"create_time" example:"2026-01-07T15:30:00+08:00"`           // 购买时间
+}
+
+// MaxCategoryDepth 分类最大层级（一级分类为1）
+const MaxCategoryDepth = 3
+
+// Category 资源分类模型（categories表，树形结构）
+// @Description parent_id为0表示一级分类；按分类筛选资源时包含全部子孙分类
+type Category struct {
+	ID         uint64    `json:"id" example:"3"`                                  // 分类主键ID
+	ParentID   uint64    `json:"parent_id" example:"1"`                           // 父分类ID（0表示一级分类）
+	Name       string    `json:"name" example:"Web开发"`                            // 分类名称（同级唯一）
+	SortOrder  int       `json:"sort_order" example:"0"`                          // 同级排序（升序）
+	CreateTime time.Time `json:"create_time" example:"2026-01-07T15:30:00+08:00"` // 创建时间
+}
+
+// Comment 资源评论模型（comments表）
+// @Description 存储用户对资源的评论内容，评论者身份取自JWT，冗余用户名避免联表查询
+type Comment struct {
+	ID         uint64    `json:"id" example:"1"`                                           // 评论主键ID（自增）
+	ResourceID uint64    `json:"resource_id" example:"1001"`                               // 关联resources表的主键ID
+	ParentID   uint64    `json:"parent_id" example:"0"`                                    // 父评论ID（0表示直接评论资源）
+	RootID     uint64    `json:"root_id" example:"0"`                                      // 所属楼层的顶层评论ID（顶层评论为0）
+	Depth      int       `json:"depth" example:"0"`                                        // 回复层级（顶层评论为0）
+	UserID     uint64    `json:"user_id" example:"10001"`                                  // 评论者users表主键ID
+	UserUUID   string    `json:"user_uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 评论者UUID（用于鉴权编辑/删除）
+	Username   string    `json:"username" example:"test_user"`                             // 评论者用户名（冗余存储）
+	Content    string    `json:"content" example:"这篇教程很实用！"`                               // 评论内容
+	CreateTime time.Time `json:"create_time" example:"2026-01-07T15:30:00+08:00"`          // 评论时间
+	UpdateTime time.Time `json:"update_time" example:"2026-01-07T15:30:00+08:00"`          // 最后编辑时间
+	Hidden     bool      `json:"hidden" example:"false"`                                   // 是否被管理员隐藏（隐藏后不出现在评论列表中，不计入评论量）
+}
+
+// CommentMention 评论@提及记录（comment_mentions表）
+// @Description 记录评论中@到的用户，供被提及用户查看提醒
+type CommentMention struct {
+	ID              uint64    `json:"id" example:"1"`                                                // 提及记录主键ID
+	CommentID       uint64    `json:"comment_id" example:"1"`                                        // 关联comments.id
+	MentionedUserID uint64    `json:"mentioned_user_id" example:"10002"`                             // 被提及用户users.id
+	MentionedUUID   string    `json:"mentioned_uuid" example:"123e4567-e89b-12d3-a456-426614174001"` // 被提及用户UUID
+	IsRead          bool      `json:"is_read" example:"false"`                                       // 是否已读
+	CreateTime      time.Time `json:"create_time" example:"2026-01-07T15:30:00+08:00"`               // 提及时间
+	Comment         Comment   `json:"comment"`                                                       // 提及所在的评论（联表查询填充）
+}
+
+// 管理员操作类型（admin_audit_logs.action）
+const (
+	AuditActionUserRole      = "user.role"       // 修改用户角色
+	AuditActionUserStatus    = "user.status"     // 停用/封禁/恢复账号
+	AuditActionResourceHide  = "resource.hide"   // 隐藏/取消隐藏资源
+	AuditActionResourceDel   = "resource.delete" // 删除资源
+	AuditActionCommentHide   = "comment.hide"    // 隐藏/取消隐藏评论
+	AuditActionCommentDel    = "comment.delete"  // 删除评论
+	AuditActionBalanceAdjust = "balance.adjust"  // 手工调账
+	AuditActionCategoryAdd   = "category.create" // 新增资源分类
+	AuditActionCategoryDel   = "category.delete" // 删除资源分类
+)
+
+// 审计对象类型（admin_audit_logs.target_type）
+const (
+	AuditTargetUser     = "user"
+	AuditTargetResource = "resource"
+	AuditTargetComment  = "comment"
+	AuditTargetAccount  = "account"
+	AuditTargetCategory = "category"
+)
+
+// AdminAuditLog 管理员操作审计日志（admin_audit_logs表，只增不改）
+// @Description 管理员的每次操作在同一事务内写入一条审计记录，detail为操作前后状态的JSON快照
+type AdminAuditLog struct {
+	ID         uint64    `json:"id" example:"1"`                                            // 审计记录主键ID
+	AdminUUID  string    `json:"admin_uuid" example:"123e4567-e89b-12d3-a456-426614174000"` // 操作管理员UUID
+	AdminName  string    `json:"admin_name" example:"admin"`                                // 操作管理员用户名（冗余存储）
+	Action     string    `json:"action" example:"user.status"`                              // 操作类型
+	TargetType string    `json:"target_type" example:"user"`                                // 操作对象类型（user/resource/comment/account）
+	TargetID   string    `json:"target_id" example:"123e4567-e89b-12d3-a456-426614174001"`  // 操作对象ID（用户为UUID，资源/评论为自增ID）
+	Reason     string    `json:"reason" example:"发布违规广告"`                                   // 操作原因
+	Detail     string    `json:"detail" example:"{\"from\":\"active\",\"to\":\"banned\"}"`  // 操作详情（JSON）
+	CreateTime time.Time `json:"create_time" example:"2026-01-07T15:30:00+08:00"`           // 操作时间
+}
+
+// 验证码用途（verify_codes.purpose，不同用途的验证码互不影响）
+const (
+	CodePurposeLogin         = "login"          // 邮箱验证码登录
+	CodePurposeResetPassword = "reset_password" // 忘记密码-重置密码
+	CodePurposeVerifyEmail   = "verify_email"   // 邮箱归属验证（注册/修改邮箱）
+)
+
+// VerifyCode 邮箱验证码（verify_codes表，同一用途+邮箱只保留最新一条；只存哈希不存明文）
+type VerifyCode struct {
+	Purpose    string    // 用途
+	Email      string    // 邮箱（小写）
+	CodeHash   string    // 验证码SHA-256哈希（hex）
+	Attempts   int       // 已校验失败次数
+	ExpiresAt  time.Time // 过期时间
+	CreateTime time.Time // 签发时间
+}
+
+// 密码登录结果（login_attempts.result）
+const (
+	LoginResultSuccess        = "success"         // 登录成功
+	LoginResultBadCredentials = "bad_credentials" // 账号或密码错误
+	LoginResultLocked         = "locked"          // 失败次数过多，登录被临时锁定
+	LoginResultDisabled       = "disabled"        // 密码正确但账号已停用/封禁
+	LoginResultTwoFactor      = "2fa_pending"     // 密码正确，等待提交两步验证动态码
+	LoginResultTwoFactorFail  = "2fa_failed"      // 两步验证动态码/恢复码错误
+)
+
+// LoginAttempt 密码登录记录（login_attempts表，用户可在个人中心查看自己账号的登录记录）
+type LoginAttempt struct {
+	ID         uint64    // 记录主键ID
+	UserUUID   string    // 账号UUID（账号不存在时为空）
+	Identifier string    // 登录时填写的用户名/手机号/邮箱
+	IP         string    // 客户端IP
+	UserAgent  string    // 客户端User-Agent
+	Result     string    // 登录结果
+	CreateTime time.Time // 登录时间
+}
+
+// LoginLockout 登录失败计数与锁定状态（login_lockouts表，按账号、IP分别计数）
+type LoginLockout struct {
+	LockKey     string       // 计数key：account|账号 或 ip|IP
+	Failures    int          // 连续失败次数
+	LastFailure time.Time    // 最近一次失败时间
+	LockedUntil sql.NullTime // 锁定结束时间（未锁定为NULL）
+}
+
+// UserTwoFactor 账号TOTP两步验证设置（user_two_factor表，开启前为待确认状态）
+type UserTwoFactor struct {
+	UserUUID   string       // 账号UUID
+	SecretEnc  string       // 加密后的TOTP密钥（AES-GCM，Base64）
+	Enabled    bool         // 是否已开启（绑定后需提交一次动态码确认才开启）
+	LastStep   int64        // 最近一次校验通过的时间步（同一动态码不能重复使用）
+	CreateTime time.Time    // 绑定时间
+	EnableTime sql.NullTime // 开启时间
+}
+
+// TwoFactorChallenge 两步验证登录挑战（two_factor_challenges表，密码校验通过后签发，只存令牌哈希）
+type TwoFactorChallenge struct {
+	TokenHash  string    // 挑战令牌SHA-256哈希（hex）
+	UserUUID   string    // 账号UUID
+	IP         string    // 发起登录的客户端IP
+	Attempts   int       // 已校验失败次数
+	ExpiresAt  time.Time // 过期时间
+	CreateTime time.Time // 签发时间
 }
@@ -0,0 +1,143 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MockProviderName      = "mock"
+	mockCallbackMaxSkew   = 5 * time.Minute // 回调时间戳允许的最大偏差（防重放）
+	mockCallbackBodyLimit = 1 << 16         // 回调请求体上限（64KB）
+)
+
+// mockNotification Mock渠道回调报文（JSON）
+type mockNotification struct {
+	OrderNo   string `json:"order_no"`
+	TradeNo   string `json:"trade_no"`
+	Amount    string `json:"amount"`
+	Status    string `json:"status"` // SUCCESS / FAILED
+	Timestamp int64  `json:"timestamp"`
+	Sign      string `json:"sign"` // HMAC-SHA256(secret, 规范化字符串)，hex编码
+}
+
+// MockProvider 本地Mock支付渠道：支付链接指向本服务的模拟收银台，回调使用HMAC-SHA256签名，可完全离线联调
+type MockProvider struct {
+	secret    []byte
+	notifyURL string // 回调地址（如http://localhost:8080/payment/callback/mock）
+	client    *http.Client
+}
+
+// NewMockProvider 创建Mock支付渠道
+func NewMockProvider(secret, notifyURL string) *MockProvider {
+	return &MockProvider{
+		secret:    []byte(secret),
+		notifyURL: notifyURL,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name 渠道标识
+func (p *MockProvider) Name() string {
+	return MockProviderName
+}
+
+// CreatePayment 返回模拟收银台地址（调用该地址即模拟用户完成支付）
+func (p *MockProvider) CreatePayment(ctx context.Context, orderNo string, amount decimal.Decimal, subject string) (*PaymentIntent, error) {
+	if orderNo == "" {
+		return nil, errors.New("充值单号不能为空")
+	}
+	return &PaymentIntent{PayURL: "/payment/mock/pay?order_no=" + url.QueryEscape(orderNo)}, nil
+}
+
+// ParseCallback 校验回调签名与时间戳，解析支付结果
+func (p *MockProvider) ParseCallback(r *http.Request) (*CallbackResult, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, mockCallbackBodyLimit))
+	if err != nil {
+		return nil, fmt.Errorf("读取回调报文失败：%w", err)
+	}
+	var n mockNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("解析回调报文失败：%w", err)
+	}
+
+	// 1. 验签（常量时间比较）
+	expected := p.sign(n)
+	if !hmac.Equal([]byte(expected), []byte(n.Sign)) {
+		return nil, errors.New("回调签名校验失败")
+	}
+	// 2. 时间戳校验（防止截获的回调被长期重放）
+	if skew := time.Since(time.Unix(n.Timestamp, 0)); skew > mockCallbackMaxSkew || skew < -mockCallbackMaxSkew {
+		return nil, errors.New("回调时间戳已过期")
+	}
+
+	amount, err := decimal.NewFromString(n.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("回调金额格式错误：%w", err)
+	}
+	return &CallbackResult{
+		OrderNo: n.OrderNo,
+		TradeNo: n.TradeNo,
+		Amount:  amount,
+		Paid:    n.Status == "SUCCESS",
+	}, nil
+}
+
+// SimulatePay 模拟用户支付成功：构造签名回调并POST到notifyURL（走与真实渠道相同的回调验签入账流程）
+func (p *MockProvider) SimulatePay(ctx context.Context, orderNo string, amount decimal.Decimal) error {
+	n := mockNotification{
+		OrderNo:   orderNo,
+		TradeNo:   "MOCK" + uuid.NewString(),
+		Amount:    amount.StringFixed(2),
+		Status:    "SUCCESS",
+		Timestamp: time.Now().Unix(),
+	}
+	n.Sign = p.sign(n)
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("构造回调报文失败：%w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.notifyURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("构造回调请求失败：%w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送支付回调失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("支付回调处理失败（HTTP %d）：%s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// sign 按字段名字典序拼接规范化字符串后计算HMAC-SHA256
+func (p *MockProvider) sign(n mockNotification) string {
+	canonical := "amount=" + n.Amount +
+		"&order_no=" + n.OrderNo +
+		"&status=" + n.Status +
+		"&timestamp=" + strconv.FormatInt(n.Timestamp, 10) +
+		"&trade_no=" + n.TradeNo
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
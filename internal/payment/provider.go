@@ -0,0 +1,39 @@
+// Package payment 定义充值支付渠道抽象
+// 充值流程：创建待支付充值单 → PaymentProvider生成支付链接 → 渠道回调（带签名）确认支付 → 入账
+// 余额只在回调验签通过后入账，前端无法直接给自己加钱
+package payment
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/shopspring/decimal"
+)
+
+// PaymentIntent 支付渠道返回的支付信息
+type PaymentIntent struct {
+	PayURL string // 支付链接（前端跳转或调用该地址完成支付）
+}
+
+// CallbackResult 回调验签通过后解析出的支付结果
+type CallbackResult struct {
+	OrderNo string          // 本系统充值单号
+	TradeNo string          // 渠道交易号
+	Amount  decimal.Decimal // 实付金额（需与充值单金额一致）
+	Paid    bool            // 是否支付成功（false表示支付失败/关闭）
+}
+
+// PaymentProvider 支付渠道接口（每个渠道一个实现，按Name注册）
+type PaymentProvider interface {
+	// Name 渠道标识（对应回调地址/payment/callback/:provider 及 recharge_orders.provider）
+	Name() string
+	// CreatePayment 为充值单创建支付，返回支付链接
+	CreatePayment(ctx context.Context, orderNo string, amount decimal.Decimal, subject string) (*PaymentIntent, error)
+	// ParseCallback 校验回调签名并解析支付结果（签名不合法必须返回错误）
+	ParseCallback(r *http.Request) (*CallbackResult, error)
+}
+
+// Simulator 可在本地模拟用户完成支付的渠道（仅MockProvider实现，用于离线联调）
+type Simulator interface {
+	SimulatePay(ctx context.Context, orderNo string, amount decimal.Decimal) error
+}
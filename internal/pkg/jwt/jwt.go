@@ -1,6 +1,11 @@
 package pkg
 
 import (
+	"CMS/internal/config"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -25,35 +30,60 @@ func CleanToken(tokenStr string) string {
 
 // JWTConfig JWT配置项
 type JWTConfig struct {
-	Secret []byte        // 签名密钥
-	Expire time.Duration // Token有效期
-	Issuer string        // 签发者
+	Secret        []byte        // 签名密钥
+	Expire        time.Duration // Token有效期
+	Issuer        string        // 签发者
+	RefreshExpire time.Duration // 刷新令牌有效期
 }
 
-var abc = JWTConfig{
-	Secret: []byte("aiuegfiuewgfiuwfeiuwheqowhfoiqfiifenfeqnfeq"),
-	Expire: 30 * time.Minute,
-	Issuer: "https://jwt.io",
+// NewJWTConfig 由应用配置构建JWT配置项
+func NewJWTConfig(cfg config.JWTConfig) JWTConfig {
+	return JWTConfig{
+		Secret:        []byte(cfg.Secret),
+		Expire:        cfg.AccessTTL,
+		Issuer:        cfg.Issuer,
+		RefreshExpire: cfg.RefreshTTL,
+	}
 }
 
-// UserClaims JWT自定义声明
+// abc 签发Token使用的配置（启动时由SetJWTConfig注入，未注入时签发失败）
+var abc JWTConfig
+
+// SetJWTConfig 注入签发Token使用的配置
+func SetJWTConfig(cfg JWTConfig) {
+	abc = cfg
+}
+
+// UserClaims JWT自定义声明（RegisteredClaims.ID为jti，用于吊销单个Token）
 type UserClaims struct {
-	UserID   string `json:"uuid"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID    string `json:"uuid"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"` // 登录会话ID（同一次登录签发的刷新令牌族ID，退出登录时据此吊销刷新令牌）
 	jwtv5.RegisteredClaims
 }
 
-// GenerateToken 生成JWT Token
-func GenerateToken(uuid string, username, role string) (string, error) {
+// AccessTokenExpire 访问Token有效期（吊销记录只需保留到Token自然过期）
+func AccessTokenExpire() time.Duration {
+	return abc.Expire
+}
+
+// GenerateToken 生成JWT Token（每个Token带唯一jti）
+func GenerateToken(uuid string, username, role, sessionID string) (string, error) {
+	if len(abc.Secret) == 0 {
+		return "", errors.New("JWT签名密钥未配置")
+	}
+	now := time.Now()
 	claims := UserClaims{
-		UserID:   uuid,
-		Username: username,
-		Role:     role,
+		UserID:    uuid,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 
 		RegisteredClaims: jwtv5.RegisteredClaims{
-			ExpiresAt: jwtv5.NewNumericDate(time.Now().Add(abc.Expire)),
-			IssuedAt:  jwtv5.NewNumericDate(time.Now()),
+			ID:        GenerateUUID(),
+			ExpiresAt: jwtv5.NewNumericDate(now.Add(abc.Expire)),
+			IssuedAt:  jwtv5.NewNumericDate(now),
 			Issuer:    abc.Issuer,
 		},
 	}
@@ -62,12 +92,36 @@ func GenerateToken(uuid string, username, role string) (string, error) {
 	return token.SignedString(abc.Secret)
 }
 
+// RefreshTokenExpire 刷新令牌有效期
+func RefreshTokenExpire() time.Duration {
+	return abc.RefreshExpire
+}
+
+// GenerateRefreshToken 生成刷新令牌：返回明文（仅下发给客户端）及其SHA-256哈希（仅哈希入库）
+func GenerateRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("生成刷新令牌失败：%w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken 计算刷新令牌哈希（hex编码，用于入库及查询）
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // ParseToken 解析并验证JWT Token
 func ParseToken(cfg JWTConfig, tokenStr string) (*UserClaims, error) {
 	tokenStr = CleanToken(tokenStr)
 	if tokenStr == "" {
 		return nil, errors.New("token为空")
 	}
+	if len(cfg.Secret) == 0 {
+		return nil, errors.New("JWT签名密钥未配置")
+	}
 
 	token, err := jwtv5.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwtv5.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwtv5.SigningMethodHMAC); !ok {
@@ -106,12 +160,18 @@ func GenerateUUID() string {
 }
 
 const (
-	AvatarSaveRoot  = "./static/avatars" // 本地保存根目录
-	AvatarBaseURL   = "/static/avatars/" // 前端访问基础URL
-	MaxAvatarSize   = 2 * 1024 * 1024    // 最大2MB
-	AllowAvatarExts = ".jpg,.jpeg,.png"  // 允许的格式
+	MaxAvatarSize   = 2 * 1024 * 1024   // 最大2MB
+	AllowAvatarExts = ".jpg,.jpeg,.png" // 允许的格式
 )
 
+// storage 本地文件存储配置（头像/MD文件的保存目录与访问URL前缀，启动时由SetStorageConfig注入）
+var storage = config.Default().Storage
+
+// SetStorageConfig 注入本地文件存储配置
+func SetStorageConfig(cfg config.StorageConfig) {
+	storage = cfg
+}
+
 // SaveAvatar 保存头像文件并生成访问URL
 func SaveAvatar(file io.Reader, fileName string) (string, error) {
 	// 1. 空文件校验
@@ -131,10 +191,10 @@ func SaveAvatar(file io.Reader, fileName string) (string, error) {
 	// 3. 生成唯一文件名（避免覆盖）
 	uniqueID := uuid.New().String()
 	saveFileName := uniqueID + ext
-	savePath := filepath.Join(AvatarSaveRoot, saveFileName)
+	savePath := filepath.Join(storage.AvatarDir, saveFileName)
 
 	// 4. 自动创建目录
-	if err := os.MkdirAll(AvatarSaveRoot, 0755); err != nil {
+	if err := os.MkdirAll(storage.AvatarDir, 0755); err != nil {
 		return "", fmt.Errorf("创建头像目录失败：%w", err)
 	}
 
@@ -161,7 +221,7 @@ func SaveAvatar(file io.Reader, fileName string) (string, error) {
 	}
 
 	// 7. 生成访问URL
-	return AvatarBaseURL + saveFileName, nil
+	return storage.AvatarURLPrefix + saveFileName, nil
 }
 
 // 辅助：大小限制Writer
@@ -182,9 +242,8 @@ func (w *sizeLimitWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 func DeleteAvatar(avatarURL string) error {
-	const AvatarSaveRoot = "D:\\朱子墨\\Go\\CMS\\static\\avatars" // 本地保存根目录
 	fileName := filepath.Base(avatarURL)
-	fullPath := filepath.Join(AvatarSaveRoot, fileName)
+	fullPath := filepath.Join(storage.AvatarDir, fileName)
 
 	// 删除文件
 	if err := os.Remove(fullPath); err != nil {
@@ -0,0 +1,311 @@
+// Package langdetect 编程语言标识与代码语言识别
+// 识别基于关键字/语法特征打分（不依赖文件名），只用于资源分类展示与筛选，识别不出时返回空字符串
+package langdetect
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Language 支持的编程语言
+type Language struct {
+	ID   string // 标识（小写，存入resources.language）
+	Name string // 展示名称
+}
+
+// Languages 支持的编程语言（展示顺序）
+var Languages = []Language{
+	{"go", "Go"},
+	{"python", "Python"},
+	{"java", "Java"},
+	{"kotlin", "Kotlin"},
+	{"javascript", "JavaScript"},
+	{"typescript", "TypeScript"},
+	{"c", "C"},
+	{"cpp", "C++"},
+	{"csharp", "C#"},
+	{"rust", "Rust"},
+	{"php", "PHP"},
+	{"ruby", "Ruby"},
+	{"shell", "Shell"},
+	{"sql", "SQL"},
+	{"html", "HTML"},
+	{"css", "CSS"},
+}
+
+// aliases 常见别名/文件扩展名 → 语言标识
+var aliases = map[string]string{
+	"golang":  "go",
+	"py":      "python",
+	"python3": "python",
+	"kt":      "kotlin",
+	"js":      "javascript",
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"ts":      "typescript",
+	"c++":     "cpp",
+	"cc":      "cpp",
+	"cxx":     "cpp",
+	"c#":      "csharp",
+	"cs":      "csharp",
+	"rs":      "rust",
+	"rb":      "ruby",
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"mysql":   "sql",
+	"htm":     "html",
+}
+
+// Name 返回语言的展示名称（未知标识原样返回）
+func Name(id string) string {
+	for _, lang := range Languages {
+		if lang.ID == id {
+			return lang.Name
+		}
+	}
+	return id
+}
+
+// Normalize 将语言标识/展示名称/别名转换为标准标识，不支持的语言返回false
+func Normalize(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if id, ok := aliases[s]; ok {
+		return id, true
+	}
+	for _, lang := range Languages {
+		if s == lang.ID || s == strings.ToLower(lang.Name) {
+			return lang.ID, true
+		}
+	}
+	return "", false
+}
+
+const (
+	// maxDetectBytes 只取代码开头的部分参与识别（特征通常集中在开头，避免超长代码拖慢发布）
+	maxDetectBytes = 16 << 10
+	// minScore 最高得分低于该值时视为无法识别
+	minScore = 5
+)
+
+// rule 语法特征：命中即加weight分（每条规则只计一次）
+type rule struct {
+	re     *regexp.Regexp
+	weight int
+}
+
+// detector 单个语言的识别规则；extends非空时该语言自身有命中才叠加被扩展语言的得分（如C++叠加C）
+type detector struct {
+	id      string
+	extends string
+	rules   []rule
+}
+
+// r 构造规则（统一开启多行模式，^/$匹配行首/行尾）
+func r(weight int, pattern string) rule {
+	return rule{re: regexp.MustCompile(`(?m)` + pattern), weight: weight}
+}
+
+// detectors 识别规则（同分时靠前的语言优先）
+var detectors = []detector{
+	{id: "go", rules: []rule{
+		r(6, `^package \w+\s*$`),
+		r(4, `\bfunc (\(\w+ \*?\w+\) )?\w+\(`),
+		r(4, `\berr != nil\b`),
+		r(3, `\bfmt\.\w+\(`),
+		r(3, `^import \($`),
+		r(2, `\w+ := `),
+		r(2, `\b(go func|defer|chan) `),
+	}},
+	{id: "python", rules: []rule{
+		r(5, `^\s*def \w+\(.*\)( -> [\w\[\], .]+)?:\s*$`),
+		r(5, `^from [\w.]+ import `),
+		r(5, `__name__ == ['"]__main__['"]`),
+		r(4, `^\s*elif .*:\s*$`),
+		r(4, `^\s*for \w+(, ?\w+)* in .+:\s*$`),
+		r(2, `^\s*(if|while|with|try|except|else)\b.*:\s*$`),
+		r(3, `^\s*class \w+(\(.*\))?:\s*$`),
+		r(2, `^import [\w.]+( as \w+)?\s*$`),
+		r(2, `\bself\.\w+`),
+		r(1, `\bprint\(`),
+	}},
+	{id: "java", rules: []rule{
+		r(6, `\bpublic static void main\(String`),
+		r(5, `\bSystem\.out\.print`),
+		r(5, `^import java(x)?\.[\w.*]+;`),
+		r(4, `^package [\w.]+;`),
+		r(3, `\b(public|private|protected) (static )?(final )?(class|interface|enum) \w+`),
+		r(3, `@Override\b`),
+		r(2, `\b(public|private|protected) [\w<>\[\]]+ \w+\(`),
+	}},
+	{id: "kotlin", rules: []rule{
+		r(5, `\bfun main\(`),
+		r(4, `\bfun \w+\(.*\)(: [\w<>?]+)? [{=]`),
+		r(3, `\bval \w+(: [\w<>?]+)? = `),
+		r(2, `\bvar \w+: [\w<>?]+`),
+		r(2, `\bdata class \w+`),
+		r(1, `\bprintln\(`),
+	}},
+	{id: "javascript", rules: []rule{
+		r(5, `\bconsole\.(log|error|warn)\(`),
+		r(5, `\brequire\(['"][\w./@-]+['"]\)`),
+		r(5, `\bmodule\.exports\b`),
+		r(4, `\bdocument\.(getElementById|querySelector|addEventListener)`),
+		r(3, `^import .+ from ['"][\w./@-]+['"];?\s*$`),
+		r(3, `^export (default |const |function )`),
+		r(2, `\b(const|let) \w+ = `),
+		r(2, `\bfunction\s*\w*\s*\(`),
+		r(2, `===|!==`),
+		r(1, `=>`),
+	}},
+	{id: "typescript", extends: "javascript", rules: []rule{
+		r(4, `\b(const|let|var) \w+: [\w<>\[\]| ]+ = `),
+		r(4, `\((\w+\??: [\w<>\[\]| ]+,? ?)+\)(: [\w<>\[\]| ]+)? (=>|\{)`),
+		r(4, `^(export )?(interface|type) \w+(<.+>)? (=|\{)`),
+		r(3, `\b(public|private|readonly) \w+: `),
+		r(2, `: (string|number|boolean|void|any|unknown)\b`),
+	}},
+	{id: "c", rules: []rule{
+		r(5, `^#include\s*<\w+\.h>`),
+		r(4, `\bint main\s*\(`),
+		r(3, `\bprintf\s*\(`),
+		r(3, `\b(malloc|free|sizeof)\s*\(`),
+		r(2, `^#define \w+`),
+		r(2, `\b(typedef )?struct \w*\s*\{`),
+	}},
+	{id: "cpp", extends: "c", rules: []rule{
+		r(6, `^#include\s*<(iostream|vector|string|map|set|algorithm|memory|unordered_map|queue|bits/stdc\+\+\.h)>`),
+		r(5, `\bstd::\w+`),
+		r(5, `\b(cout|cerr)\s*<<|\bcin\s*>>`),
+		r(4, `^using namespace \w+;`),
+		r(3, `\btemplate\s*<`),
+		r(2, `\bclass \w+\s*(:\s*(public|private) \w+\s*)?\{`),
+	}},
+	{id: "csharp", rules: []rule{
+		r(6, `^using System(\.\w+)*;`),
+		r(5, `\bConsole\.Write(Line)?\(`),
+		r(5, `\{ get; (private )?set; \}`),
+		r(3, `^namespace [\w.]+`),
+		r(2, `\b(public|private|internal) (static )?(async )?(void|Task|string|int|bool) \w+\(`),
+		r(2, `\bvar \w+ = new \w+`),
+	}},
+	{id: "rust", rules: []rule{
+		r(5, `\bfn \w+(<.+>)?\(.*\)( -> .+)? \{`),
+		r(5, `\blet mut \w+`),
+		r(5, `\b(println|format|vec)!\(`),
+		r(5, `^use (std|crate|super)::`),
+		r(3, `^\s*impl(<.+>)? \w+`),
+		r(3, `&mut \w+`),
+		r(2, `\bmatch \w+ \{`),
+		r(1, `\b(Some|Ok|Err)\(`),
+	}},
+	{id: "php", rules: []rule{
+		r(10, `<\?php`),
+		r(4, `\$this->\w+`),
+		r(4, `\bfunction \w+\(\$`),
+		r(2, `\$\w+\s*=[^=]`),
+		r(2, `\becho \S`),
+	}},
+	{id: "ruby", rules: []rule{
+		r(5, `\.each(_with_index)? do \|`),
+		r(5, `\battr_(accessor|reader|writer) :`),
+		r(4, `^require(_relative)? ['"][\w./-]+['"]\s*$`),
+		r(3, `^\s*def \w+[?!]?(\(.*\))?\s*$`),
+		r(3, `^\s*end\s*$`),
+		r(2, `\bputs \S`),
+		r(1, `@\w+ = `),
+	}},
+	{id: "shell", rules: []rule{
+		r(4, `^\s*(if|while) \[\[? .+ \]\]?; (then|do)\s*$`),
+		r(3, `^\s*(fi|done|esac)\s*$`),
+		r(3, `^\s*(echo|export|sudo|apt-get|apt|yum|chmod|mkdir|cd|source) \S`),
+		r(2, `\$\{?\w+\}?`),
+		r(1, `\s(\|\||&&)\s`),
+	}},
+	{id: "sql", rules: []rule{
+		r(5, `(?i)^\s*SELECT\s.+\sFROM\s`),
+		r(5, `(?i)^\s*(INSERT INTO|UPDATE \w+ SET|DELETE FROM|CREATE (TABLE|INDEX|VIEW|DATABASE)|ALTER TABLE|DROP (TABLE|INDEX))\b`),
+		r(2, `(?i)\b(WHERE|GROUP BY|ORDER BY|LEFT JOIN|INNER JOIN)\b`),
+		r(1, `;\s*$`),
+	}},
+	{id: "html", rules: []rule{
+		r(10, `(?i)<!DOCTYPE html>`),
+		r(4, `(?i)<(html|head|body)[\s>]`),
+		r(3, `(?i)<(div|span|p|a|ul|li|table|form|input|button)[\s>]`),
+		r(2, `(?i)</(div|span|p|a|ul|li|table|form|button)>`),
+		r(1, `(?i)<(script|link|meta|style)[\s>]`),
+	}},
+	{id: "css", rules: []rule{
+		r(4, `^\s*@(media|import|keyframes|font-face)\b`),
+		r(3, `^\s*[.#]?[\w-]+([\s,>+~:]+[.#]?[\w-]+)*\s*\{\s*$`),
+		r(3, `^\s*(color|margin|padding|font-size|display|background(-color)?|border|width|height)\s*:\s*[^;]+;\s*$`),
+		r(2, `!important\b`),
+	}},
+}
+
+// shebangs 脚本首行解释器 → 语言标识
+var shebangs = map[string]string{
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"python":  "python",
+	"python3": "python",
+	"node":    "javascript",
+	"ruby":    "ruby",
+	"php":     "php",
+}
+
+// Detect 识别代码的编程语言，无法识别返回空字符串
+func Detect(code string) string {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ""
+	}
+	if id := detectShebang(code); id != "" {
+		return id
+	}
+	if len(code) > maxDetectBytes {
+		code = code[:maxDetectBytes]
+	}
+
+	own := make(map[string]int, len(detectors))
+	for _, d := range detectors {
+		for _, rl := range d.rules {
+			if rl.re.MatchString(code) {
+				own[d.id] += rl.weight
+			}
+		}
+	}
+
+	best, bestScore := "", 0
+	for _, d := range detectors {
+		score := own[d.id]
+		if score > 0 && d.extends != "" {
+			score += own[d.extends]
+		}
+		if score > bestScore {
+			best, bestScore = d.id, score
+		}
+	}
+	if bestScore < minScore {
+		return ""
+	}
+	return best
+}
+
+// detectShebang 按首行#!解释器识别脚本语言（如#!/usr/bin/env python3）
+func detectShebang(code string) string {
+	line, _, _ := strings.Cut(code, "\n")
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+	interp := fields[0][strings.LastIndex(fields[0], "/")+1:]
+	if interp == "env" && len(fields) > 1 {
+		interp = fields[1]
+	}
+	return shebangs[interp]
+}
@@ -0,0 +1,223 @@
+package langdetect
+
+import (
+	"strings"
+	"testing"
+)
+
+// samples 每种支持语言的典型代码片段
+var samples = map[string]string{
+	"go": `package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	n, err := fmt.Println("hello")
+	if err != nil {
+		panic(err)
+	}
+	_ = n
+}`,
+	"python": `from collections import Counter
+
+def top(words: list) -> list:
+    for w in words:
+        print(w)
+    return Counter(words).most_common(3)
+
+if __name__ == "__main__":
+    top(["a"])`,
+	"java": `package com.example;
+
+import java.util.List;
+
+public class Main {
+    public static void main(String[] args) {
+        System.out.println("hello");
+    }
+}`,
+	"kotlin": `data class User(val name: String)
+
+fun main() {
+    val user: User = User("alice")
+    println(user.name)
+}`,
+	"javascript": `const express = require('express');
+const app = express();
+app.get('/', (req, res) => {
+  console.log('hit');
+  res.send('ok');
+});
+module.exports = app;`,
+	"typescript": `export interface User {
+  name: string;
+}
+
+function greet(user: User): string {
+  const prefix: string = "hi ";
+  console.log(prefix);
+  return prefix + user.name;
+}`,
+	"c": `#include <stdio.h>
+#include <stdlib.h>
+
+int main(void) {
+    int *p = malloc(sizeof(int));
+    printf("%d\n", *p);
+    free(p);
+    return 0;
+}`,
+	"cpp": `#include <iostream>
+#include <vector>
+using namespace std;
+
+int main() {
+    std::vector<int> v{1, 2, 3};
+    cout << v.size() << endl;
+    return 0;
+}`,
+	"csharp": `using System;
+
+namespace Demo
+{
+    public class User
+    {
+        public string Name { get; set; }
+        public static void Main(string[] args)
+        {
+            Console.WriteLine("hello");
+        }
+    }
+}`,
+	"rust": `use std::collections::HashMap;
+
+fn main() {
+    let mut counts = HashMap::new();
+    counts.insert("a", 1);
+    println!("{:?}", counts);
+}`,
+	"php": `<?php
+class User {
+    public function name($prefix) {
+        return $prefix . $this->name;
+    }
+}
+echo "hello";`,
+	"ruby": `require 'json'
+
+class User
+  attr_accessor :name
+
+  def greet
+    [1, 2].each do |i|
+      puts i
+    end
+  end
+end`,
+	"shell": `export PATH=$HOME/bin:$PATH
+if [ -d "$DIR" ]; then
+  echo "exists"
+fi
+mkdir -p /tmp/out && cd /tmp/out`,
+	"sql": `SELECT u.id, COUNT(o.id)
+FROM users u
+LEFT JOIN orders o ON o.user_id = u.id
+GROUP BY u.id;`,
+	"html": `<!DOCTYPE html>
+<html>
+<head><title>demo</title></head>
+<body>
+  <div class="box"><p>hello</p></div>
+</body>
+</html>`,
+	"css": `@media (max-width: 600px) {
+  .box {
+    color: red;
+    margin: 0 auto;
+  }
+}`,
+}
+
+func TestDetectSupportedLanguages(t *testing.T) {
+	for _, lang := range Languages {
+		code, ok := samples[lang.ID]
+		if !ok {
+			t.Errorf("缺少%s的测试代码", lang.ID)
+			continue
+		}
+		if got := Detect(code); got != lang.ID {
+			t.Errorf("%s代码识别为%q", lang.Name, got)
+		}
+	}
+}
+
+func TestDetectShebang(t *testing.T) {
+	cases := []struct {
+		code string
+		want string
+	}{
+		{"#!/bin/bash\nls -l", "shell"},
+		{"#!/usr/bin/env python3\nx = 1", "python"},
+		{"#!/usr/bin/env node\nrun()", "javascript"},
+		{"#!/usr/bin/ruby -w\nx = 1", "ruby"},
+		// 未知解释器按代码内容识别
+		{"#!/usr/bin/env perl\nSELECT id FROM users WHERE id = 1;", "sql"},
+	}
+	for _, tc := range cases {
+		if got := Detect(tc.code); got != tc.want {
+			t.Errorf("Detect(%q)=%q，期望%q", tc.code, got, tc.want)
+		}
+	}
+}
+
+func TestDetectFallback(t *testing.T) {
+	cases := map[string]string{
+		"空字符串":    "",
+		"只有空白":    " \n\t ",
+		"自然语言":    "这是一段面试经验总结，没有任何代码。",
+		"单个弱特征":   "x => y",
+		"无法识别的语言": "(defun hello () (format t \"hi\"))",
+	}
+	for name, code := range cases {
+		if got := Detect(code); got != "" {
+			t.Errorf("%s：识别为%q，期望空字符串", name, got)
+		}
+	}
+}
+
+func TestDetectLimitsInput(t *testing.T) {
+	// 特征只出现在maxDetectBytes之后时不参与识别
+	code := strings.Repeat("这是一段说明文字\n", maxDetectBytes/10) + samples["go"]
+	if got := Detect(code); got != "" {
+		t.Errorf("超出识别范围的代码识别为%q，期望空字符串", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"go", "go", true},
+		{" Golang ", "go", true},
+		{"C++", "cpp", true},
+		{"c#", "csharp", true},
+		{"JavaScript", "javascript", true},
+		{"ts", "typescript", true},
+		{"bash", "shell", true},
+		{"perl", "", false},
+		{"", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := Normalize(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Normalize(%q)=%q, %v，期望%q, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+	if Name("cpp") != "C++" || Name("perl") != "perl" {
+		t.Errorf("Name返回%q/%q，期望C++/perl", Name("cpp"), Name("perl"))
+	}
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"CMS/internal/config"
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// redacted 敏感字段脱敏后的占位值
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 需要脱敏的字段名（小写；日志属性名与URL查询参数名均按此匹配）
+// 验证码类字段使用带前缀的名称（verify_code/totp_code），不要加入通用的code：错误日志中的code是业务错误码
+var sensitiveKeys = map[string]bool{
+	"password":        true,
+	"old_password":    true,
+	"new_password":    true,
+	"password_hash":   true,
+	"token":           true,
+	"access_token":    true,
+	"refresh_token":   true,
+	"challenge_token": true,
+	"verify_code":     true,
+	"totp_code":       true,
+	"recovery_code":   true,
+	"secret":          true,
+	"otpauth_uri":     true,
+	"recovery_codes":  true,
+	"authorization":   true,
+	"cookie":          true,
+	"sign":            true,
+}
+
+// emailKeys 需要部分打码的邮箱字段名（保留首字符与域名，便于排查问题）
+var emailKeys = map[string]bool{
+	"email": true,
+	"to":    true,
+}
+
+// New 按配置创建日志（输出到标准输出，敏感字段自动脱敏）
+func New(cfg config.LogConfig) *slog.Logger {
+	return NewWithWriter(os.Stdout, cfg)
+}
+
+// NewWithWriter 按配置创建输出到w的日志
+func NewWithWriter(w io.Writer, cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	if cfg.Format == config.LogFormatText {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type ctxKey struct{}
+
+// WithContext 将日志放入ctx（请求日志中间件注入带request_id的日志，Service/Repo层通过FromContext取用）
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext 取出ctx中的日志，未注入时（后台任务、启动阶段）返回slog.Default()
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// With 在ctx中的日志上追加属性并放回ctx（如JWT校验通过后追加user_uuid）
+func With(ctx context.Context, args ...any) context.Context {
+	return WithContext(ctx, FromContext(ctx).With(args...))
+}
+
+// RedactQuery URL查询参数脱敏（敏感参数值替换为占位值）
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redacted
+	}
+	for key := range values {
+		if sensitiveKeys[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+		}
+	}
+	return values.Encode()
+}
+
+// MaskEmail 邮箱打码：a***@example.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		if email == "" {
+			return ""
+		}
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// redactAttr 日志属性脱敏（slog.HandlerOptions.ReplaceAttr）：敏感字段整体替换，邮箱字段打码
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	switch {
+	case sensitiveKeys[key]:
+		return slog.String(a.Key, redacted)
+	case emailKeys[key] && a.Value.Kind() == slog.KindString:
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	}
+	return a
+}
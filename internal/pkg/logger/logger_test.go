@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"CMS/internal/config"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactKeepsErrorCode(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewWithWriter(&buf, config.LogConfig{Level: "info", Format: config.LogFormatJSON})
+	l.Info("test", "code", 40001, "verify_code", "123456", "totp_code", "654321", "email", "alice@example.com")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("解析日志失败：%v", err)
+	}
+	if entry["code"] != float64(40001) {
+		t.Errorf("业务错误码不应脱敏，实际为%v", entry["code"])
+	}
+	for _, key := range []string{"verify_code", "totp_code"} {
+		if entry[key] != redacted {
+			t.Errorf("%s应脱敏，实际为%v", key, entry[key])
+		}
+	}
+	if entry["email"] != "a***@example.com" {
+		t.Errorf("邮箱应打码，实际为%v", entry["email"])
+	}
+}
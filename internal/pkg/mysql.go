@@ -1,15 +1,16 @@
 package pkg
 
 import (
+	"CMS/internal/config"
+	"CMS/internal/metrics"
 	"database/sql"
 	"errors"
-	"time"
 )
 
-// InitMySQL 初始化GORM的MySQL连接
-func InitMySQL(dsn string) (*sql.DB, error) {
+// InitMySQL 初始化MySQL连接（连接串与连接池参数来自config.database）
+func InitMySQL(cfg config.DatabaseConfig) (*sql.DB, error) {
 	// 打开数据库连接（不立即校验，需Ping）
-	db, err := sql.Open("mysql", dsn)
+	db, err := sql.Open("mysql", cfg.DSN)
 	if err != nil {
 		return nil, errors.New("连接MySQL失败：" + err.Error())
 	}
@@ -21,10 +22,13 @@ func InitMySQL(dsn string) (*sql.DB, error) {
 	}
 
 	// 设置连接池参数（优化性能）
-	db.SetMaxOpenConns(20)                  // 最大打开连接数
-	db.SetMaxIdleConns(10)                  // 最大空闲连接数
-	db.SetConnMaxLifetime(1)                // 连接最大存活时间
-	db.SetConnMaxIdleTime(30 * time.Minute) // 连接最大空闲时间
+	db.SetMaxOpenConns(cfg.MaxOpenConns)       // 最大打开连接数
+	db.SetMaxIdleConns(cfg.MaxIdleConns)       // 最大空闲连接数
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime) // 连接最大存活时间
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime) // 连接最大空闲时间
+
+	// 连接池状态（sql.DBStats）通过/metrics暴露
+	metrics.ObserveDB(db)
 
 	return db, nil
 }
@@ -0,0 +1,201 @@
+package totp
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238附录B的SHA1测试密钥"12345678901234567890"（Base32编码）
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// RFC给出8位动态码，6位动态码为其后6位
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tc := range cases {
+		step := Step(time.Unix(tc.unix, 0))
+		got, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatalf("T=%d：返回错误：%v", tc.unix, err)
+		}
+		if got != tc.want {
+			t.Errorf("T=%d：动态码=%s，期望%s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestCodeAcceptsLowercaseSecret(t *testing.T) {
+	got, err := Code(" gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", 1)
+	if err != nil || got != "287082" {
+		t.Fatalf("小写/带空格密钥：返回%q, %v，期望287082", got, err)
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("非Base32密钥应返回错误")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+	codeAt := func(step int64) string {
+		code, err := Code(rfcSecret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	cases := []struct {
+		name   string
+		offset int64
+		skew   int
+		ok     bool
+	}{
+		{"当前时间步", 0, 0, true},
+		{"上一时间步且skew=0", -1, 0, false},
+		{"上一时间步且skew=1", -1, 1, true},
+		{"下一时间步且skew=1", 1, 1, true},
+		{"超出skew", 2, 1, false},
+		{"skew=2覆盖前两步", -2, 2, true},
+	}
+	for _, tc := range cases {
+		step, ok := Validate(rfcSecret, codeAt(current+tc.offset), now, tc.skew, 0)
+		if ok != tc.ok {
+			t.Errorf("%s：校验结果=%v，期望%v", tc.name, ok, tc.ok)
+			continue
+		}
+		if ok && step != current+tc.offset {
+			t.Errorf("%s：匹配时间步=%d，期望%d", tc.name, step, current+tc.offset)
+		}
+	}
+
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := Validate(rfcSecret, code, now, 1, 0); ok {
+			t.Errorf("格式错误的动态码%q不应通过", code)
+		}
+	}
+}
+
+func TestValidateRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := Validate(rfcSecret, code, now, 1, 0)
+	if !ok {
+		t.Fatal("首次使用应校验通过")
+	}
+	// 记录lastStep后同一动态码（及更早时间步的动态码）不能再用
+	if _, ok := Validate(rfcSecret, code, now, 1, step); ok {
+		t.Error("已使用的时间步不应再次通过")
+	}
+	prev, err := Code(rfcSecret, step-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(rfcSecret, prev, now, 1, step); ok {
+		t.Error("早于lastStep的动态码不应通过")
+	}
+	// 下一个时间步的新动态码仍可使用
+	next, err := Code(rfcSecret, step+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := Validate(rfcSecret, next, now.Add(Period), 1, step); !ok || got != step+1 {
+		t.Errorf("下一时间步：返回%d, %v，期望%d, true", got, ok, step+1)
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	const key = "0123456789abcdef0123456789abcdef"
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := SealSecret(key, secret)
+	if err != nil {
+		t.Fatalf("加密返回错误：%v", err)
+	}
+	again, err := SealSecret(key, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed == again {
+		t.Error("每次加密应使用不同的随机数")
+	}
+	for _, s := range []string{sealed, again} {
+		plain, err := OpenSecret(key, s)
+		if err != nil || plain != secret {
+			t.Fatalf("解密返回%q, %v，期望%q", plain, err, secret)
+		}
+	}
+
+	if _, err := OpenSecret("another-key-another-key-another-key", sealed); err == nil {
+		t.Error("加密密钥变更后解密应失败")
+	}
+	if _, err := SealSecret("", secret); err == nil {
+		t.Error("未配置加密密钥时加密应失败")
+	}
+}
+
+func TestOpenRejectsTamperedCiphertext(t *testing.T) {
+	const key = "0123456789abcdef0123456789abcdef"
+	sealed, err := SealSecret(key, rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"非Base64": "!!" + sealed,
+		"长度不足随机数": base64.StdEncoding.EncodeToString(raw[:8]),
+	}
+	// 分别篡改随机数、密文、认证标签中的一个字节
+	for name, i := range map[string]int{"篡改随机数": 0, "篡改密文": 12, "篡改认证标签": len(raw) - 1} {
+		tampered := append([]byte(nil), raw...)
+		tampered[i] ^= 0x01
+		cases[name] = base64.StdEncoding.EncodeToString(tampered)
+	}
+	for name, s := range cases {
+		if plain, err := OpenSecret(key, s); err == nil {
+			t.Errorf("%s：解密应失败，实际得到%q", name, plain)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("恢复码%q格式应为xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("恢复码%q重复", code)
+		}
+		seen[code] = true
+	}
+	// 哈希忽略大小写、空格与连字符
+	if HashRecoveryCode("abcde-fghij") != HashRecoveryCode(" ABCDE FGHIJ ") {
+		t.Error("恢复码哈希应忽略大小写/空格/连字符")
+	}
+}
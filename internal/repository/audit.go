@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// AuditRepo 管理员审计日志Repo接口（admin_audit_logs表，只增不改）
+type AuditRepo interface {
+	// CreateAuditLog 写入审计日志（支持传入事务，与管理操作保持原子性：操作回滚则日志一并回滚）
+	CreateAuditLog(ctx context.Context, tx *sql.Tx, log *model.AdminAuditLog) error
+	// ListAuditLogs 分页查询审计日志（按时间倒序，过滤条件为空不过滤）
+	ListAuditLogs(ctx context.Context, filter AuditFilter, offset, limit int) ([]*model.AdminAuditLog, error)
+	// CountAuditLogs 统计审计日志条数（过滤条件与ListAuditLogs一致）
+	CountAuditLogs(ctx context.Context, filter AuditFilter) (int64, error)
+}
+
+// AuditFilter 审计日志查询条件
+type AuditFilter struct {
+	AdminUUID  string // 操作管理员UUID
+	Action     string // 操作类型
+	TargetType string // 操作对象类型
+	TargetID   string // 操作对象ID
+}
+
+// auditRepoImpl AuditRepo实现
+type auditRepoImpl struct {
+	db *sql.DB
+}
+
+// NewAuditRepo 创建AuditRepo实例
+func NewAuditRepo(db *sql.DB) AuditRepo {
+	return &auditRepoImpl{db: db}
+}
+
+// CreateAuditLog 写入审计日志
+func (r *auditRepoImpl) CreateAuditLog(ctx context.Context, tx *sql.Tx, log *model.AdminAuditLog) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	// detail为空时写NULL（JSON列不接受空字符串）
+	detail := sql.NullString{String: log.Detail, Valid: log.Detail != ""}
+	sqlStr := `
+	INSERT INTO admin_audit_logs (admin_uuid, admin_name, action, target_type, target_id, reason, detail, create_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := execFunc(ctx, sqlStr,
+		log.AdminUUID,
+		log.AdminName,
+		log.Action,
+		log.TargetType,
+		log.TargetID,
+		log.Reason,
+		detail,
+		log.CreateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1048:
+				return fmt.Errorf("审计日志必填字段为空：%s", mysqlErr.Message)
+			case 1406:
+				return fmt.Errorf("审计日志字段长度超过限制：%s", mysqlErr.Message)
+			}
+		}
+		return fmt.Errorf("写入审计日志失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取审计日志ID失败：%w", err)
+	}
+	log.ID = uint64(id)
+	return nil
+}
+
+// auditWhere 构建ListAuditLogs/CountAuditLogs共用的WHERE条件
+func auditWhere(filter AuditFilter) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if filter.AdminUUID != "" {
+		conds = append(conds, "admin_uuid = ?")
+		args = append(args, filter.AdminUUID)
+	}
+	if filter.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conds = append(conds, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conds = append(conds, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ListAuditLogs 分页查询审计日志
+func (r *auditRepoImpl) ListAuditLogs(ctx context.Context, filter AuditFilter, offset, limit int) ([]*model.AdminAuditLog, error) {
+	where, args := auditWhere(filter)
+	sqlStr := `
+	SELECT id, admin_uuid, admin_name, action, target_type, target_id, reason, detail, create_time
+	FROM admin_audit_logs` + where + `
+	ORDER BY create_time DESC, id DESC
+	LIMIT ?, ?
+	`
+	args = append(args, offset, limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return nil, fmt.Errorf("查询审计日志失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return nil, fmt.Errorf("查询审计日志失败：%w", err)
+	}
+	defer rows.Close()
+
+	var logs []*model.AdminAuditLog
+	for rows.Next() {
+		var (
+			l      model.AdminAuditLog
+			detail sql.NullString
+		)
+		if err := rows.Scan(
+			&l.ID,
+			&l.AdminUUID,
+			&l.AdminName,
+			&l.Action,
+			&l.TargetType,
+			&l.TargetID,
+			&l.Reason,
+			&detail,
+			&l.CreateTime,
+		); err != nil {
+			return nil, fmt.Errorf("扫描审计日志失败：%w", err)
+		}
+		l.Detail = detail.String
+		logs = append(logs, &l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历审计日志结果集失败：%w", err)
+	}
+	return logs, nil
+}
+
+// CountAuditLogs 统计审计日志条数
+func (r *auditRepoImpl) CountAuditLogs(ctx context.Context, filter AuditFilter) (int64, error) {
+	where, args := auditWhere(filter)
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM admin_audit_logs`+where, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计审计日志失败：%w", err)
+	}
+	return total, nil
+}
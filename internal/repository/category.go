@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CategoryRepo 资源分类Repo接口（categories表，parent_id构成树形结构）
+// 分类数量少且变动不频繁，层级展开、子孙分类查找均由Service层基于ListCategories的全量结果完成
+type CategoryRepo interface {
+	// ListCategories 查询全部分类（按sort_order、id升序）
+	ListCategories(ctx context.Context) ([]*model.Category, error)
+	// LockCategory 在事务内锁定分类行（SELECT ... FOR UPDATE），分类不存在返回nil, nil
+	LockCategory(ctx context.Context, tx *sql.Tx, id uint64) (*model.Category, error)
+	// CreateCategory 新增分类（支持传入事务，同一父分类下名称重复返回AlreadyExists）
+	CreateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) error
+	// CountUsage 统计分类的直接子分类数及直接归属的资源数（含被隐藏的资源，需在LockCategory的事务内调用）
+	CountUsage(ctx context.Context, tx *sql.Tx, id uint64) (children, resources int64, err error)
+	// DeleteCategory 删除分类记录（支持传入事务）
+	DeleteCategory(ctx context.Context, tx *sql.Tx, id uint64) error
+}
+
+// categoryRepoImpl CategoryRepo实现
+type categoryRepoImpl struct {
+	db *sql.DB
+}
+
+// NewCategoryRepo 创建CategoryRepo实例
+func NewCategoryRepo(db *sql.DB) CategoryRepo {
+	return &categoryRepoImpl{db: db}
+}
+
+// ListCategories 查询全部分类
+func (r *categoryRepoImpl) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, parent_id, name, sort_order, create_time
+		FROM categories
+		ORDER BY sort_order, id
+	`)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return nil, fmt.Errorf("查询分类列表失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return nil, fmt.Errorf("查询分类列表失败：%w", err)
+	}
+	defer rows.Close()
+
+	var categories []*model.Category
+	for rows.Next() {
+		var c model.Category
+		if err := rows.Scan(&c.ID, &c.ParentID, &c.Name, &c.SortOrder, &c.CreateTime); err != nil {
+			return nil, fmt.Errorf("扫描分类数据失败：%w", err)
+		}
+		categories = append(categories, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历分类结果集失败：%w", err)
+	}
+	return categories, nil
+}
+
+// LockCategory 锁定分类行（新增子分类与删除分类串行执行，避免删除后出现孤立的子分类）
+func (r *categoryRepoImpl) LockCategory(ctx context.Context, tx *sql.Tx, id uint64) (*model.Category, error) {
+	if tx == nil {
+		return nil, errors.New("锁定分类必须在事务内执行")
+	}
+
+	var c model.Category
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, parent_id, name, sort_order, create_time
+		FROM categories
+		WHERE id = ?
+		FOR UPDATE
+	`, id).Scan(&c.ID, &c.ParentID, &c.Name, &c.SortOrder, &c.CreateTime)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return nil, fmt.Errorf("锁定分类失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return nil, fmt.Errorf("锁定分类失败（id=%d）：%w", id, err)
+	}
+	return &c, nil
+}
+
+// CreateCategory 新增分类（uk_parent_name拦截同级重名）
+func (r *categoryRepoImpl) CreateCategory(ctx context.Context, tx *sql.Tx, category *model.Category) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	result, err := execFunc(ctx, `
+		INSERT INTO categories (parent_id, name, sort_order, create_time)
+		VALUES (?, ?, ?, ?)
+	`, category.ParentID, category.Name, category.SortOrder, category.CreateTime)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1062: // 唯一约束冲突（同一父分类下名称重复）
+				return apperr.AlreadyExists(fmt.Sprintf("同级分类中已存在「%s」", category.Name)).Wrap(mysqlErr)
+			case 1406: // 名称超过字段长度
+				return fmt.Errorf("分类名称长度超过限制：%s", mysqlErr.Message)
+			}
+			return fmt.Errorf("新增分类失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("新增分类失败：%w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取分类ID失败：%w", err)
+	}
+	category.ID = uint64(id)
+	return nil
+}
+
+// CountUsage 统计分类的直接子分类数及直接归属的资源数
+func (r *categoryRepoImpl) CountUsage(ctx context.Context, tx *sql.Tx, id uint64) (children, resources int64, err error) {
+	queryRowFunc := r.db.QueryRowContext
+	if tx != nil {
+		queryRowFunc = tx.QueryRowContext
+	}
+
+	if err := queryRowFunc(ctx, `SELECT COUNT(*) FROM categories WHERE parent_id = ?`, id).Scan(&children); err != nil {
+		return 0, 0, fmt.Errorf("统计子分类失败（id=%d）：%w", id, err)
+	}
+	if err := queryRowFunc(ctx, `SELECT COUNT(*) FROM resources WHERE category_id = ?`, id).Scan(&resources); err != nil {
+		return 0, 0, fmt.Errorf("统计分类下的资源失败（id=%d）：%w", id, err)
+	}
+	return children, resources, nil
+}
+
+// DeleteCategory 删除分类记录
+func (r *categoryRepoImpl) DeleteCategory(ctx context.Context, tx *sql.Tx, id uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	result, err := execFunc(ctx, `DELETE FROM categories WHERE id = ? LIMIT 1`, id)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("删除分类失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("删除分类失败（id=%d）：%w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取删除影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrCategoryNotFound.WithMsgf("分类不存在（id=%d）", id)
+	}
+	return nil
+}
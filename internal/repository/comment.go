@@ -0,0 +1,509 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CommentRepo 评论Repo接口（定义comments/comment_mentions表操作）
+type CommentRepo interface {
+	// CreateComment 新增评论（支持传入事务，与评论量更新保持原子性）
+	CreateComment(ctx context.Context, tx *sql.Tx, comment *model.Comment) error
+	// GetCommentByID 根据评论ID查询单条评论（不存在返回nil, nil；包含被隐藏的评论，由调用方按Hidden判断）
+	GetCommentByID(ctx context.Context, id uint64) (*model.Comment, error)
+	// ListByResourceID 分页查询资源下的全部评论（平铺，按评论时间倒序）
+	ListByResourceID(ctx context.Context, resourceID uint64, offset, limit int) ([]*model.Comment, error)
+	// CountByResourceID 统计资源下的评论总数
+	CountByResourceID(ctx context.Context, resourceID uint64) (int64, error)
+	// ListRootComments 分页查询资源下的顶层评论（楼层，按评论时间倒序）
+	ListRootComments(ctx context.Context, resourceID uint64, offset, limit int) ([]*model.Comment, error)
+	// CountRootComments 统计资源下的顶层评论总数
+	CountRootComments(ctx context.Context, resourceID uint64) (int64, error)
+	// ListByRootIDs 查询指定楼层下层级不超过maxDepth的全部回复（按评论时间正序）
+	// 以上列表/统计方法均不包含被管理员隐藏的评论
+	ListByRootIDs(ctx context.Context, rootIDs []uint64, maxDepth int) ([]*model.Comment, error)
+	// UpdateContent 编辑评论内容（支持传入事务，与提及记录更新保持原子性）
+	UpdateContent(ctx context.Context, tx *sql.Tx, id uint64, content string) error
+	// DeleteComments 批量删除评论（支持传入事务），返回实际删除条数
+	DeleteComments(ctx context.Context, tx *sql.Tx, ids []uint64) (int64, error)
+	// ListFloor 查询整个楼层的评论（顶层评论及其全部回复，包含被隐藏的评论），用于删除/隐藏时收集子树
+	ListFloor(ctx context.Context, rootID uint64) ([]*model.Comment, error)
+	// SetHidden 批量隐藏/取消隐藏评论（支持传入事务），返回状态实际变化的条数
+	SetHidden(ctx context.Context, tx *sql.Tx, ids []uint64, hidden bool) (int64, error)
+	// DeleteByResourceID 删除资源下的全部评论及@提及记录（必须传入事务，删除资源时使用）
+	DeleteByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) (int64, error)
+
+	// CreateMentions 批量写入评论的@提及记录（支持传入事务）
+	CreateMentions(ctx context.Context, tx *sql.Tx, commentID uint64, users []*model.User) error
+	// PruneMentions 删除评论中不在keepUserIDs内的@提及记录（编辑评论时使用，支持传入事务）
+	PruneMentions(ctx context.Context, tx *sql.Tx, commentID uint64, keepUserIDs []uint64) error
+	// DeleteMentions 删除指定评论的全部@提及记录（支持传入事务）
+	DeleteMentions(ctx context.Context, tx *sql.Tx, commentIDs []uint64) error
+	// ListMentions 分页查询@到指定用户的提及记录（联表带出评论内容）
+	ListMentions(ctx context.Context, userUUID string, offset, limit int) ([]*model.CommentMention, error)
+	// CountMentions 统计@到指定用户的提及数（unreadOnly=true时仅统计未读）
+	CountMentions(ctx context.Context, userUUID string, unreadOnly bool) (int64, error)
+	// MarkMentionsRead 将指定用户的提及全部标记为已读
+	MarkMentionsRead(ctx context.Context, userUUID string) error
+}
+
+// commentRepoImpl CommentRepo实现（复用db连接，与resourceRepoImpl结构一致）
+type commentRepoImpl struct {
+	db *sql.DB
+}
+
+// NewCommentRepo 创建CommentRepo实例
+func NewCommentRepo(db *sql.DB) CommentRepo {
+	return &commentRepoImpl{db: db}
+}
+
+// commentColumns comments表查询列（与scanComment扫描顺序一致）
+const commentColumns = `id, resource_id, parent_id, root_id, depth, user_id, user_uuid, username, content, create_time, update_time, hidden`
+
+// rowScanner 兼容*sql.Row与*sql.Rows的扫描接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanComment 按commentColumns顺序扫描一行评论
+func scanComment(row rowScanner, c *model.Comment) error {
+	return row.Scan(
+		&c.ID,
+		&c.ResourceID,
+		&c.ParentID,
+		&c.RootID,
+		&c.Depth,
+		&c.UserID,
+		&c.UserUUID,
+		&c.Username,
+		&c.Content,
+		&c.CreateTime,
+		&c.UpdateTime,
+		&c.Hidden,
+	)
+}
+
+// inPlaceholders 生成IN子句占位符及参数（如"?, ?, ?"）
+func inPlaceholders(ids []uint64) (string, []interface{}) {
+	marks := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		marks[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(marks, ", "), args
+}
+
+// CreateComment 新增评论（有tx用tx执行，无tx用db执行，和CreateResource逻辑一致）
+func (r *commentRepoImpl) CreateComment(ctx context.Context, tx *sql.Tx, comment *model.Comment) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+	INSERT INTO comments (resource_id, parent_id, root_id, depth, user_id, user_uuid, username, content, create_time, update_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := execFunc(ctx, sqlStr,
+		comment.ResourceID,
+		comment.ParentID,
+		comment.RootID,
+		comment.Depth,
+		comment.UserID,
+		comment.UserUUID,
+		comment.Username,
+		comment.Content,
+		comment.CreateTime,
+		comment.UpdateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1048: // 非空约束（resource_id/content为空）
+				return fmt.Errorf("评论必填字段为空：%s", mysqlErr.Message)
+			case 1452: // 外键约束失败（resource_id不存在）
+				return fmt.Errorf("评论关联的资源不存在：%s", mysqlErr.Message)
+			}
+		}
+		return fmt.Errorf("插入评论失败：%w", err)
+	}
+
+	// 回填自增ID，便于上层返回给前端
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取评论自增ID失败：%w", err)
+	}
+	comment.ID = uint64(id)
+	return nil
+}
+
+// GetCommentByID 根据ID查询单条评论
+func (r *commentRepoImpl) GetCommentByID(ctx context.Context, id uint64) (*model.Comment, error) {
+	sqlStr := `SELECT ` + commentColumns + ` FROM comments WHERE id = ? LIMIT 1`
+	var c model.Comment
+	if err := scanComment(r.db.QueryRowContext(ctx, sqlStr, id), &c); err != nil {
+		// 评论不存在：返回nil（无错误），由上层处理404
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询评论失败（id=%d）：%w", id, err)
+	}
+	return &c, nil
+}
+
+// queryComments 执行多行评论查询并扫描结果集
+func (r *commentRepoImpl) queryComments(ctx context.Context, sqlStr string, args ...interface{}) ([]*model.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return nil, fmt.Errorf("查询评论失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	defer rows.Close() // 必须关闭rows，避免资源泄漏
+
+	var comments []*model.Comment
+	for rows.Next() {
+		var c model.Comment
+		if err := scanComment(rows, &c); err != nil {
+			return nil, fmt.Errorf("扫描评论数据失败：%w", err)
+		}
+		comments = append(comments, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历评论结果集失败：%w", err)
+	}
+	return comments, nil
+}
+
+// ListByResourceID 分页查询资源下的全部评论
+func (r *commentRepoImpl) ListByResourceID(ctx context.Context, resourceID uint64, offset, limit int) ([]*model.Comment, error) {
+	sqlStr := `
+	SELECT ` + commentColumns + `
+	FROM comments
+	WHERE resource_id = ? AND hidden = 0
+	ORDER BY create_time DESC, id DESC
+	LIMIT ?, ?
+	`
+	return r.queryComments(ctx, sqlStr, resourceID, offset, limit)
+}
+
+// CountByResourceID 统计资源下的评论总数（与ListByResourceID过滤条件一致）
+func (r *commentRepoImpl) CountByResourceID(ctx context.Context, resourceID uint64) (int64, error) {
+	var total int64
+	sqlStr := `SELECT COUNT(*) FROM comments WHERE resource_id = ? AND hidden = 0`
+	if err := r.db.QueryRowContext(ctx, sqlStr, resourceID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计评论总数失败：%w", err)
+	}
+	return total, nil
+}
+
+// ListRootComments 分页查询资源下的顶层评论
+func (r *commentRepoImpl) ListRootComments(ctx context.Context, resourceID uint64, offset, limit int) ([]*model.Comment, error) {
+	sqlStr := `
+	SELECT ` + commentColumns + `
+	FROM comments
+	WHERE resource_id = ? AND parent_id = 0 AND hidden = 0
+	ORDER BY create_time DESC, id DESC
+	LIMIT ?, ?
+	`
+	return r.queryComments(ctx, sqlStr, resourceID, offset, limit)
+}
+
+// CountRootComments 统计资源下的顶层评论总数（与ListRootComments过滤条件一致）
+func (r *commentRepoImpl) CountRootComments(ctx context.Context, resourceID uint64) (int64, error) {
+	var total int64
+	sqlStr := `SELECT COUNT(*) FROM comments WHERE resource_id = ? AND parent_id = 0 AND hidden = 0`
+	if err := r.db.QueryRowContext(ctx, sqlStr, resourceID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计顶层评论总数失败：%w", err)
+	}
+	return total, nil
+}
+
+// ListByRootIDs 查询多个楼层下的回复（IN查询一次取回，避免逐条N+1查询）
+func (r *commentRepoImpl) ListByRootIDs(ctx context.Context, rootIDs []uint64, maxDepth int) ([]*model.Comment, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+	marks, args := inPlaceholders(rootIDs)
+	sqlStr := `
+	SELECT ` + commentColumns + `
+	FROM comments
+	WHERE root_id IN (` + marks + `) AND depth <= ? AND hidden = 0
+	ORDER BY create_time ASC, id ASC
+	`
+	args = append(args, maxDepth)
+	return r.queryComments(ctx, sqlStr, args...)
+}
+
+// UpdateContent 编辑评论内容（同时刷新update_time）
+func (r *commentRepoImpl) UpdateContent(ctx context.Context, tx *sql.Tx, id uint64, content string) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+		UPDATE comments
+		SET content = ?, update_time = CURRENT_TIMESTAMP
+		WHERE id = ?
+		LIMIT 1
+	`
+	result, err := execFunc(ctx, sqlStr, content, id)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("编辑评论失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("编辑评论失败（id=%d）：%w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取编辑影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrCommentNotFound
+	}
+	return nil
+}
+
+// DeleteComments 批量删除评论
+func (r *commentRepoImpl) DeleteComments(ctx context.Context, tx *sql.Tx, ids []uint64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	marks, args := inPlaceholders(ids)
+	result, err := execFunc(ctx, `DELETE FROM comments WHERE id IN (`+marks+`)`, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return 0, fmt.Errorf("删除评论失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return 0, fmt.Errorf("删除评论失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("获取删除影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, apperr.ErrCommentNotFound
+	}
+	return rowsAffected, nil
+}
+
+// ListFloor 查询整个楼层的评论（按评论时间正序）
+func (r *commentRepoImpl) ListFloor(ctx context.Context, rootID uint64) ([]*model.Comment, error) {
+	sqlStr := `
+	SELECT ` + commentColumns + `
+	FROM comments
+	WHERE id = ? OR root_id = ?
+	ORDER BY create_time ASC, id ASC
+	`
+	return r.queryComments(ctx, sqlStr, rootID, rootID)
+}
+
+// SetHidden 批量隐藏/取消隐藏评论（WHERE带上原状态，已处于目标状态的评论不计入返回条数）
+func (r *commentRepoImpl) SetHidden(ctx context.Context, tx *sql.Tx, ids []uint64, hidden bool) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	marks, idArgs := inPlaceholders(ids)
+	sqlStr := `UPDATE comments SET hidden = ? WHERE id IN (` + marks + `) AND hidden = ?`
+	args := append([]interface{}{hidden}, idArgs...)
+	args = append(args, !hidden)
+	result, err := execFunc(ctx, sqlStr, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return 0, fmt.Errorf("更新评论隐藏状态失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return 0, fmt.Errorf("更新评论隐藏状态失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("获取隐藏状态影响行数失败：%w", err)
+	}
+	return rowsAffected, nil
+}
+
+// DeleteByResourceID 删除资源下的全部评论（先删@提及再删评论，两条语句在同一事务内）
+func (r *commentRepoImpl) DeleteByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) (int64, error) {
+	if tx == nil {
+		return 0, errors.New("批量删除资源评论必须在事务内执行")
+	}
+
+	sqlStr := `
+	DELETE m FROM comment_mentions m
+	JOIN comments c ON c.id = m.comment_id
+	WHERE c.resource_id = ?
+	`
+	if _, err := tx.ExecContext(ctx, sqlStr, resourceID); err != nil {
+		return 0, fmt.Errorf("删除资源评论的@提及记录失败：%w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE resource_id = ?`, resourceID)
+	if err != nil {
+		return 0, fmt.Errorf("删除资源评论失败（resource_id=%d）：%w", resourceID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("获取删除影响行数失败：%w", err)
+	}
+	return rowsAffected, nil
+}
+
+// CreateMentions 批量写入@提及记录（同一评论对同一用户仅记录一次，由uk_comment_user保证）
+func (r *commentRepoImpl) CreateMentions(ctx context.Context, tx *sql.Tx, commentID uint64, users []*model.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	values := make([]string, 0, len(users))
+	args := make([]interface{}, 0, len(users)*3)
+	for _, u := range users {
+		values = append(values, "(?, ?, ?)")
+		args = append(args, commentID, u.ID, u.UUID)
+	}
+	sqlStr := `INSERT IGNORE INTO comment_mentions (comment_id, mentioned_user_id, mentioned_uuid) VALUES ` + strings.Join(values, ", ")
+	if _, err := execFunc(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("写入@提及记录失败：%w", err)
+	}
+	return nil
+}
+
+// PruneMentions 删除评论中不再被@的用户记录（keepUserIDs为空时删除该评论全部提及）
+func (r *commentRepoImpl) PruneMentions(ctx context.Context, tx *sql.Tx, commentID uint64, keepUserIDs []uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `DELETE FROM comment_mentions WHERE comment_id = ?`
+	args := []interface{}{commentID}
+	if len(keepUserIDs) > 0 {
+		marks, keepArgs := inPlaceholders(keepUserIDs)
+		sqlStr += ` AND mentioned_user_id NOT IN (` + marks + `)`
+		args = append(args, keepArgs...)
+	}
+	if _, err := execFunc(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("清理@提及记录失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteMentions 删除指定评论的@提及记录
+func (r *commentRepoImpl) DeleteMentions(ctx context.Context, tx *sql.Tx, commentIDs []uint64) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	marks, args := inPlaceholders(commentIDs)
+	if _, err := execFunc(ctx, `DELETE FROM comment_mentions WHERE comment_id IN (`+marks+`)`, args...); err != nil {
+		return fmt.Errorf("删除@提及记录失败：%w", err)
+	}
+	return nil
+}
+
+// ListMentions 分页查询@到指定用户的提及记录（按提及时间倒序）
+func (r *commentRepoImpl) ListMentions(ctx context.Context, userUUID string, offset, limit int) ([]*model.CommentMention, error) {
+	sqlStr := `
+	SELECT m.id, m.comment_id, m.mentioned_user_id, m.mentioned_uuid, m.is_read, m.create_time,
+		c.id, c.resource_id, c.parent_id, c.root_id, c.depth, c.user_id, c.user_uuid, c.username, c.content, c.create_time, c.update_time
+	FROM comment_mentions m
+	JOIN comments c ON c.id = m.comment_id
+	WHERE m.mentioned_uuid = ? AND c.hidden = 0
+	ORDER BY m.create_time DESC, m.id DESC
+	LIMIT ?, ?
+	`
+	rows, err := r.db.QueryContext(ctx, sqlStr, userUUID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询@提及记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	var mentions []*model.CommentMention
+	for rows.Next() {
+		var m model.CommentMention
+		c := &m.Comment
+		if err := rows.Scan(
+			&m.ID,
+			&m.CommentID,
+			&m.MentionedUserID,
+			&m.MentionedUUID,
+			&m.IsRead,
+			&m.CreateTime,
+			&c.ID,
+			&c.ResourceID,
+			&c.ParentID,
+			&c.RootID,
+			&c.Depth,
+			&c.UserID,
+			&c.UserUUID,
+			&c.Username,
+			&c.Content,
+			&c.CreateTime,
+			&c.UpdateTime,
+		); err != nil {
+			return nil, fmt.Errorf("扫描@提及记录失败：%w", err)
+		}
+		mentions = append(mentions, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历@提及结果集失败：%w", err)
+	}
+	return mentions, nil
+}
+
+// CountMentions 统计@到指定用户的提及数
+func (r *commentRepoImpl) CountMentions(ctx context.Context, userUUID string, unreadOnly bool) (int64, error) {
+	sqlStr := `
+	SELECT COUNT(*)
+	FROM comment_mentions m
+	JOIN comments c ON c.id = m.comment_id
+	WHERE m.mentioned_uuid = ? AND c.hidden = 0
+	`
+	if unreadOnly {
+		sqlStr += ` AND m.is_read = 0`
+	}
+	var total int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, userUUID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计@提及数失败：%w", err)
+	}
+	return total, nil
+}
+
+// MarkMentionsRead 将指定用户的提及全部标记为已读
+func (r *commentRepoImpl) MarkMentionsRead(ctx context.Context, userUUID string) error {
+	sqlStr := `UPDATE comment_mentions SET is_read = 1 WHERE mentioned_uuid = ? AND is_read = 0`
+	if _, err := r.db.ExecContext(ctx, sqlStr, userUUID); err != nil {
+		return fmt.Errorf("标记@提及已读失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// LikeRepo 点赞Repo接口（定义resource_likes表操作，(resource_id, user_id)唯一）
+type LikeRepo interface {
+	// AddLike 新增点赞记录（支持传入事务），已点赞过返回false
+	AddLike(ctx context.Context, tx *sql.Tx, resourceID, userID uint64) (bool, error)
+	// RemoveLike 删除点赞记录（支持传入事务），本就未点赞返回false
+	RemoveLike(ctx context.Context, tx *sql.Tx, resourceID, userID uint64) (bool, error)
+	// IsLiked 查询用户是否已点赞指定资源
+	IsLiked(ctx context.Context, resourceID, userID uint64) (bool, error)
+	// LikedResourceIDs 批量查询用户在给定资源中已点赞的资源ID（用于列表页标记）
+	LikedResourceIDs(ctx context.Context, userID uint64, resourceIDs []uint64) (map[uint64]bool, error)
+	// DeleteByResourceID 删除资源下的全部点赞记录（支持传入事务，删除资源时使用）
+	DeleteByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) error
+}
+
+// likeRepoImpl LikeRepo实现（复用db连接，与commentRepoImpl结构一致）
+type likeRepoImpl struct {
+	db *sql.DB
+}
+
+// NewLikeRepo 创建LikeRepo实例
+func NewLikeRepo(db *sql.DB) LikeRepo {
+	return &likeRepoImpl{db: db}
+}
+
+// AddLike 新增点赞记录（INSERT IGNORE：重复点赞由uk_resource_user唯一索引拦截，影响行数为0）
+func (r *likeRepoImpl) AddLike(ctx context.Context, tx *sql.Tx, resourceID, userID uint64) (bool, error) {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `INSERT IGNORE INTO resource_likes (resource_id, user_id) VALUES (?, ?)`
+	result, err := execFunc(ctx, sqlStr, resourceID, userID)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return false, fmt.Errorf("写入点赞记录失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return false, fmt.Errorf("写入点赞记录失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取点赞影响行数失败：%w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// RemoveLike 删除点赞记录（影响行数为0表示本就未点赞）
+func (r *likeRepoImpl) RemoveLike(ctx context.Context, tx *sql.Tx, resourceID, userID uint64) (bool, error) {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `DELETE FROM resource_likes WHERE resource_id = ? AND user_id = ?`
+	result, err := execFunc(ctx, sqlStr, resourceID, userID)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return false, fmt.Errorf("删除点赞记录失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return false, fmt.Errorf("删除点赞记录失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取取消点赞影响行数失败：%w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// IsLiked 查询用户是否已点赞指定资源
+func (r *likeRepoImpl) IsLiked(ctx context.Context, resourceID, userID uint64) (bool, error) {
+	sqlStr := `SELECT EXISTS(SELECT 1 FROM resource_likes WHERE resource_id = ? AND user_id = ?)`
+	var liked bool
+	if err := r.db.QueryRowContext(ctx, sqlStr, resourceID, userID).Scan(&liked); err != nil {
+		return false, fmt.Errorf("查询点赞状态失败：%w", err)
+	}
+	return liked, nil
+}
+
+// LikedResourceIDs 批量查询已点赞的资源ID（IN查询一次取回，避免列表页逐条查询）
+func (r *likeRepoImpl) LikedResourceIDs(ctx context.Context, userID uint64, resourceIDs []uint64) (map[uint64]bool, error) {
+	liked := make(map[uint64]bool)
+	if len(resourceIDs) == 0 {
+		return liked, nil
+	}
+
+	marks, args := inPlaceholders(resourceIDs)
+	sqlStr := `SELECT resource_id FROM resource_likes WHERE user_id = ? AND resource_id IN (` + marks + `)`
+	rows, err := r.db.QueryContext(ctx, sqlStr, append([]interface{}{userID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("批量查询点赞状态失败：%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("扫描点赞记录失败：%w", err)
+		}
+		liked[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历点赞记录失败：%w", err)
+	}
+	return liked, nil
+}
+
+// DeleteByResourceID 删除资源下的全部点赞记录
+func (r *likeRepoImpl) DeleteByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	if _, err := execFunc(ctx, `DELETE FROM resource_likes WHERE resource_id = ?`, resourceID); err != nil {
+		return fmt.Errorf("删除资源点赞记录失败（resource_id=%d）：%w", resourceID, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// LoginAttemptRepo 密码登录记录与失败锁定Repo接口（login_attempts登录记录表、login_lockouts失败计数表）
+type LoginAttemptRepo interface {
+	// CreateAttempt 写入一条登录记录
+	CreateAttempt(ctx context.Context, attempt *model.LoginAttempt) error
+	// ListAttempts 分页查询账号的登录记录（按时间倒序）
+	ListAttempts(ctx context.Context, userUUID string, offset, limit int) ([]*model.LoginAttempt, error)
+	// CountAttempts 统计账号的登录记录条数
+	CountAttempts(ctx context.Context, userUUID string) (int64, error)
+	// GetLockout 查询失败计数与锁定状态，不存在返回nil, nil
+	GetLockout(ctx context.Context, key string) (*model.LoginLockout, error)
+	// AddFailure 失败次数+1并返回累计次数：最近一次失败和锁定结束都早于now-window时从1重新计数
+	AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	// SetLockedUntil 设置锁定结束时间（只延长不缩短）
+	SetLockedUntil(ctx context.Context, key string, until time.Time) error
+	// DeleteLockout 清除失败计数与锁定（登录成功/重置密码后调用）
+	DeleteLockout(ctx context.Context, key string) error
+	// PurgeExpired 清理窗口外的失败计数及超过保留期的登录记录，返回清理条数
+	PurgeExpired(ctx context.Context, now time.Time, window, retention time.Duration) (int64, error)
+}
+
+// loginAttemptRepoImpl LoginAttemptRepo实现
+type loginAttemptRepoImpl struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepo 创建LoginAttemptRepo实例
+func NewLoginAttemptRepo(db *sql.DB) LoginAttemptRepo {
+	return &loginAttemptRepoImpl{db: db}
+}
+
+// CreateAttempt 写入登录记录（账号不存在时user_uuid写NULL）
+func (r *loginAttemptRepoImpl) CreateAttempt(ctx context.Context, attempt *model.LoginAttempt) error {
+	sqlStr := `
+	INSERT INTO login_attempts (user_uuid, identifier, ip, user_agent, result, create_time)
+	VALUES (NULLIF(?, ''), ?, ?, ?, ?, ?)
+	`
+	result, err := r.db.ExecContext(ctx, sqlStr,
+		attempt.UserUUID,
+		attempt.Identifier,
+		attempt.IP,
+		attempt.UserAgent,
+		attempt.Result,
+		attempt.CreateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1406 {
+			return fmt.Errorf("登录记录字段长度超过限制：%s", mysqlErr.Message)
+		}
+		return fmt.Errorf("写入登录记录失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取登录记录ID失败：%w", err)
+	}
+	attempt.ID = uint64(id)
+	return nil
+}
+
+// ListAttempts 分页查询登录记录
+func (r *loginAttemptRepoImpl) ListAttempts(ctx context.Context, userUUID string, offset, limit int) ([]*model.LoginAttempt, error) {
+	sqlStr := `
+	SELECT id, user_uuid, identifier, ip, user_agent, result, create_time
+	FROM login_attempts
+	WHERE user_uuid = ?
+	ORDER BY create_time DESC, id DESC
+	LIMIT ?, ?
+	`
+	rows, err := r.db.QueryContext(ctx, sqlStr, userUUID, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询登录记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*model.LoginAttempt
+	for rows.Next() {
+		var a model.LoginAttempt
+		if err := rows.Scan(&a.ID, &a.UserUUID, &a.Identifier, &a.IP, &a.UserAgent, &a.Result, &a.CreateTime); err != nil {
+			return nil, fmt.Errorf("扫描登录记录失败：%w", err)
+		}
+		attempts = append(attempts, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历登录记录结果集失败：%w", err)
+	}
+	return attempts, nil
+}
+
+// CountAttempts 统计登录记录条数
+func (r *loginAttemptRepoImpl) CountAttempts(ctx context.Context, userUUID string) (int64, error) {
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM login_attempts WHERE user_uuid = ?`, userUUID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计登录记录失败：%w", err)
+	}
+	return total, nil
+}
+
+// GetLockout 查询失败计数与锁定状态
+func (r *loginAttemptRepoImpl) GetLockout(ctx context.Context, key string) (*model.LoginLockout, error) {
+	var l model.LoginLockout
+	err := r.db.QueryRowContext(ctx, `SELECT lock_key, failures, last_failure, locked_until FROM login_lockouts WHERE lock_key = ?`, key).
+		Scan(&l.LockKey, &l.Failures, &l.LastFailure, &l.LockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询登录锁定状态失败：%w", err)
+	}
+	return &l, nil
+}
+
+// AddFailure 失败次数+1（插入或按窗口重置后累加，单条语句保证并发安全），再读取累计次数
+func (r *loginAttemptRepoImpl) AddFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	_, err := r.db.ExecContext(ctx, `
+	INSERT INTO login_lockouts (lock_key, failures, last_failure) VALUES (?, 1, ?)
+	ON DUPLICATE KEY UPDATE
+		failures = IF(GREATEST(last_failure, COALESCE(locked_until, last_failure)) <= ?, 1, failures + 1),
+		last_failure = VALUES(last_failure)
+	`, key, now, now.Add(-window))
+	if err != nil {
+		return 0, fmt.Errorf("记录登录失败次数失败：%w", err)
+	}
+
+	var failures int
+	if err := r.db.QueryRowContext(ctx, `SELECT failures FROM login_lockouts WHERE lock_key = ?`, key).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("查询登录失败次数失败：%w", err)
+	}
+	return failures, nil
+}
+
+// SetLockedUntil 设置锁定结束时间
+func (r *loginAttemptRepoImpl) SetLockedUntil(ctx context.Context, key string, until time.Time) error {
+	sqlStr := `UPDATE login_lockouts SET locked_until = GREATEST(COALESCE(locked_until, ?), ?) WHERE lock_key = ?`
+	if _, err := r.db.ExecContext(ctx, sqlStr, until, until, key); err != nil {
+		return fmt.Errorf("设置登录锁定失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteLockout 清除失败计数与锁定
+func (r *loginAttemptRepoImpl) DeleteLockout(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM login_lockouts WHERE lock_key = ?`, key); err != nil {
+		return fmt.Errorf("清除登录锁定失败：%w", err)
+	}
+	return nil
+}
+
+// PurgeExpired 清理窗口外且已解除锁定的失败计数，以及超过保留期的登录记录
+func (r *loginAttemptRepoImpl) PurgeExpired(ctx context.Context, now time.Time, window, retention time.Duration) (int64, error) {
+	var total int64
+	for _, q := range []struct {
+		sqlStr string
+		before time.Time
+	}{
+		{`DELETE FROM login_lockouts WHERE GREATEST(last_failure, COALESCE(locked_until, last_failure)) <= ?`, now.Add(-window)},
+		{`DELETE FROM login_attempts WHERE create_time < ?`, now.Add(-retention)},
+	} {
+		result, err := r.db.ExecContext(ctx, q.sqlStr, q.before)
+		if err != nil {
+			return total, fmt.Errorf("清理过期登录记录失败：%w", err)
+		}
+		n, _ := result.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
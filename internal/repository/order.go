@@ -6,7 +6,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
+	"CMS/internal/apperr"
 	"CMS/internal/model"
 
 	"github.com/go-sql-driver/mysql"
@@ -17,10 +19,25 @@ import (
 type AccountRepo interface {
 	// CreateAccount 创建设户账户（支持传入事务，保证原子性）
 	CreateAccount(ctx context.Context, tx *sql.Tx, userUUID string) error
+	// 以下余额变动方法均必须传入事务，并在同一事务内写入一条流水（entry提供类型/关联ID/幂等键/备注）
 	// RechargeBalance 账户充值
-	RechargeBalance(ctx context.Context, userUUID string, amount decimal.Decimal) error
-	// DeductBalance 账户扣减
-	DeductBalance(ctx context.Context, userUUID string, amount decimal.Decimal) error
+	RechargeBalance(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error
+	// DeductBalance 账户扣减（锁定账户行后校验余额，余额不足返回ErrInsufficientBalance；消费与购买资源共用）
+	DeductBalance(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error
+	// CreditBalance 事务内增加余额（资源售出收入，不计入累计充值）
+	CreditBalance(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error
+	// AdjustBalance 事务内手工调账（delta可正可负，调整后余额不能为负；不计入累计充值/消费）
+	AdjustBalance(ctx context.Context, tx *sql.Tx, userUUID string, delta decimal.Decimal, entry *model.AccountTransaction) error
+	// LockAccount 事务内锁定账户行（SELECT ... FOR UPDATE），返回当前余额；同一账户的余额变动由此串行化
+	LockAccount(ctx context.Context, tx *sql.Tx, userUUID string) (decimal.Decimal, error)
+	// GetTransactionByIdempotencyKey 按幂等键查询流水（不存在返回nil, nil），用于识别客户端重试请求
+	GetTransactionByIdempotencyKey(ctx context.Context, tx *sql.Tx, userUUID, key string) (*model.AccountTransaction, error)
+	// ListTransactions 分页查询用户流水（按时间倒序，txType为空查询全部类型）
+	ListTransactions(ctx context.Context, userUUID, txType string, offset, limit int) ([]*model.AccountTransaction, error)
+	// CountTransactions 统计用户流水条数（过滤条件与ListTransactions一致）
+	CountTransactions(ctx context.Context, userUUID, txType string) (int64, error)
+	// GetDB 返回数据库连接（供Service层开启事务）
+	GetDB() *sql.DB
 	// GetAccountByUserUUID 根据用户UUID查询账户
 	GetAccountByUserUUID(ctx context.Context, userUUID string) (*model.UserAccount, error)
 }
@@ -35,6 +52,11 @@ func NewAccountRepo(db *sql.DB) AccountRepo {
 	return &accountRepoImpl{db: db}
 }
 
+// GetDB 返回数据库连接（与userRepoImpl.GetDB一致）
+func (r *accountRepoImpl) GetDB() *sql.DB {
+	return r.db
+}
+
 // CreateAccount 创建设户账户（核心：支持传入外部事务）
 func (r *accountRepoImpl) CreateAccount(ctx context.Context, tx *sql.Tx, userUUID string) error {
 	// 适配外部事务：有tx则用tx执行，无则用db执行（兼容单独创建账户场景）
@@ -61,10 +83,13 @@ func (r *accountRepoImpl) CreateAccount(ctx context.Context, tx *sql.Tx, userUUI
 	return nil
 }
 
-// RechargeBalance 充值（复用原有逻辑）
-func (r *accountRepoImpl) RechargeBalance(ctx context.Context, userUUID string, amount decimal.Decimal) error {
+// RechargeBalance 充值（复用原有逻辑，新增：同一事务内写入充值流水）
+func (r *accountRepoImpl) RechargeBalance(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error {
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("充值金额必须大于0")
+		return apperr.InvalidArgument("充值金额必须大于0")
+	}
+	if tx == nil {
+		return errors.New("余额变动必须在事务内执行")
 	}
 
 	sqlStr := `
@@ -72,7 +97,7 @@ func (r *accountRepoImpl) RechargeBalance(ctx context.Context, userUUID string,
 	SET balance = balance + ?, total_recharge = total_recharge + ?
 	WHERE user_uuid = ?
 	`
-	result, err := r.db.ExecContext(ctx, sqlStr,
+	result, err := tx.ExecContext(ctx, sqlStr,
 		amount.String(),
 		amount.String(),
 		userUUID,
@@ -86,34 +111,27 @@ func (r *accountRepoImpl) RechargeBalance(ctx context.Context, userUUID string,
 		return fmt.Errorf("获取充值影响行数失败：%w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("用户账户不存在或更新失败")
+		return apperr.ErrAccountNotFound.WithMsg("用户账户不存在或更新失败")
 	}
-	return nil
+	return r.appendTransaction(ctx, tx, userUUID, amount, entry)
 }
 
-// DeductBalance 扣减余额（复用原有逻辑）
-func (r *accountRepoImpl) DeductBalance(ctx context.Context, userUUID string, amount decimal.Decimal) error {
+// DeductBalance 扣减余额（行锁保证余额校验与扣减原子执行，同一事务内写入扣减流水）
+func (r *accountRepoImpl) DeductBalance(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error {
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("消费金额必须大于0")
+		return apperr.InvalidArgument("消费金额必须大于0")
 	}
-
-	// 查询余额
-	var balanceStr string
-	querySql := `SELECT balance FROM user_account WHERE user_uuid = ?`
-	err := r.db.QueryRowContext(ctx, querySql, userUUID).Scan(&balanceStr)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("用户账户不存在")
-		}
-		return fmt.Errorf("查询余额失败：%w", err)
+	if tx == nil {
+		return errors.New("余额变动必须在事务内执行")
 	}
 
-	balance, err := decimal.NewFromString(balanceStr)
+	// 锁定账户行并读取余额（FOR UPDATE：并发扣减在此排队，校验与扣减之间余额不会被其他事务修改）
+	balance, err := r.LockAccount(ctx, tx, userUUID)
 	if err != nil {
-		return fmt.Errorf("解析余额失败：%w", err)
+		return err
 	}
 	if balance.LessThan(amount) {
-		return fmt.Errorf("账户余额不足（当前：%s，需扣减：%s）", balance.String(), amount.String())
+		return apperr.ErrInsufficientBalance.WithMsgf("账户余额不足（当前：%s，需扣减：%s）", balance.String(), amount.String())
 	}
 
 	// 扣减
@@ -122,7 +140,7 @@ func (r *accountRepoImpl) DeductBalance(ctx context.Context, userUUID string, am
 	SET balance = balance - ?, total_consume = total_consume + ?
 	WHERE user_uuid = ?
 	`
-	result, err := r.db.ExecContext(ctx, updateSql,
+	result, err := tx.ExecContext(ctx, updateSql,
 		amount.String(),
 		amount.String(),
 		userUUID,
@@ -138,9 +156,219 @@ func (r *accountRepoImpl) DeductBalance(ctx context.Context, userUUID string, am
 	if rowsAffected == 0 {
 		return fmt.Errorf("余额扣减失败（账户状态异常）")
 	}
+	return r.appendTransaction(ctx, tx, userUUID, amount.Neg(), entry)
+}
+
+// CreditBalance 事务内增加余额
+func (r *accountRepoImpl) CreditBalance(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return apperr.InvalidArgument("入账金额必须大于0")
+	}
+	if tx == nil {
+		return errors.New("余额变动必须在事务内执行")
+	}
+
+	sqlStr := `UPDATE user_account SET balance = balance + ? WHERE user_uuid = ?`
+	result, err := tx.ExecContext(ctx, sqlStr, amount.String(), userUUID)
+	if err != nil {
+		return fmt.Errorf("入账失败：%w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取入账影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrAccountNotFound.WithMsg("收款账户不存在")
+	}
+	return r.appendTransaction(ctx, tx, userUUID, amount, entry)
+}
+
+// AdjustBalance 事务内手工调账（余额校验与调整在同一条UPDATE中完成）
+func (r *accountRepoImpl) AdjustBalance(ctx context.Context, tx *sql.Tx, userUUID string, delta decimal.Decimal, entry *model.AccountTransaction) error {
+	if delta.IsZero() {
+		return apperr.InvalidArgument("调账金额不能为0")
+	}
+	if tx == nil {
+		return errors.New("余额变动必须在事务内执行")
+	}
+
+	sqlStr := `UPDATE user_account SET balance = balance + ? WHERE user_uuid = ? AND balance + ? >= 0`
+	result, err := tx.ExecContext(ctx, sqlStr, delta.String(), userUUID, delta.String())
+	if err != nil {
+		return fmt.Errorf("调账失败：%w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取调账影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrInsufficientBalance.WithMsgf("账户余额不足或账户不存在（调账金额：%s）", delta.String())
+	}
+	return r.appendTransaction(ctx, tx, userUUID, delta, entry)
+}
+
+// LockAccount 锁定账户行并返回当前余额
+func (r *accountRepoImpl) LockAccount(ctx context.Context, tx *sql.Tx, userUUID string) (decimal.Decimal, error) {
+	if tx == nil {
+		return decimal.Zero, errors.New("锁定账户必须在事务内执行")
+	}
+
+	var balance decimal.Decimal
+	err := tx.QueryRowContext(ctx, `SELECT balance FROM user_account WHERE user_uuid = ? FOR UPDATE`, userUUID).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, apperr.ErrAccountNotFound.WithMsg("用户账户不存在")
+		}
+		return decimal.Zero, fmt.Errorf("查询余额失败：%w", err)
+	}
+	return balance, nil
+}
+
+// GetTransactionByIdempotencyKey 按(user_uuid, idempotency_key)查询流水（有tx用tx查询，可读到本事务内的写入）
+func (r *accountRepoImpl) GetTransactionByIdempotencyKey(ctx context.Context, tx *sql.Tx, userUUID, key string) (*model.AccountTransaction, error) {
+	queryRowFunc := r.db.QueryRowContext
+	if tx != nil {
+		queryRowFunc = tx.QueryRowContext
+	}
+
+	sqlStr := `
+	SELECT id, user_uuid, type, amount, balance_after, reference_id, idempotency_key, remark, create_time
+	FROM account_transactions
+	WHERE user_uuid = ? AND idempotency_key = ?
+	LIMIT 1
+	`
+	var t model.AccountTransaction
+	err := queryRowFunc(ctx, sqlStr, userUUID, key).Scan(
+		&t.ID,
+		&t.UserUUID,
+		&t.Type,
+		&t.Amount,
+		&t.BalanceAfter,
+		&t.ReferenceID,
+		&t.IdempotencyKey,
+		&t.Remark,
+		&t.CreateTime,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("按幂等键查询流水失败：%w", err)
+	}
+	return &t, nil
+}
+
+// appendTransaction 写入一条账户流水（在余额UPDATE之后调用，读取同一事务内的最新余额作为balance_after）
+func (r *accountRepoImpl) appendTransaction(ctx context.Context, tx *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error {
+	if entry == nil || entry.Type == "" {
+		return errors.New("账户流水类型不能为空")
+	}
+
+	var balanceAfter decimal.Decimal
+	if err := tx.QueryRowContext(ctx, `SELECT balance FROM user_account WHERE user_uuid = ?`, userUUID).Scan(&balanceAfter); err != nil {
+		return fmt.Errorf("查询变动后余额失败：%w", err)
+	}
+
+	entry.UserUUID = userUUID
+	entry.Amount = amount
+	entry.BalanceAfter = balanceAfter
+	entry.CreateTime = time.Now()
+
+	// 幂等键为空时写NULL（唯一索引允许多个NULL）
+	idempotencyKey := sql.NullString{String: entry.IdempotencyKey, Valid: entry.IdempotencyKey != ""}
+	sqlStr := `
+	INSERT INTO account_transactions (user_uuid, type, amount, balance_after, reference_id, idempotency_key, remark, create_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := tx.ExecContext(ctx, sqlStr,
+		entry.UserUUID,
+		entry.Type,
+		entry.Amount.String(),
+		entry.BalanceAfter.String(),
+		entry.ReferenceID,
+		idempotencyKey,
+		entry.Remark,
+		entry.CreateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 { // uk_user_idempotency唯一索引冲突
+			return apperr.ErrIdempotencyConflict.WithMsgf("重复的幂等键：%s", entry.IdempotencyKey)
+		}
+		return fmt.Errorf("写入账户流水失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取流水ID失败：%w", err)
+	}
+	entry.ID = uint64(id)
 	return nil
 }
 
+// ListTransactions 分页查询用户流水
+func (r *accountRepoImpl) ListTransactions(ctx context.Context, userUUID, txType string, offset, limit int) ([]*model.AccountTransaction, error) {
+	sqlStr := `
+	SELECT id, user_uuid, type, amount, balance_after, reference_id, IFNULL(idempotency_key, ''), remark, create_time
+	FROM account_transactions
+	WHERE user_uuid = ?
+	`
+	args := []interface{}{userUUID}
+	if txType != "" {
+		sqlStr += ` AND type = ?`
+		args = append(args, txType)
+	}
+	sqlStr += ` ORDER BY id DESC LIMIT ?, ?`
+	args = append(args, offset, limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询账户流水失败：%w", err)
+	}
+	defer rows.Close()
+
+	var list []*model.AccountTransaction
+	for rows.Next() {
+		var t model.AccountTransaction
+		if err := rows.Scan(
+			&t.ID,
+			&t.UserUUID,
+			&t.Type,
+			&t.Amount,
+			&t.BalanceAfter,
+			&t.ReferenceID,
+			&t.IdempotencyKey,
+			&t.Remark,
+			&t.CreateTime,
+		); err != nil {
+			return nil, fmt.Errorf("扫描账户流水失败：%w", err)
+		}
+		list = append(list, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历账户流水失败：%w", err)
+	}
+	return list, nil
+}
+
+// CountTransactions 统计用户流水条数
+func (r *accountRepoImpl) CountTransactions(ctx context.Context, userUUID, txType string) (int64, error) {
+	sqlStr := `SELECT COUNT(*) FROM account_transactions WHERE user_uuid = ?`
+	args := []interface{}{userUUID}
+	if txType != "" {
+		sqlStr += ` AND type = ?`
+		args = append(args, txType)
+	}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, sqlStr, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计账户流水失败：%w", err)
+	}
+	return total, nil
+}
+
 // GetAccountByUserUUID 查询账户
 func (r *accountRepoImpl) GetAccountByUserUUID(ctx context.Context, userUUID string) (*model.UserAccount, error) {
 	var account model.UserAccount
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/migrate"
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// openTestDB 连接CMS_TEST_MYSQL_DSN指定的测试库并执行全部迁移，未配置时跳过
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("CMS_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("未设置CMS_TEST_MYSQL_DSN，跳过数据库测试")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("连接测试库失败：%v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("初始化迁移失败：%v", err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("执行迁移失败：%v", err)
+	}
+	return db
+}
+
+// TestDeductBalanceConcurrent 并发扣减同一账户：成功次数不超过余额可承受的次数，余额不为负，流水条数与成功次数一致
+func TestDeductBalanceConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewAccountRepo(db)
+	userUUID := uuid.NewString()
+
+	const (
+		workers    = 50
+		affordable = 20 // 初始余额可支撑的扣减次数
+	)
+	amount := decimal.NewFromInt(3)
+	initial := amount.Mul(decimal.NewFromInt(affordable))
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("开启事务失败：%v", err)
+	}
+	if err := repo.CreateAccount(ctx, tx, userUUID); err != nil {
+		_ = tx.Rollback()
+		t.Fatalf("创建账户失败：%v", err)
+	}
+	if err := repo.RechargeBalance(ctx, tx, userUUID, initial, &model.AccountTransaction{Type: model.TxTypeRecharge}); err != nil {
+		_ = tx.Rollback()
+		t.Fatalf("充值失败：%v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("提交事务失败：%v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int64
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Errorf("开启事务失败：%v", err)
+				return
+			}
+			defer func() { _ = tx.Rollback() }()
+			err = repo.DeductBalance(ctx, tx, userUUID, amount, &model.AccountTransaction{Type: model.TxTypeDeduct})
+			if err != nil {
+				if !errors.Is(err, apperr.ErrInsufficientBalance) {
+					t.Errorf("扣减返回非预期错误：%v", err)
+				}
+				return
+			}
+			if err := tx.Commit(); err != nil {
+				t.Errorf("提交事务失败：%v", err)
+				return
+			}
+			succeeded.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	if got := succeeded.Load(); got != affordable {
+		t.Errorf("成功扣减%d次，期望%d次", got, affordable)
+	}
+	account, err := repo.GetAccountByUserUUID(ctx, userUUID)
+	if err != nil {
+		t.Fatalf("查询账户失败：%v", err)
+	}
+	if account.Balance.IsNegative() {
+		t.Errorf("余额为负：%s", account.Balance)
+	}
+	want := initial.Sub(amount.Mul(decimal.NewFromInt(succeeded.Load())))
+	if !account.Balance.Equal(want) {
+		t.Errorf("余额=%s，期望%s", account.Balance, want)
+	}
+
+	var ledger int64
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM account_transactions WHERE user_uuid = ? AND type = ?`, userUUID, model.TxTypeDeduct).Scan(&ledger)
+	if err != nil {
+		t.Fatalf("统计流水失败：%v", err)
+	}
+	if ledger != succeeded.Load() {
+		t.Errorf("扣减流水%d条，成功扣减%d次", ledger, succeeded.Load())
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// PurchaseRepo 资源购买Repo接口（定义resource_purchases表操作，(resource_id, buyer_id)唯一）
+type PurchaseRepo interface {
+	// CreatePurchase 写入购买记录（支持传入事务，与扣款/入账保持原子性）
+	CreatePurchase(ctx context.Context, tx *sql.Tx, purchase *model.ResourcePurchase) error
+	// HasPurchased 查询用户是否已购买指定资源
+	HasPurchased(ctx context.Context, resourceID, buyerID uint64) (bool, error)
+	// PurchasedResourceIDs 批量查询用户在给定资源中已购买的资源ID（用于列表页判断代码可见性）
+	PurchasedResourceIDs(ctx context.Context, buyerID uint64, resourceIDs []uint64) (map[uint64]bool, error)
+	// CountByResourceID 统计资源的购买记录数（有tx用tx查询）
+	CountByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) (int64, error)
+}
+
+// purchaseRepoImpl PurchaseRepo实现（复用db连接，与likeRepoImpl结构一致）
+type purchaseRepoImpl struct {
+	db *sql.DB
+}
+
+// NewPurchaseRepo 创建PurchaseRepo实例
+func NewPurchaseRepo(db *sql.DB) PurchaseRepo {
+	return &purchaseRepoImpl{db: db}
+}
+
+// CreatePurchase 写入购买记录（uk_resource_buyer唯一索引兜底，并发重复购买时第二笔事务在此失败回滚）
+func (r *purchaseRepoImpl) CreatePurchase(ctx context.Context, tx *sql.Tx, purchase *model.ResourcePurchase) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+	INSERT INTO resource_purchases (resource_id, buyer_id, buyer_uuid, author_id, price, create_time)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := execFunc(ctx, sqlStr,
+		purchase.ResourceID,
+		purchase.BuyerID,
+		purchase.BuyerUUID,
+		purchase.AuthorID,
+		purchase.Price.String(),
+		purchase.CreateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1062: // uk_resource_buyer唯一索引冲突
+				return apperr.ErrAlreadyPurchased
+			case 1048:
+				return fmt.Errorf("购买记录必填字段为空：%s", mysqlErr.Message)
+			}
+		}
+		return fmt.Errorf("写入购买记录失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取购买记录ID失败：%w", err)
+	}
+	purchase.ID = uint64(id)
+	return nil
+}
+
+// HasPurchased 查询用户是否已购买指定资源
+func (r *purchaseRepoImpl) HasPurchased(ctx context.Context, resourceID, buyerID uint64) (bool, error) {
+	sqlStr := `SELECT EXISTS(SELECT 1 FROM resource_purchases WHERE resource_id = ? AND buyer_id = ?)`
+	var purchased bool
+	if err := r.db.QueryRowContext(ctx, sqlStr, resourceID, buyerID).Scan(&purchased); err != nil {
+		return false, fmt.Errorf("查询购买状态失败：%w", err)
+	}
+	return purchased, nil
+}
+
+// PurchasedResourceIDs 批量查询已购买的资源ID（IN查询一次取回）
+func (r *purchaseRepoImpl) PurchasedResourceIDs(ctx context.Context, buyerID uint64, resourceIDs []uint64) (map[uint64]bool, error) {
+	purchased := make(map[uint64]bool)
+	if len(resourceIDs) == 0 {
+		return purchased, nil
+	}
+
+	marks, args := inPlaceholders(resourceIDs)
+	sqlStr := `SELECT resource_id FROM resource_purchases WHERE buyer_id = ? AND resource_id IN (` + marks + `)`
+	rows, err := r.db.QueryContext(ctx, sqlStr, append([]interface{}{buyerID}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("批量查询购买状态失败：%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("扫描购买记录失败：%w", err)
+		}
+		purchased[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历购买记录失败：%w", err)
+	}
+	return purchased, nil
+}
+
+// CountByResourceID 统计资源的购买记录数（已售出的资源不允许直接删除）
+func (r *purchaseRepoImpl) CountByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) (int64, error) {
+	queryRowFunc := r.db.QueryRowContext
+	if tx != nil {
+		queryRowFunc = tx.QueryRowContext
+	}
+	var total int64
+	if err := queryRowFunc(ctx, `SELECT COUNT(*) FROM resource_purchases WHERE resource_id = ?`, resourceID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计资源购买记录失败（resource_id=%d）：%w", resourceID, err)
+	}
+	return total, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// RechargeOrderRepo 充值单Repo接口（定义recharge_orders表操作，order_no唯一，(user_uuid, idempotency_key)唯一）
+type RechargeOrderRepo interface {
+	// CreateOrder 创建待支付充值单
+	CreateOrder(ctx context.Context, order *model.RechargeOrder) error
+	// GetOrderByNo 按充值单号查询（不存在返回nil, nil）
+	GetOrderByNo(ctx context.Context, orderNo string) (*model.RechargeOrder, error)
+	// GetOrderByIdempotencyKey 按幂等键查询用户充值单（不存在返回nil, nil）
+	GetOrderByIdempotencyKey(ctx context.Context, userUUID, key string) (*model.RechargeOrder, error)
+	// LockOrder 事务内锁定充值单（SELECT ... FOR UPDATE，不存在返回nil, nil），同一充值单的重复回调由此串行化
+	LockOrder(ctx context.Context, tx *sql.Tx, orderNo string) (*model.RechargeOrder, error)
+	// MarkPaid 待支付→已支付（仅pending状态可更新）
+	MarkPaid(ctx context.Context, tx *sql.Tx, orderNo, tradeNo string, paidTime time.Time) error
+	// MarkFailed 待支付→支付失败（仅pending状态可更新）
+	MarkFailed(ctx context.Context, tx *sql.Tx, orderNo, tradeNo string) error
+	// GetDB 返回数据库连接（供Service层开启事务）
+	GetDB() *sql.DB
+}
+
+// rechargeOrderRepoImpl RechargeOrderRepo实现
+type rechargeOrderRepoImpl struct {
+	db *sql.DB
+}
+
+// NewRechargeOrderRepo 创建RechargeOrderRepo实例
+func NewRechargeOrderRepo(db *sql.DB) RechargeOrderRepo {
+	return &rechargeOrderRepoImpl{db: db}
+}
+
+// GetDB 返回数据库连接
+func (r *rechargeOrderRepoImpl) GetDB() *sql.DB {
+	return r.db
+}
+
+// rechargeOrderColumns 充值单查询字段（与scanRechargeOrder顺序一致）
+const rechargeOrderColumns = `id, order_no, user_uuid, amount, provider, status, provider_trade_no, idempotency_key, create_time, paid_time`
+
+// CreateOrder 创建待支付充值单（幂等键为空时写NULL，唯一索引允许多个NULL）
+func (r *rechargeOrderRepoImpl) CreateOrder(ctx context.Context, order *model.RechargeOrder) error {
+	sqlStr := `
+	INSERT INTO recharge_orders (order_no, user_uuid, amount, provider, status, idempotency_key, create_time)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	idempotencyKey := sql.NullString{String: order.IdempotencyKey, Valid: order.IdempotencyKey != ""}
+	result, err := r.db.ExecContext(ctx, sqlStr,
+		order.OrderNo,
+		order.UserUUID,
+		order.Amount.String(),
+		order.Provider,
+		order.Status,
+		idempotencyKey,
+		order.CreateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1062: // uk_order_no / uk_user_idempotency唯一索引冲突
+				return apperr.ErrIdempotencyConflict.WithMsg("重复的充值单").Wrap(mysqlErr)
+			case 1048:
+				return fmt.Errorf("充值单必填字段为空：%s", mysqlErr.Message)
+			}
+		}
+		return fmt.Errorf("创建充值单失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取充值单ID失败：%w", err)
+	}
+	order.ID = uint64(id)
+	return nil
+}
+
+// GetOrderByNo 按充值单号查询
+func (r *rechargeOrderRepoImpl) GetOrderByNo(ctx context.Context, orderNo string) (*model.RechargeOrder, error) {
+	sqlStr := `SELECT ` + rechargeOrderColumns + ` FROM recharge_orders WHERE order_no = ?`
+	return scanRechargeOrder(r.db.QueryRowContext(ctx, sqlStr, orderNo))
+}
+
+// GetOrderByIdempotencyKey 按(user_uuid, idempotency_key)查询充值单
+func (r *rechargeOrderRepoImpl) GetOrderByIdempotencyKey(ctx context.Context, userUUID, key string) (*model.RechargeOrder, error) {
+	sqlStr := `SELECT ` + rechargeOrderColumns + ` FROM recharge_orders WHERE user_uuid = ? AND idempotency_key = ?`
+	return scanRechargeOrder(r.db.QueryRowContext(ctx, sqlStr, userUUID, key))
+}
+
+// LockOrder 锁定充值单行
+func (r *rechargeOrderRepoImpl) LockOrder(ctx context.Context, tx *sql.Tx, orderNo string) (*model.RechargeOrder, error) {
+	if tx == nil {
+		return nil, errors.New("锁定充值单必须在事务内执行")
+	}
+	sqlStr := `SELECT ` + rechargeOrderColumns + ` FROM recharge_orders WHERE order_no = ? FOR UPDATE`
+	return scanRechargeOrder(tx.QueryRowContext(ctx, sqlStr, orderNo))
+}
+
+// MarkPaid 标记充值单已支付（WHERE status='pending'：已处理过的充值单不会被重复更新）
+func (r *rechargeOrderRepoImpl) MarkPaid(ctx context.Context, tx *sql.Tx, orderNo, tradeNo string, paidTime time.Time) error {
+	sqlStr := `
+	UPDATE recharge_orders
+	SET status = ?, provider_trade_no = ?, paid_time = ?
+	WHERE order_no = ? AND status = ?
+	`
+	return r.updateStatus(ctx, tx, sqlStr, model.RechargeStatusPaid, tradeNo, paidTime, orderNo, model.RechargeStatusPending)
+}
+
+// MarkFailed 标记充值单支付失败
+func (r *rechargeOrderRepoImpl) MarkFailed(ctx context.Context, tx *sql.Tx, orderNo, tradeNo string) error {
+	sqlStr := `
+	UPDATE recharge_orders
+	SET status = ?, provider_trade_no = ?
+	WHERE order_no = ? AND status = ?
+	`
+	return r.updateStatus(ctx, tx, sqlStr, model.RechargeStatusFailed, tradeNo, orderNo, model.RechargeStatusPending)
+}
+
+// updateStatus 执行状态流转UPDATE（有tx用tx执行），影响行数为0说明充值单不存在或已不是待支付状态
+func (r *rechargeOrderRepoImpl) updateStatus(ctx context.Context, tx *sql.Tx, sqlStr string, args ...interface{}) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	result, err := execFunc(ctx, sqlStr, args...)
+	if err != nil {
+		return fmt.Errorf("更新充值单状态失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取充值单更新影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.FailedPrecondition("充值单不存在或已处理")
+	}
+	return nil
+}
+
+// scanRechargeOrder 扫描单行充值单（不存在返回nil, nil）
+func scanRechargeOrder(row *sql.Row) (*model.RechargeOrder, error) {
+	var (
+		o              model.RechargeOrder
+		tradeNo        sql.NullString
+		idempotencyKey sql.NullString
+		paidTime       sql.NullTime
+	)
+	err := row.Scan(
+		&o.ID,
+		&o.OrderNo,
+		&o.UserUUID,
+		&o.Amount,
+		&o.Provider,
+		&o.Status,
+		&tradeNo,
+		&idempotencyKey,
+		&o.CreateTime,
+		&paidTime,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询充值单失败：%w", err)
+	}
+	o.ProviderTradeNo = tradeNo.String
+	o.IdempotencyKey = idempotencyKey.String
+	if paidTime.Valid {
+		o.PaidTime = &paidTime.Time
+	}
+	return &o, nil
+}
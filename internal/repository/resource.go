@@ -1,14 +1,18 @@
 package repository
 
 import (
+	"CMS/internal/apperr"
 	"CMS/internal/model"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/shopspring/decimal"
 )
 
 // 扩展ResourceRepo接口，新增分页查询+总数统计方法（与原有方法风格一致）
@@ -17,16 +21,194 @@ type ResourceRepo interface {
 	CreateResource(ctx context.Context, tx *sql.Tx, resource *model.Resource) error
 	// GetByUserID 根据用户ID查询资源列表
 	GetByUserID(ctx context.Context, userID uint64) ([]*model.Resource, error)
-	// GetResourceList 分页查询资源列表（支持关键词模糊搜索）
-	// offset: 分页偏移量, limit: 每页条数, keyword: 搜索关键词
-	GetResourceList(ctx context.Context, offset, limit int, keyword string) ([]*model.Resource, error)
-	// CountResources 统计资源总数（支持关键词过滤）
-	CountResources(ctx context.Context, keyword string) (int64, error)
+	// GetResourceList 分页查询资源列表（按发布时间倒序，支持作者/发布时间过滤；关键词检索走search.SearchIndex）
+	// offset: 分页偏移量, limit: 每页条数, filter: 过滤条件
+	GetResourceList(ctx context.Context, offset, limit int, filter ResourceFilter) ([]*model.Resource, error)
+	// CountResources 统计资源总数（过滤条件与GetResourceList一致）
+	CountResources(ctx context.Context, filter ResourceFilter) (int64, error)
+	// GetResourcesByIDs 批量查询资源（被隐藏/不存在的资源不返回，key为资源ID）
+	GetResourcesByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Resource, error)
+	// GetResourceByID 查询资源详情（被管理员隐藏的资源返回nil, nil）
 	GetResourceByID(ctx context.Context, id uint64) (*model.Resource, error)
+	// GetAnyResourceByID 查询资源详情（包含被隐藏的资源，供管理后台使用）
+	GetAnyResourceByID(ctx context.Context, id uint64) (*model.Resource, error)
 	// 可选扩展：新增计数更新方法（如需实现点赞/浏览/评论量+1）
-	IncrViewCount(ctx context.Context, id uint64) error
-	IncrLikeCount(ctx context.Context, id uint64) error
-	IncrCommentCount(ctx context.Context, id uint64) error
+	// UpdatePrice 修改资源价格（仅作者本人，userID不匹配或资源不存在返回错误）
+	UpdatePrice(ctx context.Context, id, userID uint64, price decimal.Decimal) error
+	// UpdateMeta 修改资源的编程语言与分类（支持传入事务；作者校验由调用方在LockResource的事务内完成）
+	UpdateMeta(ctx context.Context, tx *sql.Tx, id uint64, language string, categoryID uint64) error
+	// CountFacets 统计语言/分类/标签分面（过滤条件与GetResourceList一致，见QueryFacets）
+	CountFacets(ctx context.Context, filter ResourceFilter) (*ResourceFacets, error)
+	// AddViewCounts 批量累加浏览量（key为资源ID，value为增量；由浏览计数器定时批量刷盘）
+	AddViewCounts(ctx context.Context, counts map[uint64]uint64) error
+	// LockResource 在事务内对资源行加排他锁（SELECT ... FOR UPDATE）并返回该行（不含标签，包含被隐藏的资源），资源不存在返回nil, nil
+	LockResource(ctx context.Context, tx *sql.Tx, id uint64) (*model.Resource, error)
+	// SyncLikeCount 按resource_likes表重新计算点赞量并返回最新值（需在LockResource的事务内调用）
+	SyncLikeCount(ctx context.Context, tx *sql.Tx, id uint64) (uint64, error)
+	// IncrCommentCount 评论量+1，DecrCommentCount 评论量-n（均支持传入事务，与评论写入保持原子性）
+	IncrCommentCount(ctx context.Context, tx *sql.Tx, id uint64) error
+	DecrCommentCount(ctx context.Context, tx *sql.Tx, id uint64, n int64) error
+	// SyncCommentCount 按comments表重新计算评论量（仅统计未隐藏的评论，需在LockResource的事务内调用）
+	SyncCommentCount(ctx context.Context, tx *sql.Tx, id uint64) error
+	// SetHidden 隐藏/取消隐藏资源（支持传入事务），状态未变化返回false
+	SetHidden(ctx context.Context, tx *sql.Tx, id uint64, hidden bool) (bool, error)
+	// DeleteResource 删除资源记录（支持传入事务，评论/点赞明细需由调用方在同一事务内先行删除）
+	DeleteResource(ctx context.Context, tx *sql.Tx, id uint64) error
+	// GetDB 返回数据库连接（供Service层开启跨Repo事务）
+	GetDB() *sql.DB
+}
+
+// ResourceFilter 资源列表过滤条件（零值表示不限）
+type ResourceFilter struct {
+	Author      string    // 作者用户名（精确匹配）
+	From        time.Time // 发布时间下限（含）
+	To          time.Time // 发布时间上限（不含）
+	Language    string    // 编程语言标识
+	CategoryIDs []uint64  // 所属分类（筛选的分类及其全部子孙分类，由Service层展开）
+	Tag         string    // 标签名（已转小写）
+}
+
+// Where 生成追加在WHERE之后的过滤条件（以AND开头）及对应参数（列名不带表别名，外层查询的资源表不能起别名）
+func (f ResourceFilter) Where() (string, []interface{}) {
+	var b strings.Builder
+	var args []interface{}
+	if f.Author != "" {
+		b.WriteString(" AND author = ?")
+		args = append(args, f.Author)
+	}
+	if !f.From.IsZero() {
+		b.WriteString(" AND publish_time >= ?")
+		args = append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		b.WriteString(" AND publish_time < ?")
+		args = append(args, f.To)
+	}
+	if f.Language != "" {
+		b.WriteString(" AND language = ?")
+		args = append(args, f.Language)
+	}
+	if len(f.CategoryIDs) > 0 {
+		marks, idArgs := inPlaceholders(f.CategoryIDs)
+		b.WriteString(" AND category_id IN (" + marks + ")")
+		args = append(args, idArgs...)
+	}
+	if f.Tag != "" {
+		b.WriteString(" AND EXISTS (SELECT 1 FROM resource_tags frt JOIN tags ft ON ft.id = frt.tag_id WHERE frt.resource_id = resources.id AND ft.name = ?)")
+		args = append(args, f.Tag)
+	}
+	return b.String(), args
+}
+
+// ResourceAttrs 参与过滤与分面统计的资源属性（供内存检索使用）
+type ResourceAttrs struct {
+	Author      string
+	PublishTime time.Time
+	Language    string
+	CategoryID  uint64
+	Tags        []string
+}
+
+// Match 判断资源是否满足过滤条件（供内存检索使用，与Where的语义一致）
+func (f ResourceFilter) Match(a ResourceAttrs) bool {
+	if f.Author != "" && a.Author != f.Author {
+		return false
+	}
+	if !f.From.IsZero() && a.PublishTime.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !a.PublishTime.Before(f.To) {
+		return false
+	}
+	if f.Language != "" && a.Language != f.Language {
+		return false
+	}
+	if len(f.CategoryIDs) > 0 && !slices.Contains(f.CategoryIDs, a.CategoryID) {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(a.Tags, f.Tag) {
+		return false
+	}
+	return true
+}
+
+// FacetTagLimit 标签分面最多返回的标签数（按资源数降序）
+const FacetTagLimit = 20
+
+// ResourceFacets 资源分面统计（每个维度统计时忽略该维度自身的过滤条件，便于在同一维度内切换选项）
+type ResourceFacets struct {
+	Languages  map[string]int64 // 编程语言 → 资源数（不含未识别语言）
+	Categories map[uint64]int64 // 分类ID → 直接归属该分类的资源数（父分类汇总由Service层计算，不含未分类）
+	Tags       map[string]int64 // 标签名 → 资源数（最多FacetTagLimit个）
+}
+
+// QueryFacets 按过滤条件统计分面（cond为额外的检索条件，以AND开头，如全文检索的MATCH子句；资源仓库与MySQL全文检索共用）
+func QueryFacets(ctx context.Context, db *sql.DB, cond string, condArgs []interface{}, filter ResourceFilter) (*ResourceFacets, error) {
+	facets := &ResourceFacets{
+		Languages:  make(map[string]int64),
+		Categories: make(map[uint64]int64),
+		Tags:       make(map[string]int64),
+	}
+	// where 去掉某个维度的过滤条件后拼接完整WHERE子句
+	where := func(f ResourceFilter) (string, []interface{}) {
+		filterSQL, filterArgs := f.Where()
+		args := append(append([]interface{}{}, condArgs...), filterArgs...)
+		return "hidden = 0" + cond + filterSQL, args
+	}
+
+	langFilter := filter
+	langFilter.Language = ""
+	w, args := where(langFilter)
+	if err := queryFacet(ctx, db, `SELECT language, COUNT(*) FROM resources WHERE `+w+` AND language <> '' GROUP BY language`, args, facets.Languages); err != nil {
+		return nil, fmt.Errorf("统计语言分面失败：%w", err)
+	}
+
+	categoryFilter := filter
+	categoryFilter.CategoryIDs = nil
+	w, args = where(categoryFilter)
+	if err := queryFacet(ctx, db, `SELECT category_id, COUNT(*) FROM resources WHERE `+w+` AND category_id <> 0 GROUP BY category_id`, args, facets.Categories); err != nil {
+		return nil, fmt.Errorf("统计分类分面失败：%w", err)
+	}
+
+	tagFilter := filter
+	tagFilter.Tag = ""
+	w, args = where(tagFilter)
+	sqlStr := fmt.Sprintf(`
+		SELECT t.name, COUNT(*) AS cnt
+		FROM resource_tags rt
+		JOIN tags t ON t.id = rt.tag_id
+		WHERE rt.resource_id IN (SELECT id FROM resources WHERE %s)
+		GROUP BY t.id, t.name
+		ORDER BY cnt DESC, t.name
+		LIMIT %d
+	`, w, FacetTagLimit)
+	if err := queryFacet(ctx, db, sqlStr, args, facets.Tags); err != nil {
+		return nil, fmt.Errorf("统计标签分面失败：%w", err)
+	}
+	return facets, nil
+}
+
+// queryFacet 执行GROUP BY统计，结果写入counts（key为分组值）
+func queryFacet[K comparable](ctx context.Context, db *sql.DB, sqlStr string, args []interface{}, counts map[K]int64) error {
+	rows, err := db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key K
+		var n int64
+		if err := rows.Scan(&key, &n); err != nil {
+			return fmt.Errorf("扫描分面数据失败：%w", err)
+		}
+		counts[key] = n
+	}
+	return rows.Err()
 }
 
 // resourceRepoImpl ResourceRepo实现（复用db连接，与accountRepoImpl结构一致）
@@ -39,6 +221,11 @@ func NewResourceRepo(db *sql.DB) ResourceRepo {
 	return &resourceRepoImpl{db: db}
 }
 
+// GetDB 返回数据库连接（与userRepoImpl.GetDB一致）
+func (r *resourceRepoImpl) GetDB() *sql.DB {
+	return r.db
+}
+
 // CreateResource 新增资源（核心：支持外部事务，兼容单独创建场景）- 新增：插入点赞/浏览/评论量字段
 func (r *resourceRepoImpl) CreateResource(ctx context.Context, tx *sql.Tx, resource *model.Resource) error {
 	// 适配外部事务：有tx用tx执行，无tx用db执行（和CreateAccount逻辑一致）
@@ -49,19 +236,22 @@ func (r *resourceRepoImpl) CreateResource(ctx context.Context, tx *sql.Tx, resou
 
 	// 插入资源SQL（新增：like_count, view_count, comment_count字段）
 	sqlStr := `
-	INSERT INTO resources (user_id, title, text_content, code_content, author, publish_time, like_count, view_count, comment_count)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO resources (user_id, title, text_content, code_content, language, category_id, author, publish_time, like_count, view_count, comment_count, price)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := execFunc(ctx, sqlStr,
+	result, err := execFunc(ctx, sqlStr,
 		resource.UserID,
 		resource.Title,
 		resource.TextContent,
 		resource.CodeContent,
+		resource.Language,
+		resource.CategoryID,
 		resource.Author,
 		resource.PublishTime,
 		resource.LikeCount,    // 新增：点赞量
 		resource.ViewCount,    // 新增：浏览量
 		resource.CommentCount, // 新增：评论量
+		resource.Price.String(),
 	)
 	if err != nil {
 		// 处理MySQL特定错误（和AccountRepo错误处理风格一致）
@@ -78,6 +268,11 @@ func (r *resourceRepoImpl) CreateResource(ctx context.Context, tx *sql.Tx, resou
 		}
 		return fmt.Errorf("插入资源失败：%w", err)
 	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取资源ID失败：%w", err)
+	}
+	resource.ID = uint64(id)
 	return nil
 }
 
@@ -85,7 +280,7 @@ func (r *resourceRepoImpl) CreateResource(ctx context.Context, tx *sql.Tx, resou
 func (r *resourceRepoImpl) GetByUserID(ctx context.Context, userID uint64) ([]*model.Resource, error) {
 	// 查询SQL：新增like_count, view_count, comment_count字段
 	sqlStr := `
-	SELECT id, user_id, title, text_content, code_content, author, publish_time, like_count, view_count, comment_count
+	SELECT id, user_id, title, text_content, code_content, language, category_id, author, publish_time, like_count, view_count, comment_count, price, hidden
 	FROM resources
 	WHERE user_id = ?
 	ORDER BY publish_time DESC
@@ -110,11 +305,15 @@ func (r *resourceRepoImpl) GetByUserID(ctx context.Context, userID uint64) ([]*m
 			&res.Title,
 			&textContent, // 处理NULL值
 			&codeContent, // 处理NULL值
+			&res.Language,
+			&res.CategoryID,
 			&res.Author,
 			&res.PublishTime,
 			&res.LikeCount,    // 新增：点赞量
 			&res.ViewCount,    // 新增：浏览量
 			&res.CommentCount, // 新增：评论量
+			&res.Price,
+			&res.Hidden,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("扫描资源数据失败：%w", err)
@@ -129,29 +328,26 @@ func (r *resourceRepoImpl) GetByUserID(ctx context.Context, userID uint64) ([]*m
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("遍历资源结果集失败：%w", err)
 	}
+	if err := r.attachTags(ctx, resources...); err != nil {
+		return nil, err
+	}
 
 	return resources, nil
 }
 
-// GetResourceList 分页查询资源列表（原生SQL实现，支持关键词模糊搜索）- 新增：查询/扫描点赞/浏览/评论量
-func (r *resourceRepoImpl) GetResourceList(ctx context.Context, offset, limit int, keyword string) ([]*model.Resource, error) {
+// GetResourceList 分页查询资源列表（原生SQL实现，支持作者/发布时间过滤）- 新增：查询/扫描点赞/浏览/评论量
+func (r *resourceRepoImpl) GetResourceList(ctx context.Context, offset, limit int, filter ResourceFilter) ([]*model.Resource, error) {
 	// 1. 构建基础SQL（新增：like_count, view_count, comment_count字段）
 	sqlBuilder := strings.Builder{}
 	sqlBuilder.WriteString(`
-	SELECT id, user_id, title, text_content, code_content, author, publish_time, like_count, view_count, comment_count
+	SELECT id, user_id, title, text_content, code_content, language, category_id, author, publish_time, like_count, view_count, comment_count, price, hidden
 	FROM resources
+	WHERE hidden = 0
 	`)
 
-	// 2. 构建WHERE条件（处理关键词模糊搜索）
-	var args []interface{}
-	if keyword != "" {
-		sqlBuilder.WriteString(`
-		WHERE title LIKE ? OR IFNULL(text_content, '') LIKE ? OR IFNULL(code_content, '') LIKE ?
-		`)
-		// 关键词拼接%%（模糊匹配），用?占位符防SQL注入（核心！）
-		likeKeyword := fmt.Sprintf("%%%s%%", keyword)
-		args = append(args, likeKeyword, likeKeyword, likeKeyword)
-	}
+	// 2. 构建WHERE条件（作者/发布时间过滤；被管理员隐藏的资源不出现在列表中）
+	where, args := filter.Where()
+	sqlBuilder.WriteString(where)
 
 	// 3. 排序+分页（按发布时间倒序，LIMIT offset, limit）
 	sqlBuilder.WriteString(`
@@ -184,11 +380,15 @@ func (r *resourceRepoImpl) GetResourceList(ctx context.Context, offset, limit in
 			&res.Title,
 			&textContent, // 先扫到NullString
 			&codeContent, // 先扫到NullString
+			&res.Language,
+			&res.CategoryID,
 			&res.Author,
 			&res.PublishTime,
 			&res.LikeCount,    // 新增：点赞量
 			&res.ViewCount,    // 新增：浏览量
 			&res.CommentCount, // 新增：评论量
+			&res.Price,
+			&res.Hidden,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("扫描资源数据失败：%w", err)
@@ -203,25 +403,22 @@ func (r *resourceRepoImpl) GetResourceList(ctx context.Context, offset, limit in
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("遍历分页资源结果集失败：%w", err)
 	}
+	if err := r.attachTags(ctx, resources...); err != nil {
+		return nil, err
+	}
 
 	return resources, nil
 }
 
-// CountResources 统计资源总数（原生SQL，与GetResourceList过滤条件一致）- 无需修改
-func (r *resourceRepoImpl) CountResources(ctx context.Context, keyword string) (int64, error) {
+// CountResources 统计资源总数（原生SQL，与GetResourceList过滤条件一致）
+func (r *resourceRepoImpl) CountResources(ctx context.Context, filter ResourceFilter) (int64, error) {
 	// 1. 构建统计SQL
 	sqlBuilder := strings.Builder{}
-	sqlBuilder.WriteString(`SELECT COUNT(*) FROM resources`)
+	sqlBuilder.WriteString(`SELECT COUNT(*) FROM resources WHERE hidden = 0`)
 
-	// 2. 处理关键词过滤条件
-	var args []interface{}
-	if keyword != "" {
-		sqlBuilder.WriteString(`
-		WHERE title LIKE ? OR IFNULL(text_content, '') LIKE ? OR IFNULL(code_content, '') LIKE ?
-		`)
-		likeKeyword := fmt.Sprintf("%%%s%%", keyword)
-		args = append(args, likeKeyword, likeKeyword, likeKeyword)
-	}
+	// 2. 处理作者/发布时间过滤条件
+	where, args := filter.Where()
+	sqlBuilder.WriteString(where)
 
 	// 3. 执行统计查询
 	var total int64
@@ -238,38 +435,123 @@ func (r *resourceRepoImpl) CountResources(ctx context.Context, keyword string) (
 	return total, nil
 }
 
-// GetResourceByID 根据ID查询单条资源详情（纯原生SQL，适配*sql.DB）- 新增：查询/扫描点赞/浏览/评论量
+// GetResourcesByIDs 批量查询资源（检索命中后按ID回表取完整字段；顺序由调用方按相关度自行排列）
+func (r *resourceRepoImpl) GetResourcesByIDs(ctx context.Context, ids []uint64) (map[uint64]*model.Resource, error) {
+	resources := make(map[uint64]*model.Resource, len(ids))
+	if len(ids) == 0 {
+		return resources, nil
+	}
+	marks, args := inPlaceholders(ids)
+	sqlStr := `
+		SELECT id, user_id, title, text_content, code_content, language, category_id, author, publish_time, like_count, view_count, comment_count, price, hidden
+		FROM resources
+		WHERE hidden = 0 AND id IN (` + marks + `)
+	`
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return nil, fmt.Errorf("批量查询资源失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return nil, fmt.Errorf("批量查询资源失败：%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res model.Resource
+		var textContent, codeContent sql.NullString
+		if err := rows.Scan(
+			&res.ID,
+			&res.UserID,
+			&res.Title,
+			&textContent,
+			&codeContent,
+			&res.Language,
+			&res.CategoryID,
+			&res.Author,
+			&res.PublishTime,
+			&res.LikeCount,
+			&res.ViewCount,
+			&res.CommentCount,
+			&res.Price,
+			&res.Hidden,
+		); err != nil {
+			return nil, fmt.Errorf("扫描资源数据失败：%w", err)
+		}
+		res.TextContent = textContent.String
+		res.CodeContent = codeContent.String
+		resources[res.ID] = &res
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历资源结果集失败：%w", err)
+	}
+	list := make([]*model.Resource, 0, len(resources))
+	for _, res := range resources {
+		list = append(list, res)
+	}
+	if err := r.attachTags(ctx, list...); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetResourceByID 根据ID查询单条资源详情（被隐藏的资源视为不存在）
 func (r *resourceRepoImpl) GetResourceByID(ctx context.Context, id uint64) (*model.Resource, error) {
+	return r.getResource(ctx, id, false)
+}
+
+// GetAnyResourceByID 根据ID查询单条资源（包含被隐藏的资源）
+func (r *resourceRepoImpl) GetAnyResourceByID(ctx context.Context, id uint64) (*model.Resource, error) {
+	return r.getResource(ctx, id, true)
+}
+
+// getResource 根据ID查询单条资源详情（纯原生SQL，适配*sql.DB）- 新增：查询/扫描点赞/浏览/评论量
+func (r *resourceRepoImpl) getResource(ctx context.Context, id uint64, includeHidden bool) (*model.Resource, error) {
 	// 1. 定义原生SQL（新增：like_count, view_count, comment_count字段）
 	sqlStr := `
-		SELECT id, user_id, title, text_content, code_content, author, publish_time, like_count, view_count, comment_count
+		SELECT id, user_id, title, text_content, code_content, language, category_id, author, publish_time, like_count, view_count, comment_count, price, hidden
 		FROM resources 
-		WHERE id = ? 
+		WHERE id = ? AND (hidden = 0 OR ?)
 		LIMIT 1
 	`
 
 	// 2. 执行单行查询（QueryRowContext适配*sql.DB，带上下文）
-	row := r.db.QueryRowContext(ctx, sqlStr, id)
+	res, err := scanResource(r.db.QueryRowContext(ctx, sqlStr, id, includeHidden), id, "查询")
+	if err != nil || res == nil {
+		return nil, err
+	}
+	// 3. 补充标签
+	if err := r.attachTags(ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
 
-	// 3. 定义变量接收结果（处理NULL值，和GetResourceList一致）
+// scanResource 扫描单条资源行（列顺序与getResource/LockResource的SELECT一致；不存在返回nil, nil）
+func scanResource(row *sql.Row, id uint64, action string) (*model.Resource, error) {
+	// 1. 定义变量接收结果（处理NULL值，和GetResourceList一致）
 	var res model.Resource
 	var textContent, codeContent sql.NullString // 处理可能为NULL的字段
 
-	// 4. 扫描结果到变量（新增：like_count, view_count, comment_count）
+	// 2. 扫描结果到变量（新增：like_count, view_count, comment_count）
 	err := row.Scan(
 		&res.ID,
 		&res.UserID,
 		&res.Title,
 		&textContent, // 先扫到NullString（处理NULL）
 		&codeContent, // 先扫到NullString（处理NULL）
+		&res.Language,
+		&res.CategoryID,
 		&res.Author,
 		&res.PublishTime,
 		&res.LikeCount,    // 新增：点赞量
 		&res.ViewCount,    // 新增：浏览量
 		&res.CommentCount, // 新增：评论量
+		&res.Price,
+		&res.Hidden,
 	)
 
-	// 5. 错误处理（适配*sql.DB的错误类型，和现有逻辑一致）
+	// 3. 错误处理（适配*sql.DB的错误类型，和现有逻辑一致）
 	if err != nil {
 		// 资源不存在（sql.ErrNoRows）：返回nil（无错误），由上层处理404
 		if err == sql.ErrNoRows {
@@ -278,64 +560,209 @@ func (r *resourceRepoImpl) GetResourceByID(ctx context.Context, id uint64) (*mod
 		// 其他数据库错误（如连接失败、字段不匹配）：包装错误返回
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
-			return nil, fmt.Errorf("查询资源失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+			return nil, fmt.Errorf("%s资源失败：MySQL错误[%d] %s", action, mysqlErr.Number, mysqlErr.Message)
 		}
-		return nil, fmt.Errorf("查询资源失败（id=%d）：%w", id, err)
+		return nil, fmt.Errorf("%s资源失败（id=%d）：%w", action, id, err)
 	}
 
-	// 6. 转换NULL值为普通字符串（NULL→空字符串，和GetResourceList一致）
+	// 4. 转换NULL值为普通字符串（NULL→空字符串，和GetResourceList一致）
 	res.TextContent = textContent.String
 	res.CodeContent = codeContent.String
-
-	// 7. 返回资源指针
 	return &res, nil
 }
 
-// ========== 可选扩展：计数更新方法（实现点赞/浏览/评论量+1） ==========
-// IncrViewCount 浏览量+1（原子更新，避免并发问题）
-func (r *resourceRepoImpl) IncrViewCount(ctx context.Context, id uint64) error {
+// UpdatePrice 修改资源价格（WHERE带上user_id，非作者本人影响行数为0）
+func (r *resourceRepoImpl) UpdatePrice(ctx context.Context, id, userID uint64, price decimal.Decimal) error {
 	sqlStr := `
 		UPDATE resources 
-		SET view_count = view_count + 1, updated_at = CURRENT_TIMESTAMP
+		SET price = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND user_id = ?
+	`
+	result, err := r.db.ExecContext(ctx, sqlStr, price.String(), id, userID)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("更新资源价格失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("更新资源价格失败（id=%d）：%w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取价格更新影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrResourceNotFound.WithMsgf("资源不存在或无权修改（id=%d）", id)
+	}
+	return nil
+}
+
+// UpdateMeta 修改资源的编程语言与分类
+func (r *resourceRepoImpl) UpdateMeta(ctx context.Context, tx *sql.Tx, id uint64, language string, categoryID uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+		UPDATE resources
+		SET language = ?, category_id = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
-	_, err := r.db.ExecContext(ctx, sqlStr, id)
+	if _, err := execFunc(ctx, sqlStr, language, categoryID, id); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("更新资源语言/分类失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("更新资源语言/分类失败（id=%d）：%w", id, err)
+	}
+	return nil
+}
+
+// CountFacets 统计资源列表的分面（不带检索条件）
+func (r *resourceRepoImpl) CountFacets(ctx context.Context, filter ResourceFilter) (*ResourceFacets, error) {
+	return QueryFacets(ctx, r.db, "", nil, filter)
+}
+
+// attachTags 批量查询并填充资源的标签（一次查询，按标签名排序；无标签为空切片）
+func (r *resourceRepoImpl) attachTags(ctx context.Context, resources ...*model.Resource) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	byID := make(map[uint64]*model.Resource, len(resources))
+	ids := make([]uint64, 0, len(resources))
+	for _, res := range resources {
+		res.Tags = []string{}
+		byID[res.ID] = res
+		ids = append(ids, res.ID)
+	}
+	marks, args := inPlaceholders(ids)
+	sqlStr := `
+		SELECT rt.resource_id, t.name
+		FROM resource_tags rt
+		JOIN tags t ON t.id = rt.tag_id
+		WHERE rt.resource_id IN (` + marks + `)
+		ORDER BY t.name
+	`
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
-			return fmt.Errorf("更新浏览量失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+			return fmt.Errorf("查询资源标签失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("查询资源标签失败：%w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return fmt.Errorf("扫描资源标签失败：%w", err)
+		}
+		if res, ok := byID[id]; ok {
+			res.Tags = append(res.Tags, name)
 		}
-		return fmt.Errorf("更新浏览量失败（id=%d）：%w", id, err)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("遍历资源标签失败：%w", err)
 	}
 	return nil
 }
 
-// IncrLikeCount 点赞量+1
-func (r *resourceRepoImpl) IncrLikeCount(ctx context.Context, id uint64) error {
+// ========== 可选扩展：计数更新方法（实现点赞/浏览/评论量+1） ==========
+// AddViewCounts 批量累加浏览量（单条UPDATE + CASE，一次刷盘多个资源，减少热点资源的写入次数）
+func (r *resourceRepoImpl) AddViewCounts(ctx context.Context, counts map[uint64]uint64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(counts))
+	caseBuilder := strings.Builder{}
+	var caseArgs []interface{}
+	for id, n := range counts {
+		ids = append(ids, id)
+		caseBuilder.WriteString(" WHEN ? THEN ?")
+		caseArgs = append(caseArgs, id, n)
+	}
+	marks, idArgs := inPlaceholders(ids)
+
 	sqlStr := `
 		UPDATE resources 
-		SET like_count = like_count + 1, updated_at = CURRENT_TIMESTAMP
-		WHERE id = ?
+		SET view_count = view_count + CASE id` + caseBuilder.String() + ` ELSE 0 END, updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (` + marks + `)
 	`
-	_, err := r.db.ExecContext(ctx, sqlStr, id)
+	_, err := r.db.ExecContext(ctx, sqlStr, append(caseArgs, idArgs...)...)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
-			return fmt.Errorf("更新点赞量失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+			return fmt.Errorf("批量更新浏览量失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
 		}
-		return fmt.Errorf("更新点赞量失败（id=%d）：%w", id, err)
+		return fmt.Errorf("批量更新浏览量失败（%d个资源）：%w", len(counts), err)
 	}
 	return nil
 }
 
-// IncrCommentCount 评论量+1
-func (r *resourceRepoImpl) IncrCommentCount(ctx context.Context, id uint64) error {
+// LockResource 锁定资源行并返回加锁时读到的资源（同一资源的点赞/取消点赞、购买等串行执行，避免计数与明细不一致及交叉加锁死锁）
+// 整行经事务读取，调用方持锁期间无需再从连接池查询资源；不含标签，包含被隐藏的资源
+func (r *resourceRepoImpl) LockResource(ctx context.Context, tx *sql.Tx, id uint64) (*model.Resource, error) {
+	if tx == nil {
+		return nil, errors.New("锁定资源必须在事务内执行")
+	}
+
+	sqlStr := `
+		SELECT id, user_id, title, text_content, code_content, language, category_id, author, publish_time, like_count, view_count, comment_count, price, hidden
+		FROM resources 
+		WHERE id = ? 
+		FOR UPDATE
+	`
+	return scanResource(tx.QueryRowContext(ctx, sqlStr, id), id, "锁定")
+}
+
+// SyncLikeCount 点赞量取自resource_likes明细计数（不再盲目+1，重复请求不会累加）
+func (r *resourceRepoImpl) SyncLikeCount(ctx context.Context, tx *sql.Tx, id uint64) (uint64, error) {
+	execFunc := r.db.ExecContext
+	queryRowFunc := r.db.QueryRowContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+		queryRowFunc = tx.QueryRowContext
+	}
+
+	sqlStr := `
+		UPDATE resources 
+		SET like_count = (SELECT COUNT(*) FROM resource_likes WHERE resource_id = ?), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	if _, err := execFunc(ctx, sqlStr, id, id); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return 0, fmt.Errorf("更新点赞量失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return 0, fmt.Errorf("更新点赞量失败（id=%d）：%w", id, err)
+	}
+
+	var likeCount uint64
+	if err := queryRowFunc(ctx, `SELECT like_count FROM resources WHERE id = ?`, id).Scan(&likeCount); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+		}
+		return 0, fmt.Errorf("查询点赞量失败（id=%d）：%w", id, err)
+	}
+	return likeCount, nil
+}
+
+// IncrCommentCount 评论量+1（有tx用tx执行，保证与评论插入同时提交/回滚）
+func (r *resourceRepoImpl) IncrCommentCount(ctx context.Context, tx *sql.Tx, id uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
 	sqlStr := `
 		UPDATE resources 
 		SET comment_count = comment_count + 1, updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?
 	`
-	_, err := r.db.ExecContext(ctx, sqlStr, id)
+	result, err := execFunc(ctx, sqlStr, id)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) {
@@ -343,5 +770,113 @@ func (r *resourceRepoImpl) IncrCommentCount(ctx context.Context, id uint64) erro
 		}
 		return fmt.Errorf("更新评论量失败（id=%d）：%w", id, err)
 	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取评论量影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+	}
+	return nil
+}
+
+// DecrCommentCount 评论量-n（删除评论楼层时一次扣减整棵回复树；comment_count为无符号字段，最多扣减到0）
+func (r *resourceRepoImpl) DecrCommentCount(ctx context.Context, tx *sql.Tx, id uint64, n int64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+		UPDATE resources 
+		SET comment_count = IF(comment_count > ?, comment_count - ?, 0), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	_, err := execFunc(ctx, sqlStr, n, n, id)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("更新评论量失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("更新评论量失败（id=%d）：%w", id, err)
+	}
+	return nil
+}
+
+// SyncCommentCount 评论量取自comments明细计数（管理员隐藏/恢复/删除评论后重新计算，避免增减计数出现偏差）
+func (r *resourceRepoImpl) SyncCommentCount(ctx context.Context, tx *sql.Tx, id uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+		UPDATE resources 
+		SET comment_count = (SELECT COUNT(*) FROM comments WHERE resource_id = ? AND hidden = 0), updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
+	if _, err := execFunc(ctx, sqlStr, id, id); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("更新评论量失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("更新评论量失败（id=%d）：%w", id, err)
+	}
+	return nil
+}
+
+// SetHidden 隐藏/取消隐藏资源（WHERE带上原状态，重复操作影响行数为0）
+func (r *resourceRepoImpl) SetHidden(ctx context.Context, tx *sql.Tx, id uint64, hidden bool) (bool, error) {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+		UPDATE resources 
+		SET hidden = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND hidden = ?
+	`
+	result, err := execFunc(ctx, sqlStr, hidden, id, !hidden)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return false, fmt.Errorf("更新资源隐藏状态失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return false, fmt.Errorf("更新资源隐藏状态失败（id=%d）：%w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取隐藏状态影响行数失败：%w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteResource 删除资源记录
+func (r *resourceRepoImpl) DeleteResource(ctx context.Context, tx *sql.Tx, id uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	result, err := execFunc(ctx, `DELETE FROM resources WHERE id = ? LIMIT 1`, id)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1451: // 外键约束：仍有关联记录引用该资源
+				return apperr.FailedPrecondition("资源仍被其他记录引用，无法删除").Wrap(mysqlErr)
+			}
+			return fmt.Errorf("删除资源失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("删除资源失败（id=%d）：%w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取删除影响行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+	}
 	return nil
 }
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// TagRepo 标签Repo接口（tags表存标签名，resource_tags表存资源与标签的关联）
+// 资源的标签随资源查询由ResourceRepo批量填充，本接口只负责写入
+type TagRepo interface {
+	// SetResourceTags 整体替换资源的标签（支持传入事务，标签名需已规范化；不存在的标签自动创建）
+	SetResourceTags(ctx context.Context, tx *sql.Tx, resourceID uint64, names []string) error
+	// DeleteByResourceID 删除资源的全部标签关联（支持传入事务，删除资源时使用）
+	DeleteByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) error
+}
+
+// tagRepoImpl TagRepo实现（复用db连接，与likeRepoImpl结构一致）
+type tagRepoImpl struct {
+	db *sql.DB
+}
+
+// NewTagRepo 创建TagRepo实例
+func NewTagRepo(db *sql.DB) TagRepo {
+	return &tagRepoImpl{db: db}
+}
+
+// SetResourceTags 先删除旧关联，再INSERT IGNORE补齐标签（uk_name拦截已存在的标签），最后按标签名写入关联
+func (r *tagRepoImpl) SetResourceTags(ctx context.Context, tx *sql.Tx, resourceID uint64, names []string) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	if _, err := execFunc(ctx, `DELETE FROM resource_tags WHERE resource_id = ?`, resourceID); err != nil {
+		return wrapTagErr("清除资源标签失败", err)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	marks := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		marks[i] = "?"
+		args[i] = name
+	}
+	values := "(" + strings.Join(marks, "), (") + ")"
+	if _, err := execFunc(ctx, `INSERT IGNORE INTO tags (name) VALUES `+values, args...); err != nil {
+		return wrapTagErr("写入标签失败", err)
+	}
+
+	sqlStr := `
+		INSERT INTO resource_tags (resource_id, tag_id)
+		SELECT ?, id FROM tags WHERE name IN (` + strings.Join(marks, ", ") + `)
+	`
+	if _, err := execFunc(ctx, sqlStr, append([]interface{}{resourceID}, args...)...); err != nil {
+		return wrapTagErr("写入资源标签失败", err)
+	}
+	return nil
+}
+
+// DeleteByResourceID 删除资源的全部标签关联（标签本身保留，供其他资源继续使用）
+func (r *tagRepoImpl) DeleteByResourceID(ctx context.Context, tx *sql.Tx, resourceID uint64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	if _, err := execFunc(ctx, `DELETE FROM resource_tags WHERE resource_id = ?`, resourceID); err != nil {
+		return wrapTagErr("删除资源标签失败", err)
+	}
+	return nil
+}
+
+// wrapTagErr 包装标签相关的数据库错误（与其他Repo的MySQL错误格式一致）
+func wrapTagErr(action string, err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return fmt.Errorf("%s：MySQL错误[%d] %s", action, mysqlErr.Number, mysqlErr.Message)
+	}
+	return fmt.Errorf("%s：%w", action, err)
+}
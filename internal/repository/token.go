@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// TokenRepo 登录令牌Repo接口（refresh_tokens刷新令牌表、revoked_tokens访问Token吊销表、user_token_revocations用户级吊销表）
+type TokenRepo interface {
+	// CreateRefreshToken 写入刷新令牌（支持传入事务，与旧令牌吊销保持原子性）
+	CreateRefreshToken(ctx context.Context, tx *sql.Tx, token *model.RefreshToken) error
+	// LockRefreshToken 事务内按哈希锁定刷新令牌（不存在返回nil, nil），同一令牌的并发刷新由此串行化
+	LockRefreshToken(ctx context.Context, tx *sql.Tx, tokenHash string) (*model.RefreshToken, error)
+	// RevokeRefreshToken 吊销单个刷新令牌
+	RevokeRefreshToken(ctx context.Context, tx *sql.Tx, id uint64) error
+	// RevokeFamily 吊销令牌族内全部未吊销的刷新令牌（退出单个会话/检测到令牌重用）
+	RevokeFamily(ctx context.Context, tx *sql.Tx, familyID string) error
+	// RevokeUserRefreshTokens 吊销用户全部未吊销的刷新令牌（退出所有设备）
+	RevokeUserRefreshTokens(ctx context.Context, tx *sql.Tx, userUUID string) error
+	// AddRevokedToken 记录被吊销的访问Token（jti），保留到Token过期
+	AddRevokedToken(ctx context.Context, jti, userUUID string, expiresAt time.Time) error
+	// ListRevokedTokens 查询未过期的吊销记录（jti → 过期时间），启动时加载到内存
+	ListRevokedTokens(ctx context.Context, now time.Time) (map[string]time.Time, error)
+	// SetUserRevokedBefore 设置用户级吊销时间：签发时间早于该时间的访问Token全部失效
+	SetUserRevokedBefore(ctx context.Context, userUUID string, before time.Time) error
+	// ListUserRevocations 查询仍在生效期内的用户级吊销记录（uuid → 吊销时间）
+	ListUserRevocations(ctx context.Context, since time.Time) (map[string]time.Time, error)
+	// PurgeExpired 清理已无意义的记录：过期的吊销jti、过期的刷新令牌、早于since的用户级吊销
+	PurgeExpired(ctx context.Context, now, since time.Time) error
+	// GetDB 返回数据库连接（供Service层开启事务）
+	GetDB() *sql.DB
+}
+
+// tokenRepoImpl TokenRepo实现
+type tokenRepoImpl struct {
+	db *sql.DB
+}
+
+// NewTokenRepo 创建TokenRepo实例
+func NewTokenRepo(db *sql.DB) TokenRepo {
+	return &tokenRepoImpl{db: db}
+}
+
+// GetDB 返回数据库连接
+func (r *tokenRepoImpl) GetDB() *sql.DB {
+	return r.db
+}
+
+// CreateRefreshToken 写入刷新令牌
+func (r *tokenRepoImpl) CreateRefreshToken(ctx context.Context, tx *sql.Tx, token *model.RefreshToken) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	sqlStr := `
+	INSERT INTO refresh_tokens (user_uuid, token_hash, family_id, expires_at, create_time)
+	VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := execFunc(ctx, sqlStr,
+		token.UserUUID,
+		token.TokenHash,
+		token.FamilyID,
+		token.ExpiresAt,
+		token.CreateTime,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case 1062: // uk_token_hash唯一索引冲突（随机令牌碰撞，理论上不会发生）
+				return fmt.Errorf("刷新令牌重复：%s", mysqlErr.Message)
+			case 1048:
+				return fmt.Errorf("刷新令牌必填字段为空：%s", mysqlErr.Message)
+			}
+		}
+		return fmt.Errorf("写入刷新令牌失败：%w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取刷新令牌ID失败：%w", err)
+	}
+	token.ID = uint64(id)
+	return nil
+}
+
+// LockRefreshToken 按哈希锁定刷新令牌
+func (r *tokenRepoImpl) LockRefreshToken(ctx context.Context, tx *sql.Tx, tokenHash string) (*model.RefreshToken, error) {
+	if tx == nil {
+		return nil, errors.New("锁定刷新令牌必须在事务内执行")
+	}
+
+	sqlStr := `
+	SELECT id, user_uuid, token_hash, family_id, expires_at, revoked_at, create_time
+	FROM refresh_tokens
+	WHERE token_hash = ?
+	FOR UPDATE
+	`
+	var (
+		t         model.RefreshToken
+		revokedAt sql.NullTime
+	)
+	err := tx.QueryRowContext(ctx, sqlStr, tokenHash).Scan(
+		&t.ID,
+		&t.UserUUID,
+		&t.TokenHash,
+		&t.FamilyID,
+		&t.ExpiresAt,
+		&revokedAt,
+		&t.CreateTime,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询刷新令牌失败：%w", err)
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+// RevokeRefreshToken 吊销单个刷新令牌（已吊销的不重复更新）
+func (r *tokenRepoImpl) RevokeRefreshToken(ctx context.Context, tx *sql.Tx, id uint64) error {
+	sqlStr := `UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	return r.revoke(ctx, tx, sqlStr, time.Now(), id)
+}
+
+// RevokeFamily 吊销令牌族
+func (r *tokenRepoImpl) RevokeFamily(ctx context.Context, tx *sql.Tx, familyID string) error {
+	sqlStr := `UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`
+	return r.revoke(ctx, tx, sqlStr, time.Now(), familyID)
+}
+
+// RevokeUserRefreshTokens 吊销用户全部刷新令牌
+func (r *tokenRepoImpl) RevokeUserRefreshTokens(ctx context.Context, tx *sql.Tx, userUUID string) error {
+	sqlStr := `UPDATE refresh_tokens SET revoked_at = ? WHERE user_uuid = ? AND revoked_at IS NULL`
+	return r.revoke(ctx, tx, sqlStr, time.Now(), userUUID)
+}
+
+// revoke 执行吊销UPDATE（有tx用tx执行；影响0行说明本就没有可吊销的令牌，不视为错误）
+func (r *tokenRepoImpl) revoke(ctx context.Context, tx *sql.Tx, sqlStr string, args ...interface{}) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	if _, err := execFunc(ctx, sqlStr, args...); err != nil {
+		return fmt.Errorf("吊销刷新令牌失败：%w", err)
+	}
+	return nil
+}
+
+// AddRevokedToken 记录吊销的jti（INSERT IGNORE：重复退出登录不报错）
+func (r *tokenRepoImpl) AddRevokedToken(ctx context.Context, jti, userUUID string, expiresAt time.Time) error {
+	sqlStr := `INSERT IGNORE INTO revoked_tokens (jti, user_uuid, expires_at) VALUES (?, ?, ?)`
+	if _, err := r.db.ExecContext(ctx, sqlStr, jti, userUUID, expiresAt); err != nil {
+		return fmt.Errorf("写入Token吊销记录失败：%w", err)
+	}
+	return nil
+}
+
+// ListRevokedTokens 查询未过期的吊销jti
+func (r *tokenRepoImpl) ListRevokedTokens(ctx context.Context, now time.Time) (map[string]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > ?`, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询Token吊销记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			jti       string
+			expiresAt time.Time
+		)
+		if err := rows.Scan(&jti, &expiresAt); err != nil {
+			return nil, fmt.Errorf("扫描Token吊销记录失败：%w", err)
+		}
+		revoked[jti] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历Token吊销记录失败：%w", err)
+	}
+	return revoked, nil
+}
+
+// SetUserRevokedBefore 写入/更新用户级吊销时间
+func (r *tokenRepoImpl) SetUserRevokedBefore(ctx context.Context, userUUID string, before time.Time) error {
+	sqlStr := `
+	INSERT INTO user_token_revocations (user_uuid, revoked_before) VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE revoked_before = VALUES(revoked_before)
+	`
+	if _, err := r.db.ExecContext(ctx, sqlStr, userUUID, before); err != nil {
+		return fmt.Errorf("写入用户Token吊销记录失败：%w", err)
+	}
+	return nil
+}
+
+// ListUserRevocations 查询吊销时间晚于since的用户级吊销记录（更早的吊销对应的Token已全部自然过期）
+func (r *tokenRepoImpl) ListUserRevocations(ctx context.Context, since time.Time) (map[string]time.Time, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_uuid, revoked_before FROM user_token_revocations WHERE revoked_before > ?`, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户Token吊销记录失败：%w", err)
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			userUUID string
+			before   time.Time
+		)
+		if err := rows.Scan(&userUUID, &before); err != nil {
+			return nil, fmt.Errorf("扫描用户Token吊销记录失败：%w", err)
+		}
+		revoked[userUUID] = before
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历用户Token吊销记录失败：%w", err)
+	}
+	return revoked, nil
+}
+
+// PurgeExpired 清理过期记录
+func (r *tokenRepoImpl) PurgeExpired(ctx context.Context, now, since time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= ?`, now); err != nil {
+		return fmt.Errorf("清理Token吊销记录失败：%w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at <= ?`, now); err != nil {
+		return fmt.Errorf("清理过期刷新令牌失败：%w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_token_revocations WHERE revoked_before <= ?`, since); err != nil {
+		return fmt.Errorf("清理用户Token吊销记录失败：%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// TwoFactorRepo TOTP两步验证Repo接口（user_two_factor设置表、two_factor_recovery_codes恢复码表、two_factor_challenges登录挑战表）
+// 带tx参数的方法支持传入事务：校验动态码与更新时间步/恢复码/挑战状态需在同一事务内完成
+type TwoFactorRepo interface {
+	// GetTwoFactor 查询两步验证设置，不存在返回nil, nil
+	GetTwoFactor(ctx context.Context, userUUID string) (*model.UserTwoFactor, error)
+	// LockTwoFactor 事务内锁定两步验证设置（SELECT ... FOR UPDATE），不存在返回nil, nil
+	LockTwoFactor(ctx context.Context, tx *sql.Tx, userUUID string) (*model.UserTwoFactor, error)
+	// SavePendingSecret 保存待确认的TOTP密钥（已开启时不覆盖，返回false）
+	SavePendingSecret(ctx context.Context, userUUID, secretEnc string, now time.Time) (bool, error)
+	// EnableTwoFactor 开启两步验证并记录本次校验通过的时间步
+	EnableTwoFactor(ctx context.Context, tx *sql.Tx, userUUID string, step int64, now time.Time) error
+	// UpdateLastStep 记录最近一次校验通过的时间步
+	UpdateLastStep(ctx context.Context, tx *sql.Tx, userUUID string, step int64) error
+	// DeleteTwoFactor 关闭两步验证：删除设置、恢复码及未完成的登录挑战
+	DeleteTwoFactor(ctx context.Context, tx *sql.Tx, userUUID string) error
+	// ReplaceRecoveryCodes 用新的恢复码哈希整体替换旧恢复码
+	ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userUUID string, codeHashes []string, now time.Time) error
+	// UseRecoveryCode 使用恢复码（仅未使用的恢复码生效），返回是否使用成功
+	UseRecoveryCode(ctx context.Context, tx *sql.Tx, userUUID, codeHash string, now time.Time) (bool, error)
+	// CountRecoveryCodes 统计未使用的恢复码个数
+	CountRecoveryCodes(ctx context.Context, userUUID string) (int, error)
+	// CreateChallenge 写入登录挑战
+	CreateChallenge(ctx context.Context, challenge *model.TwoFactorChallenge) error
+	// GetChallenge 查询登录挑战（tx不为空时加行锁），不存在返回nil, nil
+	GetChallenge(ctx context.Context, tx *sql.Tx, tokenHash string) (*model.TwoFactorChallenge, error)
+	// IncrChallengeAttempts 登录挑战失败次数+1
+	IncrChallengeAttempts(ctx context.Context, tx *sql.Tx, tokenHash string) error
+	// DeleteChallenge 删除登录挑战（校验通过或失败次数达到上限）
+	DeleteChallenge(ctx context.Context, tx *sql.Tx, tokenHash string) error
+	// PurgeExpiredChallenges 清理过期的登录挑战，返回清理条数
+	PurgeExpiredChallenges(ctx context.Context, now time.Time) (int64, error)
+	GetDB() *sql.DB
+}
+
+// twoFactorRepoImpl TwoFactorRepo实现
+type twoFactorRepoImpl struct {
+	db *sql.DB
+}
+
+// NewTwoFactorRepo 创建TwoFactorRepo实例
+func NewTwoFactorRepo(db *sql.DB) TwoFactorRepo {
+	return &twoFactorRepoImpl{db: db}
+}
+
+// GetDB 返回数据库连接（Service层开启事务使用）
+func (r *twoFactorRepoImpl) GetDB() *sql.DB {
+	return r.db
+}
+
+const twoFactorColumns = `user_uuid, secret_enc, enabled, last_step, create_time, enable_time`
+
+// scanTwoFactor 扫描两步验证设置（无记录返回nil, nil）
+func scanTwoFactor(row *sql.Row) (*model.UserTwoFactor, error) {
+	var tf model.UserTwoFactor
+	err := row.Scan(&tf.UserUUID, &tf.SecretEnc, &tf.Enabled, &tf.LastStep, &tf.CreateTime, &tf.EnableTime)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询两步验证设置失败：%w", err)
+	}
+	return &tf, nil
+}
+
+// GetTwoFactor 查询两步验证设置
+func (r *twoFactorRepoImpl) GetTwoFactor(ctx context.Context, userUUID string) (*model.UserTwoFactor, error) {
+	return scanTwoFactor(r.db.QueryRowContext(ctx, `SELECT `+twoFactorColumns+` FROM user_two_factor WHERE user_uuid = ?`, userUUID))
+}
+
+// LockTwoFactor 锁定两步验证设置（同一账号的动态码校验由此串行化，防止同一动态码并发重放）
+func (r *twoFactorRepoImpl) LockTwoFactor(ctx context.Context, tx *sql.Tx, userUUID string) (*model.UserTwoFactor, error) {
+	if tx == nil {
+		return nil, errors.New("锁定两步验证设置必须在事务内执行")
+	}
+	return scanTwoFactor(tx.QueryRowContext(ctx, `SELECT `+twoFactorColumns+` FROM user_two_factor WHERE user_uuid = ? FOR UPDATE`, userUUID))
+}
+
+// SavePendingSecret 保存待确认的TOTP密钥（未开启时覆盖旧密钥；影响0行说明已开启）
+func (r *twoFactorRepoImpl) SavePendingSecret(ctx context.Context, userUUID, secretEnc string, now time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+	INSERT INTO user_two_factor (user_uuid, secret_enc, enabled, last_step, create_time) VALUES (?, ?, 0, 0, ?)
+	ON DUPLICATE KEY UPDATE
+		secret_enc = IF(enabled = 0, VALUES(secret_enc), secret_enc),
+		create_time = IF(enabled = 0, VALUES(create_time), create_time)
+	`, userUUID, secretEnc, now)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1406 {
+			return false, fmt.Errorf("TOTP密钥长度超过限制：%s", mysqlErr.Message)
+		}
+		return false, fmt.Errorf("保存TOTP密钥失败：%w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取保存结果失败：%w", err)
+	}
+	return affected > 0, nil
+}
+
+// EnableTwoFactor 开启两步验证
+func (r *twoFactorRepoImpl) EnableTwoFactor(ctx context.Context, tx *sql.Tx, userUUID string, step int64, now time.Time) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	sqlStr := `UPDATE user_two_factor SET enabled = 1, last_step = ?, enable_time = ? WHERE user_uuid = ? AND enabled = 0`
+	result, err := execFunc(ctx, sqlStr, step, now, userUUID)
+	if err != nil {
+		return fmt.Errorf("开启两步验证失败：%w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return apperr.FailedPrecondition("两步验证已开启或未生成密钥")
+	}
+	return nil
+}
+
+// UpdateLastStep 记录最近一次校验通过的时间步
+func (r *twoFactorRepoImpl) UpdateLastStep(ctx context.Context, tx *sql.Tx, userUUID string, step int64) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	if _, err := execFunc(ctx, `UPDATE user_two_factor SET last_step = ? WHERE user_uuid = ?`, step, userUUID); err != nil {
+		return fmt.Errorf("更新动态码时间步失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteTwoFactor 删除两步验证设置、恢复码及登录挑战
+func (r *twoFactorRepoImpl) DeleteTwoFactor(ctx context.Context, tx *sql.Tx, userUUID string) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	for _, sqlStr := range []string{
+		`DELETE FROM user_two_factor WHERE user_uuid = ?`,
+		`DELETE FROM two_factor_recovery_codes WHERE user_uuid = ?`,
+		`DELETE FROM two_factor_challenges WHERE user_uuid = ?`,
+	} {
+		if _, err := execFunc(ctx, sqlStr, userUUID); err != nil {
+			return fmt.Errorf("关闭两步验证失败：%w", err)
+		}
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes 整体替换恢复码
+func (r *twoFactorRepoImpl) ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userUUID string, codeHashes []string, now time.Time) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	if _, err := execFunc(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_uuid = ?`, userUUID); err != nil {
+		return fmt.Errorf("删除旧恢复码失败：%w", err)
+	}
+	for _, hash := range codeHashes {
+		if _, err := execFunc(ctx, `INSERT INTO two_factor_recovery_codes (user_uuid, code_hash, create_time) VALUES (?, ?, ?)`, userUUID, hash, now); err != nil {
+			return fmt.Errorf("保存恢复码失败：%w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode 使用恢复码（条件更新保证同一恢复码只能使用一次）
+func (r *twoFactorRepoImpl) UseRecoveryCode(ctx context.Context, tx *sql.Tx, userUUID, codeHash string, now time.Time) (bool, error) {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	sqlStr := `UPDATE two_factor_recovery_codes SET used_at = ? WHERE user_uuid = ? AND code_hash = ? AND used_at IS NULL`
+	result, err := execFunc(ctx, sqlStr, now, userUUID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("使用恢复码失败：%w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取恢复码使用结果失败：%w", err)
+	}
+	return affected == 1, nil
+}
+
+// CountRecoveryCodes 统计未使用的恢复码个数
+func (r *twoFactorRepoImpl) CountRecoveryCodes(ctx context.Context, userUUID string) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM two_factor_recovery_codes WHERE user_uuid = ? AND used_at IS NULL`, userUUID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("统计恢复码失败：%w", err)
+	}
+	return n, nil
+}
+
+// CreateChallenge 写入登录挑战
+func (r *twoFactorRepoImpl) CreateChallenge(ctx context.Context, c *model.TwoFactorChallenge) error {
+	sqlStr := `
+	INSERT INTO two_factor_challenges (token_hash, user_uuid, ip, attempts, expires_at, create_time)
+	VALUES (?, ?, ?, 0, ?, ?)
+	`
+	if _, err := r.db.ExecContext(ctx, sqlStr, c.TokenHash, c.UserUUID, c.IP, c.ExpiresAt, c.CreateTime); err != nil {
+		return fmt.Errorf("创建登录挑战失败：%w", err)
+	}
+	return nil
+}
+
+// GetChallenge 查询登录挑战（事务内加行锁，同一挑战令牌的并发校验串行化）
+func (r *twoFactorRepoImpl) GetChallenge(ctx context.Context, tx *sql.Tx, tokenHash string) (*model.TwoFactorChallenge, error) {
+	sqlStr := `SELECT token_hash, user_uuid, ip, attempts, expires_at, create_time FROM two_factor_challenges WHERE token_hash = ?`
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, sqlStr+` FOR UPDATE`, tokenHash)
+	} else {
+		row = r.db.QueryRowContext(ctx, sqlStr, tokenHash)
+	}
+
+	var c model.TwoFactorChallenge
+	if err := row.Scan(&c.TokenHash, &c.UserUUID, &c.IP, &c.Attempts, &c.ExpiresAt, &c.CreateTime); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询登录挑战失败：%w", err)
+	}
+	return &c, nil
+}
+
+// IncrChallengeAttempts 登录挑战失败次数+1
+func (r *twoFactorRepoImpl) IncrChallengeAttempts(ctx context.Context, tx *sql.Tx, tokenHash string) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	if _, err := execFunc(ctx, `UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE token_hash = ?`, tokenHash); err != nil {
+		return fmt.Errorf("更新登录挑战失败次数失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteChallenge 删除登录挑战
+func (r *twoFactorRepoImpl) DeleteChallenge(ctx context.Context, tx *sql.Tx, tokenHash string) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+	if _, err := execFunc(ctx, `DELETE FROM two_factor_challenges WHERE token_hash = ?`, tokenHash); err != nil {
+		return fmt.Errorf("删除登录挑战失败：%w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredChallenges 清理过期的登录挑战
+func (r *twoFactorRepoImpl) PurgeExpiredChallenges(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM two_factor_challenges WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, fmt.Errorf("清理过期登录挑战失败：%w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n, nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 
+	"CMS/internal/apperr"
 	"CMS/internal/model"
 
 	"github.com/go-sql-driver/mysql"
@@ -24,8 +25,26 @@ type UserRepo interface {
 	GetUserByUuid(ctx context.Context, uuid string) (*model.User, error)
 	GetDB() *sql.DB
 	GetUserById(ctx context.Context, id uint64) (*model.User, error)
+	// GetUserByIdTx 同GetUserById，传入事务时经事务查询（tx为nil时走连接池）
+	GetUserByIdTx(ctx context.Context, tx *sql.Tx, id uint64) (*model.User, error)
 	FindByemail(user *model.User) (*model.User, error)
 	GetByemail(ctx context.Context, Email string) (*model.User, error)
+
+	// 以下为管理后台使用的方法
+	// ListUsers 分页查询用户（keyword模糊匹配用户名/邮箱/手机号，role/status为空不过滤）
+	ListUsers(ctx context.Context, keyword, role, status string, offset, limit int) ([]*model.User, error)
+	// CountUsers 统计用户数（过滤条件与ListUsers一致）
+	CountUsers(ctx context.Context, keyword, role, status string) (int64, error)
+	// LockUser 事务内锁定用户行（SELECT ... FOR UPDATE），不存在返回nil, nil
+	LockUser(ctx context.Context, tx *sql.Tx, uuid string) (*model.User, error)
+	// UpdateRole 修改用户角色（支持传入事务，与审计日志保持原子性）
+	UpdateRole(ctx context.Context, tx *sql.Tx, uuid, role string) error
+	// UpdateStatus 修改账号状态（支持传入事务，与审计日志保持原子性）
+	UpdateStatus(ctx context.Context, tx *sql.Tx, uuid, status string) error
+	// MarkEmailVerified 将邮箱标记为已验证（仅当用户当前邮箱仍为email时生效）
+	MarkEmailVerified(ctx context.Context, uuid, email string) error
+	// UpdatePassword 修改密码哈希（支持传入事务，与吊销刷新令牌保持原子性）
+	UpdatePassword(ctx context.Context, tx *sql.Tx, uuid, passwordHash string) error
 }
 
 type userRepoImpl struct {
@@ -45,7 +64,7 @@ func (r *userRepoImpl) GetUserByCredential(ctx context.Context, username, phone,
 	// 匹配数据库字段：uuid（不是wuid）、password_hash（不是password hash）
 	case username != "":
 		sqlStr = `
-			SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date
+			SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date, status
 			FROM users 
 			WHERE username = ? 
 			LIMIT 1
@@ -53,7 +72,7 @@ func (r *userRepoImpl) GetUserByCredential(ctx context.Context, username, phone,
 		args = []interface{}{username}
 	case phone != "":
 		sqlStr = `
-			SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date
+			SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date, status
 			FROM users 
 			WHERE phone = ? 
 			LIMIT 1
@@ -61,7 +80,7 @@ func (r *userRepoImpl) GetUserByCredential(ctx context.Context, username, phone,
 		args = []interface{}{phone}
 	case email != "":
 		sqlStr = `
-			SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date
+			SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date, status
 			FROM users 
 			WHERE email = ? 
 			LIMIT 1
@@ -91,6 +110,7 @@ func (r *userRepoImpl) GetUserByCredential(ctx context.Context, username, phone,
 		&user.RealName,
 		&user.Gender,
 		&user.BirthDate,
+		&user.Status,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -140,7 +160,7 @@ func (r *userRepoImpl) CreateUser(ctx context.Context, tx *sql.Tx, user *model.U
 		if errors.As(err, &mysqlErr) {
 			switch mysqlErr.Number {
 			case 1062: // 唯一键冲突（uuid/username/email/phone重复）
-				return fmt.Errorf("唯一约束冲突：%s", mysqlErr.Message)
+				return userDuplicateError(mysqlErr)
 			case 1048: // 非空约束（uuid/username/password_hash为空）
 				return fmt.Errorf("必填字段为空：%s", mysqlErr.Message)
 			case 1406: // 数据太长（字段值超过数据库定义长度）
@@ -174,7 +194,7 @@ func (r *userRepoImpl) CheckUsernameExist(ctx context.Context, username string)
 func (r *userRepoImpl) UpdateUser(ctx context.Context, user *model.User) error {
 	// ========== 步骤1：前置校验（确保更新条件有效） ==========
 	if user == nil || strings.TrimSpace(user.UUID) == "" {
-		return errors.New("用户 UUID 不能为空")
+		return apperr.InvalidArgument("用户 UUID 不能为空")
 	}
 
 	// ========== 步骤2：动态拼接更新字段（仅处理有值的字段） ==========
@@ -188,9 +208,10 @@ func (r *userRepoImpl) UpdateUser(ctx context.Context, user *model.User) error {
 	}
 
 	// 2. 邮箱（*string，非 nil 且内容非空则更新）
+	// 邮箱变化时重置为未验证（MySQL按书写顺序执行SET，须在email赋值之前与旧值比较）
 	if user.Email != nil && strings.TrimSpace(*user.Email) != "" {
-		setClauses = append(setClauses, "email = ?")
-		args = append(args, *user.Email)
+		setClauses = append(setClauses, "email_verified = IF(email <=> ?, email_verified, 0)", "email = ?")
+		args = append(args, *user.Email, *user.Email)
 	}
 
 	// 3. 手机号（*string，非 nil 且内容非空则更新）
@@ -219,7 +240,7 @@ func (r *userRepoImpl) UpdateUser(ctx context.Context, user *model.User) error {
 
 	// ========== 步骤3：无更新字段校验 ==========
 	if len(setClauses) == 0 {
-		return errors.New("无有效更新字段")
+		return apperr.InvalidArgument("无有效更新字段")
 	}
 
 	// ========== 步骤4：拼接 SQL（用 strings.Builder，避免 fmt.Sprintf 报错） ==========
@@ -240,7 +261,7 @@ func (r *userRepoImpl) UpdateUser(ctx context.Context, user *model.User) error {
 		if errors.As(err, &mysqlErr) {
 			switch mysqlErr.Number {
 			case 1062: // 唯一约束冲突（username/phone/email 重复）
-				return fmt.Errorf("Duplicate entry: %s", mysqlErr.Message)
+				return userDuplicateError(mysqlErr)
 			case 1054: // 字段不存在（表结构与代码不匹配）
 				return fmt.Errorf("字段不存在：%s", mysqlErr.Message)
 			case 1146: // 表不存在
@@ -259,12 +280,33 @@ func (r *userRepoImpl) UpdateUser(ctx context.Context, user *model.User) error {
 		return fmt.Errorf("获取更新行数失败：%w", err)
 	}
 	if rowsAffected == 0 {
-		return errors.New("未找到该用户（UUID 不存在）或数据无变化")
+		return apperr.ErrUserNotFound.WithMsg("未找到该用户（UUID 不存在）或数据无变化")
 	}
 
 	return nil
 }
 
+// userDuplicateKeys users表唯一索引名 → 冲突提示
+var userDuplicateKeys = []struct {
+	key string
+	msg string
+}{
+	{"idx_username", "用户名已存在"},
+	{"idx_email", "邮箱已存在"},
+	{"idx_phone", "手机号已存在"},
+}
+
+// userDuplicateError 按冲突的唯一索引名返回具体的AlreadyExists错误
+// MySQL 1062的报错信息形如 Duplicate entry 'x' for key 'users.idx_email'（5.7无表名前缀）
+func userDuplicateError(mysqlErr *mysql.MySQLError) error {
+	for _, k := range userDuplicateKeys {
+		if strings.HasSuffix(mysqlErr.Message, k.key+"'") {
+			return apperr.AlreadyExists(k.msg).Wrap(mysqlErr)
+		}
+	}
+	return apperr.AlreadyExists("用户名/手机号/邮箱已存在").Wrap(mysqlErr)
+}
+
 func (r *userRepoImpl) Getwod(ctx context.Context, uuid string) (*model.User, error) {
 	sqlStr := `
 			SELECT word
@@ -305,10 +347,10 @@ func (r *userRepoImpl) CheckPhoneExist(ctx context.Context, phone string) (bool,
 func (r *userRepoImpl) UpdateAvatar(ctx context.Context, user *model.User) error {
 	// ========== 1. 前置校验 ==========
 	if user == nil || strings.TrimSpace(user.UUID) == "" {
-		return errors.New("用户UUID不能为空")
+		return apperr.InvalidArgument("用户UUID不能为空")
 	}
 	if user.AvatarURL == nil || strings.TrimSpace(*user.AvatarURL) == "" {
-		return errors.New("头像URL不能为空")
+		return apperr.InvalidArgument("头像URL不能为空")
 	}
 
 	// ========== 2. 拼接SQL ==========
@@ -330,7 +372,7 @@ func (r *userRepoImpl) UpdateAvatar(ctx context.Context, user *model.User) error
 		return fmt.Errorf("获取更新行数失败：%w", err)
 	}
 	if rowsAffected == 0 {
-		return errors.New("未找到该用户（UUID不存在）或头像无变化")
+		return apperr.ErrUserNotFound.WithMsg("未找到该用户（UUID不存在）或头像无变化")
 	}
 
 	return nil
@@ -338,7 +380,7 @@ func (r *userRepoImpl) UpdateAvatar(ctx context.Context, user *model.User) error
 func (r *userRepoImpl) GetUserByUuid(ctx context.Context, uuid string) (*model.User, error) {
 	// 1. 显式指定列（避免SELECT * 导致列顺序不一致问题），和model.User字段一一对应
 	sqlStr := `
-		SELECT id, username, email, phone, role, avatar_url, real_name, gender, birth_date 
+		SELECT id, username, email, phone, role, avatar_url, real_name, gender, birth_date, status, email_verified
 		FROM users 
 		WHERE uuid = ?
 	`
@@ -351,6 +393,8 @@ func (r *userRepoImpl) GetUserByUuid(ctx context.Context, uuid string) (*model.U
 		id       uint64
 		username string
 		role     string
+		status   string
+		verified bool
 		// 指针字符串字段：先用sql.NullString接收，再转换为*string
 		email     sql.NullString
 		phone     sql.NullString
@@ -372,21 +416,25 @@ func (r *userRepoImpl) GetUserByUuid(ctx context.Context, uuid string) (*model.U
 		&realName,
 		&gender,
 		&birthDate,
+		&status,
+		&verified,
 	)
 	// 5. 错误处理（核心）
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("用户不存在") // 友好的业务错误
+			return nil, apperr.ErrUserNotFound // 友好的业务错误
 		}
-		return nil, errors.New("查询用户失败：" + err.Error()) // 包装系统错误
+		return nil, fmt.Errorf("查询用户失败：%w", err) // 包装系统错误
 	}
 
 	// 6. 转换临时变量为model.User的特殊类型
 	user := &model.User{
-		ID:        id,
-		Username:  username,
-		Role:      role,
-		BirthDate: birthDate, // 直接赋值（类型一致）
+		ID:            id,
+		Username:      username,
+		Role:          role,
+		BirthDate:     birthDate, // 直接赋值（类型一致）
+		Status:        status,
+		EmailVerified: verified,
 	}
 	// 把sql.NullString转换为*string（Valid=true则取地址，否则为nil）
 	if email.Valid {
@@ -408,16 +456,25 @@ func (r *userRepoImpl) GetUserByUuid(ctx context.Context, uuid string) (*model.U
 	return user, nil
 }
 func (r *userRepoImpl) GetUserById(ctx context.Context, id uint64) (*model.User, error) {
+	return r.GetUserByIdTx(ctx, nil, id)
+}
+
+// GetUserByIdTx 根据ID查询用户（传入事务时经事务查询，持有行锁的事务内不必再占用连接池的其他连接）
+func (r *userRepoImpl) GetUserByIdTx(ctx context.Context, tx *sql.Tx, id uint64) (*model.User, error) {
 	// 1. 显式指定所有字段（与数据库/Model完全对齐，避免SELECT *的坑）
 	sqlStr := `
-		SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date
+		SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date, status
 		FROM users 
 		WHERE id = ? 
 		LIMIT 1
 	`
 
-	// 2. 执行查询（带Context，支持超时/取消）
-	row := r.db.QueryRowContext(ctx, sqlStr, id)
+	// 2. 执行查询（带Context，支持超时/取消；有事务时走事务连接）
+	queryRowFunc := r.db.QueryRowContext
+	if tx != nil {
+		queryRowFunc = tx.QueryRowContext
+	}
+	row := queryRowFunc(ctx, sqlStr, id)
 	if row.Err() != nil {
 		return nil, fmt.Errorf("查询用户失败：%w", row.Err())
 	}
@@ -430,6 +487,7 @@ func (r *userRepoImpl) GetUserById(ctx context.Context, id uint64) (*model.User,
 		username     string
 		passwordHash string
 		role         string
+		status       string
 		// 可空字段（先用sql.NullString接收，再转换为*string）
 		email     sql.NullString
 		phone     sql.NullString
@@ -453,10 +511,11 @@ func (r *userRepoImpl) GetUserById(ctx context.Context, id uint64) (*model.User,
 		&realName,
 		&gender,
 		&birthDate,
+		&status,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("用户ID不存在") // 友好的业务错误
+			return nil, apperr.ErrUserNotFound.WithMsg("用户ID不存在") // 友好的业务错误
 		}
 		return nil, fmt.Errorf("扫描用户数据失败：%w", err) // 包装系统错误
 	}
@@ -469,6 +528,7 @@ func (r *userRepoImpl) GetUserById(ctx context.Context, id uint64) (*model.User,
 		PasswordHash: passwordHash,
 		Role:         role,
 		BirthDate:    birthDate, // 直接赋值（类型一致）
+		Status:       status,
 	}
 
 	// 6. 处理可空字段（Valid=true则赋值*string，否则为nil）
@@ -501,7 +561,7 @@ func (u *userRepoImpl) FindByemail(user *model.User) (*model.User, error) {
 }
 func (r *userRepoImpl) GetByemail(ctx context.Context, Email string) (*model.User, error) {
 	sqlStr := `
-		SELECT id, uuid, username, phone, password_hash, role, avatar_url, real_name, gender, birth_date
+		SELECT id, uuid, username, email, phone, password_hash, role, avatar_url, real_name, gender, birth_date, status, email_verified
 		FROM users 
 		WHERE email = ? 
 		LIMIT 1
@@ -521,6 +581,8 @@ func (r *userRepoImpl) GetByemail(ctx context.Context, Email string) (*model.Use
 		username     string
 		passwordHash string
 		role         string
+		status       string
+		verified     bool
 		// 可空字段（先用sql.NullString接收，再转换为*string）
 		email     sql.NullString
 		phone     sql.NullString
@@ -536,6 +598,7 @@ func (r *userRepoImpl) GetByemail(ctx context.Context, Email string) (*model.Use
 		&userID,
 		&uuid,
 		&username,
+		&email,
 		&phone,
 		&passwordHash,
 		&role,
@@ -543,22 +606,26 @@ func (r *userRepoImpl) GetByemail(ctx context.Context, Email string) (*model.Use
 		&realName,
 		&gender,
 		&birthDate,
+		&status,
+		&verified,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("用户ID不存在") // 友好的业务错误
+			return nil, apperr.ErrUserNotFound.WithMsg("用户ID不存在") // 友好的业务错误
 		}
 		return nil, fmt.Errorf("扫描用户数据失败：%w", err) // 包装系统错误
 	}
 
 	// 5. 转换临时变量为model.User（适配*string类型）
 	user := &model.User{
-		ID:           userID,
-		UUID:         uuid,
-		Username:     username,
-		PasswordHash: passwordHash,
-		Role:         role,
-		BirthDate:    birthDate, // 直接赋值（类型一致）
+		ID:            userID,
+		UUID:          uuid,
+		Username:      username,
+		PasswordHash:  passwordHash,
+		Role:          role,
+		BirthDate:     birthDate, // 直接赋值（类型一致）
+		Status:        status,
+		EmailVerified: verified,
 	}
 
 	// 6. 处理可空字段（Valid=true则赋值*string，否则为nil）
@@ -580,3 +647,163 @@ func (r *userRepoImpl) GetByemail(ctx context.Context, Email string) (*model.Use
 
 	return user, nil
 }
+
+// userFilter 构建ListUsers/CountUsers共用的WHERE条件
+func userFilter(keyword, role, status string) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if keyword != "" {
+		conds = append(conds, "(username LIKE ? OR IFNULL(email, '') LIKE ? OR IFNULL(phone, '') LIKE ?)")
+		likeKeyword := fmt.Sprintf("%%%s%%", keyword)
+		args = append(args, likeKeyword, likeKeyword, likeKeyword)
+	}
+	if role != "" {
+		conds = append(conds, "role = ?")
+		args = append(args, role)
+	}
+	if status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, status)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ListUsers 分页查询用户（按ID倒序，新注册用户在前）
+func (r *userRepoImpl) ListUsers(ctx context.Context, keyword, role, status string, offset, limit int) ([]*model.User, error) {
+	where, args := userFilter(keyword, role, status)
+	sqlStr := `
+	SELECT id, uuid, username, email, phone, role, avatar_url, real_name, status
+	FROM users` + where + `
+	ORDER BY id DESC
+	LIMIT ?, ?
+	`
+	args = append(args, offset, limit)
+
+	rows, err := r.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return nil, fmt.Errorf("分页查询用户失败：MySQL错误[%d] %s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return nil, fmt.Errorf("分页查询用户失败：%w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(
+			&user.ID,
+			&user.UUID,
+			&user.Username,
+			&user.Email,
+			&user.Phone,
+			&user.Role,
+			&user.AvatarURL,
+			&user.RealName,
+			&user.Status,
+		); err != nil {
+			return nil, fmt.Errorf("扫描用户数据失败：%w", err)
+		}
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历用户结果集失败：%w", err)
+	}
+	return users, nil
+}
+
+// CountUsers 统计用户数
+func (r *userRepoImpl) CountUsers(ctx context.Context, keyword, role, status string) (int64, error) {
+	where, args := userFilter(keyword, role, status)
+	var total int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("统计用户总数失败：%w", err)
+	}
+	return total, nil
+}
+
+// LockUser 锁定用户行并返回角色/状态/密码哈希（同一用户的管理操作、修改密码由此串行化）
+func (r *userRepoImpl) LockUser(ctx context.Context, tx *sql.Tx, uuid string) (*model.User, error) {
+	if tx == nil {
+		return nil, errors.New("锁定用户必须在事务内执行")
+	}
+
+	var user model.User
+	err := tx.QueryRowContext(ctx, `SELECT id, uuid, username, password_hash, role, status FROM users WHERE uuid = ? FOR UPDATE`, uuid).Scan(
+		&user.ID,
+		&user.UUID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.Status,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	return &user, nil
+}
+
+// UpdateRole 修改用户角色
+func (r *userRepoImpl) UpdateRole(ctx context.Context, tx *sql.Tx, uuid, role string) error {
+	return r.updateColumn(ctx, tx, `UPDATE users SET role = ? WHERE uuid = ? LIMIT 1`, role, uuid)
+}
+
+// UpdateStatus 修改账号状态
+func (r *userRepoImpl) UpdateStatus(ctx context.Context, tx *sql.Tx, uuid, status string) error {
+	return r.updateColumn(ctx, tx, `UPDATE users SET status = ? WHERE uuid = ? LIMIT 1`, status, uuid)
+}
+
+// MarkEmailVerified 标记邮箱已验证（影响0行说明验证期间邮箱已被修改）
+func (r *userRepoImpl) MarkEmailVerified(ctx context.Context, uuid, email string) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET email_verified = 1 WHERE uuid = ? AND email = ? LIMIT 1`, uuid, email)
+	if err != nil {
+		return fmt.Errorf("更新邮箱验证状态失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取更新行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrVerifyCodeInvalid.WithMsg("账号邮箱已变更，请重新获取验证码")
+	}
+	return nil
+}
+
+// UpdatePassword 修改密码哈希
+func (r *userRepoImpl) UpdatePassword(ctx context.Context, tx *sql.Tx, uuid, passwordHash string) error {
+	return r.updateColumn(ctx, tx, `UPDATE users SET password_hash = ? WHERE uuid = ? LIMIT 1`, passwordHash, uuid)
+}
+
+// updateColumn 执行单列UPDATE（有tx用tx执行；影响0行说明用户不存在或值未变化）
+func (r *userRepoImpl) updateColumn(ctx context.Context, tx *sql.Tx, sqlStr, value, uuid string) error {
+	execFunc := r.db.ExecContext
+	if tx != nil {
+		execFunc = tx.ExecContext
+	}
+
+	result, err := execFunc(ctx, sqlStr, value, uuid)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			return fmt.Errorf("数据库错误（码：%d）：%s", mysqlErr.Number, mysqlErr.Message)
+		}
+		return fmt.Errorf("更新用户失败：%w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取更新行数失败：%w", err)
+	}
+	if rowsAffected == 0 {
+		return apperr.ErrUserNotFound.WithMsg("未找到该用户（UUID不存在）或数据无变化")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"CMS/internal/apperr"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestUserDuplicateError(t *testing.T) {
+	cases := []struct {
+		msg  string
+		want string
+	}{
+		{"Duplicate entry 'alice' for key 'users.idx_username'", "用户名已存在"},
+		{"Duplicate entry 'a@example.com' for key 'idx_email'", "邮箱已存在"},
+		{"Duplicate entry '13800000000' for key 'users.idx_phone'", "手机号已存在"},
+		{"Duplicate entry 'x' for key 'users.idx_uuid'", "用户名/手机号/邮箱已存在"},
+	}
+	for _, tc := range cases {
+		err := userDuplicateError(&mysql.MySQLError{Number: 1062, Message: tc.msg})
+		if !errors.Is(err, apperr.ErrAlreadyExists) {
+			t.Fatalf("%q：返回%v，期望ErrAlreadyExists", tc.msg, err)
+		}
+		appErr, _ := apperr.As(err)
+		if appErr.Msg != tc.want {
+			t.Errorf("%q：提示=%q，期望%q", tc.msg, appErr.Msg, tc.want)
+		}
+	}
+}
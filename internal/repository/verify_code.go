@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"CMS/internal/model"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// CodeStore 邮箱验证码存储接口（MySQL实现供多实例共享，内存实现供单实例/本地开发）
+// 校验与发送冷却均由存储层原子完成，避免并发请求绕过尝试次数和冷却限制
+type CodeStore interface {
+	// SaveCode 保存验证码（覆盖同一用途+邮箱的旧验证码，失败次数清零）
+	SaveCode(ctx context.Context, code *model.VerifyCode) error
+	// CheckCode 校验验证码：正确、过期或失败次数达到上限时删除记录，错误时失败次数+1
+	// 返回校验结果及剩余可尝试次数
+	CheckCode(ctx context.Context, purpose, email, codeHash string, maxAttempts int, now time.Time) (CodeCheckResult, int, error)
+	// DeleteCode 删除验证码（发送失败时撤销）
+	DeleteCode(ctx context.Context, purpose, email string) error
+	// AcquireCooldown 占用发送冷却：key不在冷却期内时设置冷却到until并返回true；否则返回false及冷却结束时间
+	AcquireCooldown(ctx context.Context, key string, until, now time.Time) (bool, time.Time, error)
+	// ReleaseCooldown 释放发送冷却（发送失败时撤销）
+	ReleaseCooldown(ctx context.Context, key string) error
+	// PurgeExpired 清理过期的验证码与冷却记录，返回清理条数
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// CodeCheckResult 验证码校验结果
+type CodeCheckResult int
+
+const (
+	CodeMatched         CodeCheckResult = iota // 验证通过（记录已删除，不可重复使用）
+	CodeNotFound                               // 未发送或已被使用
+	CodeExpired                                // 已过期（记录已删除）
+	CodeMismatch                               // 验证码错误（失败次数+1）
+	CodeTooManyAttempts                        // 失败次数达到上限（记录已删除，需重新获取）
+)
+
+// evaluateCode 按存储中的记录判断校验结果（MySQL与内存实现共用）
+// 返回校验结果、剩余次数及记录应如何处理：删除或失败次数+1
+func evaluateCode(code *model.VerifyCode, codeHash string, maxAttempts int, now time.Time) (result CodeCheckResult, left int, remove bool) {
+	switch {
+	case code == nil:
+		return CodeNotFound, 0, false
+	case !code.ExpiresAt.After(now):
+		return CodeExpired, 0, true
+	case code.Attempts >= maxAttempts:
+		return CodeTooManyAttempts, 0, true
+	case subtle.ConstantTimeCompare([]byte(code.CodeHash), []byte(codeHash)) == 1:
+		return CodeMatched, maxAttempts - code.Attempts, true
+	}
+	left = maxAttempts - code.Attempts - 1
+	if left <= 0 {
+		return CodeTooManyAttempts, 0, true
+	}
+	return CodeMismatch, left, false
+}
+
+// codeStoreImpl CodeStore的MySQL实现（verify_codes验证码表、verify_code_cooldowns发送冷却表）
+type codeStoreImpl struct {
+	db *sql.DB
+}
+
+// NewCodeStore 创建MySQL验证码存储
+func NewCodeStore(db *sql.DB) CodeStore {
+	return &codeStoreImpl{db: db}
+}
+
+// SaveCode 保存验证码（同一用途+邮箱主键冲突时覆盖）
+func (r *codeStoreImpl) SaveCode(ctx context.Context, code *model.VerifyCode) error {
+	sqlStr := `
+	INSERT INTO verify_codes (purpose, email, code_hash, attempts, expires_at, create_time)
+	VALUES (?, ?, ?, 0, ?, ?)
+	ON DUPLICATE KEY UPDATE code_hash = VALUES(code_hash), attempts = 0, expires_at = VALUES(expires_at), create_time = VALUES(create_time)
+	`
+	if _, err := r.db.ExecContext(ctx, sqlStr, code.Purpose, code.Email, code.CodeHash, code.ExpiresAt, code.CreateTime); err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1406 {
+			return fmt.Errorf("验证码字段长度超过限制：%s", mysqlErr.Message)
+		}
+		return fmt.Errorf("保存验证码失败：%w", err)
+	}
+	return nil
+}
+
+// CheckCode 事务内锁定验证码记录后校验（同一邮箱的并发校验由行锁串行化）
+func (r *codeStoreImpl) CheckCode(ctx context.Context, purpose, email, codeHash string, maxAttempts int, now time.Time) (CodeCheckResult, int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return CodeNotFound, 0, fmt.Errorf("开启验证码校验事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var code model.VerifyCode
+	err = tx.QueryRowContext(ctx, `
+	SELECT purpose, email, code_hash, attempts, expires_at, create_time
+	FROM verify_codes
+	WHERE purpose = ? AND email = ?
+	FOR UPDATE
+	`, purpose, email).Scan(&code.Purpose, &code.Email, &code.CodeHash, &code.Attempts, &code.ExpiresAt, &code.CreateTime)
+	var found *model.VerifyCode
+	switch {
+	case err == nil:
+		found = &code
+	case !errors.Is(err, sql.ErrNoRows):
+		return CodeNotFound, 0, fmt.Errorf("查询验证码失败：%w", err)
+	}
+
+	result, left, remove := evaluateCode(found, codeHash, maxAttempts, now)
+	if found == nil {
+		return result, left, nil
+	}
+	if remove {
+		_, err = tx.ExecContext(ctx, `DELETE FROM verify_codes WHERE purpose = ? AND email = ?`, purpose, email)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE verify_codes SET attempts = attempts + 1 WHERE purpose = ? AND email = ?`, purpose, email)
+	}
+	if err != nil {
+		return CodeNotFound, 0, fmt.Errorf("更新验证码状态失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return CodeNotFound, 0, fmt.Errorf("提交验证码校验事务失败：%w", err)
+	}
+	return result, left, nil
+}
+
+// DeleteCode 删除验证码
+func (r *codeStoreImpl) DeleteCode(ctx context.Context, purpose, email string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM verify_codes WHERE purpose = ? AND email = ?`, purpose, email); err != nil {
+		return fmt.Errorf("删除验证码失败：%w", err)
+	}
+	return nil
+}
+
+// AcquireCooldown 占用发送冷却：插入或仅在原冷却已结束时更新，影响行数为0表示仍在冷却期内
+func (r *codeStoreImpl) AcquireCooldown(ctx context.Context, key string, until, now time.Time) (bool, time.Time, error) {
+	result, err := r.db.ExecContext(ctx, `
+	INSERT INTO verify_code_cooldowns (cooldown_key, until) VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE until = IF(until <= ?, VALUES(until), until)
+	`, key, until, now)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("设置发送冷却失败：%w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("获取发送冷却结果失败：%w", err)
+	}
+	if affected > 0 {
+		return true, until, nil
+	}
+
+	var current time.Time
+	if err := r.db.QueryRowContext(ctx, `SELECT until FROM verify_code_cooldowns WHERE cooldown_key = ?`, key).Scan(&current); err != nil {
+		return false, time.Time{}, fmt.Errorf("查询发送冷却失败：%w", err)
+	}
+	return false, current, nil
+}
+
+// ReleaseCooldown 释放发送冷却
+func (r *codeStoreImpl) ReleaseCooldown(ctx context.Context, key string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM verify_code_cooldowns WHERE cooldown_key = ?`, key); err != nil {
+		return fmt.Errorf("释放发送冷却失败：%w", err)
+	}
+	return nil
+}
+
+// PurgeExpired 清理过期的验证码与冷却记录
+func (r *codeStoreImpl) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	var total int64
+	for _, sqlStr := range []string{
+		`DELETE FROM verify_codes WHERE expires_at <= ?`,
+		`DELETE FROM verify_code_cooldowns WHERE until <= ?`,
+	} {
+		result, err := r.db.ExecContext(ctx, sqlStr, now)
+		if err != nil {
+			return total, fmt.Errorf("清理过期验证码失败：%w", err)
+		}
+		n, _ := result.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
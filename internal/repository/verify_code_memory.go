@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"CMS/internal/model"
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryCodeStore CodeStore的内存实现（进程内有效，重启即丢失；多实例部署请使用MySQL实现）
+type memoryCodeStore struct {
+	mu        sync.Mutex
+	codes     map[string]*model.VerifyCode // purpose+email → 验证码
+	cooldowns map[string]time.Time         // 冷却key → 冷却结束时间
+}
+
+// NewMemoryCodeStore 创建内存验证码存储
+func NewMemoryCodeStore() CodeStore {
+	return &memoryCodeStore{
+		codes:     make(map[string]*model.VerifyCode),
+		cooldowns: make(map[string]time.Time),
+	}
+}
+
+// codeKey 内存map的key
+func codeKey(purpose, email string) string {
+	return purpose + "|" + email
+}
+
+// SaveCode 保存验证码（覆盖旧验证码）
+func (s *memoryCodeStore) SaveCode(_ context.Context, code *model.VerifyCode) error {
+	saved := *code
+	saved.Attempts = 0
+	s.mu.Lock()
+	s.codes[codeKey(code.Purpose, code.Email)] = &saved
+	s.mu.Unlock()
+	return nil
+}
+
+// CheckCode 校验验证码（整个判断过程持有锁）
+func (s *memoryCodeStore) CheckCode(_ context.Context, purpose, email, codeHash string, maxAttempts int, now time.Time) (CodeCheckResult, int, error) {
+	key := codeKey(purpose, email)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := s.codes[key]
+	result, left, remove := evaluateCode(code, codeHash, maxAttempts, now)
+	switch {
+	case code == nil:
+	case remove:
+		delete(s.codes, key)
+	default:
+		code.Attempts++
+	}
+	return result, left, nil
+}
+
+// DeleteCode 删除验证码
+func (s *memoryCodeStore) DeleteCode(_ context.Context, purpose, email string) error {
+	s.mu.Lock()
+	delete(s.codes, codeKey(purpose, email))
+	s.mu.Unlock()
+	return nil
+}
+
+// AcquireCooldown 占用发送冷却
+func (s *memoryCodeStore) AcquireCooldown(_ context.Context, key string, until, now time.Time) (bool, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.cooldowns[key]; ok && current.After(now) {
+		return false, current, nil
+	}
+	s.cooldowns[key] = until
+	return true, until, nil
+}
+
+// ReleaseCooldown 释放发送冷却
+func (s *memoryCodeStore) ReleaseCooldown(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.cooldowns, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// PurgeExpired 清理过期的验证码与冷却记录
+func (s *memoryCodeStore) PurgeExpired(_ context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total int64
+	for key, code := range s.codes {
+		if !code.ExpiresAt.After(now) {
+			delete(s.codes, key)
+			total++
+		}
+	}
+	for key, until := range s.cooldowns {
+		if !until.After(now) {
+			delete(s.cooldowns, key)
+			total++
+		}
+	}
+	return total, nil
+}
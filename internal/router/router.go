@@ -1,29 +1,31 @@
 package router
 
 import (
+	"CMS/internal/config"
 	"CMS/internal/handler"
 	"CMS/internal/middleware"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRouter 初始化路由
-func SetupRouter(staffHandler *handler.StaffHandler) *gin.Engine {
-	r := gin.Default()
-	r.Use(middleware.Cors())
+// SetupRouter 初始化路由（payment.mock_enabled为false时不注册模拟支付接口）
+func SetupRouter(staffHandler *handler.StaffHandler, healthHandler *handler.HealthHandler, cfg *config.Config) (*gin.Engine, error) {
+	r, err := newEngine(cfg.Server.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	mockPayEnabled := cfg.Payment.MockEnabled
+	r.Use(middleware.RequestLogger(), middleware.Metrics(), middleware.ErrorHandler(), gin.CustomRecovery(middleware.RecoveryHandler), middleware.Cors())
 
 	// 静态资源（存放前端CSS/JS/图片）
 	r.Static("/static", "./static")
 	r.StaticFile("/favicon.ico", "favicon.ico")
 	r.LoadHTMLGlob("templates/*") // 加载前端页面模板
-	// 根路由
-	r.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "公式面试网站接口服务正常",
-		})
-	})
+	// 存活/就绪探针（均检查MySQL连通性；优雅关闭期间就绪探针返回503）
+	r.GET("/healthz", healthHandler.Healthz)
+	r.GET("/readyz", healthHandler.Readyz)
 	page := r.Group("/page")
 	{
 		page.GET("/login", func(c *gin.Context) {
@@ -63,37 +65,93 @@ func SetupRouter(staffHandler *handler.StaffHandler) *gin.Engine {
 	{
 		staffGroup.POST("/register", staffHandler.Register)
 		staffGroup.POST("/login", staffHandler.Login)
+		staffGroup.POST("/login/2fa", staffHandler.LoginTwoFactorHandler)
 		staffGroup.POST("/elogin", staffHandler.Elogin)
 		staffGroup.POST("/elogin/res", staffHandler.Eres)
 		staffGroup.POST("/logout", staffHandler.Logout)
-		staffGroup.POST("/update", middleware.JWTMiddleware(), staffHandler.UpdateUserHandler)
-		staffGroup.POST("/update-avatar", middleware.JWTMiddleware(), staffHandler.UpdateAvatarHandler) // 更新头像
-		staffGroup.GET("/get-info", middleware.JWTMiddleware(), staffHandler.GetUserByUuid)
+		staffGroup.POST("/logout-all", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.LogoutAll)
+		staffGroup.POST("/refresh", staffHandler.Refresh)
+		staffGroup.GET("/login-attempts", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.LoginAttemptsHandler)
+		staffGroup.POST("/password/forgot", staffHandler.ForgotPasswordHandler)
+		staffGroup.POST("/password/reset", staffHandler.ResetPasswordHandler)
+		staffGroup.POST("/email/verify/send", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.SendEmailVerifyHandler)
+		staffGroup.POST("/email/verify/confirm", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.ConfirmEmailHandler)
+		staffGroup.POST("/password/change", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.ChangePasswordHandler)
+		staffGroup.GET("/2fa/status", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.TwoFactorStatusHandler)
+		staffGroup.POST("/2fa/setup", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.TwoFactorSetupHandler)
+		staffGroup.POST("/2fa/enable", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.TwoFactorEnableHandler)
+		staffGroup.POST("/2fa/disable", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.TwoFactorDisableHandler)
+		staffGroup.POST("/2fa/recovery-codes", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.TwoFactorRecoveryCodesHandler)
+		staffGroup.POST("/update", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.UpdateUserHandler)
+		staffGroup.POST("/update-avatar", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.UpdateAvatarHandler) // 更新头像
+		staffGroup.GET("/get-info", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermProfile), staffHandler.GetUserByUuid)
 
 	}
 	accountGroup := r.Group("/account")
 	{
-		accountGroup.GET("/get-account", middleware.JWTMiddleware(), staffHandler.GetAccountByUserUUID)
-		accountGroup.POST("/recharge", middleware.JWTMiddleware(), staffHandler.Recharge)
-		accountGroup.POST("/deduct", middleware.JWTMiddleware(), staffHandler.Deduct)
+		accountGroup.GET("/get-account", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermAccountView), staffHandler.GetAccountByUserUUID)
+		accountGroup.POST("/recharge", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermAccountRecharge), staffHandler.Recharge)
+		accountGroup.GET("/recharge/order", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermAccountView), staffHandler.GetRechargeOrder)
+		accountGroup.POST("/deduct", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermAccountDeduct), staffHandler.Deduct)
+		accountGroup.GET("/transactions", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermAccountView), staffHandler.GetTransactions)
+	}
+	// 支付渠道：回调无需登录（由渠道签名校验，未注册的渠道返回404），模拟支付仅在启用Mock渠道时注册
+	paymentGroup := r.Group("/payment")
+	{
+		paymentGroup.POST("/callback/:provider", staffHandler.PaymentCallback)
+		if mockPayEnabled {
+			paymentGroup.POST("/mock/pay", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermAccountRecharge), staffHandler.SimulatePayment)
+		}
 	}
 	resourceGroup := r.Group("/resource")
 	{
-		resourceGroup.POST("/create", middleware.JWTMiddleware(), staffHandler.CreateResourceHandler)
-		resourceGroup.POST("/list", middleware.JWTMiddleware(), staffHandler.ResourceListHandler)
-		resourceGroup.GET("/detail", staffHandler.ResourceDetailHandler)
-		resourceGroup.POST("/incr-view-count", staffHandler.IncrViewCountHandler)
-		resourceGroup.POST("/like", staffHandler.IncrLikeCountHandler)
-		resourceGroup.POST("/comment", staffHandler.CreateCommentHandler)
+		resourceGroup.POST("/create", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourcePublish), staffHandler.CreateResourceHandler)
+		resourceGroup.POST("/list", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourceView), staffHandler.ResourceListHandler)
+		resourceGroup.GET("/detail", middleware.OptionalJWTMiddleware(), staffHandler.ResourceDetailHandler)
+		resourceGroup.POST("/incr-view-count", middleware.OptionalJWTMiddleware(), staffHandler.IncrViewCountHandler)
+		resourceGroup.POST("/like", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourceView), staffHandler.LikeHandler)
+		resourceGroup.POST("/unlike", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourceView), staffHandler.UnlikeHandler)
+		resourceGroup.POST("/purchase", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourceView), staffHandler.PurchaseResourceHandler)
+		resourceGroup.POST("/price", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourcePublish), staffHandler.SetPriceHandler)
+		resourceGroup.POST("/meta", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermResourcePublish), staffHandler.UpdateResourceMetaHandler)
+		resourceGroup.GET("/taxonomy", staffHandler.TaxonomyHandler)
+		resourceGroup.POST("/comment", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermCommentWrite), staffHandler.CreateCommentHandler)
+		resourceGroup.GET("/comments", staffHandler.CommentListHandler)
+		resourceGroup.GET("/comments/tree", staffHandler.CommentTreeHandler)
+		resourceGroup.POST("/comment/update", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermCommentWrite), staffHandler.UpdateCommentHandler)
+		resourceGroup.POST("/comment/delete", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermCommentWrite), staffHandler.DeleteCommentHandler)
+		resourceGroup.GET("/comment/mentions", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermCommentWrite), staffHandler.MentionListHandler)
+		resourceGroup.POST("/comment/mentions/read", middleware.JWTMiddleware(), middleware.RequirePermission(middleware.PermCommentWrite), staffHandler.MarkMentionsReadHandler)
+	}
+	// 管理后台：仅管理员角色拥有admin:*权限，所有操作写入审计日志
+	adminGroup := r.Group("/admin", middleware.JWTMiddleware())
+	{
+		adminGroup.GET("/users", middleware.RequirePermission(middleware.PermAdminUsers), staffHandler.AdminListUsersHandler)
+		adminGroup.POST("/users/role", middleware.RequirePermission(middleware.PermAdminUsers), staffHandler.AdminSetUserRoleHandler)
+		adminGroup.POST("/users/status", middleware.RequirePermission(middleware.PermAdminUsers), staffHandler.AdminSetUserStatusHandler)
+		adminGroup.POST("/resources/hide", middleware.RequirePermission(middleware.PermAdminContent), staffHandler.AdminHideResourceHandler)
+		adminGroup.POST("/resources/delete", middleware.RequirePermission(middleware.PermAdminContent), staffHandler.AdminDeleteResourceHandler)
+		adminGroup.POST("/comments/hide", middleware.RequirePermission(middleware.PermAdminContent), staffHandler.AdminHideCommentHandler)
+		adminGroup.POST("/comments/delete", middleware.RequirePermission(middleware.PermAdminContent), staffHandler.AdminDeleteCommentHandler)
+		adminGroup.POST("/categories/create", middleware.RequirePermission(middleware.PermAdminContent), staffHandler.AdminCreateCategoryHandler)
+		adminGroup.POST("/categories/delete", middleware.RequirePermission(middleware.PermAdminContent), staffHandler.AdminDeleteCategoryHandler)
+		adminGroup.POST("/accounts/adjust", middleware.RequirePermission(middleware.PermAdminBalance), staffHandler.AdminAdjustBalanceHandler)
+		adminGroup.GET("/audit-logs", middleware.RequirePermission(middleware.PermAdminAudit), staffHandler.AdminAuditLogsHandler)
 	}
 	r.GET("/api/auth/verify-token", middleware.JWTMiddleware(), staffHandler.Checktoken) //检验token有效性
 	r.GET("get-letter", middleware.JWTMiddleware(), middleware.JWTMiddleware(), staffHandler.GetWordText)
-	r.GET("/test", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"code":    200,
-			"message": "success",
-			"data":    "This is a test",
-		})
-	})
-	return r
+	return r, nil
+}
+
+// newEngine 创建gin引擎并设置可信代理：c.ClientIP()只在连接对端属于trustedProxies时才采信X-Forwarded-For/X-Real-IP，
+// 否则直接使用对端IP（gin默认信任所有来源的转发头，客户端可随意伪造IP绕过按IP的登录锁定与发送冷却）
+func newEngine(trustedProxies []string) (*gin.Engine, error) {
+	r := gin.New()
+	if len(trustedProxies) == 0 {
+		trustedProxies = nil
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("设置可信代理失败：%w", err)
+	}
+	return r, nil
 }
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPOf 经newEngine创建的引擎处理一次请求，返回handler看到的c.ClientIP()（即登录锁定/发送冷却使用的IP）
+func clientIPOf(t *testing.T, trustedProxies []string, remoteAddr string, headers map[string]string) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r, err := newEngine(trustedProxies)
+	if err != nil {
+		t.Fatalf("newEngine返回错误：%v", err)
+	}
+	var got string
+	r.GET("/ip", func(c *gin.Context) { got = c.ClientIP() })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return got
+}
+
+func TestClientIPIgnoresSpoofedHeaders(t *testing.T) {
+	// 未配置可信代理：每次请求伪造不同的转发头，客户端IP（锁定key）保持为连接对端IP
+	for _, spoofed := range []map[string]string{
+		nil,
+		{"X-Forwarded-For": "1.1.1.1"},
+		{"X-Forwarded-For": "2.2.2.2, 3.3.3.3"},
+		{"X-Real-IP": "4.4.4.4"},
+	} {
+		if got := clientIPOf(t, nil, "203.0.113.7:5555", spoofed); got != "203.0.113.7" {
+			t.Errorf("转发头%v：ClientIP=%q，期望203.0.113.7", spoofed, got)
+		}
+	}
+}
+
+func TestClientIPTrustsConfiguredProxy(t *testing.T) {
+	proxies := []string{"10.0.0.0/8"}
+	headers := map[string]string{"X-Forwarded-For": "198.51.100.9"}
+
+	// 来自可信代理的转发头被采信
+	if got := clientIPOf(t, proxies, "10.1.2.3:4000", headers); got != "198.51.100.9" {
+		t.Errorf("可信代理转发：ClientIP=%q，期望198.51.100.9", got)
+	}
+	// 非可信来源直连时转发头被忽略
+	if got := clientIPOf(t, proxies, "203.0.113.7:4000", headers); got != "203.0.113.7" {
+		t.Errorf("非可信来源：ClientIP=%q，期望203.0.113.7", got)
+	}
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultSnippetLength 默认摘要长度（字符数）
+	DefaultSnippetLength = 120
+	// snippetLead 摘要中首个命中词之前最多保留的字符数（不超过摘要长度的1/3）
+	snippetLead = 20
+
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+)
+
+// Highlight 返回HTML转义后的text，检索词出现处（不区分大小写）用<mark>包裹
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	return render(runes, markTerms(runes, terms), 0, len(runes))
+}
+
+// Snippet 依次在texts中查找首个命中检索词的文本，截取命中处附近约maxRunes个字符作为摘要（HTML转义并高亮）
+// 均未命中时取第一个非空文本的开头；被截断的一端以省略号表示
+func Snippet(terms []string, maxRunes int, texts ...string) string {
+	if maxRunes <= 0 {
+		maxRunes = DefaultSnippetLength
+	}
+	var fallback []rune
+	for _, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		runes := []rune(text)
+		marks := markTerms(runes, terms)
+		for i, marked := range marks {
+			if marked {
+				start := max(0, i-min(snippetLead, maxRunes/3))
+				return render(runes, marks, start, min(len(runes), start+maxRunes))
+			}
+		}
+		if fallback == nil {
+			fallback = runes
+		}
+	}
+	if fallback == nil {
+		return ""
+	}
+	return render(fallback, nil, 0, min(len(fallback), maxRunes))
+}
+
+// markTerms 标记runes中属于检索词的位置
+func markTerms(runes []rune, terms []string) []bool {
+	marks := make([]bool, len(runes))
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	for _, term := range terms {
+		t := []rune(strings.ToLower(term))
+		if len(t) == 0 {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if equalRunes(lower[i:i+len(t)], t) {
+				for k := i; k < i+len(t); k++ {
+					marks[k] = true
+				}
+			}
+		}
+	}
+	return marks
+}
+
+func equalRunes(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// render 输出runes[start:end]：HTML转义，marks标记的连续片段包裹<mark>，截断处加省略号
+func render(runes []rune, marks []bool, start, end int) string {
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	open := false
+	for i := start; i < end; i++ {
+		marked := marks != nil && marks[i]
+		if marked != open {
+			if marked {
+				b.WriteString(markOpen)
+			} else {
+				b.WriteString(markClose)
+			}
+			open = marked
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+	}
+	if open {
+		b.WriteString(markClose)
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
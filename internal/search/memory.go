@@ -0,0 +1,414 @@
+package search
+
+import (
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRebuildInterval 内存索引默认全量重建间隔
+	DefaultRebuildInterval = 10 * time.Minute
+	// rebuildTimeout 单次全量重建（加载全部资源）的超时时间
+	rebuildTimeout = 2 * time.Minute
+
+	// BM25参数
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// MemoryIndex 进程内倒排索引（BM25排序，标题词频加权）
+// 启动时从resources表全量加载，发布/隐藏/删除资源时增量更新；多实例部署时其他实例的改动依赖定期全量重建同步
+type MemoryIndex struct {
+	db *sql.DB
+
+	mu      sync.RWMutex
+	state   *memState
+	journal []func(*memState) // 全量重建期间的增量操作，重建完成后在新索引上重放
+	loading bool
+}
+
+// NewMemoryIndex 创建空的内存索引（需调用Rebuild加载数据）
+func NewMemoryIndex(db *sql.DB) *MemoryIndex {
+	return &MemoryIndex{db: db, state: newMemState()}
+}
+
+// Search 按相关度分页检索（检索词分词后需全部命中）
+func (m *MemoryIndex) Search(_ context.Context, q Query) (*Result, error) {
+	tokens := queryTokens(q.Keyword)
+	if len(tokens) == 0 {
+		return &Result{}, nil
+	}
+
+	m.mu.RLock()
+	hits := m.state.search(tokens, q.Filter)
+	m.mu.RUnlock()
+
+	result := &Result{Total: int64(len(hits))}
+	if q.Offset < len(hits) {
+		result.Hits = hits[q.Offset:min(q.Offset+q.Limit, len(hits))]
+	}
+	return result, nil
+}
+
+// Facets 统计检索结果的分面（每个维度忽略自身的过滤条件）
+func (m *MemoryIndex) Facets(_ context.Context, q Query) (*repository.ResourceFacets, error) {
+	facets := &repository.ResourceFacets{
+		Languages:  make(map[string]int64),
+		Categories: make(map[uint64]int64),
+		Tags:       make(map[string]int64),
+	}
+	tokens := queryTokens(q.Keyword)
+	if len(tokens) == 0 {
+		return facets, nil
+	}
+
+	langFilter, categoryFilter, tagFilter := q.Filter, q.Filter, q.Filter
+	langFilter.Language = ""
+	categoryFilter.CategoryIDs = nil
+	tagFilter.Tag = ""
+
+	m.mu.RLock()
+	m.state.match(tokens, func(_ uint64, doc *memDoc, _ float64) {
+		if doc.Language != "" && langFilter.Match(doc.ResourceAttrs) {
+			facets.Languages[doc.Language]++
+		}
+		if doc.CategoryID != 0 && categoryFilter.Match(doc.ResourceAttrs) {
+			facets.Categories[doc.CategoryID]++
+		}
+		if tagFilter.Match(doc.ResourceAttrs) {
+			for _, tag := range doc.Tags {
+				facets.Tags[tag]++
+			}
+		}
+	})
+	m.mu.RUnlock()
+
+	if len(facets.Tags) > repository.FacetTagLimit {
+		tags := make([]string, 0, len(facets.Tags))
+		for tag := range facets.Tags {
+			tags = append(tags, tag)
+		}
+		slices.SortFunc(tags, func(a, b string) int {
+			if c := cmp.Compare(facets.Tags[b], facets.Tags[a]); c != 0 {
+				return c
+			}
+			return strings.Compare(a, b)
+		})
+		for _, tag := range tags[repository.FacetTagLimit:] {
+			delete(facets.Tags, tag)
+		}
+	}
+	return facets, nil
+}
+
+// queryTokens 关键词分词（去重）
+func queryTokens(keyword string) []string {
+	var tokens []string
+	for _, term := range Terms(keyword) {
+		tokens = append(tokens, tokenize(term, true)...)
+	}
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
+
+// Index 新增/更新资源索引
+func (m *MemoryIndex) Index(_ context.Context, doc *Document) error {
+	m.apply(func(s *memState) { s.add(doc) })
+	return nil
+}
+
+// Remove 删除资源索引
+func (m *MemoryIndex) Remove(_ context.Context, id uint64) error {
+	m.apply(func(s *memState) { s.remove(id) })
+	return nil
+}
+
+// apply 在当前索引上执行增量操作（重建中同时记入journal）
+func (m *MemoryIndex) apply(op func(*memState)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op(m.state)
+	if m.loading {
+		m.journal = append(m.journal, op)
+	}
+}
+
+// Rebuild 从resources表全量加载未隐藏的资源并替换当前索引（加载期间检索仍使用旧索引）
+func (m *MemoryIndex) Rebuild(ctx context.Context) error {
+	m.mu.Lock()
+	if m.loading {
+		m.mu.Unlock()
+		return nil
+	}
+	m.loading, m.journal = true, nil
+	m.mu.Unlock()
+
+	state, err := m.load(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		for _, op := range m.journal {
+			op(state)
+		}
+		m.state = state
+	}
+	m.loading, m.journal = false, nil
+	return err
+}
+
+// Run 后台定期全量重建（阻塞，ctx取消后返回）
+func (m *MemoryIndex) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRebuildInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rebuildCtx, cancel := context.WithTimeout(ctx, rebuildTimeout)
+			if err := m.Rebuild(rebuildCtx); err != nil && ctx.Err() == nil {
+				logger.FromContext(ctx).Error("[全文检索] 重建内存索引失败", "error", err)
+			}
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// load 读取全部未隐藏的资源及其标签并构建新索引
+func (m *MemoryIndex) load(ctx context.Context) (*memState, error) {
+	tags, err := m.loadTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, title, text_content, code_content, language, category_id, author, publish_time
+		FROM resources
+		WHERE hidden = 0
+	`)
+	if err != nil {
+		return nil, wrapMySQLErr("加载待索引资源失败", err)
+	}
+	defer rows.Close()
+
+	state := newMemState()
+	for rows.Next() {
+		var doc Document
+		var text, code sql.NullString
+		if err := rows.Scan(&doc.ID, &doc.Title, &text, &code, &doc.Language, &doc.CategoryID, &doc.Author, &doc.PublishTime); err != nil {
+			return nil, fmt.Errorf("扫描待索引资源失败：%w", err)
+		}
+		doc.Text, doc.Code = text.String, code.String
+		doc.Tags = tags[doc.ID]
+		state.add(&doc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历待索引资源失败：%w", err)
+	}
+	return state, nil
+}
+
+// loadTags 读取全部资源的标签（key为资源ID）
+func (m *MemoryIndex) loadTags(ctx context.Context) (map[uint64][]string, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT rt.resource_id, t.name
+		FROM resource_tags rt
+		JOIN tags t ON t.id = rt.tag_id
+	`)
+	if err != nil {
+		return nil, wrapMySQLErr("加载资源标签失败", err)
+	}
+	defer rows.Close()
+
+	tags := make(map[uint64][]string)
+	for rows.Next() {
+		var id uint64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("扫描资源标签失败：%w", err)
+		}
+		tags[id] = append(tags[id], name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历资源标签失败：%w", err)
+	}
+	return tags, nil
+}
+
+// Len 已索引的资源数
+func (m *MemoryIndex) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.state.docs)
+}
+
+// termFreq 词元在单个资源中的出现次数（标题/正文+代码分别统计）
+type termFreq struct {
+	title int
+	body  int
+}
+
+// weighted 加权词频（标题命中按titleBoost计）
+func (f termFreq) weighted() float64 {
+	return float64(titleBoost*f.title + f.body)
+}
+
+// memDoc 已索引的资源
+type memDoc struct {
+	repository.ResourceAttrs
+	terms  []string // 包含的词元（删除时用于清理倒排表）
+	length float64  // 加权文档长度
+}
+
+// memState 倒排索引数据（非并发安全，由MemoryIndex加锁访问）
+type memState struct {
+	docs     map[uint64]*memDoc
+	postings map[string]map[uint64]termFreq
+	totalLen float64
+}
+
+func newMemState() *memState {
+	return &memState{
+		docs:     make(map[uint64]*memDoc),
+		postings: make(map[string]map[uint64]termFreq),
+	}
+}
+
+// add 新增/覆盖资源
+func (s *memState) add(doc *Document) {
+	s.remove(doc.ID)
+
+	freqs := make(map[string]termFreq)
+	titleTokens := tokenize(doc.Title, false)
+	for _, t := range titleTokens {
+		f := freqs[t]
+		f.title++
+		freqs[t] = f
+	}
+	bodyTokens := append(tokenize(doc.Text, false), tokenize(doc.Code, false)...)
+	for _, t := range bodyTokens {
+		f := freqs[t]
+		f.body++
+		freqs[t] = f
+	}
+
+	d := &memDoc{
+		ResourceAttrs: doc.ResourceAttrs,
+		terms:         make([]string, 0, len(freqs)),
+		length:        float64(titleBoost*len(titleTokens) + len(bodyTokens)),
+	}
+	d.Tags = slices.Clone(doc.Tags)
+	for t, f := range freqs {
+		d.terms = append(d.terms, t)
+		posting, ok := s.postings[t]
+		if !ok {
+			posting = make(map[uint64]termFreq)
+			s.postings[t] = posting
+		}
+		posting[doc.ID] = f
+	}
+	s.docs[doc.ID] = d
+	s.totalLen += d.length
+}
+
+// remove 删除资源（不存在时忽略）
+func (s *memState) remove(id uint64) {
+	d, ok := s.docs[id]
+	if !ok {
+		return
+	}
+	for _, t := range d.terms {
+		posting := s.postings[t]
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(s.postings, t)
+		}
+	}
+	delete(s.docs, id)
+	s.totalLen -= d.length
+}
+
+// search 取全部词元都命中且满足过滤条件的资源，按BM25得分降序（同分按发布时间倒序）
+func (s *memState) search(tokens []string, filter repository.ResourceFilter) []Hit {
+	var hits []Hit
+	s.match(tokens, func(id uint64, doc *memDoc, score float64) {
+		if filter.Match(doc.ResourceAttrs) {
+			hits = append(hits, Hit{ID: id, Score: score})
+		}
+	})
+
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		if c := s.docs[b.ID].PublishTime.Compare(s.docs[a.ID].PublishTime); c != 0 {
+			return c
+		}
+		if a.ID > b.ID {
+			return -1
+		}
+		return 1
+	})
+	return hits
+}
+
+// match 遍历全部词元都命中的资源（不做过滤），fn接收资源及其BM25得分
+func (s *memState) match(tokens []string, fn func(id uint64, doc *memDoc, score float64)) {
+	postings := make([]map[uint64]termFreq, len(tokens))
+	for i, t := range tokens {
+		postings[i] = s.postings[t]
+		if len(postings[i]) == 0 {
+			return
+		}
+	}
+	// 从最短的倒排表开始求交集
+	slices.SortFunc(postings, func(a, b map[uint64]termFreq) int { return len(a) - len(b) })
+
+	n := float64(len(s.docs))
+	avgLen := s.totalLen / n
+	idf := make([]float64, len(postings))
+	for i, p := range postings {
+		df := float64(len(p))
+		idf[i] = math.Log(1 + (n-df+0.5)/(df+0.5))
+	}
+
+	for id, first := range postings[0] {
+		doc := s.docs[id]
+		norm := bm25K1 * (1 - bm25B + bm25B*doc.length/avgLen)
+		score := idf[0] * bm25(first.weighted(), norm)
+		matched := true
+		for i := 1; i < len(postings); i++ {
+			f, ok := postings[i][id]
+			if !ok {
+				matched = false
+				break
+			}
+			score += idf[i] * bm25(f.weighted(), norm)
+		}
+		if matched {
+			fn(id, doc, score)
+		}
+	}
+}
+
+// bm25 单个词元的BM25词频项
+func bm25(tf, norm float64) float64 {
+	return tf * (bm25K1 + 1) / (tf + norm)
+}
@@ -0,0 +1,131 @@
+package search
+
+import (
+	"CMS/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLIndex 基于resources表ngram FULLTEXT索引的检索实现（索引由migrate 0009创建，随数据写入自动维护）
+// 过滤：BOOLEAN MODE，每个检索词都必须命中（词长不小于ngram_token_size时按短语匹配，单字按前缀匹配）
+// 排序：NATURAL LANGUAGE MODE相关度，标题得分加权
+type MySQLIndex struct {
+	db *sql.DB
+}
+
+// NewMySQLIndex 创建MySQL全文检索实例
+func NewMySQLIndex(db *sql.DB) *MySQLIndex {
+	return &MySQLIndex{db: db}
+}
+
+// Search 按相关度分页检索
+func (m *MySQLIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	boolean, natural := booleanQuery(Terms(q.Keyword))
+	if boolean == "" {
+		return &Result{}, nil
+	}
+
+	filterSQL, filterArgs := q.Filter.Where()
+	where := `hidden = 0` + matchCond + filterSQL
+	whereArgs := append([]interface{}{boolean}, filterArgs...)
+
+	var total int64
+	if err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM resources WHERE `+where, whereArgs...).Scan(&total); err != nil {
+		return nil, wrapMySQLErr("统计检索结果失败", err)
+	}
+	if total == 0 || int64(q.Offset) >= total {
+		return &Result{Total: total}, nil
+	}
+
+	sqlStr := fmt.Sprintf(`
+		SELECT id, MATCH(title) AGAINST(? IN NATURAL LANGUAGE MODE) * %d
+			+ MATCH(title, text_content, code_content) AGAINST(? IN NATURAL LANGUAGE MODE) AS score
+		FROM resources
+		WHERE %s
+		ORDER BY score DESC, publish_time DESC, id DESC
+		LIMIT ?, ?
+	`, titleBoost, where)
+	args := append([]interface{}{natural, natural}, whereArgs...)
+	args = append(args, q.Offset, q.Limit)
+	rows, err := m.db.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, wrapMySQLErr("全文检索失败", err)
+	}
+	defer rows.Close()
+
+	result := &Result{Total: total}
+	for rows.Next() {
+		var hit Hit
+		if err := rows.Scan(&hit.ID, &hit.Score); err != nil {
+			return nil, fmt.Errorf("扫描检索结果失败：%w", err)
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历检索结果失败：%w", err)
+	}
+	return result, nil
+}
+
+// Facets 统计检索结果的分面（检索条件与Search一致）
+func (m *MySQLIndex) Facets(ctx context.Context, q Query) (*repository.ResourceFacets, error) {
+	boolean, _ := booleanQuery(Terms(q.Keyword))
+	if boolean == "" {
+		return &repository.ResourceFacets{}, nil
+	}
+	return repository.QueryFacets(ctx, m.db, matchCond, []interface{}{boolean}, q.Filter)
+}
+
+// Index FULLTEXT索引随resources表写入自动维护，无需额外操作
+func (m *MySQLIndex) Index(context.Context, *Document) error {
+	return nil
+}
+
+// Remove 同Index，hidden条件在查询时过滤
+func (m *MySQLIndex) Remove(context.Context, uint64) error {
+	return nil
+}
+
+// matchCond 全文检索过滤条件（参数为booleanQuery生成的BOOLEAN MODE表达式）
+const matchCond = ` AND MATCH(title, text_content, code_content) AGAINST(? IN BOOLEAN MODE)`
+
+// booleanOperators BOOLEAN MODE的运算符，作为普通字符出现在检索词中时去除
+const booleanOperators = `+-<>()~*"@`
+
+// booleanQuery 构造BOOLEAN MODE过滤表达式（每个词前加+）及NATURAL LANGUAGE MODE排序表达式
+func booleanQuery(terms []string) (boolean, natural string) {
+	var b, n []string
+	for _, term := range terms {
+		term = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(booleanOperators, r) {
+				return -1
+			}
+			return r
+		}, term)
+		if term == "" {
+			continue
+		}
+		n = append(n, term)
+		// 短于ngram_token_size（默认2）的词没有对应的ngram词元，只能按前缀匹配
+		if runeLen(term) < 2 {
+			b = append(b, "+"+term+"*")
+		} else {
+			b = append(b, `+"`+term+`"`)
+		}
+	}
+	return strings.Join(b, " "), strings.Join(n, " ")
+}
+
+// wrapMySQLErr 包装MySQL错误（与repository层错误信息格式一致）
+func wrapMySQLErr(action string, err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return fmt.Errorf("%s：MySQL错误[%d] %s", action, mysqlErr.Number, mysqlErr.Message)
+	}
+	return fmt.Errorf("%s：%w", action, err)
+}
@@ -0,0 +1,98 @@
+// Package search 资源全文检索：MySQL FULLTEXT（ngram分词）与进程内倒排索引两种实现，由config.search.driver选择
+package search
+
+import (
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTerms 单次检索最多使用的关键词个数（按空白切分，超出部分忽略）
+	MaxTerms = 10
+	// titleBoost 标题命中的相关度权重（相对正文/代码）
+	titleBoost = 3
+)
+
+// Document 待索引的资源（只包含参与检索、过滤与分面统计的字段）
+type Document struct {
+	ID    uint64
+	Title string
+	Text  string
+	Code  string
+	repository.ResourceAttrs
+}
+
+// NewDocument 由资源模型构造索引文档
+func NewDocument(res *model.Resource) *Document {
+	return &Document{
+		ID:    res.ID,
+		Title: res.Title,
+		Text:  res.TextContent,
+		Code:  res.CodeContent,
+		ResourceAttrs: repository.ResourceAttrs{
+			Author:      res.Author,
+			PublishTime: res.PublishTime,
+			Language:    res.Language,
+			CategoryID:  res.CategoryID,
+			Tags:        res.Tags,
+		},
+	}
+}
+
+// Query 检索条件
+type Query struct {
+	Keyword string                    // 关键词（空白分隔的多个词需同时命中）
+	Filter  repository.ResourceFilter // 作者/发布时间/语言/分类/标签过滤
+	Offset  int
+	Limit   int
+}
+
+// Hit 单条命中结果
+type Hit struct {
+	ID    uint64
+	Score float64 // 相关度得分（仅用于同一次检索内的排序比较，不同实现的得分不可比）
+}
+
+// Result 检索结果（Hits按相关度从高到低排列，Total为满足条件的总条数）
+type Result struct {
+	Hits  []Hit
+	Total int64
+}
+
+// SearchIndex 资源全文检索接口（只包含未隐藏的资源）
+type SearchIndex interface {
+	// Search 按相关度分页检索
+	Search(ctx context.Context, q Query) (*Result, error)
+	// Facets 统计检索结果的语言/分类/标签分面（忽略q.Offset/q.Limit，语义同repository.QueryFacets）
+	Facets(ctx context.Context, q Query) (*repository.ResourceFacets, error)
+	// Index 新增/更新资源索引（资源发布、取消隐藏后调用）
+	Index(ctx context.Context, doc *Document) error
+	// Remove 删除资源索引（资源隐藏、删除后调用）
+	Remove(ctx context.Context, id uint64) error
+}
+
+// Terms 将关键词按空白切分为检索词（去重，最多MaxTerms个）
+func Terms(keyword string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.Fields(keyword) {
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == MaxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// runeLen 字符数（按Unicode码点计）
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
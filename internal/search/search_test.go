@@ -0,0 +1,255 @@
+package search
+
+import (
+	"CMS/internal/repository"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		name     string
+		text     string
+		forQuery bool
+		want     []string
+	}{
+		{"中文文档输出单字与二元组", "并发编程", false, []string{"并", "发", "编", "程", "并发", "发编", "编程"}},
+		{"中文检索词只输出二元组", "并发编程", true, []string{"并发", "发编", "编程"}},
+		{"单字检索词", "锁", true, []string{"锁"}},
+		{"中英混排", "Go语言sync.Mutex", true, []string{"go", "语言", "sync", "mutex"}},
+		{"中英混排文档", "用Redis锁", false, []string{"用", "redis", "锁"}},
+		{"下划线标识符与数字", "max_open_conns=20", false, []string{"max_open_conns", "20"}},
+		{"日文假名按CJK切分", "テスト", true, []string{"テス", "スト"}},
+		{"标点与空白", "  ，。!? ", false, nil},
+		{"超长词元不入索引", "key " + strings.Repeat("a", maxTokenLen+1), false, []string{"key"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tokenize(tc.text, tc.forQuery); !slices.Equal(got, tc.want) {
+				t.Errorf("tokenize(%q, %v)=%q，期望%q", tc.text, tc.forQuery, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTerms(t *testing.T) {
+	if got := Terms("  Go  go 并发\tGO 并发 "); !slices.Equal(got, []string{"Go", "并发"}) {
+		t.Errorf("Terms去重结果=%q，期望[Go 并发]", got)
+	}
+	if got := Terms("a b c d e f g h i j k l"); len(got) != MaxTerms {
+		t.Errorf("检索词个数=%d，期望最多%d个", len(got), MaxTerms)
+	}
+}
+
+// newTestIndex 创建只包含给定资源的内存索引（不连接数据库）
+func newTestIndex(t *testing.T, docs ...*Document) *MemoryIndex {
+	t.Helper()
+	m := NewMemoryIndex(nil)
+	for _, doc := range docs {
+		if err := m.Index(context.Background(), doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func searchIDs(t *testing.T, m *MemoryIndex, keyword string, filter repository.ResourceFilter) []uint64 {
+	t.Helper()
+	result, err := m.Search(context.Background(), Query{Keyword: keyword, Filter: filter, Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != int64(len(result.Hits)) {
+		t.Fatalf("Total=%d与命中数%d不一致", result.Total, len(result.Hits))
+	}
+	ids := make([]uint64, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestMemoryIndexRanking(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	doc := func(id uint64, title, text string, publish time.Time) *Document {
+		d := &Document{ID: id, Title: title, Text: text}
+		d.PublishTime = publish
+		return d
+	}
+	m := newTestIndex(t,
+		doc(1, "数据库事务", "介绍Go的并发模型与channel用法", day),
+		doc(2, "Go并发编程实战", "介绍数据库事务的隔离级别", day),
+		doc(3, "面试题汇总", "并发与goroutine：并发安全", day),
+		doc(4, "面试题汇总", "介绍Go的并发模型与channel用法", day.Add(time.Hour)),
+		doc(5, "面试题汇总", "介绍Go的并发模型与channel用法", day),
+		doc(6, "缓存设计", "Redis缓存穿透与雪崩", day),
+	)
+
+	cases := []struct {
+		keyword string
+		want    []uint64
+	}{
+		// 标题命中加权最高；正文词频高的其次；同分按发布时间倒序，再按ID倒序
+		{"并发", []uint64{2, 3, 4, 5, 1}},
+		// 多个检索词需同时命中
+		{"并发 channel", []uint64{4, 5, 1}},
+		// 中文短语按二元组近似匹配，"事务数据"不是原文中的相邻片段
+		{"数据库事务", []uint64{1, 2}},
+		{"事务数据", nil},
+		{"不存在的词", nil},
+		{"   ", nil},
+	}
+	for _, tc := range cases {
+		if got := searchIDs(t, m, tc.keyword, repository.ResourceFilter{}); !slices.Equal(got, tc.want) {
+			t.Errorf("检索%q：结果=%v，期望%v", tc.keyword, got, tc.want)
+		}
+	}
+
+	// 分页在排序之后进行
+	result, err := m.Search(context.Background(), Query{Keyword: "并发", Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 5 || len(result.Hits) != 2 || result.Hits[0].ID != 3 || result.Hits[1].ID != 4 {
+		t.Errorf("分页结果=%+v，期望Total=5且为[3 4]", result)
+	}
+}
+
+func TestMemoryIndexFilters(t *testing.T) {
+	doc := func(id uint64, author string, publish time.Time) *Document {
+		d := &Document{ID: id, Title: "Go并发", Text: "goroutine"}
+		d.Author, d.PublishTime = author, publish
+		return d
+	}
+	may1 := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	m := newTestIndex(t,
+		doc(1, "alice", may1),
+		doc(2, "bob", may1.AddDate(0, 0, 1)),
+		doc(3, "alice", may1.AddDate(0, 0, 2)),
+	)
+
+	cases := []struct {
+		name   string
+		filter repository.ResourceFilter
+		want   []uint64
+	}{
+		{"不过滤", repository.ResourceFilter{}, []uint64{3, 2, 1}},
+		{"按作者", repository.ResourceFilter{Author: "alice"}, []uint64{3, 1}},
+		{"作者精确匹配", repository.ResourceFilter{Author: "ali"}, []uint64{}},
+		{"开始日期含当天", repository.ResourceFilter{From: may1.AddDate(0, 0, 1)}, []uint64{3, 2}},
+		{"结束日期不含", repository.ResourceFilter{To: may1.AddDate(0, 0, 2)}, []uint64{2, 1}},
+		{"作者与日期组合", repository.ResourceFilter{Author: "alice", From: may1.AddDate(0, 0, 1)}, []uint64{3}},
+	}
+	for _, tc := range cases {
+		if got := searchIDs(t, m, "并发", tc.filter); !slices.Equal(got, tc.want) {
+			t.Errorf("%s：结果=%v，期望%v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryIndexRemoveHidden(t *testing.T) {
+	ctx := context.Background()
+	m := newTestIndex(t,
+		&Document{ID: 1, Title: "Go并发编程", Text: "goroutine调度"},
+		&Document{ID: 2, Title: "Java并发编程", Text: "线程池"},
+	)
+
+	// 资源被隐藏：从索引删除，相关词元的倒排表一并清理
+	if err := m.Remove(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, m, "并发", repository.ResourceFilter{}); !slices.Equal(got, []uint64{2}) {
+		t.Errorf("隐藏后检索结果=%v，期望[2]", got)
+	}
+	if got := searchIDs(t, m, "goroutine", repository.ResourceFilter{}); len(got) != 0 {
+		t.Errorf("隐藏资源独有的词仍可检索到：%v", got)
+	}
+	if _, ok := m.state.postings["goroutine"]; ok {
+		t.Error("隐藏资源独有的词元应从倒排表删除")
+	}
+	if m.Len() != 1 {
+		t.Errorf("索引资源数=%d，期望1", m.Len())
+	}
+	// 重复删除不报错
+	if err := m.Remove(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 取消隐藏后重新索引可再次检索到
+	if err := m.Index(ctx, &Document{ID: 1, Title: "Go并发编程", Text: "goroutine调度"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, m, "goroutine", repository.ResourceFilter{}); !slices.Equal(got, []uint64{1}) {
+		t.Errorf("重新索引后检索结果=%v，期望[1]", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	body := strings.Repeat("一", 50) + "并发" + strings.Repeat("二", 50)
+	cases := []struct {
+		name     string
+		terms    []string
+		maxRunes int
+		texts    []string
+		want     string
+	}{
+		{
+			// 命中处之前保留min(20, 30/3)=10个字符，总长30个字符，两端截断
+			"命中在中间", []string{"并发"}, 30, []string{body},
+			"…" + strings.Repeat("一", 10) + "<mark>并发</mark>" + strings.Repeat("二", 18) + "…",
+		},
+		{
+			"命中在开头不加前省略号", []string{"go"}, 10, []string{"Go并发编程与channel实践"},
+			"<mark>Go</mark>并发编程与cha…",
+		},
+		{
+			"摘要长度恰好到文本结尾", []string{"结尾"}, 6, []string{"开头中间结尾"},
+			"…中间<mark>结尾</mark>",
+		},
+		{
+			"高亮跨越摘要末尾时闭合标签", []string{"channel"}, 8, []string{"abcdefgchannel"},
+			"…fg<mark>channe</mark>…",
+		},
+		{
+			"标题未命中时取正文命中处", []string{"锁"}, 20, []string{"面试题", "互斥锁"},
+			"互斥<mark>锁</mark>",
+		},
+		{
+			"均未命中取第一个非空文本开头", []string{"redis"}, 4, []string{"  ", "一二三四五六"},
+			"一二三四…",
+		},
+		{
+			"HTML转义", []string{"map"}, 30, []string{"<b>map[string]int</b>"},
+			"&lt;b&gt;<mark>map</mark>[string]int&lt;/b&gt;",
+		},
+		{"全部为空", []string{"a"}, 10, []string{"", " "}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Snippet(tc.terms, tc.maxRunes, tc.texts...); got != tc.want {
+				t.Errorf("Snippet=%q\n期望%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	cases := []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{"Go并发与go协程", []string{"GO"}, "<mark>Go</mark>并发与<mark>go</mark>协程"},
+		{"并发编程", []string{"并发", "发编"}, "<mark>并发编</mark>程"},
+		{"a<b", []string{"<"}, "a<mark>&lt;</mark>b"},
+		{"无命中", nil, "无命中"},
+	}
+	for _, tc := range cases {
+		if got := Highlight(tc.text, tc.terms); got != tc.want {
+			t.Errorf("Highlight(%q, %q)=%q，期望%q", tc.text, tc.terms, got, tc.want)
+		}
+	}
+}
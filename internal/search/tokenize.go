@@ -0,0 +1,64 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxTokenLen 超过该长度的英文/数字词元不入索引（base64、哈希等长串没有检索价值）
+const maxTokenLen = 64
+
+// isCJK 中日韩文字（没有空格分词，按单字+二元组切分）
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// isWordRune 英文/数字词的组成字符（下划线视为词的一部分，便于检索代码标识符）
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') && !isCJK(r)
+}
+
+// tokenize 分词：英文/数字连续片段为一个词（转小写）；CJK连续片段按字切分
+// 文档分词（forQuery=false）输出全部单字与相邻二元组，单字检索词也能命中；
+// 检索词分词（forQuery=true）片段长度≥2时只输出二元组，要求全部命中，近似短语匹配
+func tokenize(text string, forQuery bool) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			tokens = appendCJK(tokens, runes[i:j], forQuery)
+			i = j
+		case isWordRune(r):
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			if j-i <= maxTokenLen {
+				tokens = append(tokens, strings.ToLower(string(runes[i:j])))
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// appendCJK CJK片段切分为单字/二元组
+func appendCJK(tokens []string, seg []rune, forQuery bool) []string {
+	if len(seg) == 1 || !forQuery {
+		for _, r := range seg {
+			tokens = append(tokens, string(r))
+		}
+	}
+	for k := 0; k+1 < len(seg); k++ {
+		tokens = append(tokens, string(seg[k:k+2]))
+	}
+	return tokens
+}
@@ -1,47 +1,75 @@
 package service
 
 import (
+	"CMS/internal/apperr"
 	"CMS/internal/dto"
+	"CMS/internal/metrics"
+	"CMS/internal/model"
+	"CMS/internal/payment"
 	"CMS/internal/repository"
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
 
 	"github.com/shopspring/decimal"
 )
 
+// idempotencyKeyRegex 幂等键允许的字符
+var idempotencyKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_\-:.]+$`)
+
 // AccountService 账户业务接口（专属账户操作）
 type AccountService interface {
 	// CreateAccount 创建设户账户（支持事务，注册时调用）
 	CreateAccount(ctx context.Context, tx *sql.Tx, userUUID string) error
-	// Recharge 账户充值（含参数校验+业务逻辑）
-	Recharge(ctx context.Context, req dto.RechargeRequest) (*dto.AccountResponse, error)
+	// Recharge 创建待支付充值单并交由支付渠道发起支付（余额在渠道回调验签通过后入账）
+	Recharge(ctx context.Context, req dto.RechargeRequest) (*dto.RechargeOrderResp, error)
+	// HandlePaymentCallback 处理支付渠道回调：验签 → 锁定充值单 → 入账（重复回调幂等）
+	HandlePaymentCallback(ctx context.Context, providerName string, r *http.Request) error
+	// GetRechargeOrder 查询当前用户的充值单
+	GetRechargeOrder(ctx context.Context, userUUID, orderNo string) (*dto.RechargeOrderResp, error)
+	// SimulatePayment 模拟支付（仅支持实现了payment.Simulator的渠道，用于本地联调）
+	SimulatePayment(ctx context.Context, userUUID, orderNo string) (*dto.RechargeOrderResp, error)
 	// Deduct 扣减账户余额（含余额校验+业务逻辑）
 	Deduct(ctx context.Context, req dto.DeductRequest) (*dto.AccountResponse, error)
 	// GetAccountByUserUUID 查询用户账户信息
 	GetAccountByUserUUID(ctx context.Context, userUUID string) (*dto.AccountResponse, error)
+	// GetTransactions 分页查询用户账户流水（对账单）
+	GetTransactions(ctx context.Context, userUUID string, req dto.TransactionListReq) (*dto.TransactionListResp, error)
 }
 
 // accountServiceImpl 账户业务实现
 type accountServiceImpl struct {
-	accountRepo repository.AccountRepo // 依赖账户Repo
-	userRepo    repository.UserRepo    // 可选：依赖用户Repo，校验用户是否存在
+	accountRepo  repository.AccountRepo       // 依赖账户Repo
+	userRepo     repository.UserRepo          // 可选：依赖用户Repo，校验用户是否存在
+	rechargeRepo repository.RechargeOrderRepo // 依赖充值单Repo
+	providers    map[string]payment.PaymentProvider
+	defaultPay   string // 默认支付渠道（providers中第一个）
 }
 
-// NewAccountService 创建账户业务实例
-func NewAccountService(accountRepo repository.AccountRepo, userRepo repository.UserRepo) AccountService {
-	return &accountServiceImpl{
-		accountRepo: accountRepo,
-		userRepo:    userRepo,
-	}
+// NewAccountService 创建账户业务实例（providers为可用支付渠道，第一个作为默认渠道）
+func NewAccountService(accountRepo repository.AccountRepo, userRepo repository.UserRepo, rechargeRepo repository.RechargeOrderRepo, providers ...payment.PaymentProvider) AccountService {
+	s := &accountServiceImpl{
+		accountRepo:  accountRepo,
+		userRepo:     userRepo,
+		rechargeRepo: rechargeRepo,
+		providers:    make(map[string]payment.PaymentProvider, len(providers)),
+	}
+	for _, p := range providers {
+		if s.defaultPay == "" {
+			s.defaultPay = p.Name()
+		}
+		s.providers[p.Name()] = p
+	}
+	return s
 }
 
 // CreateAccount 创建设户账户（透传Repo层，支持事务）
 func (s *accountServiceImpl) CreateAccount(ctx context.Context, tx *sql.Tx, userUUID string) error {
 	// 1. 业务参数校验（Service层兜底）
 	if userUUID == "" {
-		return errors.New("用户UUID不能为空")
+		return apperr.InvalidArgument("用户UUID不能为空")
 	}
 
 	// 2. 调用Repo层创建账户（传入事务）
@@ -51,89 +79,114 @@ func (s *accountServiceImpl) CreateAccount(ctx context.Context, tx *sql.Tx, user
 	return nil
 }
 
-// Recharge 账户充值（完整业务逻辑）
-func (s *accountServiceImpl) Recharge(ctx context.Context, req dto.RechargeRequest) (*dto.AccountResponse, error) {
-	// 1. 严格参数校验（Service层核心职责）
+// Deduct 扣减账户余额（完整业务逻辑）
+func (s *accountServiceImpl) Deduct(ctx context.Context, req dto.DeductRequest) (*dto.AccountResponse, error) {
+	// 1. 严格参数校验
 	if req.UserUUID == "" {
-		return nil, errors.New("用户UUID不能为空")
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
 	}
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		return nil, errors.New("充值金额必须大于0")
+		return nil, apperr.InvalidArgument("扣减金额必须大于0")
 	}
 
-	// 2. 校验用户是否存在（可选，增强业务安全性）
+	// 2. 校验用户是否存在
 	_, err := s.userRepo.GetUserByUuid(ctx, req.UserUUID)
 	if err != nil {
 		return nil, fmt.Errorf("用户不存在：%w", err)
 	}
 
-	// 3. 调用Repo层执行充值
-	if err := s.accountRepo.RechargeBalance(ctx, req.UserUUID, req.Amount); err != nil {
-		return nil, fmt.Errorf("充值操作失败：%w", err)
-	}
-
-	// 4. 查询充值后的账户信息，返回给前端
-	account, err := s.accountRepo.GetAccountByUserUUID(ctx, req.UserUUID)
+	// 3. 执行扣减（行锁+幂等键，重试请求不会重复扣款）
+	resp, err := s.changeBalance(ctx, req.UserUUID, req.Amount, req.IdempotencyKey, model.TxTypeDeduct)
 	if err != nil {
-		return nil, fmt.Errorf("查询充值后账户信息失败：%w", err)
+		return nil, err
 	}
-	if account == nil {
-		return nil, errors.New("账户信息不存在")
+	if !resp.Replayed {
+		metrics.Deductions.Inc()
+		metrics.DeductionAmount.Add(req.Amount.InexactFloat64())
 	}
-
-	// 5. 转换为响应DTO
-	return &dto.AccountResponse{
-		UserUUID:      account.UserUUID,
-		Balance:       account.Balance,
-		TotalRecharge: account.TotalRecharge,
-		TotalConsume:  account.TotalConsume,
-	}, nil
+	return resp, nil
 }
 
-// Deduct 扣减账户余额（完整业务逻辑）
-func (s *accountServiceImpl) Deduct(ctx context.Context, req dto.DeductRequest) (*dto.AccountResponse, error) {
-	// 1. 严格参数校验
-	if req.UserUUID == "" {
-		return nil, errors.New("用户UUID不能为空")
-	}
-	if req.Amount.LessThanOrEqual(decimal.Zero) {
-		return nil, errors.New("扣减金额必须大于0")
+// changeBalance 余额扣减核心逻辑（充值改为走充值单+支付回调，见recharge_ser.go）：
+// 锁定账户行 → 幂等键已存在则直接返回（不再变动余额）→ 变动余额并写流水 → 提交
+// 同一账户的并发请求在行锁处排队，余额校验与扣减原子执行，不会扣成负数
+func (s *accountServiceImpl) changeBalance(ctx context.Context, userUUID string, amount decimal.Decimal, idempotencyKey, txType string) (*dto.AccountResponse, error) {
+	if err := checkIdempotencyKey(idempotencyKey); err != nil {
+		return nil, err
 	}
 
-	// 2. 校验用户是否存在
-	_, err := s.userRepo.GetUserByUuid(ctx, req.UserUUID)
+	tx, err := s.accountRepo.GetDB().BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("用户不存在：%w", err)
+		return nil, fmt.Errorf("开启账户事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 1. 锁定账户行（幂等键检查也在锁内进行，避免同一幂等键的并发请求同时通过检查）
+	if _, err := s.accountRepo.LockAccount(ctx, tx, userUUID); err != nil {
+		return nil, err
+	}
+
+	// 2. 幂等键已使用：同一请求的重试直接返回当前账户信息；参数不同视为冲突
+	if idempotencyKey != "" {
+		prev, err := s.accountRepo.GetTransactionByIdempotencyKey(ctx, tx, userUUID, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			if prev.Type != txType || !prev.Amount.Abs().Equal(amount) {
+				return nil, apperr.ErrIdempotencyConflict.WithMsgf("幂等键已被用于其他请求（idempotency_key=%s）", idempotencyKey)
+			}
+			resp, err := s.GetAccountByUserUUID(ctx, userUUID)
+			if err != nil {
+				return nil, err
+			}
+			resp.TransactionID = prev.ID
+			resp.Replayed = true
+			return resp, nil
+		}
+	}
+
+	// 3. 变动余额并写入流水
+	entry := &model.AccountTransaction{Type: txType, IdempotencyKey: idempotencyKey}
+	switch txType {
+	case model.TxTypeDeduct:
+		entry.Remark = "账户消费"
+		err = s.accountRepo.DeductBalance(ctx, tx, userUUID, amount, entry)
+	default:
+		err = fmt.Errorf("不支持的流水类型（type=%s）", txType)
 	}
-
-	// 3. 调用Repo层执行扣减
-	if err := s.accountRepo.DeductBalance(ctx, req.UserUUID, req.Amount); err != nil {
-		return nil, fmt.Errorf("扣减余额失败：%w", err)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交账户事务失败：%w", err)
 	}
 
-	// 4. 查询扣减后的账户信息
-	account, err := s.accountRepo.GetAccountByUserUUID(ctx, req.UserUUID)
+	// 4. 查询变动后的账户信息
+	resp, err := s.GetAccountByUserUUID(ctx, userUUID)
 	if err != nil {
-		return nil, fmt.Errorf("查询扣减后账户信息失败：%w", err)
-	}
-	if account == nil {
-		return nil, errors.New("账户信息不存在")
+		return nil, err
 	}
+	resp.TransactionID = entry.ID
+	return resp, nil
+}
 
-	// 5. 转换为响应DTO
-	return &dto.AccountResponse{
-		UserUUID:      account.UserUUID,
-		Balance:       account.Balance,
-		TotalRecharge: account.TotalRecharge,
-		TotalConsume:  account.TotalConsume,
-	}, nil
+// checkIdempotencyKey 校验幂等键（可为空；非空时最长64位，仅允许字母数字及-_:.）
+func checkIdempotencyKey(key string) error {
+	if key == "" {
+		return nil
+	}
+	if len(key) > 64 || !idempotencyKeyRegex.MatchString(key) {
+		return apperr.InvalidArgument("幂等键格式错误（最长64位，仅允许字母、数字及-_:.）")
+	}
+	return nil
 }
 
 // GetAccountByUserUUID 查询用户账户信息
 func (s *accountServiceImpl) GetAccountByUserUUID(ctx context.Context, userUUID string) (*dto.AccountResponse, error) {
 	// 1. 参数校验
 	if userUUID == "" {
-		return nil, errors.New("用户UUID不能为空")
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
 	}
 
 	// 2. 调用Repo层查询
@@ -142,7 +195,7 @@ func (s *accountServiceImpl) GetAccountByUserUUID(ctx context.Context, userUUID
 		return nil, fmt.Errorf("查询账户信息失败：%w", err)
 	}
 	if account == nil {
-		return nil, errors.New("未查询到用户账户信息")
+		return nil, apperr.ErrAccountNotFound
 	}
 
 	// 3. 转换为响应DTO
@@ -153,3 +206,49 @@ func (s *accountServiceImpl) GetAccountByUserUUID(ctx context.Context, userUUID
 		TotalConsume:  account.TotalConsume,
 	}, nil
 }
+
+// GetTransactions 分页查询用户账户流水（按时间倒序）
+func (s *accountServiceImpl) GetTransactions(ctx context.Context, userUUID string, req dto.TransactionListReq) (*dto.TransactionListResp, error) {
+	// 1. 参数校验
+	if userUUID == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	switch req.Type {
+	case "", model.TxTypeRecharge, model.TxTypeDeduct, model.TxTypePurchase, model.TxTypeSale, model.TxTypeAdjust:
+	default:
+		return nil, apperr.InvalidArgumentf("流水类型无效（type=%s）", req.Type)
+	}
+
+	// 2. 统计总数
+	total, err := s.accountRepo.CountTransactions(ctx, userUUID, req.Type)
+	if err != nil {
+		return nil, fmt.Errorf("统计账户流水失败：%w", err)
+	}
+	resp := &dto.TransactionListResp{
+		List:  []dto.TransactionItem{},
+		Total: total,
+		Page:  req.Page,
+		Size:  req.Size,
+	}
+	if total == 0 {
+		return resp, nil
+	}
+
+	// 3. 分页查询并转换为DTO
+	list, err := s.accountRepo.ListTransactions(ctx, userUUID, req.Type, (req.Page-1)*req.Size, req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("查询账户流水失败：%w", err)
+	}
+	for _, t := range list {
+		resp.List = append(resp.List, dto.TransactionItem{
+			ID:           t.ID,
+			Type:         t.Type,
+			Amount:       t.Amount,
+			BalanceAfter: t.BalanceAfter,
+			ReferenceID:  t.ReferenceID,
+			Remark:       t.Remark,
+			CreateTime:   t.CreateTime.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return resp, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// stubDriver 只支持开启/提交/回滚事务的database/sql驱动（执行SQL直接报错）
+// 用于Service层在内存fake仓库上走完整的事务流程，不需要真实数据库
+type stubDriver struct{}
+
+func (stubDriver) Open(string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("stub驱动不支持执行SQL")
+}
+func (stubConn) Close() error              { return nil }
+func (stubConn) Begin() (driver.Tx, error) { return stubConn{}, nil }
+func (stubConn) Commit() error             { return nil }
+func (stubConn) Rollback() error           { return nil }
+
+var registerStubDriver sync.Once
+
+// openStubDB 打开stubDriver连接（事务内的读写全部由fake仓库完成）
+func openStubDB(t *testing.T) *sql.DB {
+	t.Helper()
+	registerStubDriver.Do(func() { sql.Register("stub", stubDriver{}) })
+	db, err := sql.Open("stub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// memAccountRepo 账户与流水的内存实现（只实现changeBalance用到的方法）
+type memAccountRepo struct {
+	repository.AccountRepo
+	db       *sql.DB
+	accounts map[string]*model.UserAccount
+	txs      []*model.AccountTransaction
+}
+
+func (r *memAccountRepo) GetDB() *sql.DB { return r.db }
+
+func (r *memAccountRepo) LockAccount(_ context.Context, _ *sql.Tx, userUUID string) (decimal.Decimal, error) {
+	acc, ok := r.accounts[userUUID]
+	if !ok {
+		return decimal.Zero, apperr.ErrAccountNotFound
+	}
+	return acc.Balance, nil
+}
+
+func (r *memAccountRepo) GetTransactionByIdempotencyKey(_ context.Context, _ *sql.Tx, userUUID, key string) (*model.AccountTransaction, error) {
+	for _, tx := range r.txs {
+		if tx.UserUUID == userUUID && tx.IdempotencyKey == key {
+			return tx, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memAccountRepo) DeductBalance(_ context.Context, _ *sql.Tx, userUUID string, amount decimal.Decimal, entry *model.AccountTransaction) error {
+	acc := r.accounts[userUUID]
+	if acc.Balance.LessThan(amount) {
+		return apperr.ErrInsufficientBalance
+	}
+	acc.Balance = acc.Balance.Sub(amount)
+	acc.TotalConsume = acc.TotalConsume.Add(amount)
+	entry.ID = uint64(len(r.txs) + 1)
+	entry.UserUUID, entry.Amount, entry.BalanceAfter = userUUID, amount.Neg(), acc.Balance
+	r.txs = append(r.txs, entry)
+	return nil
+}
+
+func (r *memAccountRepo) GetAccountByUserUUID(_ context.Context, userUUID string) (*model.UserAccount, error) {
+	acc, ok := r.accounts[userUUID]
+	if !ok {
+		return nil, nil
+	}
+	cp := *acc
+	return &cp, nil
+}
+
+func newTestAccountService(t *testing.T, balance string) (*accountServiceImpl, *memAccountRepo) {
+	t.Helper()
+	repo := &memAccountRepo{
+		db:       openStubDB(t),
+		accounts: map[string]*model.UserAccount{"u1": {UserUUID: "u1", Balance: decimal.RequireFromString(balance)}},
+	}
+	return &accountServiceImpl{accountRepo: repo}, repo
+}
+
+func TestChangeBalanceIdempotentReplay(t *testing.T) {
+	s, repo := newTestAccountService(t, "100")
+	ctx := context.Background()
+	amount := decimal.RequireFromString("30")
+
+	first, err := s.changeBalance(ctx, "u1", amount, "order-1", model.TxTypeDeduct)
+	if err != nil {
+		t.Fatalf("首次扣减返回错误：%v", err)
+	}
+	if first.Replayed || !first.Balance.Equal(decimal.RequireFromString("70")) {
+		t.Fatalf("首次扣减结果=%+v，期望余额70且非重放", first)
+	}
+
+	// 同一幂等键重试：返回首次的流水ID，不重复扣款
+	replay, err := s.changeBalance(ctx, "u1", amount, "order-1", model.TxTypeDeduct)
+	if err != nil {
+		t.Fatalf("重试返回错误：%v", err)
+	}
+	if !replay.Replayed || replay.TransactionID != first.TransactionID {
+		t.Errorf("重试结果=%+v，期望重放首次流水%d", replay, first.TransactionID)
+	}
+	if !replay.Balance.Equal(decimal.RequireFromString("70")) || len(repo.txs) != 1 {
+		t.Errorf("重试后余额=%s、流水数=%d，期望70、1", replay.Balance, len(repo.txs))
+	}
+
+	// 同一幂等键用于不同金额视为冲突
+	if _, err := s.changeBalance(ctx, "u1", decimal.RequireFromString("31"), "order-1", model.TxTypeDeduct); !errors.Is(err, apperr.ErrIdempotencyConflict) {
+		t.Errorf("幂等键金额不同返回%v，期望ErrIdempotencyConflict", err)
+	}
+
+	// 未带幂等键的请求每次都扣款
+	for range 2 {
+		if _, err := s.changeBalance(ctx, "u1", decimal.RequireFromString("10"), "", model.TxTypeDeduct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := repo.accounts["u1"].Balance; !got.Equal(decimal.RequireFromString("50")) {
+		t.Errorf("余额=%s，期望50", got)
+	}
+}
+
+func TestChangeBalanceRejectsOverBalance(t *testing.T) {
+	s, repo := newTestAccountService(t, "20")
+	ctx := context.Background()
+
+	if _, err := s.changeBalance(ctx, "u1", decimal.RequireFromString("20.01"), "order-2", model.TxTypeDeduct); !errors.Is(err, apperr.ErrInsufficientBalance) {
+		t.Fatalf("超额扣减返回%v，期望ErrInsufficientBalance", err)
+	}
+	if got := repo.accounts["u1"].Balance; !got.Equal(decimal.RequireFromString("20")) || len(repo.txs) != 0 {
+		t.Fatalf("超额扣减后余额=%s、流水数=%d，期望不变", got, len(repo.txs))
+	}
+
+	// 失败的请求不占用幂等键：金额不超过余额时用同一键重试可以成功
+	resp, err := s.changeBalance(ctx, "u1", decimal.RequireFromString("20"), "order-2", model.TxTypeDeduct)
+	if err != nil {
+		t.Fatalf("余额恰好足够时返回错误：%v", err)
+	}
+	if resp.Replayed || !resp.Balance.IsZero() {
+		t.Errorf("扣减结果=%+v，期望余额0且非重放", resp)
+	}
+}
+
+func TestChangeBalanceRejectsInvalidKey(t *testing.T) {
+	s, _ := newTestAccountService(t, "100")
+	for _, key := range []string{"含中文", "a b", string(make([]byte, 65))} {
+		if _, err := s.changeBalance(context.Background(), "u1", decimal.RequireFromString("1"), key, model.TxTypeDeduct); !errors.Is(err, apperr.ErrInvalidArgument) {
+			t.Errorf("幂等键%q返回%v，期望ErrInvalidArgument", key, err)
+		}
+	}
+}
@@ -0,0 +1,740 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"CMS/internal/search"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxAdjustAmount 单笔手工调账金额上限（绝对值）
+var MaxAdjustAmount = decimal.NewFromInt(50000)
+
+// AdminService 管理后台业务接口：用户管理、内容审核、手工调账
+// 每个管理操作与其审计日志在同一事务内写入，操作成功则必有审计记录
+type AdminService interface {
+	ListUsers(ctx context.Context, req dto.AdminUserListReq) (*dto.AdminUserListResp, error)                                 // 分页查询/搜索用户
+	SetUserRole(ctx context.Context, adminUUID string, req dto.AdminSetRoleReq) error                                        // 修改用户角色
+	SetUserStatus(ctx context.Context, adminUUID string, req dto.AdminSetStatusReq) error                                    // 停用/封禁/恢复账号
+	SetResourceHidden(ctx context.Context, adminUUID string, req dto.AdminHideReq) error                                     // 隐藏/取消隐藏资源
+	DeleteResource(ctx context.Context, adminUUID string, req dto.AdminDeleteReq) error                                      // 删除资源（含评论、点赞明细、标签关联）
+	SetCommentHidden(ctx context.Context, adminUUID string, req dto.AdminHideReq) error                                      // 隐藏/取消隐藏评论（含回复）
+	DeleteComment(ctx context.Context, adminUUID string, req dto.AdminDeleteReq) error                                       // 删除评论（含回复）
+	CreateCategory(ctx context.Context, adminUUID string, req dto.AdminCreateCategoryReq) (*dto.CategoryNode, error)         // 新增资源分类
+	DeleteCategory(ctx context.Context, adminUUID string, req dto.AdminDeleteReq) error                                      // 删除资源分类（需无子分类和资源）
+	AdjustBalance(ctx context.Context, adminUUID string, req dto.AdminAdjustBalanceReq) (*dto.AdminAdjustBalanceResp, error) // 手工调账
+	ListAuditLogs(ctx context.Context, req dto.AdminAuditListReq) (*dto.AdminAuditListResp, error)                           // 分页查询审计日志
+}
+
+// adminServiceImpl 实现AdminService
+type adminServiceImpl struct {
+	userRepo     repository.UserRepo
+	accountRepo  repository.AccountRepo
+	resourceRepo repository.ResourceRepo
+	commentRepo  repository.CommentRepo
+	likeRepo     repository.LikeRepo
+	purchaseRepo repository.PurchaseRepo
+	tagRepo      repository.TagRepo
+	categoryRepo repository.CategoryRepo
+	auditRepo    repository.AuditRepo
+	tokenRepo    repository.TokenRepo
+	denylist     *TokenDenylist     // 角色/状态变更后吊销目标用户已签发的访问Token
+	searchIndex  search.SearchIndex // 隐藏/删除资源后同步检索索引
+}
+
+// NewAdminService 创建管理后台业务实例
+func NewAdminService(userRepo repository.UserRepo, accountRepo repository.AccountRepo, resourceRepo repository.ResourceRepo, commentRepo repository.CommentRepo, likeRepo repository.LikeRepo, purchaseRepo repository.PurchaseRepo, tagRepo repository.TagRepo, categoryRepo repository.CategoryRepo, auditRepo repository.AuditRepo, tokenRepo repository.TokenRepo, denylist *TokenDenylist, searchIndex search.SearchIndex) AdminService {
+	return &adminServiceImpl{
+		userRepo:     userRepo,
+		accountRepo:  accountRepo,
+		resourceRepo: resourceRepo,
+		commentRepo:  commentRepo,
+		likeRepo:     likeRepo,
+		purchaseRepo: purchaseRepo,
+		tagRepo:      tagRepo,
+		categoryRepo: categoryRepo,
+		auditRepo:    auditRepo,
+		tokenRepo:    tokenRepo,
+		denylist:     denylist,
+		searchIndex:  searchIndex,
+	}
+}
+
+// ListUsers 分页查询用户
+func (s *adminServiceImpl) ListUsers(ctx context.Context, req dto.AdminUserListReq) (*dto.AdminUserListResp, error) {
+	keyword := strings.TrimSpace(req.Keyword)
+	total, err := s.userRepo.CountUsers(ctx, keyword, req.Role, req.Status)
+	if err != nil {
+		return nil, fmt.Errorf("统计用户总数失败：%w", err)
+	}
+	resp := &dto.AdminUserListResp{List: []dto.AdminUserItem{}, Total: total, Page: req.Page, Size: req.Size}
+	if total == 0 {
+		return resp, nil
+	}
+
+	users, err := s.userRepo.ListUsers(ctx, keyword, req.Role, req.Status, (req.Page-1)*req.Size, req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户列表失败：%w", err)
+	}
+	for _, u := range users {
+		resp.List = append(resp.List, dto.AdminUserItem{
+			ID:       u.ID,
+			UUID:     u.UUID,
+			Username: u.Username,
+			Email:    u.Email,
+			Phone:    u.Phone,
+			RealName: u.RealName,
+			Role:     u.Role,
+			Status:   u.Status,
+		})
+	}
+	return resp, nil
+}
+
+// SetUserRole 修改用户角色：锁定用户行 → 更新角色 → 写审计日志 → 提交后吊销旧Token（旧Token中的角色声明立即失效）
+func (s *adminServiceImpl) SetUserRole(ctx context.Context, adminUUID string, req dto.AdminSetRoleReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+	switch req.Role {
+	case model.RoleCandidate, model.RoleHR, model.RoleAdmin:
+	default:
+		return apperr.InvalidArgumentf("角色无效（role=%s）", req.Role)
+	}
+	if req.UUID == adminUUID {
+		return apperr.PermissionDenied("不能修改自己的角色")
+	}
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	target, err := s.lockTargetUser(ctx, tx, req.UUID)
+	if err != nil {
+		return err
+	}
+	if target.Role == req.Role {
+		return apperr.FailedPrecondition(fmt.Sprintf("用户角色未变化（role=%s）", req.Role))
+	}
+	if err := s.userRepo.UpdateRole(ctx, tx, req.UUID, req.Role); err != nil {
+		return fmt.Errorf("修改用户角色失败：%w", err)
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionUserRole, model.AuditTargetUser, req.UUID, reason, map[string]interface{}{
+		"username": target.Username,
+		"from":     target.Role,
+		"to":       req.Role,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交修改角色事务失败：%w", err)
+	}
+
+	// 刷新令牌保留：客户端刷新后获得携带新角色的访问Token
+	if err := s.denylist.RevokeUser(ctx, req.UUID, time.Now()); err != nil {
+		return fmt.Errorf("角色已修改，但吊销旧Token失败：%w", err)
+	}
+	return nil
+}
+
+// SetUserStatus 停用/封禁/恢复账号：非active状态下同一事务内吊销全部刷新令牌，提交后吊销已签发的访问Token
+func (s *adminServiceImpl) SetUserStatus(ctx context.Context, adminUUID string, req dto.AdminSetStatusReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+	switch req.Status {
+	case model.UserStatusActive, model.UserStatusDisabled, model.UserStatusBanned:
+	default:
+		return apperr.InvalidArgumentf("账号状态无效（status=%s）", req.Status)
+	}
+	if req.UUID == adminUUID {
+		return apperr.PermissionDenied("不能修改自己的账号状态")
+	}
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	target, err := s.lockTargetUser(ctx, tx, req.UUID)
+	if err != nil {
+		return err
+	}
+	if target.Status == req.Status {
+		return apperr.FailedPrecondition(fmt.Sprintf("账号状态未变化（status=%s）", req.Status))
+	}
+	if err := s.userRepo.UpdateStatus(ctx, tx, req.UUID, req.Status); err != nil {
+		return fmt.Errorf("修改账号状态失败：%w", err)
+	}
+	revoke := req.Status != model.UserStatusActive
+	if revoke {
+		if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, tx, req.UUID); err != nil {
+			return err
+		}
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionUserStatus, model.AuditTargetUser, req.UUID, reason, map[string]interface{}{
+		"username": target.Username,
+		"from":     target.Status,
+		"to":       req.Status,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交修改账号状态事务失败：%w", err)
+	}
+
+	if revoke {
+		if err := s.denylist.RevokeUser(ctx, req.UUID, time.Now()); err != nil {
+			return fmt.Errorf("账号状态已修改，但吊销已签发的Token失败：%w", err)
+		}
+	}
+	return nil
+}
+
+// SetResourceHidden 隐藏/取消隐藏资源（隐藏后不出现在资源列表，详情按不存在处理）
+func (s *adminServiceImpl) SetResourceHidden(ctx context.Context, adminUUID string, req dto.AdminHideReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+	if req.Hidden == nil {
+		return apperr.InvalidArgument("隐藏状态不能为空")
+	}
+	hidden := *req.Hidden
+
+	resource, err := s.getAnyResource(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	changed, err := s.resourceRepo.SetHidden(ctx, tx, req.ID, hidden)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		if hidden {
+			return apperr.FailedPrecondition(fmt.Sprintf("资源已处于隐藏状态（id=%d）", req.ID))
+		}
+		return apperr.FailedPrecondition(fmt.Sprintf("资源未被隐藏（id=%d）", req.ID))
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionResourceHide, model.AuditTargetResource, strconv.FormatUint(req.ID, 10), reason, map[string]interface{}{
+		"title":   resource.Title,
+		"author":  resource.Author,
+		"user_id": resource.UserID,
+		"hidden":  hidden,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交隐藏资源事务失败：%w", err)
+	}
+	if hidden {
+		unindexResource(ctx, s.searchIndex, req.ID)
+	} else {
+		indexResource(ctx, s.searchIndex, resource)
+	}
+	return nil
+}
+
+// DeleteResource 删除资源：已有购买记录的资源不允许删除（购买者的所有权记录需保留），应改为隐藏
+// 评论、@提及、点赞明细、标签关联与资源记录在同一事务内删除
+func (s *adminServiceImpl) DeleteResource(ctx context.Context, adminUUID string, req dto.AdminDeleteReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+	resource, err := s.getAnyResource(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 锁定资源行：与购买、点赞、评论等写操作串行，避免删除过程中产生新的关联记录
+	locked, err := s.resourceRepo.LockResource(ctx, tx, req.ID)
+	if err != nil {
+		return err
+	}
+	if locked == nil {
+		return apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", req.ID)
+	}
+	purchases, err := s.purchaseRepo.CountByResourceID(ctx, tx, req.ID)
+	if err != nil {
+		return err
+	}
+	if purchases > 0 {
+		return apperr.FailedPrecondition(fmt.Sprintf("资源已有%d条购买记录，不能删除，请改为隐藏（id=%d）", purchases, req.ID))
+	}
+
+	if err := s.likeRepo.DeleteByResourceID(ctx, tx, req.ID); err != nil {
+		return err
+	}
+	if err := s.tagRepo.DeleteByResourceID(ctx, tx, req.ID); err != nil {
+		return err
+	}
+	comments, err := s.commentRepo.DeleteByResourceID(ctx, tx, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.resourceRepo.DeleteResource(ctx, tx, req.ID); err != nil {
+		return err
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionResourceDel, model.AuditTargetResource, strconv.FormatUint(req.ID, 10), reason, map[string]interface{}{
+		"title":            resource.Title,
+		"author":           resource.Author,
+		"user_id":          resource.UserID,
+		"price":            resource.Price,
+		"like_count":       resource.LikeCount,
+		"tags":             resource.Tags,
+		"comments_deleted": comments,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交删除资源事务失败：%w", err)
+	}
+	unindexResource(ctx, s.searchIndex, req.ID)
+	return nil
+}
+
+// SetCommentHidden 隐藏/取消隐藏评论（评论下的全部回复一并处理，评论量按未隐藏的评论重新计算）
+func (s *adminServiceImpl) SetCommentHidden(ctx context.Context, adminUUID string, req dto.AdminHideReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+	if req.Hidden == nil {
+		return apperr.InvalidArgument("隐藏状态不能为空")
+	}
+	hidden := *req.Hidden
+
+	comment, err := s.getComment(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if !hidden && comment.ParentID > 0 {
+		// 上级评论仍隐藏时恢复回复没有意义（评论树中不可见），需先恢复上级评论
+		parent, err := s.commentRepo.GetCommentByID(ctx, comment.ParentID)
+		if err != nil {
+			return fmt.Errorf("查询上级评论失败：%w", err)
+		}
+		if parent != nil && parent.Hidden {
+			return apperr.FailedPrecondition(fmt.Sprintf("上级评论仍处于隐藏状态，请先恢复上级评论（id=%d）", parent.ID))
+		}
+	}
+	ids, _, err := collectCommentSubtree(ctx, s.commentRepo, comment)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 锁定所属资源：与评论发布/删除串行，保证重新计算的评论量准确
+	if _, err := s.resourceRepo.LockResource(ctx, tx, comment.ResourceID); err != nil {
+		return err
+	}
+	affected, err := s.commentRepo.SetHidden(ctx, tx, ids, hidden)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if hidden {
+			return apperr.FailedPrecondition(fmt.Sprintf("评论已处于隐藏状态（id=%d）", req.ID))
+		}
+		return apperr.FailedPrecondition(fmt.Sprintf("评论未被隐藏（id=%d）", req.ID))
+	}
+	if err := s.resourceRepo.SyncCommentCount(ctx, tx, comment.ResourceID); err != nil {
+		return err
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionCommentHide, model.AuditTargetComment, strconv.FormatUint(req.ID, 10), reason, map[string]interface{}{
+		"resource_id": comment.ResourceID,
+		"user_uuid":   comment.UserUUID,
+		"username":    comment.Username,
+		"content":     comment.Content,
+		"hidden":      hidden,
+		"affected":    affected,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交隐藏评论事务失败：%w", err)
+	}
+	return nil
+}
+
+// DeleteComment 删除评论及其全部回复（审计日志保留被删评论内容快照）
+func (s *adminServiceImpl) DeleteComment(ctx context.Context, adminUUID string, req dto.AdminDeleteReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+	comment, err := s.getComment(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	ids, _, err := collectCommentSubtree(ctx, s.commentRepo, comment)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := s.resourceRepo.LockResource(ctx, tx, comment.ResourceID); err != nil {
+		return err
+	}
+	if err := s.commentRepo.DeleteMentions(ctx, tx, ids); err != nil {
+		return fmt.Errorf("删除@提及失败：%w", err)
+	}
+	deleted, err := s.commentRepo.DeleteComments(ctx, tx, ids)
+	if err != nil {
+		return fmt.Errorf("删除评论记录失败：%w", err)
+	}
+	if err := s.resourceRepo.SyncCommentCount(ctx, tx, comment.ResourceID); err != nil {
+		return err
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionCommentDel, model.AuditTargetComment, strconv.FormatUint(req.ID, 10), reason, map[string]interface{}{
+		"resource_id": comment.ResourceID,
+		"user_uuid":   comment.UserUUID,
+		"username":    comment.Username,
+		"content":     comment.Content,
+		"deleted":     deleted,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交删除评论事务失败：%w", err)
+	}
+	return nil
+}
+
+// CreateCategory 新增资源分类：锁定父分类（与删除父分类串行）→ 写入分类 → 写审计日志
+func (s *adminServiceImpl) CreateCategory(ctx context.Context, adminUUID string, req dto.AdminCreateCategoryReq) (*dto.CategoryNode, error) {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperr.InvalidArgument("分类名称不能为空")
+	}
+	if n := utf8.RuneCountInString(name); n > MaxCategoryNameLen {
+		return nil, apperr.InvalidArgumentf("分类名称不能超过%d个字符（当前：%d）", MaxCategoryNameLen, n)
+	}
+	if req.ParentID != 0 {
+		categories, err := s.categoryRepo.ListCategories(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// 父分类的祖先链不会变化（分类不支持移动，删除需先清空子分类），事务外计算层级即可
+		if depth := newCategoryTree(categories).depth(req.ParentID); depth >= model.MaxCategoryDepth {
+			return nil, apperr.FailedPrecondition(fmt.Sprintf("分类最多%d级，不能在该分类下新增子分类（parent_id=%d）", model.MaxCategoryDepth, req.ParentID))
+		}
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if req.ParentID != 0 {
+		parent, err := s.categoryRepo.LockCategory(ctx, tx, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, apperr.ErrCategoryNotFound.WithMsgf("父分类不存在（id=%d）", req.ParentID)
+		}
+	}
+	category := &model.Category{
+		ParentID:   req.ParentID,
+		Name:       name,
+		SortOrder:  req.SortOrder,
+		CreateTime: time.Now(),
+	}
+	if err := s.categoryRepo.CreateCategory(ctx, tx, category); err != nil {
+		return nil, err
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionCategoryAdd, model.AuditTargetCategory, strconv.FormatUint(category.ID, 10), reason, map[string]interface{}{
+		"parent_id":  category.ParentID,
+		"name":       category.Name,
+		"sort_order": category.SortOrder,
+	}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交新增分类事务失败：%w", err)
+	}
+	return &dto.CategoryNode{
+		ID:        category.ID,
+		ParentID:  category.ParentID,
+		Name:      category.Name,
+		SortOrder: category.SortOrder,
+		Children:  []*dto.CategoryNode{},
+	}, nil
+}
+
+// DeleteCategory 删除资源分类：分类下仍有子分类或资源（含被隐藏的资源）时不允许删除，需先迁移或删除
+func (s *adminServiceImpl) DeleteCategory(ctx context.Context, adminUUID string, req dto.AdminDeleteReq) error {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 锁定分类行：与新增子分类、发布/修改资源时的分类校验串行，避免删除后出现孤立引用
+	category, err := s.categoryRepo.LockCategory(ctx, tx, req.ID)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return apperr.ErrCategoryNotFound.WithMsgf("分类不存在（id=%d）", req.ID)
+	}
+	children, resources, err := s.categoryRepo.CountUsage(ctx, tx, req.ID)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return apperr.FailedPrecondition(fmt.Sprintf("分类下还有%d个子分类，不能删除（id=%d）", children, req.ID))
+	}
+	if resources > 0 {
+		return apperr.FailedPrecondition(fmt.Sprintf("分类下还有%d个资源，不能删除（id=%d）", resources, req.ID))
+	}
+	if err := s.categoryRepo.DeleteCategory(ctx, tx, req.ID); err != nil {
+		return err
+	}
+	if _, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionCategoryDel, model.AuditTargetCategory, strconv.FormatUint(req.ID, 10), reason, map[string]interface{}{
+		"parent_id":  category.ParentID,
+		"name":       category.Name,
+		"sort_order": category.SortOrder,
+	}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交删除分类事务失败：%w", err)
+	}
+	return nil
+}
+
+// AdjustBalance 手工调账：锁定账户 → 写审计日志 → 调整余额并写入adjust流水（reference_id为审计日志ID）
+func (s *adminServiceImpl) AdjustBalance(ctx context.Context, adminUUID string, req dto.AdminAdjustBalanceReq) (*dto.AdminAdjustBalanceResp, error) {
+	reason, err := checkAdminReason(req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.UUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if req.Amount.IsZero() {
+		return nil, apperr.InvalidArgument("调账金额不能为0")
+	}
+	if req.Amount.Abs().GreaterThan(MaxAdjustAmount) {
+		return nil, apperr.InvalidArgumentf("单笔调账金额不能超过%s", MaxAdjustAmount.StringFixed(2))
+	}
+	if !req.Amount.Equal(req.Amount.Round(2)) {
+		return nil, apperr.InvalidArgument("调账金额最多保留2位小数")
+	}
+
+	tx, err := s.accountRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	before, err := s.accountRepo.LockAccount(ctx, tx, req.UUID)
+	if err != nil {
+		return nil, err
+	}
+	after := before.Add(req.Amount)
+	if after.IsNegative() {
+		return nil, apperr.ErrInsufficientBalance.WithMsgf("账户余额不足（当前余额：%s，调账金额：%s）", before.String(), req.Amount.String())
+	}
+
+	audit, err := s.writeAudit(ctx, tx, adminUUID, model.AuditActionBalanceAdjust, model.AuditTargetAccount, req.UUID, reason, map[string]interface{}{
+		"amount":         req.Amount,
+		"balance_before": before,
+		"balance_after":  after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := &model.AccountTransaction{
+		Type:        model.TxTypeAdjust,
+		ReferenceID: strconv.FormatUint(audit.ID, 10),
+		Remark:      reason,
+	}
+	if err := s.accountRepo.AdjustBalance(ctx, tx, req.UUID, req.Amount, entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交调账事务失败：%w", err)
+	}
+
+	return &dto.AdminAdjustBalanceResp{
+		UserUUID:      req.UUID,
+		Amount:        req.Amount,
+		BalanceAfter:  entry.BalanceAfter,
+		TransactionID: entry.ID,
+		AuditID:       audit.ID,
+	}, nil
+}
+
+// ListAuditLogs 分页查询审计日志
+func (s *adminServiceImpl) ListAuditLogs(ctx context.Context, req dto.AdminAuditListReq) (*dto.AdminAuditListResp, error) {
+	filter := repository.AuditFilter{
+		AdminUUID:  strings.TrimSpace(req.AdminUUID),
+		Action:     strings.TrimSpace(req.Action),
+		TargetType: req.TargetType,
+		TargetID:   strings.TrimSpace(req.TargetID),
+	}
+	total, err := s.auditRepo.CountAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.AdminAuditListResp{List: []dto.AdminAuditItem{}, Total: total, Page: req.Page, Size: req.Size}
+	if total == 0 {
+		return resp, nil
+	}
+
+	logs, err := s.auditRepo.ListAuditLogs(ctx, filter, (req.Page-1)*req.Size, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		item := dto.AdminAuditItem{
+			ID:         l.ID,
+			AdminUUID:  l.AdminUUID,
+			AdminName:  l.AdminName,
+			Action:     l.Action,
+			TargetType: l.TargetType,
+			TargetID:   l.TargetID,
+			Reason:     l.Reason,
+			CreateTime: l.CreateTime.Format("2006-01-02 15:04:05"),
+		}
+		if l.Detail != "" {
+			item.Detail = json.RawMessage(l.Detail)
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
+}
+
+// writeAudit 在事务内写入一条审计日志（操作人用户名冗余存储，便于账号删除/改名后追溯）
+func (s *adminServiceImpl) writeAudit(ctx context.Context, tx *sql.Tx, adminUUID, action, targetType, targetID, reason string, detail map[string]interface{}) (*model.AdminAuditLog, error) {
+	admin, err := s.userRepo.GetUserByUuid(ctx, adminUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询管理员信息失败：%w", err)
+	}
+	raw, err := json.Marshal(detail)
+	if err != nil {
+		return nil, fmt.Errorf("序列化审计详情失败：%w", err)
+	}
+
+	log := &model.AdminAuditLog{
+		AdminUUID:  adminUUID,
+		AdminName:  admin.Username,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Detail:     string(raw),
+		CreateTime: time.Now(),
+	}
+	if err := s.auditRepo.CreateAuditLog(ctx, tx, log); err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// lockTargetUser 锁定被操作的用户（不存在返回业务错误）
+func (s *adminServiceImpl) lockTargetUser(ctx context.Context, tx *sql.Tx, userUUID string) (*model.User, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	user, err := s.userRepo.LockUser(ctx, tx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperr.ErrUserNotFound.WithMsgf("用户不存在（uuid=%s）", userUUID)
+	}
+	return user, nil
+}
+
+// getAnyResource 查询资源（包含被隐藏的资源，不存在返回业务错误）
+func (s *adminServiceImpl) getAnyResource(ctx context.Context, id uint64) (*model.Resource, error) {
+	if id <= 0 {
+		return nil, apperr.InvalidArgumentf("资源ID无效（id=%d）", id)
+	}
+	resource, err := s.resourceRepo.GetAnyResourceByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询资源失败：%w", err)
+	}
+	if resource == nil {
+		return nil, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+	}
+	return resource, nil
+}
+
+// getComment 查询评论（包含被隐藏的评论，不存在返回业务错误）
+func (s *adminServiceImpl) getComment(ctx context.Context, id uint64) (*model.Comment, error) {
+	if id <= 0 {
+		return nil, apperr.InvalidArgumentf("评论ID无效（id=%d）", id)
+	}
+	comment, err := s.commentRepo.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	if comment == nil {
+		return nil, apperr.ErrCommentNotFound.WithMsgf("评论不存在（id=%d）", id)
+	}
+	return comment, nil
+}
+
+// checkAdminReason 管理操作原因去空格 + 非空校验（原因写入审计日志，不允许为空）
+func checkAdminReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", apperr.InvalidArgument("操作原因不能为空")
+	}
+	return reason, nil
+}
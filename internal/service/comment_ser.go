@@ -0,0 +1,484 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxCommentLen           = 1000 // 评论内容最大字符数（按rune计算，兼容中文）
+	MaxCommentDepth         = 5    // 最大回复层级（顶层评论为0）
+	DefaultCommentTreeDepth = 3    // 评论树默认返回的回复层级
+	MaxMentionsPerComment   = 10   // 单条评论最多解析的@用户数（防止刷提醒）
+)
+
+// mentionRegex 匹配评论中的@用户名（用户名由字母/数字/中文/下划线/短横线组成）
+var mentionRegex = regexp.MustCompile(`@([\p{L}\p{N}_-]{1,50})`)
+
+// CreateComment 创建评论/回复（业务逻辑：新增评论记录 + 原子更新评论数 + 写入@提及，三者在同一事务内）
+func (s *ResourceServiceImpl) CreateComment(ctx context.Context, userUUID string, id, parentID uint64, content string) (*dto.CommentItem, error) {
+	// 1. 参数校验
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if id <= 0 {
+		return nil, apperr.InvalidArgumentf("资源ID无效（id=%d）", id)
+	}
+	content, err := checkCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 查询评论者信息（身份取自JWT，避免前端伪造）
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评论用户失败：%w", err)
+	}
+
+	// 3. 校验资源是否存在
+	resource, err := s.resourceRepo.GetResourceByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询资源失败（id=%d）：%w", id, err)
+	}
+	if resource == nil {
+		return nil, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+	}
+
+	now := time.Now()
+	comment := &model.Comment{
+		ResourceID: id,
+		UserID:     user.ID,
+		UserUUID:   userUUID,
+		Username:   user.Username,
+		Content:    content,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+
+	// 4. 回复场景：校验父评论并计算楼层/层级
+	if parentID > 0 {
+		parent, err := s.commentRepo.GetCommentByID(ctx, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("查询被回复的评论失败：%w", err)
+		}
+		if parent == nil || parent.Hidden {
+			return nil, apperr.ErrCommentNotFound.WithMsgf("被回复的评论不存在（id=%d）", parentID)
+		}
+		if parent.ResourceID != id {
+			return nil, apperr.InvalidArgumentf("被回复的评论不属于该资源（评论ID=%d，资源ID=%d）", parentID, id)
+		}
+		if parent.Depth+1 > MaxCommentDepth {
+			return nil, apperr.InvalidArgumentf("回复层级不能超过%d层", MaxCommentDepth)
+		}
+		comment.ParentID = parent.ID
+		comment.RootID = parent.RootID
+		if comment.RootID == 0 {
+			comment.RootID = parent.ID // 父评论为顶层评论，楼层即父评论自身
+		}
+		comment.Depth = parent.Depth + 1
+	}
+
+	// 5. 解析@提及（通过UserRepo解析为真实用户，不存在的用户名直接忽略）
+	mentioned, err := s.resolveMentions(ctx, content, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. 开启事务：插入评论 + 评论量+1 + 写入@提及
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // 已提交时Rollback返回ErrTxDone，直接忽略
+
+	if err := s.commentRepo.CreateComment(ctx, tx, comment); err != nil {
+		return nil, fmt.Errorf("创建评论记录失败：%w", err)
+	}
+	if err := s.resourceRepo.IncrCommentCount(ctx, tx, id); err != nil {
+		return nil, fmt.Errorf("更新评论数失败：%w", err)
+	}
+	if err := s.commentRepo.CreateMentions(ctx, tx, comment.ID, mentioned); err != nil {
+		return nil, fmt.Errorf("保存@提及失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交评论事务失败：%w", err)
+	}
+
+	item := convertCommentToDTO(comment)
+	item.Mentions = mentionNames(mentioned)
+	return &item, nil
+}
+
+// GetCommentList 分页查询资源评论（平铺，包含回复）
+func (s *ResourceServiceImpl) GetCommentList(ctx context.Context, resourceID uint64, page, size int) ([]dto.CommentItem, int64, error) {
+	if err := checkCommentPage(resourceID, page, size); err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.commentRepo.CountByResourceID(ctx, resourceID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计评论总数失败：%w", err)
+	}
+	if total == 0 {
+		return []dto.CommentItem{}, 0, nil
+	}
+
+	comments, err := s.commentRepo.ListByResourceID(ctx, resourceID, (page-1)*size, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询评论列表失败：%w", err)
+	}
+
+	items := make([]dto.CommentItem, 0, len(comments))
+	for _, c := range comments {
+		items = append(items, convertCommentToDTO(c))
+	}
+	return items, total, nil
+}
+
+// GetCommentTree 分页查询评论树（按顶层评论分页，每页楼层的回复一次性取回后在内存中组装）
+func (s *ResourceServiceImpl) GetCommentTree(ctx context.Context, resourceID uint64, page, size, depth int) ([]*dto.CommentNode, int64, error) {
+	if err := checkCommentPage(resourceID, page, size); err != nil {
+		return nil, 0, err
+	}
+	if depth < 1 || depth > MaxCommentDepth {
+		return nil, 0, apperr.InvalidArgumentf("回复层级必须在1~%d之间，当前值：%d", MaxCommentDepth, depth)
+	}
+
+	total, err := s.commentRepo.CountRootComments(ctx, resourceID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计顶层评论总数失败：%w", err)
+	}
+	if total == 0 {
+		return []*dto.CommentNode{}, 0, nil
+	}
+
+	roots, err := s.commentRepo.ListRootComments(ctx, resourceID, (page-1)*size, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询顶层评论失败：%w", err)
+	}
+
+	nodes := make(map[uint64]*dto.CommentNode, len(roots))
+	tree := make([]*dto.CommentNode, 0, len(roots))
+	rootIDs := make([]uint64, 0, len(roots))
+	for _, c := range roots {
+		node := &dto.CommentNode{CommentItem: convertCommentToDTO(c), Replies: []*dto.CommentNode{}}
+		nodes[c.ID] = node
+		tree = append(tree, node)
+		rootIDs = append(rootIDs, c.ID)
+	}
+
+	// 多取一层：超出depth的回复不返回内容，仅用于统计边界节点的reply_count
+	replies, err := s.commentRepo.ListByRootIDs(ctx, rootIDs, depth+1)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询评论回复失败：%w", err)
+	}
+	// replies按时间正序返回，父评论必然先于子评论出现
+	for _, c := range replies {
+		parent, ok := nodes[c.ParentID]
+		if !ok {
+			continue
+		}
+		parent.ReplyCount++
+		if c.Depth > depth {
+			continue
+		}
+		node := &dto.CommentNode{CommentItem: convertCommentToDTO(c), Replies: []*dto.CommentNode{}}
+		nodes[c.ID] = node
+		parent.Replies = append(parent.Replies, node)
+	}
+	return tree, total, nil
+}
+
+// UpdateComment 编辑评论（仅评论作者可编辑，内容与@提及在同一事务内更新）
+func (s *ResourceServiceImpl) UpdateComment(ctx context.Context, userUUID string, commentID uint64, content string) (*dto.CommentItem, error) {
+	if commentID <= 0 {
+		return nil, apperr.InvalidArgumentf("评论ID无效（id=%d）", commentID)
+	}
+	content, err := checkCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.getOwnComment(ctx, userUUID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Hidden {
+		return nil, apperr.FailedPrecondition("评论已被管理员隐藏，无法编辑")
+	}
+	mentioned, err := s.resolveMentions(ctx, content, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.commentRepo.UpdateContent(ctx, tx, commentID, content); err != nil {
+		return nil, fmt.Errorf("编辑评论失败：%w", err)
+	}
+	// 移除不再被@的用户，已存在的提及保留原已读状态（避免重复提醒）
+	keepIDs := make([]uint64, 0, len(mentioned))
+	for _, u := range mentioned {
+		keepIDs = append(keepIDs, u.ID)
+	}
+	if err := s.commentRepo.PruneMentions(ctx, tx, commentID, keepIDs); err != nil {
+		return nil, fmt.Errorf("更新@提及失败：%w", err)
+	}
+	if err := s.commentRepo.CreateMentions(ctx, tx, commentID, mentioned); err != nil {
+		return nil, fmt.Errorf("更新@提及失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交编辑评论事务失败：%w", err)
+	}
+
+	comment.Content = content
+	comment.UpdateTime = time.Now()
+	item := convertCommentToDTO(comment)
+	item.Mentions = mentionNames(mentioned)
+	return &item, nil
+}
+
+// DeleteComment 删除评论（仅评论作者可删除；评论下的回复一并删除，删除记录与评论量扣减在同一事务内）
+func (s *ResourceServiceImpl) DeleteComment(ctx context.Context, userUUID string, commentID uint64) error {
+	if commentID <= 0 {
+		return apperr.InvalidArgumentf("评论ID无效（id=%d）", commentID)
+	}
+
+	comment, err := s.getOwnComment(ctx, userUUID, commentID)
+	if err != nil {
+		return err
+	}
+
+	// 1. 收集待删除的评论：自身 + 全部子孙回复（含被隐藏的回复，同一楼层内按parent_id向下展开）
+	ids, visible, err := collectCommentSubtree(ctx, s.commentRepo, comment)
+	if err != nil {
+		return err
+	}
+
+	// 2. 开启事务：删除@提及 + 删除评论 + 评论量-n
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.commentRepo.DeleteMentions(ctx, tx, ids); err != nil {
+		return fmt.Errorf("删除@提及失败：%w", err)
+	}
+	if _, err := s.commentRepo.DeleteComments(ctx, tx, ids); err != nil {
+		return fmt.Errorf("删除评论记录失败：%w", err)
+	}
+	// 被隐藏的评论本就不计入评论量，只扣减未隐藏的条数
+	if err := s.resourceRepo.DecrCommentCount(ctx, tx, comment.ResourceID, visible); err != nil {
+		return fmt.Errorf("更新评论数失败：%w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交删除评论事务失败：%w", err)
+	}
+	return nil
+}
+
+// GetMentions 分页查询@到当前用户的提醒
+func (s *ResourceServiceImpl) GetMentions(ctx context.Context, userUUID string, page, size int) (*dto.MentionListResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if page < 1 {
+		return nil, apperr.InvalidArgumentf("页码必须≥1，当前值：%d", page)
+	}
+	if size < 1 || size > 50 {
+		return nil, apperr.InvalidArgumentf("每页条数必须在1~50之间，当前值：%d", size)
+	}
+
+	total, err := s.commentRepo.CountMentions(ctx, userUUID, false)
+	if err != nil {
+		return nil, fmt.Errorf("统计@提醒失败：%w", err)
+	}
+	unread, err := s.commentRepo.CountMentions(ctx, userUUID, true)
+	if err != nil {
+		return nil, fmt.Errorf("统计未读@提醒失败：%w", err)
+	}
+
+	resp := &dto.MentionListResp{List: []dto.MentionItem{}, Total: total, Unread: unread, Page: page, Size: size}
+	if total == 0 {
+		return resp, nil
+	}
+
+	mentions, err := s.commentRepo.ListMentions(ctx, userUUID, (page-1)*size, size)
+	if err != nil {
+		return nil, fmt.Errorf("查询@提醒失败：%w", err)
+	}
+	for _, m := range mentions {
+		resp.List = append(resp.List, dto.MentionItem{
+			ID:         m.ID,
+			IsRead:     m.IsRead,
+			CreateTime: m.CreateTime.Format("2006-01-02 15:04:05"),
+			Comment:    convertCommentToDTO(&m.Comment),
+		})
+	}
+	return resp, nil
+}
+
+// MarkMentionsRead @提醒全部标记已读
+func (s *ResourceServiceImpl) MarkMentionsRead(ctx context.Context, userUUID string) error {
+	if strings.TrimSpace(userUUID) == "" {
+		return apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if err := s.commentRepo.MarkMentionsRead(ctx, userUUID); err != nil {
+		return fmt.Errorf("标记@提醒已读失败：%w", err)
+	}
+	return nil
+}
+
+// getOwnComment 查询评论并校验归属（评论不存在/非本人均返回业务错误）
+func (s *ResourceServiceImpl) getOwnComment(ctx context.Context, userUUID string, commentID uint64) (*model.Comment, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	comment, err := s.commentRepo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("查询评论失败：%w", err)
+	}
+	if comment == nil {
+		return nil, apperr.ErrCommentNotFound.WithMsgf("评论不存在（id=%d）", commentID)
+	}
+	if comment.UserUUID != userUUID {
+		return nil, apperr.PermissionDenied("无权操作他人的评论")
+	}
+	return comment, nil
+}
+
+// resolveMentions 解析评论中的@用户名并通过UserRepo查询真实用户（忽略不存在的用户和自己）
+func (s *ResourceServiceImpl) resolveMentions(ctx context.Context, content, selfUUID string) ([]*model.User, error) {
+	var users []*model.User
+	for _, name := range parseMentions(content) {
+		user, err := s.userRepo.GetUserByCredential(ctx, name, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("解析@用户失败（%s）：%w", name, err)
+		}
+		if user == nil || user.UUID == selfUUID {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// parseMentions 提取评论中的@用户名（去重、保持出现顺序、最多MaxMentionsPerComment个）
+func parseMentions(content string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range mentionRegex.FindAllStringSubmatch(content, -1) {
+		name := m[1]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) >= MaxMentionsPerComment {
+			break
+		}
+	}
+	return names
+}
+
+// mentionNames 提取用户名列表（用于响应中回显成功解析的@用户）
+func mentionNames(users []*model.User) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	return names
+}
+
+// collectCommentSubtree 收集评论自身及全部子孙回复的ID（包含被隐藏的回复），并统计其中未隐藏的条数
+func collectCommentSubtree(ctx context.Context, commentRepo repository.CommentRepo, comment *model.Comment) ([]uint64, int64, error) {
+	rootID := comment.RootID
+	if rootID == 0 {
+		rootID = comment.ID
+	}
+	floor, err := commentRepo.ListFloor(ctx, rootID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询评论回复失败：%w", err)
+	}
+	ids := collectSubtreeIDs(comment.ID, floor)
+
+	hidden := make(map[uint64]bool, len(floor))
+	for _, c := range floor {
+		hidden[c.ID] = c.Hidden
+	}
+	var visible int64
+	for _, id := range ids {
+		if !hidden[id] {
+			visible++
+		}
+	}
+	return ids, visible, nil
+}
+
+// collectSubtreeIDs 从楼层评论中收集以rootID为根的子树ID（含根自身）
+func collectSubtreeIDs(rootID uint64, floor []*model.Comment) []uint64 {
+	children := make(map[uint64][]uint64)
+	for _, c := range floor {
+		children[c.ParentID] = append(children[c.ParentID], c.ID)
+	}
+	ids := []uint64{rootID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+// checkCommentPage 评论分页参数校验
+func checkCommentPage(resourceID uint64, page, size int) error {
+	if resourceID <= 0 {
+		return apperr.InvalidArgumentf("资源ID无效（id=%d）", resourceID)
+	}
+	if page < 1 {
+		return apperr.InvalidArgumentf("页码必须≥1，当前值：%d", page)
+	}
+	if size < 1 || size > 50 {
+		return apperr.InvalidArgumentf("每页条数必须在1~50之间，当前值：%d", size)
+	}
+	return nil
+}
+
+// checkCommentContent 评论内容去空格 + 非空/长度校验
+func checkCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", apperr.InvalidArgument("评论内容不能为空")
+	}
+	if n := utf8.RuneCountInString(content); n > MaxCommentLen {
+		return "", apperr.InvalidArgumentf("评论内容不能超过%d字（当前：%d）", MaxCommentLen, n)
+	}
+	return content, nil
+}
+
+// convertCommentToDTO 评论model转DTO（时间统一格式化）
+func convertCommentToDTO(c *model.Comment) dto.CommentItem {
+	return dto.CommentItem{
+		ID:         c.ID,
+		ResourceID: c.ResourceID,
+		ParentID:   c.ParentID,
+		RootID:     c.RootID,
+		Depth:      c.Depth,
+		UserID:     c.UserID,
+		Username:   c.Username,
+		Content:    c.Content,
+		CreateTime: c.CreateTime.Format("2006-01-02 15:04:05"),
+		UpdateTime: c.UpdateTime.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/mail"
+	"CMS/internal/model"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SendEmailVerification 向当前账号邮箱发送归属验证码（注册或修改邮箱后可重新获取）
+func (s *staffServiceImpl) SendEmailVerification(ctx context.Context, userUUID, clientIP string) error {
+	email, err := s.unverifiedEmail(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	return s.sendEmailVerifyCode(ctx, email, clientIP)
+}
+
+// ConfirmEmail 校验邮箱验证码，通过后将当前账号邮箱标记为已验证
+func (s *staffServiceImpl) ConfirmEmail(ctx context.Context, userUUID, code string) error {
+	email, err := s.unverifiedEmail(ctx, userUUID)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyCodes.Verify(ctx, model.CodePurposeVerifyEmail, email, code); err != nil {
+		return err
+	}
+	return s.userRepo.MarkEmailVerified(ctx, userUUID, email)
+}
+
+// unverifiedEmail 查询账号当前绑定且尚未验证的邮箱
+func (s *staffServiceImpl) unverifiedEmail(ctx context.Context, userUUID string) (string, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return "", apperr.InvalidArgument("用户UUID不能为空")
+	}
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return "", err
+	}
+	if user.Email == nil || strings.TrimSpace(*user.Email) == "" {
+		return "", apperr.FailedPrecondition("账号未绑定邮箱，请先设置邮箱")
+	}
+	if user.EmailVerified {
+		return "", apperr.FailedPrecondition("邮箱已验证，无需重复验证")
+	}
+	return *user.Email, nil
+}
+
+// sendEmailVerifyCode 签发并发送邮箱归属验证码（发送失败撤销验证码并归还冷却）
+func (s *staffServiceImpl) sendEmailVerifyCode(ctx context.Context, email, clientIP string) error {
+	code, err := s.verifyCodes.Issue(ctx, model.CodePurposeVerifyEmail, email, clientIP)
+	if err != nil {
+		return err
+	}
+	msg, err := mail.NewVerifyCodeMessage(email, code, s.verifyCodes.TTL())
+	if err == nil {
+		err = s.mailer.Send(ctx, msg)
+	}
+	if err != nil {
+		s.verifyCodes.Cancel(ctx, model.CodePurposeVerifyEmail, email, clientIP)
+		return fmt.Errorf("发送邮箱验证码失败：%w", err)
+	}
+	return nil
+}
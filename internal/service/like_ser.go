@@ -0,0 +1,109 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LikeResource 点赞资源（幂等：重复点赞不会重复计数）
+func (s *ResourceServiceImpl) LikeResource(ctx context.Context, userUUID string, id uint64) (*dto.LikeResp, error) {
+	return s.toggleLike(ctx, userUUID, id, true)
+}
+
+// UnlikeResource 取消点赞（幂等：未点赞时直接返回当前点赞量）
+func (s *ResourceServiceImpl) UnlikeResource(ctx context.Context, userUUID string, id uint64) (*dto.LikeResp, error) {
+	return s.toggleLike(ctx, userUUID, id, false)
+}
+
+// IsLiked 查询当前用户是否已点赞资源（未登录直接返回false）
+func (s *ResourceServiceImpl) IsLiked(ctx context.Context, userUUID string, id uint64) (bool, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return false, nil
+	}
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return false, fmt.Errorf("查询点赞用户失败：%w", err)
+	}
+	return s.likeRepo.IsLiked(ctx, id, user.ID)
+}
+
+// toggleLike 点赞/取消点赞核心逻辑：锁定资源行→写/删明细→按明细重算like_count，全部在同一事务内完成
+func (s *ResourceServiceImpl) toggleLike(ctx context.Context, userUUID string, id uint64, like bool) (*dto.LikeResp, error) {
+	// 1. 参数校验
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if id <= 0 {
+		return nil, apperr.InvalidArgumentf("资源ID无效（id=%d）", id)
+	}
+
+	// 2. 查询点赞用户（身份取自JWT）
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询点赞用户失败：%w", err)
+	}
+
+	// 3. 开启事务
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启点赞事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 4. 锁定资源行（同时校验资源是否存在）
+	locked, err := s.resourceRepo.LockResource(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if locked == nil {
+		return nil, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+	}
+
+	// 5. 写入/删除点赞明细（重复操作影响行数为0，不报错）
+	if like {
+		_, err = s.likeRepo.AddLike(ctx, tx, id, user.ID)
+	} else {
+		_, err = s.likeRepo.RemoveLike(ctx, tx, id, user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. 按明细重算点赞量
+	likeCount, err := s.resourceRepo.SyncLikeCount(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交点赞事务失败：%w", err)
+	}
+	return &dto.LikeResp{ID: id, Liked: like, LikeCount: likeCount}, nil
+}
+
+// fillLiked 批量标记列表中当前用户已点赞的资源（未登录时全部为false）
+func (s *ResourceServiceImpl) fillLiked(ctx context.Context, userUUID string, items []dto.ResourceItem) error {
+	if strings.TrimSpace(userUUID) == "" || len(items) == 0 {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return fmt.Errorf("查询点赞用户失败：%w", err)
+	}
+
+	ids := make([]uint64, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	liked, err := s.likeRepo.LikedResourceIDs(ctx, user.ID, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Liked = liked[items[i].ID]
+	}
+	return nil
+}
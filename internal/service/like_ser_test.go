@@ -0,0 +1,123 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// memLikes 点赞明细的内存实现（key为资源ID+用户ID）
+type memLikes struct {
+	repository.LikeRepo
+	likes map[[2]uint64]bool
+}
+
+func (r *memLikes) AddLike(_ context.Context, _ *sql.Tx, resourceID, userID uint64) (bool, error) {
+	key := [2]uint64{resourceID, userID}
+	if r.likes[key] {
+		return false, nil
+	}
+	r.likes[key] = true
+	return true, nil
+}
+
+func (r *memLikes) RemoveLike(_ context.Context, _ *sql.Tx, resourceID, userID uint64) (bool, error) {
+	key := [2]uint64{resourceID, userID}
+	if !r.likes[key] {
+		return false, nil
+	}
+	delete(r.likes, key)
+	return true, nil
+}
+
+// likeResourceRepo 资源行锁与点赞量重算的内存实现（点赞量按memLikes明细计数）
+type likeResourceRepo struct {
+	repository.ResourceRepo
+	db    *sql.DB
+	ids   map[uint64]bool
+	likes *memLikes
+}
+
+func (r *likeResourceRepo) GetDB() *sql.DB { return r.db }
+
+func (r *likeResourceRepo) LockResource(_ context.Context, _ *sql.Tx, id uint64) (*model.Resource, error) {
+	if !r.ids[id] {
+		return nil, nil
+	}
+	return &model.Resource{ID: id}, nil
+}
+
+func (r *likeResourceRepo) SyncLikeCount(_ context.Context, _ *sql.Tx, id uint64) (uint64, error) {
+	var n uint64
+	for key := range r.likes.likes {
+		if key[0] == id {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// uuidUserRepo 按UUID查询用户的内存实现
+type uuidUserRepo struct {
+	repository.UserRepo
+	users map[string]*model.User
+}
+
+func (r *uuidUserRepo) GetUserByUuid(_ context.Context, uuid string) (*model.User, error) {
+	if u, ok := r.users[uuid]; ok {
+		return u, nil
+	}
+	return nil, apperr.ErrUserNotFound
+}
+
+func TestToggleLike(t *testing.T) {
+	likes := &memLikes{likes: make(map[[2]uint64]bool)}
+	s := &ResourceServiceImpl{
+		resourceRepo: &likeResourceRepo{db: openStubDB(t), ids: map[uint64]bool{1: true}, likes: likes},
+		userRepo: &uuidUserRepo{users: map[string]*model.User{
+			"alice": {ID: 10, UUID: "alice"},
+			"bob":   {ID: 20, UUID: "bob"},
+		}},
+		likeRepo: likes,
+	}
+	ctx := context.Background()
+
+	steps := []struct {
+		name      string
+		user      string
+		like      bool
+		wantCount uint64
+	}{
+		{"alice点赞", "alice", true, 1},
+		{"alice重复点赞不累加", "alice", true, 1},
+		{"bob点赞", "bob", true, 2},
+		{"alice取消点赞", "alice", false, 1},
+		{"alice重复取消不扣减", "alice", false, 1},
+		{"alice再次点赞", "alice", true, 2},
+		{"bob取消点赞", "bob", false, 1},
+	}
+	for _, step := range steps {
+		toggle := s.UnlikeResource
+		if step.like {
+			toggle = s.LikeResource
+		}
+		resp, err := toggle(ctx, step.user, 1)
+		if err != nil {
+			t.Fatalf("%s：返回错误：%v", step.name, err)
+		}
+		if resp.ID != 1 || resp.Liked != step.like || resp.LikeCount != step.wantCount {
+			t.Fatalf("%s：返回%+v，期望liked=%v、点赞量%d", step.name, resp, step.like, step.wantCount)
+		}
+	}
+
+	if _, err := s.LikeResource(ctx, "alice", 2); !errors.Is(err, apperr.ErrResourceNotFound) {
+		t.Errorf("点赞不存在的资源返回%v，期望ErrResourceNotFound", err)
+	}
+	if _, err := s.LikeResource(ctx, " ", 1); !errors.Is(err, apperr.ErrInvalidArgument) {
+		t.Errorf("用户UUID为空返回%v，期望ErrInvalidArgument", err)
+	}
+}
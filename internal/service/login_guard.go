@@ -0,0 +1,171 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/config"
+	"CMS/internal/dto"
+	"CMS/internal/model"
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"context"
+	"strings"
+	"time"
+)
+
+const loginGuardSweepTimeout = 30 * time.Second // 单次清理超时
+
+// LoginGuard 密码登录防暴力破解：按账号、IP分别统计连续失败次数，达到阈值后临时锁定，
+// 锁定期间再失败则锁定时长翻倍；同时记录每次登录结果供用户查看
+type LoginGuard struct {
+	repo repository.LoginAttemptRepo
+	cfg  config.LoginGuardConfig
+}
+
+// NewLoginGuard 创建登录防护
+func NewLoginGuard(repo repository.LoginAttemptRepo, cfg config.LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{repo: repo, cfg: cfg}
+}
+
+// Check 校验key是否处于锁定期（账号与IP锁定返回同样的提示，不暴露账号是否存在）
+func (g *LoginGuard) Check(ctx context.Context, key string) error {
+	lockout, err := g.repo.GetLockout(ctx, key)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if lockout == nil || !lockout.LockedUntil.Valid || !lockout.LockedUntil.Time.After(now) {
+		return nil
+	}
+	wait := int(lockout.LockedUntil.Time.Sub(now).Round(time.Second) / time.Second)
+	if wait < 1 {
+		wait = 1
+	}
+	return apperr.ErrAccountLocked.WithMsgf("登录失败次数过多，请%d秒后再试", wait)
+}
+
+// Fail 记录一次失败：账号、IP失败次数分别+1，达到各自阈值后设置锁定（失败只记录日志）
+func (g *LoginGuard) Fail(ctx context.Context, accountKey, ipKey string) {
+	now := time.Now()
+	for _, k := range []struct {
+		key         string
+		maxFailures int
+	}{
+		{accountKey, g.cfg.AccountMaxFailures},
+		{ipKey, g.cfg.IPMaxFailures},
+	} {
+		failures, err := g.repo.AddFailure(ctx, k.key, now, g.cfg.FailureWindow)
+		if err != nil {
+			logger.FromContext(ctx).Error("[登录防护] 记录失败次数失败", "key", k.key, "error", err)
+			continue
+		}
+		if failures < k.maxFailures {
+			continue
+		}
+		until := now.Add(g.lockoutDuration(failures - k.maxFailures))
+		if err := g.repo.SetLockedUntil(ctx, k.key, until); err != nil {
+			logger.FromContext(ctx).Error("[登录防护] 设置锁定失败", "key", k.key, "error", err)
+		}
+	}
+}
+
+// Reset 清除账号的失败计数与锁定（登录成功、重置/修改密码后调用；IP计数不清除，避免用自己的账号为攻击IP解锁）
+func (g *LoginGuard) Reset(ctx context.Context, accountKey string) {
+	if err := g.repo.DeleteLockout(ctx, accountKey); err != nil {
+		logger.FromContext(ctx).Error("[登录防护] 清除锁定失败", "key", accountKey, "error", err)
+	}
+}
+
+// Record 写入登录记录（失败只记录日志，不影响登录结果）
+func (g *LoginGuard) Record(ctx context.Context, attempt *model.LoginAttempt) {
+	attempt.Identifier = truncateRunes(attempt.Identifier, 100)
+	attempt.UserAgent = truncateRunes(attempt.UserAgent, 255)
+	if err := g.repo.CreateAttempt(ctx, attempt); err != nil {
+		logger.FromContext(ctx).Error("[登录防护] 写入登录记录失败", "identifier", attempt.Identifier, "error", err)
+	}
+}
+
+// History 分页查询账号的登录记录
+func (g *LoginGuard) History(ctx context.Context, userUUID string, page, size int) (*dto.LoginAttemptListResp, error) {
+	total, err := g.repo.CountAttempts(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.LoginAttemptListResp{List: []dto.LoginAttemptItem{}, Total: total, Page: page, Size: size}
+	if total == 0 {
+		return resp, nil
+	}
+
+	attempts, err := g.repo.ListAttempts(ctx, userUUID, (page-1)*size, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attempts {
+		resp.List = append(resp.List, dto.LoginAttemptItem{
+			ID:         a.ID,
+			Identifier: a.Identifier,
+			IP:         a.IP,
+			UserAgent:  a.UserAgent,
+			Result:     a.Result,
+			CreateTime: a.CreateTime.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return resp, nil
+}
+
+// Run 后台定期清理过期的失败计数与登录记录（ctx取消时退出）
+func (g *LoginGuard) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			g.sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweep 清理过期记录
+func (g *LoginGuard) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), loginGuardSweepTimeout)
+	defer cancel()
+	if _, err := g.repo.PurgeExpired(ctx, time.Now(), g.cfg.FailureWindow, g.cfg.HistoryRetention); err != nil {
+		logger.FromContext(ctx).Error("[登录防护] 清理过期记录失败", "error", err)
+	}
+}
+
+// lockoutDuration 锁定时长：lockout_base × 2^excess，不超过lockout_max
+func (g *LoginGuard) lockoutDuration(excess int) time.Duration {
+	d := g.cfg.LockoutBase
+	for i := 0; i < excess && d < g.cfg.LockoutMax; i++ {
+		d *= 2
+	}
+	if d > g.cfg.LockoutMax {
+		d = g.cfg.LockoutMax
+	}
+	return d
+}
+
+// accountLockKey 账号计数key：账号存在时按UUID计数（用户名/手机号/邮箱登录共用一个计数），
+// 不存在时按登录标识计数，两种情况锁定行为一致，不暴露账号是否存在
+func accountLockKey(user *model.User, identifier string) string {
+	if user != nil {
+		return "account|" + user.UUID
+	}
+	return "account|" + strings.ToLower(identifier)
+}
+
+// ipLockKey IP计数key
+func ipLockKey(ip string) string {
+	return "ip|" + ip
+}
+
+// truncateRunes 按字符截断到登录记录字段长度
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
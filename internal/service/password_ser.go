@@ -0,0 +1,151 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/mail"
+	"CMS/internal/model"
+	pkg "CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ForgotPassword 忘记密码：向已验证的账号邮箱发送重置验证码
+// 邮箱未注册或未验证时同样占用发送冷却并返回成功（不提示账号是否存在，防止枚举注册邮箱；未验证的邮箱可能不属于该用户）
+func (s *staffServiceImpl) ForgotPassword(ctx context.Context, email, clientIP string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return apperr.InvalidArgument("邮箱不能为空")
+	}
+	// 先占用冷却再查账号：无论邮箱是否存在，同一邮箱/IP的请求频率限制一致
+	if err := s.verifyCodes.Throttle(ctx, model.CodePurposeResetPassword, email, clientIP); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByemail(ctx, email)
+	if err != nil {
+		if errors.Is(err, apperr.ErrUserNotFound) {
+			logger.FromContext(ctx).Info("[忘记密码] 邮箱未注册，跳过发送", "email", email)
+			return nil
+		}
+		return fmt.Errorf("查询用户失败：%w", err)
+	}
+	if !user.EmailVerified {
+		logger.FromContext(ctx).Info("[忘记密码] 邮箱未验证，跳过发送", "email", email)
+		return nil
+	}
+
+	code, err := s.verifyCodes.Generate(ctx, model.CodePurposeResetPassword, email, clientIP)
+	if err != nil {
+		return err
+	}
+	msg, err := mail.NewPasswordResetMessage(email, mail.PasswordResetData{
+		Username:      user.Username,
+		Code:          code,
+		ExpireMinutes: int(s.verifyCodes.TTL() / time.Minute),
+	})
+	if err == nil {
+		err = s.mailer.Send(ctx, msg)
+	}
+	if err != nil {
+		// 发送失败撤销验证码并归还冷却，用户可立即重试
+		s.verifyCodes.Cancel(ctx, model.CodePurposeResetPassword, email, clientIP)
+		return fmt.Errorf("发送重置验证码失败：%w", err)
+	}
+	return nil
+}
+
+// ResetPassword 通过邮箱验证码重置密码，成功后该账号所有设备需重新登录
+func (s *staffServiceImpl) ResetPassword(ctx context.Context, req dto.PasswordResetReq) error {
+	if strings.TrimSpace(req.NewPassword) == "" {
+		return apperr.InvalidArgument("新密码不能为空")
+	}
+	if err := s.verifyCodes.Verify(ctx, model.CodePurposeResetPassword, req.Email, req.Code); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByemail(ctx, strings.TrimSpace(req.Email))
+	if err != nil {
+		if errors.Is(err, apperr.ErrUserNotFound) {
+			return apperr.ErrUserNotFound
+		}
+		return fmt.Errorf("查询用户失败：%w", err)
+	}
+	// 仅已验证的邮箱可用于找回密码（验证码签发后邮箱可能已被修改为未验证状态）
+	if !user.EmailVerified {
+		return apperr.ErrEmailUnverified.WithMsg("邮箱未验证，无法通过邮箱重置密码")
+	}
+
+	return s.setPassword(ctx, user.UUID, func(*model.User) error { return nil }, req.NewPassword)
+}
+
+// ChangePassword 已登录用户修改密码（需校验原密码），成功后该账号所有设备需重新登录
+func (s *staffServiceImpl) ChangePassword(ctx context.Context, userUUID string, req dto.PasswordChangeReq) error {
+	if strings.TrimSpace(userUUID) == "" {
+		return apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if strings.TrimSpace(req.NewPassword) == "" {
+		return apperr.InvalidArgument("新密码不能为空")
+	}
+	if req.NewPassword == req.OldPassword {
+		return apperr.InvalidArgument("新密码不能与原密码相同")
+	}
+
+	return s.setPassword(ctx, userUUID, func(user *model.User) error {
+		if !pkg.CheckPassword(req.OldPassword, user.PasswordHash) {
+			return apperr.ErrWrongPassword.WithMsg("原密码错误")
+		}
+		return nil
+	}, req.NewPassword)
+}
+
+// setPassword 修改密码并吊销全部会话：锁定用户 → check校验 → 写入新密码哈希 → 吊销全部刷新令牌（同一事务）
+// 提交后再使此前签发的访问Token全部失效
+func (s *staffServiceImpl) setPassword(ctx context.Context, userUUID string, check func(user *model.User) error, newPassword string) error {
+	passwordHash, err := pkg.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("密码加密失败：%w", err)
+	}
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启修改密码事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := s.updatePasswordTx(ctx, tx, userUUID, check, passwordHash); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交修改密码事务失败：%w", err)
+	}
+
+	if err := s.denylist.RevokeUser(ctx, userUUID, time.Now()); err != nil {
+		// 密码已修改、刷新令牌已吊销，访问Token最迟在过期后失效，仅记录日志
+		logger.FromContext(ctx).Error("[修改密码] 吊销访问Token失败", "user_uuid", userUUID, "error", err)
+	}
+	// 密码已更换，解除该账号此前因登录失败产生的锁定
+	s.loginGuard.Reset(ctx, accountLockKey(&model.User{UUID: userUUID}, ""))
+	return nil
+}
+
+// updatePasswordTx 事务内锁定用户、校验并写入新密码哈希，同时吊销全部刷新令牌
+func (s *staffServiceImpl) updatePasswordTx(ctx context.Context, tx *sql.Tx, userUUID string, check func(user *model.User) error, passwordHash string) error {
+	user, err := s.userRepo.LockUser(ctx, tx, userUUID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperr.ErrUserNotFound
+	}
+	if err := check(user); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, tx, userUUID, passwordHash); err != nil {
+		return fmt.Errorf("更新密码失败：%w", err)
+	}
+	return s.tokenRepo.RevokeUserRefreshTokens(ctx, tx, userUUID)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/config"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// emailUserRepo 按邮箱查询用户的内存实现（其余方法未实现）
+type emailUserRepo struct {
+	repository.UserRepo
+	users map[string]*model.User
+}
+
+func (r *emailUserRepo) GetByemail(_ context.Context, email string) (*model.User, error) {
+	if u, ok := r.users[email]; ok {
+		return u, nil
+	}
+	return nil, apperr.ErrUserNotFound
+}
+
+func TestForgotPasswordCooldownIndependentOfAccount(t *testing.T) {
+	unverified := "unverified@example.com"
+	s := &staffServiceImpl{
+		userRepo: &emailUserRepo{users: map[string]*model.User{
+			unverified: {UUID: "u1", Username: "u1", EmailVerified: false},
+		}},
+		verifyCodes: NewVerifyCodes(repository.NewMemoryCodeStore(), config.VerifyCodeConfig{
+			TTL:           5 * time.Minute,
+			EmailCooldown: time.Minute,
+			MaxAttempts:   5,
+		}),
+	}
+	ctx := context.Background()
+
+	for _, email := range []string{"missing@example.com", unverified} {
+		if err := s.ForgotPassword(ctx, email, ""); err != nil {
+			t.Fatalf("%s：首次请求返回错误：%v", email, err)
+		}
+		// 未注册/未验证的邮箱不发送验证码，但同样受发送冷却限制，响应与已注册邮箱一致
+		if err := s.ForgotPassword(ctx, email, ""); !errors.Is(err, apperr.ErrSendTooFrequent) {
+			t.Fatalf("%s：冷却期内再次请求返回%v，期望ErrSendTooFrequent", email, err)
+		}
+	}
+
+	// 未验证邮箱不会签发验证码
+	if err := s.verifyCodes.Verify(ctx, model.CodePurposeResetPassword, unverified, "000000"); !errors.Is(err, apperr.ErrVerifyCodeInvalid) {
+		t.Fatalf("未验证邮箱校验验证码返回%v，期望ErrVerifyCodeInvalid", err)
+	}
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/mail"
+	"CMS/internal/model"
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CodePreviewLines = 10 // 付费资源对未购买用户展示的代码预览行数
+)
+
+// MaxResourcePrice 资源价格上限（与DECIMAL(10,2)及业务规则一致）
+var MaxResourcePrice = decimal.NewFromFloat(9999.99)
+
+// PurchaseResource 购买付费资源：扣减买家余额、作者入账、写入购买记录，三步在同一事务内完成
+func (s *ResourceServiceImpl) PurchaseResource(ctx context.Context, buyerUUID string, id uint64) (*dto.PurchaseResp, error) {
+	// 1. 参数校验
+	if strings.TrimSpace(buyerUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if id <= 0 {
+		return nil, apperr.InvalidArgumentf("资源ID无效（id=%d）", id)
+	}
+
+	// 2. 查询买家（身份取自JWT）
+	buyer, err := s.userRepo.GetUserByUuid(ctx, buyerUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询购买用户失败：%w", err)
+	}
+
+	// 3. 开启事务并锁定资源行（避免购买过程中价格被修改）
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启购买事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 持锁期间资源与作者都经事务读取：改用连接池查询会在事务占用连接的同时再借连接，连接池耗尽时互相等待
+	resource, err := s.resourceRepo.LockResource(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil || resource.Hidden {
+		return nil, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
+	}
+
+	// 4. 业务校验：免费资源/自己的资源无需购买
+	if !resource.Price.IsPositive() {
+		return nil, apperr.FailedPrecondition("免费资源无需购买")
+	}
+	if resource.UserID == buyer.ID {
+		return nil, apperr.FailedPrecondition("无需购买自己发布的资源")
+	}
+
+	// 5. 查询作者UUID（账户表以user_uuid关联）
+	author, err := s.userRepo.GetUserByIdTx(ctx, tx, resource.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("查询资源作者失败：%w", err)
+	}
+	if author == nil {
+		return nil, apperr.ErrUserNotFound.WithMsgf("资源作者不存在（userID=%d）", resource.UserID)
+	}
+
+	// 6. 按固定顺序锁定买家与作者账户：两个用户同时购买对方的资源时，按请求顺序加锁会互相等待导致死锁
+	if err := lockAccountsInOrder(ctx, tx, s.accRepo, buyerUUID, author.UUID); err != nil {
+		return nil, err
+	}
+
+	// 7. 写入购买记录（唯一索引拦截重复购买）→ 买家扣款 → 作者入账
+	purchase := &model.ResourcePurchase{
+		ResourceID: id,
+		BuyerID:    buyer.ID,
+		BuyerUUID:  buyerUUID,
+		AuthorID:   author.ID,
+		Price:      resource.Price,
+		CreateTime: time.Now(),
+	}
+	if err := s.purchaseRepo.CreatePurchase(ctx, tx, purchase); err != nil {
+		return nil, err
+	}
+	// 买家付款与作者收款各记一条流水，以购买记录ID关联
+	referenceID := strconv.FormatUint(purchase.ID, 10)
+	debit := &model.AccountTransaction{
+		Type:        model.TxTypePurchase,
+		ReferenceID: referenceID,
+		Remark:      fmt.Sprintf("购买资源《%s》", resource.Title),
+	}
+	if err := s.accRepo.DeductBalance(ctx, tx, buyerUUID, resource.Price, debit); err != nil {
+		return nil, err
+	}
+	credit := &model.AccountTransaction{
+		Type:        model.TxTypeSale,
+		ReferenceID: referenceID,
+		Remark:      fmt.Sprintf("资源《%s》售出", resource.Title),
+	}
+	if err := s.accRepo.CreditBalance(ctx, tx, author.UUID, resource.Price, credit); err != nil {
+		return nil, fmt.Errorf("作者入账失败：%w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交购买事务失败：%w", err)
+	}
+
+	// 8. 返回购买结果（附带买家最新余额）
+	account, err := s.accRepo.GetAccountByUserUUID(ctx, buyerUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询购买后账户信息失败：%w", err)
+	}
+	resp := &dto.PurchaseResp{
+		ID:          id,
+		Price:       resource.Price,
+		CodeContent: resource.CodeContent,
+	}
+	if account != nil {
+		resp.Balance = account.Balance
+	}
+
+	// 9. 异步发送购买回执（邮件失败不影响购买结果）
+	if buyer.Email != nil && *buyer.Email != "" {
+		receipt := mail.PurchaseReceiptData{
+			Username:      buyer.Username,
+			PurchaseID:    purchase.ID,
+			ResourceTitle: resource.Title,
+			Price:         resource.Price.StringFixed(2),
+			Balance:       resp.Balance.StringFixed(2),
+			PurchaseTime:  purchase.CreateTime.Format("2006-01-02 15:04:05"),
+		}
+		go s.sendPurchaseReceipt(context.WithoutCancel(ctx), *buyer.Email, receipt)
+	}
+	return resp, nil
+}
+
+// lockAccountsInOrder 在事务内按user_uuid升序锁定多个账户（重复的UUID只锁一次）
+// 涉及多个账户的余额变动统一经此加锁，保证各事务的加锁顺序一致，避免互相等待形成死锁
+func lockAccountsInOrder(ctx context.Context, tx *sql.Tx, accRepo repository.AccountRepo, userUUIDs ...string) error {
+	sorted := slices.Clone(userUUIDs)
+	slices.Sort(sorted)
+	for _, userUUID := range slices.Compact(sorted) {
+		if _, err := accRepo.LockAccount(ctx, tx, userUUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPurchaseReceipt 发送购买回执邮件（未启用邮件发送时跳过）
+func (s *ResourceServiceImpl) sendPurchaseReceipt(ctx context.Context, to string, receipt mail.PurchaseReceiptData) {
+	msg, err := mail.NewPurchaseReceiptMessage(to, receipt)
+	if err != nil {
+		logger.FromContext(ctx).Error("[购买回执] 生成邮件失败", "purchase_id", receipt.PurchaseID, "error", err)
+		return
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil && !errors.Is(err, mail.ErrDisabled) {
+		logger.FromContext(ctx).Error("[购买回执] 发送邮件失败", "purchase_id", receipt.PurchaseID, "to", to, "error", err)
+	}
+}
+
+// SetResourcePrice 作者修改资源价格（0表示免费）
+func (s *ResourceServiceImpl) SetResourcePrice(ctx context.Context, userUUID string, id uint64, price decimal.Decimal) error {
+	if strings.TrimSpace(userUUID) == "" {
+		return apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if id <= 0 {
+		return apperr.InvalidArgumentf("资源ID无效（id=%d）", id)
+	}
+	if err := checkResourcePrice(price); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return fmt.Errorf("查询用户失败：%w", err)
+	}
+	return s.resourceRepo.UpdatePrice(ctx, id, user.ID, price)
+}
+
+// CanViewCode 判断用户是否可查看完整代码：免费资源、作者本人、已购买用户
+func (s *ResourceServiceImpl) CanViewCode(ctx context.Context, userUUID string, resource *model.Resource) (bool, error) {
+	if !resource.Price.IsPositive() {
+		return true, nil
+	}
+	if strings.TrimSpace(userUUID) == "" {
+		return false, nil
+	}
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return false, fmt.Errorf("查询用户失败：%w", err)
+	}
+	if user.ID == resource.UserID {
+		return true, nil
+	}
+	return s.purchaseRepo.HasPurchased(ctx, resource.ID, user.ID)
+}
+
+// CodePreview 截取代码预览：最多CodePreviewLines行，且不超过总行数的一半（短代码不会被完整展示）
+func CodePreview(code string) string {
+	lines := strings.Split(code, "\n")
+	n := CodePreviewLines
+	if half := len(lines) / 2; half < n {
+		n = half
+	}
+	return strings.Join(lines[:n], "\n") + "\n// ……购买后查看完整代码"
+}
+
+// fillAccess 列表页按当前用户的购买情况隐藏付费资源代码（仅作者本人和购买者返回完整代码）
+func (s *ResourceServiceImpl) fillAccess(ctx context.Context, userUUID string, items []dto.ResourceItem) error {
+	var paidIDs []uint64
+	for _, item := range items {
+		if item.Price.IsPositive() {
+			paidIDs = append(paidIDs, item.ID)
+		}
+	}
+	if len(paidIDs) == 0 {
+		return nil
+	}
+
+	var userID uint64
+	purchased := make(map[uint64]bool)
+	if strings.TrimSpace(userUUID) != "" {
+		user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+		if err != nil {
+			return fmt.Errorf("查询用户失败：%w", err)
+		}
+		userID = user.ID
+		if purchased, err = s.purchaseRepo.PurchasedResourceIDs(ctx, userID, paidIDs); err != nil {
+			return err
+		}
+	}
+
+	for i := range items {
+		item := &items[i]
+		if !item.Price.IsPositive() || item.UserID == userID || purchased[item.ID] {
+			continue
+		}
+		item.CodeContent = CodePreview(item.CodeContent)
+		item.Locked = true
+	}
+	return nil
+}
+
+// checkResourcePrice 校验价格：0~MaxResourcePrice，最多两位小数
+func checkResourcePrice(price decimal.Decimal) error {
+	if price.IsNegative() {
+		return apperr.InvalidArgument("资源价格不能为负数")
+	}
+	if price.GreaterThan(MaxResourcePrice) {
+		return apperr.InvalidArgumentf("资源价格不能超过%s", MaxResourcePrice.StringFixed(2))
+	}
+	if !price.Equal(price.Round(2)) {
+		return apperr.InvalidArgument("资源价格最多保留2位小数")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"CMS/internal/migrate"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// lockRecorder 记录LockAccount的调用顺序（其余方法未实现，误调用会panic）
+type lockRecorder struct {
+	repository.AccountRepo
+	locked []string
+}
+
+func (r *lockRecorder) LockAccount(_ context.Context, _ *sql.Tx, userUUID string) (decimal.Decimal, error) {
+	r.locked = append(r.locked, userUUID)
+	return decimal.Zero, nil
+}
+
+func TestLockAccountsInOrder(t *testing.T) {
+	cases := []struct {
+		name  string
+		uuids []string
+		want  []string
+	}{
+		{"已有序", []string{"a", "b"}, []string{"a", "b"}},
+		{"逆序", []string{"b", "a"}, []string{"a", "b"}},
+		{"重复UUID只锁一次", []string{"b", "a", "b"}, []string{"a", "b"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := &lockRecorder{}
+			if err := lockAccountsInOrder(context.Background(), nil, rec, tc.uuids...); err != nil {
+				t.Fatalf("lockAccountsInOrder返回错误：%v", err)
+			}
+			if !slices.Equal(rec.locked, tc.want) {
+				t.Fatalf("加锁顺序=%v，期望%v", rec.locked, tc.want)
+			}
+		})
+	}
+}
+
+// openTestDB 连接CMS_TEST_MYSQL_DSN指定的测试库并执行全部迁移，未配置时跳过
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("CMS_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("未设置CMS_TEST_MYSQL_DSN，跳过数据库测试")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("连接测试库失败：%v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	m, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("初始化迁移失败：%v", err)
+	}
+	if _, err := m.Up(context.Background(), 0); err != nil {
+		t.Fatalf("执行迁移失败：%v", err)
+	}
+	return db
+}
+
+// createFundedUser 创建用户及账户并充值balance
+func createFundedUser(t *testing.T, db *sql.DB, balance decimal.Decimal) *model.User {
+	t.Helper()
+	ctx := context.Background()
+	userRepo := repository.NewUserRepo(db)
+	accRepo := repository.NewAccountRepo(db)
+
+	user := &model.User{
+		UUID:         uuid.NewString(),
+		Username:     "t_" + uuid.NewString()[:8],
+		PasswordHash: "x",
+	}
+	if err := userRepo.CreateUser(ctx, nil, user); err != nil {
+		t.Fatalf("创建用户失败：%v", err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("开启事务失败：%v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := accRepo.CreateAccount(ctx, tx, user.UUID); err != nil {
+		t.Fatalf("创建账户失败：%v", err)
+	}
+	entry := &model.AccountTransaction{Type: model.TxTypeRecharge, Remark: "测试充值"}
+	if err := accRepo.RechargeBalance(ctx, tx, user.UUID, balance, entry); err != nil {
+		t.Fatalf("充值失败：%v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("提交事务失败：%v", err)
+	}
+	return user
+}
+
+// TestPurchaseResourceCrossBuyNoDeadlock 两个用户并发互购对方的资源，固定加锁顺序下不应出现死锁
+func TestPurchaseResourceCrossBuyNoDeadlock(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	resourceRepo := repository.NewResourceRepo(db)
+	s := &ResourceServiceImpl{
+		resourceRepo: resourceRepo,
+		userRepo:     repository.NewUserRepo(db),
+		accRepo:      repository.NewAccountRepo(db),
+		purchaseRepo: repository.NewPurchaseRepo(db),
+	}
+
+	const perUser = 20
+	price := decimal.NewFromInt(1)
+	initial := decimal.NewFromInt(100)
+	alice := createFundedUser(t, db, initial)
+	bob := createFundedUser(t, db, initial)
+
+	newResources := func(author *model.User) []uint64 {
+		ids := make([]uint64, 0, perUser)
+		for i := 0; i < perUser; i++ {
+			r := &model.Resource{
+				UserID:      author.ID,
+				Title:       fmt.Sprintf("互购测试-%d", i),
+				Author:      author.Username,
+				PublishTime: time.Now(),
+				Price:       price,
+			}
+			if err := resourceRepo.CreateResource(ctx, nil, r); err != nil {
+				t.Fatalf("创建资源失败：%v", err)
+			}
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	aliceRes := newResources(alice)
+	bobRes := newResources(bob)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*perUser)
+	for i := 0; i < perUser; i++ {
+		wg.Add(2)
+		go func(id uint64) {
+			defer wg.Done()
+			_, err := s.PurchaseResource(ctx, alice.UUID, id)
+			errs <- err
+		}(bobRes[i])
+		go func(id uint64) {
+			defer wg.Done()
+			_, err := s.PurchaseResource(ctx, bob.UUID, id)
+			errs <- err
+		}(aliceRes[i])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("购买失败：%v", err)
+		}
+	}
+
+	// 双方购买数量相同，最终余额应回到初始值
+	for _, u := range []*model.User{alice, bob} {
+		acc, err := s.accRepo.GetAccountByUserUUID(ctx, u.UUID)
+		if err != nil {
+			t.Fatalf("查询账户失败：%v", err)
+		}
+		if !acc.Balance.Equal(initial) {
+			t.Errorf("用户%s余额=%s，期望%s", u.Username, acc.Balance, initial)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/metrics"
+	"CMS/internal/model"
+	"CMS/internal/payment"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxRechargeAmount 单笔充值金额上限
+var MaxRechargeAmount = decimal.NewFromInt(50000)
+
+// Recharge 创建待支付充值单：参数校验 → 幂等键查重 → 写入pending充值单 → 支付渠道生成支付链接
+// 此处不变动余额，余额只在HandlePaymentCallback验签通过后入账
+func (s *accountServiceImpl) Recharge(ctx context.Context, req dto.RechargeRequest) (*dto.RechargeOrderResp, error) {
+	// 1. 严格参数校验（Service层核心职责）
+	if req.UserUUID == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, apperr.InvalidArgument("充值金额必须大于0")
+	}
+	if req.Amount.GreaterThan(MaxRechargeAmount) {
+		return nil, apperr.InvalidArgumentf("单笔充值金额不能超过%s", MaxRechargeAmount.StringFixed(2))
+	}
+	if !req.Amount.Equal(req.Amount.Round(2)) {
+		return nil, apperr.InvalidArgument("充值金额最多保留2位小数")
+	}
+	if err := checkIdempotencyKey(req.IdempotencyKey); err != nil {
+		return nil, err
+	}
+	provider, ok := s.providers[s.defaultPay]
+	if !ok {
+		return nil, apperr.FailedPrecondition("未启用任何支付渠道，暂不支持充值")
+	}
+
+	// 2. 校验用户是否存在（可选，增强业务安全性）
+	if _, err := s.userRepo.GetUserByUuid(ctx, req.UserUUID); err != nil {
+		return nil, fmt.Errorf("用户不存在：%w", err)
+	}
+
+	// 3. 幂等键已创建过充值单：同一请求的重试返回原充值单
+	if req.IdempotencyKey != "" {
+		resp, err := s.replayRechargeOrder(ctx, req)
+		if err != nil || resp != nil {
+			return resp, err
+		}
+	}
+
+	// 4. 写入待支付充值单
+	orderNo, err := newRechargeOrderNo()
+	if err != nil {
+		return nil, err
+	}
+	order := &model.RechargeOrder{
+		OrderNo:        orderNo,
+		UserUUID:       req.UserUUID,
+		Amount:         req.Amount,
+		Provider:       provider.Name(),
+		Status:         model.RechargeStatusPending,
+		IdempotencyKey: req.IdempotencyKey,
+		CreateTime:     time.Now(),
+	}
+	if err := s.rechargeRepo.CreateOrder(ctx, order); err != nil {
+		// 同一幂等键的并发请求：唯一索引拦截后返回先写入的充值单
+		if req.IdempotencyKey != "" && errors.Is(err, apperr.ErrIdempotencyConflict) {
+			if resp, replayErr := s.replayRechargeOrder(ctx, req); replayErr != nil || resp != nil {
+				return resp, replayErr
+			}
+		}
+		return nil, err
+	}
+
+	// 5. 支付渠道生成支付链接（失败则关闭充值单，避免残留无法支付的pending单）
+	intent, err := provider.CreatePayment(ctx, order.OrderNo, order.Amount, "账户充值")
+	if err != nil {
+		_ = s.rechargeRepo.MarkFailed(ctx, nil, order.OrderNo, "")
+		return nil, fmt.Errorf("发起支付失败：%w", err)
+	}
+	metrics.RechargeOrders.Inc()
+	resp := toRechargeOrderResp(order)
+	resp.PayURL = intent.PayURL
+	return resp, nil
+}
+
+// replayRechargeOrder 按幂等键返回已创建的充值单（不存在返回nil, nil；金额不同视为冲突）
+func (s *accountServiceImpl) replayRechargeOrder(ctx context.Context, req dto.RechargeRequest) (*dto.RechargeOrderResp, error) {
+	prev, err := s.rechargeRepo.GetOrderByIdempotencyKey(ctx, req.UserUUID, req.IdempotencyKey)
+	if err != nil || prev == nil {
+		return nil, err
+	}
+	if !prev.Amount.Equal(req.Amount) {
+		return nil, apperr.ErrIdempotencyConflict.WithMsgf("幂等键已被用于其他请求（idempotency_key=%s）", req.IdempotencyKey)
+	}
+	resp, err := s.withPayURL(ctx, prev)
+	if err != nil {
+		return nil, err
+	}
+	resp.Replayed = true
+	return resp, nil
+}
+
+// HandlePaymentCallback 处理支付渠道回调
+// 验签由渠道实现（ParseCallback），随后在同一事务内：锁定充值单 → 校验渠道/金额 → 标记已支付 → 余额入账并写充值流水
+// 渠道重复回调时充值单已是paid状态，直接返回成功，不会重复入账
+func (s *accountServiceImpl) HandlePaymentCallback(ctx context.Context, providerName string, r *http.Request) error {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return apperr.NotFound(fmt.Sprintf("不支持的支付渠道（provider=%s）", providerName))
+	}
+
+	// 1. 验签并解析回调
+	result, err := provider.ParseCallback(r)
+	if err != nil {
+		return apperr.ErrPaymentCallbackInvalid.WithMsg("回调验签失败").Wrap(err)
+	}
+
+	// 2. 开启事务并锁定充值单（同一充值单的并发回调在此排队）
+	tx, err := s.rechargeRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启充值回调事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	order, err := s.rechargeRepo.LockOrder(ctx, tx, result.OrderNo)
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return apperr.ErrOrderNotFound.WithMsgf("充值单不存在（order_no=%s）", result.OrderNo)
+	}
+	if order.Provider != providerName {
+		return apperr.ErrPaymentCallbackInvalid.WithMsgf("充值单支付渠道不匹配（order_no=%s）", result.OrderNo)
+	}
+
+	// 3. 状态判断：已支付→幂等返回；已关闭→拒绝入账；支付失败回调→关闭充值单
+	switch order.Status {
+	case model.RechargeStatusPaid:
+		return nil
+	case model.RechargeStatusFailed:
+		return apperr.ErrPaymentCallbackInvalid.WithMsgf("充值单已关闭（order_no=%s）", result.OrderNo)
+	}
+	if !result.Paid {
+		if err := s.rechargeRepo.MarkFailed(ctx, tx, order.OrderNo, result.TradeNo); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交充值回调事务失败：%w", err)
+		}
+		metrics.Recharges.WithLabelValues(model.RechargeStatusFailed).Inc()
+		return nil
+	}
+	if !result.Amount.Equal(order.Amount) {
+		return apperr.ErrPaymentCallbackInvalid.WithMsgf("回调金额与充值单不一致（回调：%s，充值单：%s）", result.Amount.String(), order.Amount.String())
+	}
+
+	// 4. 标记已支付并入账（充值流水以充值单号关联）
+	if err := s.rechargeRepo.MarkPaid(ctx, tx, order.OrderNo, result.TradeNo, time.Now()); err != nil {
+		return err
+	}
+	entry := &model.AccountTransaction{
+		Type:        model.TxTypeRecharge,
+		ReferenceID: order.OrderNo,
+		Remark:      fmt.Sprintf("账户充值（%s）", providerName),
+	}
+	if err := s.accountRepo.RechargeBalance(ctx, tx, order.UserUUID, order.Amount, entry); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交充值回调事务失败：%w", err)
+	}
+	metrics.Recharges.WithLabelValues(model.RechargeStatusPaid).Inc()
+	metrics.RechargeAmount.Add(order.Amount.InexactFloat64())
+	return nil
+}
+
+// GetRechargeOrder 查询充值单（仅本人可查）
+func (s *accountServiceImpl) GetRechargeOrder(ctx context.Context, userUUID, orderNo string) (*dto.RechargeOrderResp, error) {
+	order, err := s.getOwnRechargeOrder(ctx, userUUID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPayURL(ctx, order)
+}
+
+// SimulatePayment 模拟用户完成支付：由渠道构造签名回调，走与真实支付相同的回调入账流程
+func (s *accountServiceImpl) SimulatePayment(ctx context.Context, userUUID, orderNo string) (*dto.RechargeOrderResp, error) {
+	order, err := s.getOwnRechargeOrder(ctx, userUUID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != model.RechargeStatusPending {
+		return nil, apperr.FailedPrecondition(fmt.Sprintf("充值单不是待支付状态（status=%s）", order.Status))
+	}
+	simulator, ok := s.providers[order.Provider].(payment.Simulator)
+	if !ok {
+		return nil, apperr.FailedPrecondition(fmt.Sprintf("支付渠道不支持模拟支付（provider=%s）", order.Provider))
+	}
+	if err := simulator.SimulatePay(ctx, order.OrderNo, order.Amount); err != nil {
+		return nil, fmt.Errorf("模拟支付失败：%w", err)
+	}
+	return s.GetRechargeOrder(ctx, userUUID, orderNo)
+}
+
+// getOwnRechargeOrder 查询充值单并校验归属（他人充值单同样返回不存在，避免泄露单号）
+func (s *accountServiceImpl) getOwnRechargeOrder(ctx context.Context, userUUID, orderNo string) (*model.RechargeOrder, error) {
+	if userUUID == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if strings.TrimSpace(orderNo) == "" {
+		return nil, apperr.InvalidArgument("充值单号不能为空")
+	}
+	order, err := s.rechargeRepo.GetOrderByNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.UserUUID != userUUID {
+		return nil, apperr.ErrOrderNotFound.WithMsgf("充值单不存在（order_no=%s）", orderNo)
+	}
+	return order, nil
+}
+
+// withPayURL 转换为响应DTO，待支付充值单重新获取支付链接
+func (s *accountServiceImpl) withPayURL(ctx context.Context, order *model.RechargeOrder) (*dto.RechargeOrderResp, error) {
+	resp := toRechargeOrderResp(order)
+	if order.Status != model.RechargeStatusPending {
+		return resp, nil
+	}
+	provider, ok := s.providers[order.Provider]
+	if !ok {
+		return resp, nil
+	}
+	intent, err := provider.CreatePayment(ctx, order.OrderNo, order.Amount, "账户充值")
+	if err != nil {
+		return nil, fmt.Errorf("获取支付链接失败：%w", err)
+	}
+	resp.PayURL = intent.PayURL
+	return resp, nil
+}
+
+// toRechargeOrderResp 充值单模型转响应DTO
+func toRechargeOrderResp(order *model.RechargeOrder) *dto.RechargeOrderResp {
+	resp := &dto.RechargeOrderResp{
+		OrderNo:    order.OrderNo,
+		Amount:     order.Amount,
+		Provider:   order.Provider,
+		Status:     order.Status,
+		CreateTime: order.CreateTime.Format("2006-01-02 15:04:05"),
+	}
+	if order.PaidTime != nil {
+		resp.PaidTime = order.PaidTime.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+// newRechargeOrderNo 生成充值单号：R + 秒级时间戳 + 12位随机十六进制
+func newRechargeOrderNo() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成充值单号失败：%w", err)
+	}
+	return "R" + time.Now().Format("20060102150405") + hex.EncodeToString(buf), nil
+}
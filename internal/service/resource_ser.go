@@ -1,91 +1,165 @@
 package service
 
 import (
+	"CMS/internal/apperr"
+	"CMS/internal/config"
 	"CMS/internal/dto"
+	"CMS/internal/metrics"
+	"CMS/internal/search"
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"CMS/internal/mail"
 	"CMS/internal/model"
 	"CMS/internal/repository"
+
+	"github.com/shopspring/decimal"
 )
 
 type ResourceService interface {
-	GetResourceList(ctx context.Context, page, size int, keyword string) ([]dto.ResourceItem, int64, error)
-	CreateResource(ctx context.Context, userID uint64, title, text, code string) error
+	GetResourceList(ctx context.Context, userUUID string, req dto.ResourceListReq) ([]dto.ResourceItem, int64, error) // 分页查询（带关键词时全文检索并按相关度排序，实现见search_ser.go）
+	CreateResource(ctx context.Context, userID uint64, req dto.CreateResourceReq) (*model.Resource, error)            // 发布资源（标签/语言/分类与资源在同一事务内写入）
 	GetResourceByID(ctx context.Context, id uint64) (*model.Resource, error)
-	IncrViewCount(ctx context.Context, id uint64) error
-	IncrLikeCount(ctx context.Context, id uint64) error                 // 增加点赞数
-	CreateComment(ctx context.Context, id uint64, content string) error // 创建评论（并增加评论数）
-
+	IncrViewCount(ctx context.Context, id uint64, viewer string) (bool, error) // 记录浏览（窗口期内同一浏览者去重，返回是否计入）
+	// 标签/语言/分类相关（实现见taxonomy_ser.go）：分类为树形结构，按分类筛选时包含子孙分类
+	GetResourceFacets(ctx context.Context, req dto.ResourceListReq) (*dto.ResourceFacets, error)                           // 资源列表分面统计（条件与GetResourceList一致）
+	GetTaxonomy(ctx context.Context) (*dto.TaxonomyResp, error)                                                            // 分类树与支持的编程语言
+	UpdateResourceMeta(ctx context.Context, userUUID string, req dto.UpdateResourceMetaReq) (*dto.ResourceMetaResp, error) // 作者修改标签/语言/分类
+	// 点赞相关（实现见like_ser.go）：点赞明细存resource_likes表，like_count由明细计数得出
+	LikeResource(ctx context.Context, userUUID string, id uint64) (*dto.LikeResp, error)   // 点赞（重复点赞不累加）
+	UnlikeResource(ctx context.Context, userUUID string, id uint64) (*dto.LikeResp, error) // 取消点赞
+	IsLiked(ctx context.Context, userUUID string, id uint64) (bool, error)                 // 当前用户是否已点赞（userUUID为空返回false）
+	// 付费资源相关（实现见purchase_ser.go）：购买时扣款、入账、购买记录在同一事务内完成
+	PurchaseResource(ctx context.Context, buyerUUID string, id uint64) (*dto.PurchaseResp, error)  // 购买资源
+	SetResourcePrice(ctx context.Context, userUUID string, id uint64, price decimal.Decimal) error // 作者修改价格
+	CanViewCode(ctx context.Context, userUUID string, resource *model.Resource) (bool, error)      // 是否可查看完整代码
+	// 评论相关（实现见comment_ser.go）：评论者身份统一由userUUID（JWT解析）确定
+	CreateComment(ctx context.Context, userUUID string, id, parentID uint64, content string) (*dto.CommentItem, error) // 创建评论/回复（并增加评论数）
+	GetCommentList(ctx context.Context, resourceID uint64, page, size int) ([]dto.CommentItem, int64, error)           // 分页查询评论（平铺）
+	GetCommentTree(ctx context.Context, resourceID uint64, page, size, depth int) ([]*dto.CommentNode, int64, error)   // 分页查询评论树
+	UpdateComment(ctx context.Context, userUUID string, commentID uint64, content string) (*dto.CommentItem, error)    // 编辑评论（仅作者）
+	DeleteComment(ctx context.Context, userUUID string, commentID uint64) error                                        // 删除评论及其回复（仅作者，并减少评论数）
+	GetMentions(ctx context.Context, userUUID string, page, size int) (*dto.MentionListResp, error)                    // 分页查询@我的提醒
+	MarkMentionsRead(ctx context.Context, userUUID string) error                                                       // @提醒全部标记已读
 }
 
 type ResourceServiceImpl struct {
 	resourceRepo repository.ResourceRepo
 	userRepo     repository.UserRepo // 用于查询username
 	accRepo      repository.AccountRepo
+	commentRepo  repository.CommentRepo
+	likeRepo     repository.LikeRepo
+	purchaseRepo repository.PurchaseRepo
+	tagRepo      repository.TagRepo
+	categoryRepo repository.CategoryRepo
+	viewCounter  *ViewCounter       // 浏览量去重+批量刷盘
+	mailer       mail.Mailer        // 购买回执邮件发送
+	searchIndex  search.SearchIndex // 全文检索（发布资源时同步索引）
+	snippetLen   int                // 检索高亮摘要长度
 }
 
-func NewResourceService(resourceRepo repository.ResourceRepo, userRepo repository.UserRepo, accRepo repository.AccountRepo) ResourceService {
+func NewResourceService(resourceRepo repository.ResourceRepo, userRepo repository.UserRepo, accRepo repository.AccountRepo, commentRepo repository.CommentRepo, likeRepo repository.LikeRepo, purchaseRepo repository.PurchaseRepo, tagRepo repository.TagRepo, categoryRepo repository.CategoryRepo, viewCounter *ViewCounter, mailer mail.Mailer, searchIndex search.SearchIndex, searchCfg config.SearchConfig) ResourceService {
 	return &ResourceServiceImpl{
 		resourceRepo: resourceRepo,
 		userRepo:     userRepo,
 		accRepo:      accRepo,
+		commentRepo:  commentRepo,
+		likeRepo:     likeRepo,
+		purchaseRepo: purchaseRepo,
+		tagRepo:      tagRepo,
+		categoryRepo: categoryRepo,
+		viewCounter:  viewCounter,
+		mailer:       mailer,
+		searchIndex:  searchIndex,
+		snippetLen:   searchCfg.SnippetLength,
 	}
 }
 
-// CreateResource 新增资源（业务逻辑层）- 新增：设置点赞/浏览/评论量默认值0
-func (s *ResourceServiceImpl) CreateResource(ctx context.Context, userID uint64, title, text, code string) error {
+// CreateResource 新增资源（业务逻辑层）- 新增：设置点赞/浏览/评论量默认值0；price为0表示免费资源
+// 标签与资源在同一事务内写入；未指定语言时按代码内容自动识别
+func (s *ResourceServiceImpl) CreateResource(ctx context.Context, userID uint64, req dto.CreateResourceReq) (*model.Resource, error) {
+	title, price := req.Title, req.Price
 	// ========== 步骤1：基础参数校验（service层必须做，避免脏数据进入仓库） ==========
 	// 1.1 校验用户ID合法性
 	if userID == 0 {
-		return errors.New("用户ID不能为空（userID=0）")
+		return nil, apperr.InvalidArgument("用户ID不能为空（userID=0）")
 	}
 	// 1.2 校验标题合法性（非空 + 长度限制，根据业务调整）
 	title = strings.TrimSpace(title) // 去除首尾空格
 	if title == "" {
-		return errors.New("资源标题不能为空")
+		return nil, apperr.InvalidArgument("资源标题不能为空")
 	}
 	if len(title) > 100 { // 假设业务规则：标题最长100字符
-		return fmt.Errorf("资源标题长度不能超过100字符（当前长度：%d）", len(title))
+		return nil, apperr.InvalidArgumentf("资源标题长度不能超过100字符（当前长度：%d）", len(title))
+	}
+	// 1.3 校验价格（0~9999.99，最多两位小数）
+	if err := checkResourcePrice(price); err != nil {
+		return nil, err
+	}
+
+	// 1.4 校验并规范化标签/语言（分类在事务内锁定校验）
+	meta, err := newResourceMeta(req.Language, req.CategoryID, req.Tags, req.CodeContent)
+	if err != nil {
+		return nil, err
 	}
 
 	// ========== 步骤2：查询用户信息并校验 ==========
 	user, err := s.userRepo.GetUserById(ctx, userID)
 	if err != nil {
 		// 使用%w包装原始错误，上层可通过errors.Is/As判断根因
-		return fmt.Errorf("查询用户失败（userID=%d）：%w", userID, err)
+		return nil, fmt.Errorf("查询用户失败（userID=%d）：%w", userID, err)
 	}
 	// 2.1 校验用户是否存在（避免空指针）
 	if user == nil {
-		return fmt.Errorf("用户不存在（userID=%d）", userID)
+		return nil, apperr.ErrUserNotFound.WithMsgf("用户不存在（userID=%d）", userID)
 	}
 	// 2.2 校验用户名是否有效（避免冗余存储空值）
 	if strings.TrimSpace(user.Username) == "" {
-		return fmt.Errorf("用户（userID=%d）的用户名不能为空", userID)
+		return nil, fmt.Errorf("用户（userID=%d）的用户名不能为空", userID)
 	}
 
 	// ========== 步骤3：构造资源模型 ==========
 	resource := &model.Resource{
 		UserID:       userID,
-		Title:        title,                   // 使用去空格后的标题
-		TextContent:  strings.TrimSpace(text), // 可选：文本内容去空格
-		CodeContent:  code,                    // 代码内容保留原始格式（不建议去空格）
-		Author:       user.Username,           // 冗余存储用户名
-		PublishTime:  time.Now(),              // 发布时间取当前时间
-		LikeCount:    0,                       // 新增：点赞量默认值0
-		ViewCount:    0,                       // 新增：浏览量默认值0
-		CommentCount: 0,                       // 新增：评论量默认值0
+		Title:        title,                              // 使用去空格后的标题
+		TextContent:  strings.TrimSpace(req.TextContent), // 可选：文本内容去空格
+		CodeContent:  req.CodeContent,                    // 代码内容保留原始格式（不建议去空格）
+		Author:       user.Username,                      // 冗余存储用户名
+		PublishTime:  time.Now(),                         // 发布时间取当前时间
+		LikeCount:    0,                                  // 新增：点赞量默认值0
+		ViewCount:    0,                                  // 新增：浏览量默认值0
+		CommentCount: 0,                                  // 新增：评论量默认值0
+		Price:        price,
+		Language:     meta.language,
+		CategoryID:   meta.categoryID,
 	}
 
-	// ========== 步骤4：调用仓库层插入数据 ==========
-	if err := s.resourceRepo.CreateResource(ctx, nil, resource); err != nil {
-		return fmt.Errorf("创建资源失败（title=%s, userID=%d）：%w", title, userID, err)
+	// ========== 步骤4：事务内插入资源并写入标签 ==========
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	return nil
+	if err := s.lockCategory(ctx, tx, meta.categoryID); err != nil {
+		return nil, err
+	}
+	if err := s.resourceRepo.CreateResource(ctx, tx, resource); err != nil {
+		return nil, fmt.Errorf("创建资源失败（title=%s, userID=%d）：%w", title, userID, err)
+	}
+	if err := s.tagRepo.SetResourceTags(ctx, tx, resource.ID, meta.tags); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交创建资源事务失败：%w", err)
+	}
+	resource.Tags = meta.tags
+	metrics.ResourcesCreated.Inc()
+	indexResource(ctx, s.searchIndex, resource)
+
+	return resource, nil
 }
 
 // convertModelToDTO model转DTO - 新增：映射点赞/浏览/评论量字段
@@ -105,24 +179,48 @@ func convertModelToDTO(res *model.Resource) dto.ResourceItem {
 		LikeCount:    res.LikeCount,    // 新增：映射点赞量
 		ViewCount:    res.ViewCount,    // 新增：映射浏览量
 		CommentCount: res.CommentCount, // 新增：映射评论量
+		Price:        res.Price,        // 价格（0为免费）
+		Language:     res.Language,     // 编程语言
+		CategoryID:   res.CategoryID,   // 所属分类
+		Tags:         res.Tags,         // 标签
 	}
 }
 
-// GetResourceList 分页查询资源列表（最终无类型错误版）
-func (s *ResourceServiceImpl) GetResourceList(ctx context.Context, page, size int, keyword string) ([]dto.ResourceItem, int64, error) {
+// GetResourceList 分页查询资源列表；userUUID非空时标记当前用户是否已点赞
+// 带关键词时走全文检索（按相关度排序并返回高亮片段），否则按发布时间倒序；两者均支持作者/发布日期过滤
+func (s *ResourceServiceImpl) GetResourceList(ctx context.Context, userUUID string, req dto.ResourceListReq) ([]dto.ResourceItem, int64, error) {
+	page, size := req.Page, req.Size
 	// 1. 分页参数校验
 	if page < 1 {
-		return nil, 0, fmt.Errorf("页码必须≥1，当前值：%d", page)
+		return nil, 0, apperr.InvalidArgumentf("页码必须≥1，当前值：%d", page)
 	}
 	if size < 1 || size > 50 {
-		return nil, 0, fmt.Errorf("每页条数必须在1~50之间，当前值：%d", size)
+		return nil, 0, apperr.InvalidArgumentf("每页条数必须在1~50之间，当前值：%d", size)
+	}
+
+	filter, err := parseResourceFilter(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 按分类筛选时包含全部子孙分类
+	if req.CategoryID != 0 {
+		tree, err := s.loadCategoryTree(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		if filter.CategoryIDs, err = tree.subtreeIDs(req.CategoryID); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	// 2. 计算分页偏移量（原生SQL的LIMIT offset, limit）
 	offset := (page - 1) * size
+	if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+		return s.searchResources(ctx, userUUID, keyword, filter, offset, size)
+	}
 
 	// 3. 调用Repo层统计总条数
-	total, err := s.resourceRepo.CountResources(ctx, keyword)
+	total, err := s.resourceRepo.CountResources(ctx, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("统计资源总数失败：%w", err)
 	}
@@ -133,7 +231,7 @@ func (s *ResourceServiceImpl) GetResourceList(ctx context.Context, page, size in
 	}
 
 	// 5. 调用Repo层查询原生model切片（[]*model.Resource）
-	resourceModels, err := s.resourceRepo.GetResourceList(ctx, offset, size, keyword)
+	resourceModels, err := s.resourceRepo.GetResourceList(ctx, offset, size, filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("查询资源列表失败：%w", err)
 	}
@@ -145,7 +243,17 @@ func (s *ResourceServiceImpl) GetResourceList(ctx context.Context, page, size in
 		resourceDTOs = append(resourceDTOs, dtoItem)
 	}
 
-	// 7. 返回DTO切片（完全匹配Handler层预期类型）+ 总条数
+	// 7. 标记当前用户的点赞状态
+	if err := s.fillLiked(ctx, userUUID, resourceDTOs); err != nil {
+		return nil, 0, fmt.Errorf("查询点赞状态失败：%w", err)
+	}
+
+	// 8. 付费资源对未购买用户只返回代码预览
+	if err := s.fillAccess(ctx, userUUID, resourceDTOs); err != nil {
+		return nil, 0, fmt.Errorf("查询购买状态失败：%w", err)
+	}
+
+	// 9. 返回DTO切片（完全匹配Handler层预期类型）+ 总条数
 	return resourceDTOs, total, nil
 }
 
@@ -153,7 +261,7 @@ func (s *ResourceServiceImpl) GetResourceList(ctx context.Context, page, size in
 func (s *ResourceServiceImpl) GetResourceByID(ctx context.Context, id uint64) (*model.Resource, error) {
 	// 1. 参数校验（service层必须做，避免非法参数进入repo）
 	if id <= 0 {
-		return nil, fmt.Errorf("资源ID必须为正整数（当前值：%d）", id)
+		return nil, apperr.InvalidArgumentf("资源ID必须为正整数（当前值：%d）", id)
 	}
 
 	// 2. 调用仓库层查询资源
@@ -166,53 +274,27 @@ func (s *ResourceServiceImpl) GetResourceByID(ctx context.Context, id uint64) (*
 	// 3. 资源不存在时返回nil（无错误），由handler层处理404
 	return resource, nil
 }
-func (s *ResourceServiceImpl) IncrViewCount(ctx context.Context, id uint64) error {
-	// 调用Repo层的原子更新方法
-	err := s.resourceRepo.IncrViewCount(ctx, id)
-	if err != nil {
-		return fmt.Errorf("更新浏览量失败：%w", err)
-	}
-	return nil
-}
 
-// IncrLikeCount 增加点赞数（原子更新，和浏览量逻辑一致）
-func (s *ResourceServiceImpl) IncrLikeCount(ctx context.Context, id uint64) error {
+// IncrViewCount 记录一次浏览（只写内存，由ViewCounter后台批量刷盘）
+// viewer为浏览者标识（登录用户UUID或游客IP），同一viewer在去重窗口内重复浏览不计数
+// 不存在或已隐藏的资源返回业务错误，避免任意ID的增量堆积在内存并被刷盘
+func (s *ResourceServiceImpl) IncrViewCount(ctx context.Context, id uint64, viewer string) (bool, error) {
 	if id <= 0 {
-		return fmt.Errorf("资源ID无效（id=%d）", id)
+		return false, apperr.InvalidArgumentf("资源ID无效（id=%d）", id)
 	}
-	// 调用Repo层的原子更新点赞数方法（需确保Repo已实现）
-	err := s.resourceRepo.IncrLikeCount(ctx, id)
-	if err != nil {
-		return fmt.Errorf("更新点赞数失败：%w", err)
+	if strings.TrimSpace(viewer) == "" {
+		return false, apperr.InvalidArgument("浏览者标识不能为空")
 	}
-	return nil
-}
-
-// CreateComment 创建评论（业务逻辑：新增评论记录 + 原子更新评论数）
-func (s *ResourceServiceImpl) CreateComment(ctx context.Context, id uint64, content string) error {
-	// 参数校验
-	if id <= 0 {
-		return fmt.Errorf("资源ID无效（id=%d）", id)
+	// 窗口期内的重复浏览直接返回，不查询数据库
+	if s.viewCounter.Seen(id, viewer) {
+		return false, nil
 	}
-	content = strings.TrimSpace(content)
-	if content == "" {
-		return fmt.Errorf("评论内容不能为空")
+	resource, err := s.resourceRepo.GetResourceByID(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("查询资源失败（id=%d）：%w", id, err)
 	}
-
-	// 1. 新增评论记录（需确保有Comment模型和Repo）
-	// comment := &model.Comment{
-	// 	ResourceID: id,
-	// 	Content:    content,
-	// 	CreateTime: time.Now(),
-	// 	// 可补充：UserID（评论用户ID）、Username（评论用户名）等字段
-	// }
-	// if err := s.commentRepo.CreateComment(ctx, comment); err != nil {
-	// 	return fmt.Errorf("创建评论记录失败：%w", err)
-	// }
-
-	// 2. 原子更新资源的评论数
-	if err := s.resourceRepo.IncrCommentCount(ctx, id); err != nil {
-		return fmt.Errorf("更新评论数失败：%w", err)
+	if resource == nil {
+		return false, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", id)
 	}
-	return nil
+	return s.viewCounter.Record(id, viewer), nil
 }
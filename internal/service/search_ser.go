@@ -0,0 +1,125 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/model"
+	"CMS/internal/pkg/langdetect"
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"CMS/internal/search"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayout 资源列表日期过滤参数格式
+const dateLayout = "2006-01-02"
+
+// searchResources 全文检索资源：检索索引取一页按相关度排序的资源ID → 回表查询完整字段 → 点赞/购买状态 → 高亮
+// 高亮摘要在付费内容截取之后生成，未购买用户看不到预览之外的代码
+func (s *ResourceServiceImpl) searchResources(ctx context.Context, userUUID, keyword string, filter repository.ResourceFilter, offset, size int) ([]dto.ResourceItem, int64, error) {
+	result, err := s.searchIndex.Search(ctx, search.Query{
+		Keyword: keyword,
+		Filter:  filter,
+		Offset:  offset,
+		Limit:   size,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("检索资源失败：%w", err)
+	}
+	if len(result.Hits) == 0 {
+		return []dto.ResourceItem{}, result.Total, nil
+	}
+
+	ids := make([]uint64, len(result.Hits))
+	for i, hit := range result.Hits {
+		ids[i] = hit.ID
+	}
+	resources, err := s.resourceRepo.GetResourcesByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询资源列表失败：%w", err)
+	}
+
+	// 按相关度顺序组装（索引尚未同步的已删除/隐藏资源直接跳过）
+	items := make([]dto.ResourceItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		res, ok := resources[hit.ID]
+		if !ok {
+			continue
+		}
+		item := convertModelToDTO(res)
+		item.Score = hit.Score
+		items = append(items, item)
+	}
+
+	if err := s.fillLiked(ctx, userUUID, items); err != nil {
+		return nil, 0, fmt.Errorf("查询点赞状态失败：%w", err)
+	}
+	if err := s.fillAccess(ctx, userUUID, items); err != nil {
+		return nil, 0, fmt.Errorf("查询购买状态失败：%w", err)
+	}
+
+	terms := search.Terms(keyword)
+	for i := range items {
+		items[i].Highlight = &dto.ResourceHighlight{
+			Title:   search.Highlight(items[i].Title, terms),
+			Snippet: search.Snippet(terms, s.snippetLen, items[i].TextContent, items[i].CodeContent),
+		}
+	}
+	return items, result.Total, nil
+}
+
+// parseResourceFilter 校验并转换资源列表的作者/发布日期/语言/标签过滤参数（日期按本地时区解析，结束日期含当天）
+// 分类需展开子孙分类，由调用方基于分类树填充CategoryIDs
+func parseResourceFilter(req dto.ResourceListReq) (repository.ResourceFilter, error) {
+	filter := repository.ResourceFilter{Author: strings.TrimSpace(req.Author)}
+	if len(filter.Author) > MaxUsernameLen {
+		return filter, apperr.InvalidArgumentf("作者用户名长度不能超过%d个字符", MaxUsernameLen)
+	}
+	if req.StartDate != "" {
+		from, err := time.ParseInLocation(dateLayout, req.StartDate, time.Local)
+		if err != nil {
+			return filter, apperr.InvalidArgumentf("开始日期格式错误（需为YYYY-MM-DD，当前值：%s）", req.StartDate)
+		}
+		filter.From = from
+	}
+	if req.EndDate != "" {
+		to, err := time.ParseInLocation(dateLayout, req.EndDate, time.Local)
+		if err != nil {
+			return filter, apperr.InvalidArgumentf("结束日期格式错误（需为YYYY-MM-DD，当前值：%s）", req.EndDate)
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, apperr.InvalidArgument("开始日期不能晚于结束日期")
+	}
+	if strings.TrimSpace(req.Language) != "" {
+		lang, ok := langdetect.Normalize(req.Language)
+		if !ok {
+			return filter, apperr.InvalidArgumentf("不支持的编程语言：%s", req.Language)
+		}
+		filter.Language = lang
+	}
+	tag, err := normalizeTag(req.Tag)
+	if err != nil {
+		return filter, err
+	}
+	filter.Tag = tag
+	return filter, nil
+}
+
+// indexResource 同步资源到检索索引（失败只记录日志：MySQL实现无需同步，内存实现会在下次全量重建时补齐）
+func indexResource(ctx context.Context, index search.SearchIndex, res *model.Resource) {
+	if err := index.Index(ctx, search.NewDocument(res)); err != nil {
+		logger.FromContext(ctx).Warn("[全文检索] 更新资源索引失败", "resource_id", res.ID, "error", err)
+	}
+}
+
+// unindexResource 从检索索引删除资源（失败处理同indexResource）
+func unindexResource(ctx context.Context, index search.SearchIndex, id uint64) {
+	if err := index.Remove(ctx, id); err != nil {
+		logger.FromContext(ctx).Warn("[全文检索] 删除资源索引失败", "resource_id", id, "error", err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/metrics"
+	"CMS/internal/model"
+	pkg "CMS/internal/pkg/jwt"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// recordLogin 按登录方式与结果计入cms_logins_total（签发挑战令牌记为challenge，待两步验证完成后再记success）
+func recordLogin(method string, resp *dto.LoginResponse, err error) {
+	result := metrics.LoginResultSuccess
+	switch {
+	case err != nil:
+		result = metrics.LoginResultFailure
+	case resp != nil && resp.TwoFactorRequired:
+		result = metrics.LoginResultChallenge
+	}
+	metrics.Logins.WithLabelValues(method, result).Inc()
+}
+
+// issueSession 开启新的登录会话：生成会话ID（刷新令牌族ID）→ 写入刷新令牌哈希 → 签发访问Token
+func (s *staffServiceImpl) issueSession(ctx context.Context, user *model.User) (*dto.LoginResponse, error) {
+	if err := checkUserStatus(user); err != nil {
+		return nil, err
+	}
+	familyID := pkg.GenerateUUID()
+	refreshToken, err := s.createRefreshToken(ctx, nil, user.UUID, familyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildLoginResponse(user, familyID, refreshToken)
+}
+
+// Refresh 刷新令牌轮换：锁定旧令牌 → 校验 → 吊销旧令牌并签发同族新令牌 → 签发新访问Token
+// 已吊销的令牌被再次使用说明令牌可能已泄露，吊销整个令牌族（该会话需重新登录）
+func (s *staffServiceImpl) Refresh(ctx context.Context, refreshToken string) (*dto.LoginResponse, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, apperr.InvalidArgument("刷新令牌不能为空")
+	}
+
+	tx, err := s.tokenRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启刷新令牌事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 1. 按哈希锁定令牌（并发使用同一令牌时只有一个请求能完成轮换）
+	old, err := s.tokenRepo.LockRefreshToken(ctx, tx, pkg.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, apperr.ErrTokenInvalid.WithMsg("刷新令牌无效")
+	}
+	if old.RevokedAt != nil {
+		if err := s.tokenRepo.RevokeFamily(ctx, tx, old.FamilyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("提交刷新令牌事务失败：%w", err)
+		}
+		return nil, apperr.ErrTokenInvalid.WithMsg("刷新令牌已失效（检测到重复使用，该会话已注销，请重新登录）")
+	}
+	if !old.ExpiresAt.After(time.Now()) {
+		return nil, apperr.ErrTokenInvalid.WithMsg("刷新令牌已过期，请重新登录")
+	}
+
+	// 2. 查询用户最新信息（角色可能已变更）
+	user, err := s.userRepo.GetUserByUuid(ctx, old.UserUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	user.UUID = old.UserUUID // GetUserByUuid不返回uuid字段
+	if err := checkUserStatus(user); err != nil {
+		return nil, err
+	}
+
+	// 3. 吊销旧令牌并签发同族新令牌
+	if err := s.tokenRepo.RevokeRefreshToken(ctx, tx, old.ID); err != nil {
+		return nil, err
+	}
+	newToken, err := s.createRefreshToken(ctx, tx, old.UserUUID, old.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交刷新令牌事务失败：%w", err)
+	}
+
+	return s.buildLoginResponse(user, old.FamilyID, newToken)
+}
+
+// LogoutAll 退出所有设备：吊销全部刷新令牌，并使此前签发的访问Token全部失效
+func (s *staffServiceImpl) LogoutAll(ctx context.Context, userUUID string) error {
+	if strings.TrimSpace(userUUID) == "" {
+		return apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, nil, userUUID); err != nil {
+		return err
+	}
+	return s.denylist.RevokeUser(ctx, userUUID, time.Now())
+}
+
+// checkUserStatus 校验账号状态（被管理员停用/封禁的账号不允许登录或刷新Token）
+func checkUserStatus(user *model.User) error {
+	switch user.Status {
+	case model.UserStatusDisabled:
+		return apperr.ErrAccountDisabled
+	case model.UserStatusBanned:
+		return apperr.ErrAccountDisabled.WithMsg("账号已被封禁")
+	}
+	return nil
+}
+
+// createRefreshToken 生成刷新令牌并写入哈希，返回明文
+func (s *staffServiceImpl) createRefreshToken(ctx context.Context, tx *sql.Tx, userUUID, familyID string) (string, error) {
+	token, hash, err := pkg.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	record := &model.RefreshToken{
+		UserUUID:   userUUID,
+		TokenHash:  hash,
+		FamilyID:   familyID,
+		ExpiresAt:  now.Add(pkg.RefreshTokenExpire()),
+		CreateTime: now,
+	}
+	if err := s.tokenRepo.CreateRefreshToken(ctx, tx, record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// buildLoginResponse 签发访问Token并组装登录响应
+func (s *staffServiceImpl) buildLoginResponse(user *model.User, sessionID, refreshToken string) (*dto.LoginResponse, error) {
+	token, err := pkg.GenerateToken(user.UUID, user.Username, user.Role, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("生成登录凭证失败：%w", err)
+	}
+	return &dto.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(pkg.AccessTokenExpire() / time.Second),
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+	}, nil
+}
@@ -1,18 +1,23 @@
 package service
 
 import (
+	"CMS/internal/apperr"
 	"CMS/internal/dto"
+	"CMS/internal/mail"
+	"CMS/internal/metrics"
 	"CMS/internal/model"
 	"CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
 	"CMS/internal/repository"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,64 +32,108 @@ const DefaultBirthDate = "2000-01-01"
 var (
 	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	phoneRegex = regexp.MustCompile(`^1[3-9]\d{9}$`) // 国内手机号正则
+
+	// errEmailLoginUnavailable 邮箱验证码登录不可用（邮箱未注册与未验证返回同一错误，避免被用来探测邮箱是否注册）
+	errEmailLoginUnavailable = apperr.ErrEmailUnverified.WithMsg("该邮箱不能用于验证码登录（未注册或未验证），请使用密码登录后完成邮箱验证")
 )
 
 // StaffService 业务接口
 type StaffService interface {
-	Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error)
-	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
+	// Register 注册（clientIP用于邮箱验证码的发送冷却）
+	Register(ctx context.Context, req dto.RegisterRequest, clientIP string) (*dto.RegisterResponse, error)
+	// Login 密码登录（clientIP/userAgent用于失败锁定与登录记录）
+	Login(ctx context.Context, req dto.LoginRequest, clientIP, userAgent string) (*dto.LoginResponse, error)
 	Logout(ctx context.Context, token string) error
-	UpdateUser(ctx context.Context, req *dto.UpdateUserReq) error
+	// LogoutAll 退出所有设备：吊销用户全部刷新令牌及已签发的访问Token
+	LogoutAll(ctx context.Context, userUUID string) error
+	// Refresh 使用刷新令牌换取新的访问Token（刷新令牌轮换）
+	Refresh(ctx context.Context, refreshToken string) (*dto.LoginResponse, error)
+	// UpdateUser 修改个人信息（修改邮箱后发送验证码，clientIP用于发送冷却）
+	UpdateUser(ctx context.Context, req *dto.UpdateUserReq, clientIP string) error
 	GetWordText(ctx context.Context, uuid string) (*model.WordResponse, error)
 	UpdateAvatar(ctx context.Context, file io.Reader, req *dto.UpdateAvatarReq) (string, error)
 	GetUserByUuid(ctx context.Context, uuid string) (*model.User, error)
-	Elogin(user *model.User) (*model.User, error)
-	Verify(ctx context.Context, email string, code string) (*dto.LoginResponse, error)
+	// Elogin 邮箱登录-发送验证码（未注册与未验证的邮箱返回同一错误，不泄露邮箱是否注册）
+	Elogin(ctx context.Context, user *model.User, clientIP string) (*model.User, error)
+	// Verify 邮箱验证码登录（账号已开启两步验证时只返回挑战令牌）
+	Verify(ctx context.Context, email, code, clientIP string) (*dto.LoginResponse, error)
+	// ForgotPassword 忘记密码：发送重置验证码到账号邮箱
+	ForgotPassword(ctx context.Context, email, clientIP string) error
+	// ResetPassword 凭邮箱验证码重置密码（成功后吊销全部会话）
+	ResetPassword(ctx context.Context, req dto.PasswordResetReq) error
+	// ChangePassword 校验原密码后修改密码（成功后吊销全部会话）
+	ChangePassword(ctx context.Context, userUUID string, req dto.PasswordChangeReq) error
+	// SendEmailVerification 向当前账号邮箱发送归属验证码
+	SendEmailVerification(ctx context.Context, userUUID, clientIP string) error
+	// ConfirmEmail 校验验证码并将当前账号邮箱标记为已验证
+	ConfirmEmail(ctx context.Context, userUUID, code string) error
+	// LoginHistory 分页查询当前账号的密码登录记录
+	LoginHistory(ctx context.Context, userUUID string, req dto.LoginAttemptListReq) (*dto.LoginAttemptListResp, error)
+	// LoginTwoFactor 凭挑战令牌+动态码（或恢复码）完成两步验证登录
+	LoginTwoFactor(ctx context.Context, req dto.TwoFactorLoginReq, clientIP, userAgent string) (*dto.LoginResponse, error)
+	// TwoFactorStatus 查询当前账号两步验证状态
+	TwoFactorStatus(ctx context.Context, userUUID string) (*dto.TwoFactorStatusResp, error)
+	// SetupTwoFactor 生成TOTP密钥及otpauth URI（待确认）
+	SetupTwoFactor(ctx context.Context, userUUID string) (*dto.TwoFactorSetupResp, error)
+	// EnableTwoFactor 提交动态码确认开启两步验证，返回一次性恢复码
+	EnableTwoFactor(ctx context.Context, userUUID, code string) (*dto.TwoFactorRecoveryCodesResp, error)
+	// DisableTwoFactor 校验登录密码与动态码后关闭两步验证
+	DisableTwoFactor(ctx context.Context, userUUID string, req dto.TwoFactorDisableReq) error
+	// RegenerateRecoveryCodes 重新生成恢复码（旧恢复码全部作废）
+	RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) (*dto.TwoFactorRecoveryCodesResp, error)
 }
 
 // staffServiceImpl 实现StaffService
 type staffServiceImpl struct {
 	userRepo    repository.UserRepo
 	useraccRepo repository.AccountRepo
+	tokenRepo   repository.TokenRepo
+	denylist    *TokenDenylist
 	jwtCfg      pkg.JWTConfig // 改用pkg.JWTConfig
+	mailer      mail.Mailer   // 验证码邮件发送
+	verifyCodes *VerifyCodes  // 邮箱验证码签发与校验
+	loginGuard  *LoginGuard   // 密码登录失败锁定与登录记录
+	twoFactor   *TwoFactor    // TOTP两步验证
 }
 
 // NewStaffService 创建业务实例
-func NewStaffService(userRepo repository.UserRepo, useraccRepo repository.AccountRepo) StaffService {
+func NewStaffService(userRepo repository.UserRepo, useraccRepo repository.AccountRepo, tokenRepo repository.TokenRepo, denylist *TokenDenylist, jwtCfg pkg.JWTConfig, mailer mail.Mailer, verifyCodes *VerifyCodes, loginGuard *LoginGuard, twoFactor *TwoFactor) StaffService {
 	return &staffServiceImpl{
 		userRepo:    userRepo,
 		useraccRepo: useraccRepo,
-		jwtCfg: pkg.JWTConfig{ // 调用pkg包的结构体
-			Secret: []byte("aiuegfiuewgfiuwfeiuwheqowhfoiqfiifenfeqnfeq"),
-			Expire: 24 * time.Hour,
-			Issuer: "cms-interview-platform",
-		},
+		tokenRepo:   tokenRepo,
+		denylist:    denylist,
+		jwtCfg:      jwtCfg,
+		mailer:      mailer,
+		verifyCodes: verifyCodes,
+		loginGuard:  loginGuard,
+		twoFactor:   twoFactor,
 	}
 }
 func (s *staffServiceImpl) UpdateAvatar(ctx context.Context, file io.Reader, req *dto.UpdateAvatarReq) (string, error) {
 	// ========== 步骤1：基础参数校验（兜底校验） ==========
 	// 1.1 UUID非空校验（用户唯一标识）
 	if strings.TrimSpace(req.UUID) == "" {
-		return "", errors.New("业务校验失败：用户UUID不能为空")
+		return "", apperr.InvalidArgument("业务校验失败：用户UUID不能为空")
 	}
 	// 1.2 文件流非空校验
 	if file == nil {
-		return "", errors.New("业务校验失败：头像文件不能为空")
+		return "", apperr.InvalidArgument("业务校验失败：头像文件不能为空")
 	}
 	// 1.3 文件名非空/格式校验
 	if strings.TrimSpace(req.AvatarFileName) == "" {
-		return "", errors.New("业务校验失败：头像文件名不能为空")
+		return "", apperr.InvalidArgument("业务校验失败：头像文件名不能为空")
 	}
 
 	// ========== 修正后的后缀提取逻辑 ==========
 	dotIndex := strings.LastIndex(req.AvatarFileName, ".")
 	if dotIndex <= 0 || dotIndex == len(req.AvatarFileName)-1 {
-		return "", errors.New("业务校验失败：头像文件无有效后缀（仅支持jpg/jpeg/png）")
+		return "", apperr.InvalidArgument("业务校验失败：头像文件无有效后缀（仅支持jpg/jpeg/png）")
 	}
 	ext := strings.ToLower(req.AvatarFileName[dotIndex+1:])
 	allowExts := map[string]bool{"jpg": true, "jpeg": true, "png": true}
 	if !allowExts[ext] {
-		return "", errors.New("业务校验失败：仅支持jpg/jpeg/png格式的头像文件")
+		return "", apperr.InvalidArgument("业务校验失败：仅支持jpg/jpeg/png格式的头像文件")
 	}
 
 	// ========== 步骤1.5：新增！查询用户原头像URL（核心补充） ==========
@@ -92,8 +141,8 @@ func (s *staffServiceImpl) UpdateAvatar(ctx context.Context, file io.Reader, req
 	oldUser, err := s.userRepo.GetUserByUuid(ctx, req.UUID)
 	if err != nil {
 		// 区分「用户不存在」和「查询失败」
-		if strings.Contains(err.Error(), "用户不存在") {
-			return "", errors.New("业务校验失败：用户不存在")
+		if errors.Is(err, apperr.ErrUserNotFound) {
+			return "", apperr.ErrUserNotFound.WithMsg("业务校验失败：用户不存在")
 		}
 		return "", fmt.Errorf("查询用户原头像失败：%w", err)
 	}
@@ -115,7 +164,7 @@ func (s *staffServiceImpl) UpdateAvatar(ctx context.Context, file io.Reader, req
 		// 调用pkg层的删除文件方法（需新增该方法）
 		if err := pkg.DeleteAvatar(oldAvatarURL); err != nil {
 			// 注意：删除原文件失败不阻断流程（避免新头像保存成功但更新失败），仅记录日志
-			log.Printf("[更新头像] 删除原头像失败 | UUID: %s | 原头像URL: %s | 错误：%v", req.UUID, oldAvatarURL, err)
+			logger.FromContext(ctx).Warn("[更新头像] 删除原头像失败", "avatar_url", oldAvatarURL, "error", err)
 			// 可选：如果业务要求必须删除原文件，可返回错误
 			return "", fmt.Errorf("删除原头像失败：%w", err)
 		}
@@ -133,39 +182,38 @@ func (s *staffServiceImpl) UpdateAvatar(ctx context.Context, file io.Reader, req
 		// 新头像已保存但数据库更新失败，删除刚保存的新头像，避免垃圾文件
 		rollbackErr := pkg.DeleteAvatar(newAvatarURL)
 		if rollbackErr != nil {
-			log.Printf("[更新头像] 数据库更新失败，回滚新头像失败 | UUID: %s | 新头像URL: %s | 错误：%v", req.UUID, newAvatarURL, rollbackErr)
+			logger.FromContext(ctx).Error("[更新头像] 数据库更新失败，回滚新头像失败", "avatar_url", newAvatarURL, "error", rollbackErr)
 		}
 		return "", fmt.Errorf("更新头像数据失败：%w", err)
 	}
-	println(newAvatarURL)
 	// ========== 步骤5：返回新头像访问URL ==========
 	return newAvatarURL, nil
 }
 
-func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest) (*dto.RegisterResponse, error) {
+func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest, clientIP string) (*dto.RegisterResponse, error) {
 	// ========== 1. 基础格式/长度校验 ==========
 	// 用户名校验
 	req.Username = strings.TrimSpace(req.Username)
 	if req.Username == "" {
-		return nil, errors.New("用户名不能为空")
+		return nil, apperr.InvalidArgument("用户名不能为空")
 	}
 	if len(req.Username) > MaxUsernameLen {
-		return nil, fmt.Errorf("用户名长度不能超过%d个字符", MaxUsernameLen)
+		return nil, apperr.InvalidArgumentf("用户名长度不能超过%d个字符", MaxUsernameLen)
 	}
 
 	// 密码校验（非空）
 	if strings.TrimSpace(req.Password) == "" {
-		return nil, errors.New("密码不能为空")
+		return nil, apperr.InvalidArgument("密码不能为空")
 	}
 
 	// Email校验（填则校验格式+长度）
 	req.Email = strings.TrimSpace(req.Email)
 	if req.Email != "" {
 		if len(req.Email) > MaxEmailLen {
-			return nil, fmt.Errorf("邮箱长度不能超过%d个字符", MaxEmailLen)
+			return nil, apperr.InvalidArgumentf("邮箱长度不能超过%d个字符", MaxEmailLen)
 		}
 		if !emailRegex.MatchString(req.Email) {
-			return nil, errors.New("邮箱格式不合法")
+			return nil, apperr.InvalidArgument("邮箱格式不合法")
 		}
 	}
 
@@ -173,20 +221,27 @@ func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest
 	req.Phone = strings.TrimSpace(req.Phone)
 	if req.Phone != "" {
 		if len(req.Phone) > MaxPhoneLen {
-			return nil, fmt.Errorf("手机号长度不能超过%d个字符", MaxPhoneLen)
+			return nil, apperr.InvalidArgumentf("手机号长度不能超过%d个字符", MaxPhoneLen)
 		}
 		if !phoneRegex.MatchString(req.Phone) {
-			return nil, errors.New("手机号格式不合法（需为11位国内手机号）")
+			return nil, apperr.InvalidArgument("手机号格式不合法（需为11位国内手机号）")
 		}
 	}
 
-	// Role兜底（默认candidate）+ 长度校验
+	// Role兜底（默认candidate）+ 白名单校验（管理员账号只能由管理员授予，不允许自行注册）
 	req.Role = strings.TrimSpace(req.Role)
 	if req.Role == "" {
-		req.Role = "candidate"
+		req.Role = model.RoleCandidate
 	}
 	if len(req.Role) > MaxRoleLen {
-		return nil, fmt.Errorf("角色长度不能超过%d个字符", MaxRoleLen)
+		return nil, apperr.InvalidArgumentf("角色长度不能超过%d个字符", MaxRoleLen)
+	}
+	switch req.Role {
+	case model.RoleCandidate, model.RoleHR:
+	case model.RoleAdmin:
+		return nil, apperr.PermissionDenied("不允许自行注册管理员账号")
+	default:
+		return nil, apperr.InvalidArgumentf("角色无效（role=%s），仅支持candidate/hr", req.Role)
 	}
 	if req.Gender == "" {
 		req.Gender = "female"
@@ -205,7 +260,7 @@ func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest
 		return nil, fmt.Errorf("查询用户名失败：%w", err)
 	}
 	if exist {
-		return nil, errors.New("用户名已存在")
+		return nil, apperr.AlreadyExists("用户名已存在")
 	}
 
 	// Email唯一性（填则校验）
@@ -215,7 +270,7 @@ func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest
 			return nil, fmt.Errorf("查询邮箱失败：%w", err)
 		}
 		if exist {
-			return nil, errors.New("邮箱已存在")
+			return nil, apperr.AlreadyExists("邮箱已存在")
 		}
 	}
 
@@ -226,7 +281,7 @@ func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest
 			return nil, fmt.Errorf("查询手机号失败：%w", err)
 		}
 		if exist {
-			return nil, errors.New("手机号已存在")
+			return nil, apperr.AlreadyExists("手机号已存在")
 		}
 	}
 	req.AvatarURL = strings.TrimSpace("https://example.com/avatar/lisi.jpg")
@@ -260,17 +315,8 @@ func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest
 	if err != nil {
 		return nil, fmt.Errorf("开启事务失败：%w", err)
 	}
-	// 事务兜底：失败回滚，成功提交
-	defer func() {
-		if p := recover(); p != nil {
-			_ = tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			_ = tx.Rollback()
-		} else {
-			err = tx.Commit()
-		}
-	}()
+	// 事务兜底：未提交前返回一律回滚（提交后Rollback为空操作）
+	defer func() { _ = tx.Rollback() }()
 
 	// ========== 5.1 执行：创建用户（传入事务） ==========
 	// 需改造UserRepo的CreateUser方法，支持传入tx：CreateUserWithTx(ctx context.Context, tx *sql.Tx, user *model.User) error
@@ -282,50 +328,140 @@ func (s *staffServiceImpl) Register(ctx context.Context, req dto.RegisterRequest
 	if err := s.useraccRepo.CreateAccount(ctx, tx, newUser.UUID); err != nil {
 		return nil, fmt.Errorf("创建用户账户失败：%w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交注册事务失败：%w", err)
+	}
+	metrics.Registrations.Inc()
+
+	// ========== 5.3 发送邮箱验证码（失败不影响注册，用户可登录后重新获取） ==========
+	emailVerifySent := false
+	if req.Email != "" {
+		if err := s.sendEmailVerifyCode(ctx, req.Email, clientIP); err != nil {
+			logger.FromContext(ctx).Warn("[注册] 发送邮箱验证码失败", "username", newUser.Username, "email", req.Email, "error", err)
+		} else {
+			emailVerifySent = true
+		}
+	}
 	// ========== 6. 返回响应（包含正确的自增ID） ==========
 	return &dto.RegisterResponse{
-		UserID:   newUser.ID, // 此时ID已由CreateUser赋值
-		Username: newUser.Username,
-		Role:     newUser.Role,
+		UserID:          newUser.ID, // 此时ID已由CreateUser赋值
+		Username:        newUser.Username,
+		Role:            newUser.Role,
+		EmailVerifySent: emailVerifySent,
 	}, nil
 }
 
-// Login 登录业务逻辑
-func (s *staffServiceImpl) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+// Login 登录业务逻辑：IP/账号锁定校验 → 查询用户 → 校验密码 → 签发会话
+// 账号不存在与密码错误返回同样的提示，失败次数按账号、IP分别累计，达到阈值后临时锁定
+func (s *staffServiceImpl) Login(ctx context.Context, req dto.LoginRequest, clientIP, userAgent string) (resp *dto.LoginResponse, err error) {
+	defer func() { recordLogin(metrics.LoginMethodPassword, resp, err) }()
+	ipKey := ipLockKey(clientIP)
+	if err := s.loginGuard.Check(ctx, ipKey); err != nil {
+		return nil, err
+	}
+
 	// 查询用户
 	user, err := s.userRepo.GetUserByCredential(ctx, req.Username, req.Phone, req.Email)
 	if err != nil {
-		return nil, errors.New("查询用户失败：" + err.Error())
+		return nil, fmt.Errorf("查询用户失败：%w", err)
 	}
-	if user == nil {
-		return nil, errors.New("用户不存在")
+	identifier := loginIdentifier(req)
+	accountKey := accountLockKey(user, identifier)
+	attempt := &model.LoginAttempt{
+		Identifier: identifier,
+		IP:         clientIP,
+		UserAgent:  userAgent,
+		CreateTime: time.Now(),
+	}
+	if user != nil {
+		attempt.UserUUID = user.UUID
 	}
 
-	// 验证密码（调用pkg.CheckPassword）
-	if !pkg.CheckPassword(req.Password, user.PasswordHash) {
-		return nil, errors.New("密码错误")
+	if err := s.loginGuard.Check(ctx, accountKey); err != nil {
+		attempt.Result = model.LoginResultLocked
+		s.loginGuard.Record(ctx, attempt)
+		return nil, err
 	}
 
-	// 生成Token（调用pkg.GenerateToken）
-	token, err := pkg.GenerateToken(user.UUID, user.Username, user.Role)
+	// 验证密码（账号不存在时同样执行一次哈希比较，避免通过响应耗时判断账号是否存在）
+	passwordHash := dummyPasswordHash()
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+	if !pkg.CheckPassword(req.Password, passwordHash) || user == nil {
+		s.loginGuard.Fail(ctx, accountKey, ipKey)
+		attempt.Result = model.LoginResultBadCredentials
+		s.loginGuard.Record(ctx, attempt)
+		return nil, apperr.ErrInvalidCredentials
+	}
+
+	// 签发访问Token+刷新令牌（开启新的登录会话；已开启两步验证时只签发挑战令牌）
+	resp, err = s.beginLogin(ctx, user, clientIP)
 	if err != nil {
-		return nil, errors.New("生成登录凭证失败：" + err.Error())
+		if checkUserStatus(user) != nil {
+			attempt.Result = model.LoginResultDisabled
+			s.loginGuard.Record(ctx, attempt)
+		}
+		return nil, err
+	}
+	if resp.TwoFactorRequired {
+		// 失败计数待两步验证通过后再清除，避免已知密码时借此重置动态码的失败计数
+		attempt.Result = model.LoginResultTwoFactor
+		s.loginGuard.Record(ctx, attempt)
+		return resp, nil
 	}
+	s.loginGuard.Reset(ctx, accountKey)
+	attempt.Result = model.LoginResultSuccess
+	s.loginGuard.Record(ctx, attempt)
+	return resp, nil
+}
 
-	return &dto.LoginResponse{
-		Token:    token,
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
-	}, nil
+// LoginHistory 分页查询当前账号的密码登录记录
+func (s *staffServiceImpl) LoginHistory(ctx context.Context, userUUID string, req dto.LoginAttemptListReq) (*dto.LoginAttemptListResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	return s.loginGuard.History(ctx, userUUID, req.Page, req.Size)
 }
 
-// Logout 退出登录逻辑
+// loginIdentifier 登录标识（与GetUserByCredential的匹配顺序一致：用户名 → 手机号 → 邮箱）
+func loginIdentifier(req dto.LoginRequest) string {
+	switch {
+	case req.Username != "":
+		return req.Username
+	case req.Phone != "":
+		return req.Phone
+	default:
+		return req.Email
+	}
+}
+
+// dummyPasswordHash 账号不存在时用于比较的占位哈希（首次使用时生成，与真实密码哈希成本一致）
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := pkg.HashPassword(pkg.GenerateUUID())
+	if err != nil {
+		slog.Error("[登录] 生成占位密码哈希失败", "error", err)
+	}
+	return hash
+})
+
+// Logout 退出登录逻辑：吊销当前访问Token（jti）及所属会话的刷新令牌
 func (s *staffServiceImpl) Logout(ctx context.Context, token string) error {
 	// 解析Token（调用pkg.ParseToken）
-	_, err := pkg.ParseToken(s.jwtCfg, token)
+	claims, err := pkg.ParseToken(s.jwtCfg, token)
 	if err != nil {
-		return errors.New("无效的登录凭证：" + err.Error())
+		return apperr.ErrTokenInvalid.WithMsg("无效的登录凭证").Wrap(err)
+	}
+
+	// 吊销会话的刷新令牌（旧版Token无sid时跳过）
+	if claims.SessionID != "" {
+		if err := s.tokenRepo.RevokeFamily(ctx, nil, claims.SessionID); err != nil {
+			return err
+		}
+	}
+	// 吊销当前访问Token，保留到其自然过期
+	if claims.ID != "" {
+		return s.denylist.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time)
 	}
 	return nil
 }
@@ -346,32 +482,46 @@ type UpdateUserReq struct {
 	BirthDate string
 }
 
-func (s *staffServiceImpl) UpdateUser(ctx context.Context, req *dto.UpdateUserReq) error {
+func (s *staffServiceImpl) UpdateUser(ctx context.Context, req *dto.UpdateUserReq, clientIP string) error {
 	// ========== 步骤1：基础参数校验（二次校验，防止直接调用 Service 绕过 Handler） ==========
 	// 1.1 UUID 不能为空（Handler 已覆盖，但防兜底）
 	if strings.TrimSpace(req.UUID) == "" {
-		return errors.New("业务校验失败：用户 UUID 不能为空")
+		return apperr.InvalidArgument("业务校验失败：用户 UUID 不能为空")
 	}
 
 	// 1.2 手机号格式校验（空则跳过）
 	if req.Phone != "" && !phoneRegex.MatchString(req.Phone) {
-		return errors.New("业务校验失败：手机号格式错误（需为11位有效手机号，如13800138000）")
+		return apperr.InvalidArgument("业务校验失败：手机号格式错误（需为11位有效手机号，如13800138000）")
 	}
 
 	// 1.3 出生日期格式 + 合法性校验
 	if req.BirthDate != "" {
 		// 格式校验
 		if !dateRegex.MatchString(req.BirthDate) {
-			return errors.New("业务校验失败：出生日期格式错误（需为YYYY-MM-DD，如2000-01-01）")
+			return apperr.InvalidArgument("业务校验失败：出生日期格式错误（需为YYYY-MM-DD，如2000-01-01）")
 		}
 		// 合法性校验：不能晚于当前时间
 		bd, err := time.Parse("2006-01-02", req.BirthDate)
 		if err != nil {
-			return errors.New("业务校验失败：出生日期解析失败，非有效日期")
+			return apperr.InvalidArgument("业务校验失败：出生日期解析失败，非有效日期")
 		}
 		if bd.After(time.Now()) {
-			return errors.New("业务校验失败：出生日期不能晚于当前时间")
+			return apperr.InvalidArgument("业务校验失败：出生日期不能晚于当前时间")
+		}
+	}
+
+	// 1.4 邮箱格式校验（空则跳过）；记录邮箱是否变化，变化后需重新验证
+	req.Email = strings.TrimSpace(req.Email)
+	emailChanged := false
+	if req.Email != "" {
+		if len(req.Email) > MaxEmailLen || !emailRegex.MatchString(req.Email) {
+			return apperr.InvalidArgument("业务校验失败：邮箱格式错误")
+		}
+		oldUser, err := s.userRepo.GetUserByUuid(ctx, req.UUID)
+		if err != nil {
+			return fmt.Errorf("查询用户失败：%w", err)
 		}
+		emailChanged = oldUser.Email == nil || !strings.EqualFold(*oldUser.Email, req.Email)
 	}
 
 	// ========== 步骤2：DTO 转换为 Model（适配 Repo 层） ==========
@@ -388,22 +538,20 @@ func (s *staffServiceImpl) UpdateUser(ctx context.Context, req *dto.UpdateUserRe
 
 	// ========== 步骤3：调用 Repo 层执行数据库更新 ==========
 	if err := s.userRepo.UpdateUser(ctx, userModel); err != nil {
-		// 处理 Repo 层返回的唯一约束错误（如用户名/手机号/邮箱重复）
-		if strings.Contains(err.Error(), "Duplicate entry") {
-			// 提取重复字段，返回友好提示
-			if strings.Contains(err.Error(), "username") {
-				return errors.New("业务校验失败：用户名已存在")
-			} else if strings.Contains(err.Error(), "phone") {
-				return errors.New("业务校验失败：手机号已存在")
-			} else if strings.Contains(err.Error(), "email") {
-				return errors.New("业务校验失败：邮箱已存在")
-			}
-			return errors.New("业务校验失败：用户名/手机号/邮箱已存在")
+		// 唯一约束冲突由Repo层按索引名映射为具体的AlreadyExists错误（用户名/手机号/邮箱已存在），直接返回
+		if errors.Is(err, apperr.ErrAlreadyExists) {
+			return err
 		}
 		// 其他数据库错误（非业务校验错误，Handler 会返回 500）
 		return fmt.Errorf("更新用户数据失败：%w", err)
 	}
 
+	// ========== 步骤4：邮箱变化后发送验证码（失败不影响更新，用户可重新获取） ==========
+	if emailChanged {
+		if err := s.sendEmailVerifyCode(ctx, req.Email, clientIP); err != nil {
+			logger.FromContext(ctx).Warn("[更新用户] 发送邮箱验证码失败", "email", req.Email, "error", err)
+		}
+	}
 	return nil
 }
 
@@ -458,7 +606,7 @@ func (s *staffServiceImpl) GetUserByUuid(ctx context.Context, uuid string) (*mod
 	// ========== 1. 业务参数校验（Service层核心职责） ==========
 	// 校验UUID非空（Repo层只处理数据访问，不做业务校验）
 	if uuid == "" {
-		return nil, errors.New("用户UUID不能为空")
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
 	}
 	// 可选：校验UUID格式（如是否符合UUIDv4规范）
 	// if !isValidUUID(uuid) {
@@ -472,11 +620,11 @@ func (s *staffServiceImpl) GetUserByUuid(ctx context.Context, uuid string) (*mod
 		// 区分Repo层的错误类型，返回用户友好的业务错误
 		switch {
 		// 匹配Repo层返回的“用户不存在”错误
-		case errors.Is(err, errors.New("用户不存在")):
-			return nil, errors.New("未查询到该用户")
+		case errors.Is(err, apperr.ErrUserNotFound):
+			return nil, apperr.ErrUserNotFound.WithMsg("未查询到该用户")
 		// 其他错误（如数据库连接失败、SQL语法错误等）→ 包装为系统错误
 		default:
-			return nil, errors.New("查询用户信息失败：" + err.Error())
+			return nil, fmt.Errorf("查询用户信息失败：%w", err)
 		}
 	}
 
@@ -504,39 +652,50 @@ func (s *staffServiceImpl) GetUserByUuid(ctx context.Context, uuid string) (*mod
 //	    regex := regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
 //	    return regex.MatchString(uuid)
 //	}
-func (u *staffServiceImpl) Elogin(user *model.User) (*model.User, error) {
-	user1, err := u.userRepo.FindByemail(user)
-	if err != nil {
+func (u *staffServiceImpl) Elogin(ctx context.Context, user *model.User, clientIP string) (*model.User, error) {
+	if user.Email == nil || strings.TrimSpace(*user.Email) == "" {
+		return nil, apperr.InvalidArgument("邮箱不能为空")
+	}
+	email := strings.TrimSpace(*user.Email)
+	// 先占用冷却再查账号：无论邮箱是否注册，同一邮箱/IP的请求频率限制一致
+	if err := u.verifyCodes.Throttle(ctx, model.CodePurposeLogin, email, clientIP); err != nil {
 		return nil, err
 	}
-	verifyCode, err := pkg.GenerateVerifyCode()
+	found, err := u.userRepo.GetByemail(ctx, email)
+	if err != nil && !errors.Is(err, apperr.ErrUserNotFound) {
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	// 未验证归属的邮箱不能用于验证码登录（防止他人注册时填写你的邮箱）；未注册的邮箱返回同一错误
+	if err != nil || !found.EmailVerified {
+		return nil, errEmailLoginUnavailable
+	}
+	user1 := &model.User{Email: found.Email}
+	verifyCode, err := u.verifyCodes.Generate(ctx, model.CodePurposeLogin, email, clientIP)
 	if err != nil {
 		return nil, err
 	}
 
-	err = pkg.SendVerifyCodeMail(*user1.Email, verifyCode)
+	msg, err := mail.NewVerifyCodeMessage(*user1.Email, verifyCode, u.verifyCodes.TTL())
+	if err == nil {
+		err = u.mailer.Send(ctx, msg)
+	}
 	if err != nil {
+		// 发送失败撤销验证码并归还冷却，用户可立即重试
+		u.verifyCodes.Cancel(ctx, model.CodePurposeLogin, email, clientIP)
 		return nil, fmt.Errorf("发送验证码失败：%w", err)
 	}
 	return user1, nil
 }
-func (u *staffServiceImpl) Verify(ctx context.Context, email string, code string) (*dto.LoginResponse, error) {
+func (u *staffServiceImpl) Verify(ctx context.Context, email, code, clientIP string) (resp *dto.LoginResponse, err error) {
+	defer func() { recordLogin(metrics.LoginMethodEmailCode, resp, err) }()
 
-	if err := pkg.VerifyCodeFromMap(email, code); err != nil {
+	if err := u.verifyCodes.Verify(ctx, model.CodePurposeLogin, email, code); err != nil {
 		return nil, err
 	}
-	var user *model.User
-	user, _ = u.userRepo.GetByemail(ctx, email)
-	// 生成Token（调用pkg.GenerateToken）
-	token, err := pkg.GenerateToken(user.UUID, user.Username, user.Role)
+	user, err := u.userRepo.GetByemail(ctx, email)
 	if err != nil {
-		return nil, errors.New("生成登录凭证失败：" + err.Error())
+		return nil, fmt.Errorf("查询用户失败：%w", err)
 	}
-
-	return &dto.LoginResponse{
-		Token:    token,
-		UserID:   user.ID,
-		Username: user.Username,
-		Role:     user.Role,
-	}, nil
+	// 签发访问Token+刷新令牌（开启新的登录会话；已开启两步验证时只签发挑战令牌）
+	return u.beginLogin(ctx, user, clientIP)
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/mail"
+	"CMS/internal/model"
+	"context"
+	"errors"
+	netmail "net/mail"
+	"testing"
+)
+
+func TestEloginUniformErrorForUnknownAndUnverified(t *testing.T) {
+	verified, unverified := "verified@example.com", "unverified@example.com"
+	mailer := mail.NewMemoryMailer(netmail.Address{Address: "noreply@example.com"})
+	s := &staffServiceImpl{
+		userRepo: &emailUserRepo{users: map[string]*model.User{
+			verified:   {UUID: "u1", Email: &verified, EmailVerified: true},
+			unverified: {UUID: "u2", Email: &unverified, EmailVerified: false},
+		}},
+		verifyCodes: newTestVerifyCodes(),
+		mailer:      mailer,
+	}
+	ctx := context.Background()
+
+	// 未注册与未验证的邮箱返回完全相同的错误（错误码、HTTP状态、提示文案）
+	var errs []*apperr.Error
+	for _, tc := range []struct{ email, ip string }{
+		{"missing@example.com", "203.0.113.1"},
+		{unverified, "203.0.113.2"},
+	} {
+		_, err := s.Elogin(ctx, &model.User{Email: &tc.email}, tc.ip)
+		appErr, ok := apperr.As(err)
+		if !ok {
+			t.Fatalf("%s：返回%v，期望业务错误", tc.email, err)
+		}
+		errs = append(errs, appErr)
+	}
+	if *errs[0] != *errs[1] {
+		t.Errorf("未注册邮箱返回%+v，未验证邮箱返回%+v，期望一致", errs[0], errs[1])
+	}
+	// 两种情况同样占用发送冷却
+	missing := "missing@example.com"
+	if _, err := s.Elogin(ctx, &model.User{Email: &missing}, "198.51.100.9"); !errors.Is(err, apperr.ErrSendTooFrequent) {
+		t.Errorf("冷却期内再次请求返回%v，期望ErrSendTooFrequent", err)
+	}
+	if len(mailer.Messages()) != 0 {
+		t.Fatal("未注册/未验证的邮箱不应发送验证码")
+	}
+
+	// 已验证的邮箱正常发送
+	if _, err := s.Elogin(ctx, &model.User{Email: &verified}, "192.0.2.1"); err != nil {
+		t.Fatalf("已验证邮箱返回错误：%v", err)
+	}
+	if mailer.Last(verified) == nil {
+		t.Error("已验证邮箱应收到验证码")
+	}
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/dto"
+	"CMS/internal/model"
+	"CMS/internal/pkg/langdetect"
+	"CMS/internal/repository"
+	"CMS/internal/search"
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxResourceTags    = 5  // 单个资源最多的标签数
+	MaxTagLen          = 20 // 标签最大字符数（按rune计算）
+	MaxCategoryNameLen = 30 // 分类名称最大字符数（按rune计算）
+)
+
+// tagRegex 标签允许的字符：字母（含中文）、数字及 + # . _ -（兼容c++、c#、.net等写法）
+var tagRegex = regexp.MustCompile(`^[\p{L}\p{N}+#._-]+$`)
+
+// resourceMeta 规范化后的资源标签/语言/分类
+type resourceMeta struct {
+	language   string
+	categoryID uint64
+	tags       []string
+}
+
+// newResourceMeta 规范化发布/修改资源时传入的元信息：标签转小写去重，语言为空时按代码内容识别
+// 分类是否存在由调用方在事务内锁定校验（避免与删除分类并发时产生孤立引用）
+func newResourceMeta(language string, categoryID uint64, tags []string, code string) (*resourceMeta, error) {
+	meta := &resourceMeta{categoryID: categoryID, tags: []string{}}
+	if strings.TrimSpace(language) == "" {
+		meta.language = langdetect.Detect(code)
+	} else {
+		id, ok := langdetect.Normalize(language)
+		if !ok {
+			return nil, apperr.InvalidArgumentf("不支持的编程语言：%s", language)
+		}
+		meta.language = id
+	}
+
+	for _, tag := range tags {
+		name, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" || slices.Contains(meta.tags, name) {
+			continue
+		}
+		meta.tags = append(meta.tags, name)
+	}
+	if len(meta.tags) > MaxResourceTags {
+		return nil, apperr.InvalidArgumentf("标签最多%d个（当前：%d个）", MaxResourceTags, len(meta.tags))
+	}
+	slices.Sort(meta.tags)
+	return meta, nil
+}
+
+// normalizeTag 标签去空格、转小写并校验字符与长度（空标签返回空字符串）
+func normalizeTag(tag string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(tag))
+	if name == "" {
+		return "", nil
+	}
+	if n := utf8.RuneCountInString(name); n > MaxTagLen {
+		return "", apperr.InvalidArgumentf("标签「%s」过长（最多%d个字符）", tag, MaxTagLen)
+	}
+	if !tagRegex.MatchString(name) {
+		return "", apperr.InvalidArgumentf("标签「%s」包含不支持的字符（仅支持文字、数字及+#._-）", tag)
+	}
+	return name, nil
+}
+
+// lockCategory 在事务内锁定并校验资源的所属分类（0为未分类，无需校验）
+func (s *ResourceServiceImpl) lockCategory(ctx context.Context, tx *sql.Tx, categoryID uint64) error {
+	if categoryID == 0 {
+		return nil
+	}
+	category, err := s.categoryRepo.LockCategory(ctx, tx, categoryID)
+	if err != nil {
+		return err
+	}
+	if category == nil {
+		return apperr.ErrCategoryNotFound.WithMsgf("分类不存在（id=%d）", categoryID)
+	}
+	return nil
+}
+
+// UpdateResourceMeta 作者修改资源的标签/语言/分类（标签整体替换），完成后同步检索索引
+func (s *ResourceServiceImpl) UpdateResourceMeta(ctx context.Context, userUUID string, req dto.UpdateResourceMetaReq) (*dto.ResourceMetaResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	if req.ID <= 0 {
+		return nil, apperr.InvalidArgumentf("资源ID无效（id=%d）", req.ID)
+	}
+
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	resource, err := s.resourceRepo.GetResourceByID(ctx, req.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询资源失败（id=%d）：%w", req.ID, err)
+	}
+	if resource == nil {
+		return nil, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", req.ID)
+	}
+	if resource.UserID != user.ID {
+		return nil, apperr.PermissionDenied("只能修改自己发布的资源")
+	}
+	meta, err := newResourceMeta(req.Language, req.CategoryID, req.Tags, resource.CodeContent)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.resourceRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	locked, err := s.resourceRepo.LockResource(ctx, tx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if locked == nil {
+		return nil, apperr.ErrResourceNotFound.WithMsgf("资源不存在（id=%d）", req.ID)
+	}
+	if err := s.lockCategory(ctx, tx, meta.categoryID); err != nil {
+		return nil, err
+	}
+	if err := s.resourceRepo.UpdateMeta(ctx, tx, req.ID, meta.language, meta.categoryID); err != nil {
+		return nil, err
+	}
+	if err := s.tagRepo.SetResourceTags(ctx, tx, req.ID, meta.tags); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交修改资源信息事务失败：%w", err)
+	}
+
+	resource.Language, resource.CategoryID, resource.Tags = meta.language, meta.categoryID, meta.tags
+	indexResource(ctx, s.searchIndex, resource)
+	return newResourceMetaResp(resource), nil
+}
+
+// newResourceMetaResp 资源元信息响应
+func newResourceMetaResp(res *model.Resource) *dto.ResourceMetaResp {
+	tags := res.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return &dto.ResourceMetaResp{
+		ID:           res.ID,
+		Language:     res.Language,
+		LanguageName: langdetect.Name(res.Language),
+		CategoryID:   res.CategoryID,
+		Tags:         tags,
+	}
+}
+
+// GetTaxonomy 查询分类树与支持的编程语言
+func (s *ResourceServiceImpl) GetTaxonomy(ctx context.Context) (*dto.TaxonomyResp, error) {
+	tree, err := s.loadCategoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.TaxonomyResp{
+		Categories: tree.nodes(0),
+		Languages:  make([]dto.LanguageItem, 0, len(langdetect.Languages)),
+	}
+	for _, lang := range langdetect.Languages {
+		resp.Languages = append(resp.Languages, dto.LanguageItem{ID: lang.ID, Name: lang.Name})
+	}
+	return resp, nil
+}
+
+// GetResourceFacets 统计资源列表的语言/分类/标签分面（检索与过滤条件与GetResourceList一致）
+func (s *ResourceServiceImpl) GetResourceFacets(ctx context.Context, req dto.ResourceListReq) (*dto.ResourceFacets, error) {
+	filter, err := parseResourceFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.loadCategoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.CategoryID != 0 {
+		if filter.CategoryIDs, err = tree.subtreeIDs(req.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	var raw *repository.ResourceFacets
+	if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+		raw, err = s.searchIndex.Facets(ctx, search.Query{Keyword: keyword, Filter: filter})
+	} else {
+		raw, err = s.resourceRepo.CountFacets(ctx, filter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("统计资源分面失败：%w", err)
+	}
+
+	facets := &dto.ResourceFacets{
+		Languages:  make([]dto.FacetCount, 0, len(raw.Languages)),
+		Categories: tree.facets(raw.Categories),
+		Tags:       make([]dto.FacetCount, 0, len(raw.Tags)),
+	}
+	for lang, n := range raw.Languages {
+		facets.Languages = append(facets.Languages, dto.FacetCount{Value: lang, Label: langdetect.Name(lang), Count: n})
+	}
+	for tag, n := range raw.Tags {
+		facets.Tags = append(facets.Tags, dto.FacetCount{Value: tag, Label: tag, Count: n})
+	}
+	sortFacets(facets.Languages)
+	sortFacets(facets.Tags)
+	return facets, nil
+}
+
+// sortFacets 按资源数降序（同数按筛选值升序，保证结果稳定）
+func sortFacets(items []dto.FacetCount) {
+	slices.SortFunc(items, func(a, b dto.FacetCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+}
+
+// loadCategoryTree 查询全部分类并构建分类树（分类数量少，每次请求直接查询）
+func (s *ResourceServiceImpl) loadCategoryTree(ctx context.Context) (*categoryTree, error) {
+	categories, err := s.categoryRepo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newCategoryTree(categories), nil
+}
+
+// categoryTree 内存中的分类树（由ListCategories的全量结果构建，子分类保持sort_order顺序）
+type categoryTree struct {
+	byID     map[uint64]*model.Category
+	children map[uint64][]*model.Category // 父分类ID → 子分类（0为一级分类）
+}
+
+func newCategoryTree(categories []*model.Category) *categoryTree {
+	t := &categoryTree{
+		byID:     make(map[uint64]*model.Category, len(categories)),
+		children: make(map[uint64][]*model.Category),
+	}
+	for _, c := range categories {
+		t.byID[c.ID] = c
+		t.children[c.ParentID] = append(t.children[c.ParentID], c)
+	}
+	return t
+}
+
+// depth 分类所在层级（一级分类为1，0表示根）
+func (t *categoryTree) depth(id uint64) int {
+	d := 0
+	for c := t.byID[id]; c != nil && d <= model.MaxCategoryDepth; c = t.byID[c.ParentID] {
+		d++
+	}
+	return d
+}
+
+// subtreeIDs 分类及其全部子孙分类的ID（分类不存在返回业务错误）
+func (t *categoryTree) subtreeIDs(id uint64) ([]uint64, error) {
+	if _, ok := t.byID[id]; !ok {
+		return nil, apperr.ErrCategoryNotFound.WithMsgf("分类不存在（id=%d）", id)
+	}
+	ids := []uint64{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range t.children[ids[i]] {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+// nodes 构建parentID下的分类子树
+func (t *categoryTree) nodes(parentID uint64) []*dto.CategoryNode {
+	children := t.children[parentID]
+	nodes := make([]*dto.CategoryNode, 0, len(children))
+	for _, c := range children {
+		nodes = append(nodes, &dto.CategoryNode{
+			ID:        c.ID,
+			ParentID:  c.ParentID,
+			Name:      c.Name,
+			SortOrder: c.SortOrder,
+			Children:  t.nodes(c.ID),
+		})
+	}
+	return nodes
+}
+
+// facets 将各分类直接归属的资源数汇总到祖先分类，按分类树先序输出资源数大于0的分类
+func (t *categoryTree) facets(direct map[uint64]int64) []dto.CategoryFacet {
+	items := []dto.CategoryFacet{}
+	var walk func(parentID uint64, depth int) int64
+	walk = func(parentID uint64, depth int) int64 {
+		var total int64
+		for _, c := range t.children[parentID] {
+			i := len(items)
+			items = append(items, dto.CategoryFacet{ID: c.ID, ParentID: c.ParentID, Name: c.Name, Depth: depth})
+			n := direct[c.ID] + walk(c.ID, depth+1)
+			if n == 0 {
+				items = items[:i]
+				continue
+			}
+			items[i].Count = n
+			total += n
+		}
+		return total
+	}
+	walk(0, 1)
+	return items
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	pkg "CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultDenylistSweepInterval = 10 * time.Minute // 吊销记录清理间隔
+	DefaultDenylistSyncInterval  = 30 * time.Second // 从数据库同步吊销记录的间隔（多实例部署时其他实例的吊销最迟在此间隔后生效）
+	denylistSweepTimeout         = 10 * time.Second // 单次清理/同步超时
+)
+
+// TokenDenylist 访问Token吊销名单：MySQL持久化，内存缓存供JWTMiddleware每次请求O(1)判断
+// 两类吊销：单个Token（jti，退出登录）、用户级（签发时间早于revoked_before的全部Token，退出所有设备）
+// 吊销记录只需保留到对应Token自然过期，后台协程定期清理，并定期从数据库同步其他实例写入的吊销记录
+type TokenDenylist struct {
+	tokenRepo repository.TokenRepo
+
+	mu    sync.RWMutex
+	jtis  map[string]time.Time // jti → Token过期时间
+	users map[string]time.Time // 用户UUID → 吊销时间点
+}
+
+// NewTokenDenylist 创建吊销名单（需调用Load从数据库加载已有记录）
+func NewTokenDenylist(tokenRepo repository.TokenRepo) *TokenDenylist {
+	return &TokenDenylist{
+		tokenRepo: tokenRepo,
+		jtis:      make(map[string]time.Time),
+		users:     make(map[string]time.Time),
+	}
+}
+
+// Load 从数据库加载仍有效的吊销记录并合并到内存（服务启动时调用，保证重启后已退出的Token仍不可用；Run中定期调用同步其他实例的吊销）
+// 只合并不替换：查询期间本实例新写入的吊销不会被覆盖丢失，过期记录由sweep清理
+func (d *TokenDenylist) Load(ctx context.Context) error {
+	now := time.Now()
+	jtis, err := d.tokenRepo.ListRevokedTokens(ctx, now)
+	if err != nil {
+		return err
+	}
+	users, err := d.tokenRepo.ListUserRevocations(ctx, now.Add(-pkg.AccessTokenExpire()))
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for jti, exp := range jtis {
+		d.jtis[jti] = exp
+	}
+	for userUUID, before := range users {
+		if before.After(d.users[userUUID]) {
+			d.users[userUUID] = before
+		}
+	}
+	return nil
+}
+
+// RevokeToken 吊销单个访问Token（先落库再更新内存）
+func (d *TokenDenylist) RevokeToken(ctx context.Context, jti, userUUID string, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("Token缺少jti，无法吊销")
+	}
+	if err := d.tokenRepo.AddRevokedToken(ctx, jti, userUUID, expiresAt); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.jtis[jti] = expiresAt
+	d.mu.Unlock()
+	return nil
+}
+
+// RevokeUser 吊销用户在before之前签发的全部访问Token
+// iat为秒级精度，before截断到秒后按"签发时间早于before"判断：吊销后同一秒内重新签发的Token（如改密后的新登录）仍然有效
+func (d *TokenDenylist) RevokeUser(ctx context.Context, userUUID string, before time.Time) error {
+	before = before.Truncate(time.Second)
+	if err := d.tokenRepo.SetUserRevokedBefore(ctx, userUUID, before); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.users[userUUID] = before
+	d.mu.Unlock()
+	return nil
+}
+
+// IsRevoked 判断访问Token是否已被吊销（实现middleware.RevocationChecker）
+func (d *TokenDenylist) IsRevoked(claims *pkg.UserClaims) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, ok := d.jtis[claims.ID]; ok && claims.ID != "" {
+		return true
+	}
+	if before, ok := d.users[claims.UserID]; ok {
+		if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(before) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 后台清理与同步循环（阻塞，ctx取消后返回）
+func (d *TokenDenylist) Run(ctx context.Context, sweepInterval, syncInterval time.Duration) {
+	if sweepInterval <= 0 {
+		sweepInterval = DefaultDenylistSweepInterval
+	}
+	if syncInterval <= 0 {
+		syncInterval = DefaultDenylistSyncInterval
+	}
+	sweepTicker := time.NewTicker(sweepInterval)
+	defer sweepTicker.Stop()
+	syncTicker := time.NewTicker(syncInterval)
+	defer syncTicker.Stop()
+
+	for {
+		select {
+		case <-sweepTicker.C:
+			d.sweep()
+		case <-syncTicker.C:
+			d.sync(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync 从数据库同步吊销记录（失败只记录日志，下个周期重试）
+func (d *TokenDenylist) sync(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, denylistSweepTimeout)
+	defer cancel()
+	if err := d.Load(ctx); err != nil && ctx.Err() == nil {
+		logger.FromContext(ctx).Error("[Token吊销] 同步吊销记录失败", "error", err)
+	}
+}
+
+// sweep 清理内存及数据库中已过期的吊销记录
+func (d *TokenDenylist) sweep() {
+	now := time.Now()
+	since := now.Add(-pkg.AccessTokenExpire())
+
+	d.mu.Lock()
+	for jti, exp := range d.jtis {
+		if !exp.After(now) {
+			delete(d.jtis, jti)
+		}
+	}
+	for userUUID, before := range d.users {
+		if !before.After(since) {
+			delete(d.users, userUUID)
+		}
+	}
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), denylistSweepTimeout)
+	defer cancel()
+	if err := d.tokenRepo.PurgeExpired(ctx, now, since); err != nil {
+		logger.FromContext(ctx).Error("[Token吊销] 清理过期记录失败", "error", err)
+	}
+}
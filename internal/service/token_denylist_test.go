@@ -0,0 +1,110 @@
+package service
+
+import (
+	pkg "CMS/internal/pkg/jwt"
+	"CMS/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+)
+
+// fakeTokenRepo 内存版吊销记录（只实现吊销名单用到的方法）
+type fakeTokenRepo struct {
+	repository.TokenRepo
+	jtis  map[string]time.Time
+	users map[string]time.Time
+}
+
+func (r *fakeTokenRepo) SetUserRevokedBefore(_ context.Context, userUUID string, before time.Time) error {
+	r.users[userUUID] = before
+	return nil
+}
+
+func (r *fakeTokenRepo) ListRevokedTokens(context.Context, time.Time) (map[string]time.Time, error) {
+	out := make(map[string]time.Time, len(r.jtis))
+	for k, v := range r.jtis {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (r *fakeTokenRepo) ListUserRevocations(context.Context, time.Time) (map[string]time.Time, error) {
+	out := make(map[string]time.Time, len(r.users))
+	for k, v := range r.users {
+		out[k] = v
+	}
+	return out, nil
+}
+
+func claimsIssuedAt(userUUID, jti string, iat time.Time) *pkg.UserClaims {
+	return &pkg.UserClaims{
+		UserID: userUUID,
+		RegisteredClaims: jwtv5.RegisteredClaims{
+			ID:       jti,
+			IssuedAt: jwtv5.NewNumericDate(iat),
+		},
+	}
+}
+
+func TestTokenDenylistRevokeUserSameSecond(t *testing.T) {
+	repo := &fakeTokenRepo{jtis: map[string]time.Time{}, users: map[string]time.Time{}}
+	d := NewTokenDenylist(repo)
+
+	revokedAt := time.Date(2026, 1, 7, 12, 0, 0, 700*int(time.Millisecond), time.Local)
+	if err := d.RevokeUser(context.Background(), "u1", revokedAt); err != nil {
+		t.Fatalf("RevokeUser返回错误：%v", err)
+	}
+	if got := repo.users["u1"]; !got.Equal(revokedAt.Truncate(time.Second)) {
+		t.Fatalf("落库吊销时间=%v，期望截断到秒", got)
+	}
+
+	cases := []struct {
+		name string
+		iat  time.Time
+		want bool
+	}{
+		{"吊销前一秒签发", revokedAt.Add(-time.Second), true},
+		{"吊销同一秒内重新签发", revokedAt, false},
+		{"吊销后签发", revokedAt.Add(time.Second), false},
+	}
+	for _, tc := range cases {
+		if got := d.IsRevoked(claimsIssuedAt("u1", "", tc.iat)); got != tc.want {
+			t.Errorf("%s：IsRevoked=%v，期望%v", tc.name, got, tc.want)
+		}
+	}
+	if !d.IsRevoked(&pkg.UserClaims{UserID: "u1"}) {
+		t.Error("缺少iat的Token应视为已吊销")
+	}
+	if d.IsRevoked(claimsIssuedAt("u2", "", revokedAt.Add(-time.Hour))) {
+		t.Error("未被吊销的用户不应受影响")
+	}
+}
+
+func TestTokenDenylistLoadMergesOtherInstances(t *testing.T) {
+	repo := &fakeTokenRepo{jtis: map[string]time.Time{}, users: map[string]time.Time{}}
+	d := NewTokenDenylist(repo)
+	now := time.Now().Truncate(time.Second)
+
+	// 本实例内存中已有的吊销（例如在查询数据库之后写入），同步时不应丢失
+	d.users["local"] = now
+
+	// 其他实例写入数据库的吊销
+	repo.jtis["jti-other"] = now.Add(time.Hour)
+	repo.users["other"] = now
+	repo.users["local"] = now.Add(-time.Minute)
+
+	if err := d.Load(context.Background()); err != nil {
+		t.Fatalf("Load返回错误：%v", err)
+	}
+	if !d.IsRevoked(claimsIssuedAt("x", "jti-other", now)) {
+		t.Error("其他实例吊销的jti应同步生效")
+	}
+	if !d.IsRevoked(claimsIssuedAt("other", "", now.Add(-time.Second))) {
+		t.Error("其他实例的用户级吊销应同步生效")
+	}
+	if !d.users["local"].Equal(now) {
+		t.Errorf("合并后用户级吊销时间=%v，应保留较晚的%v", d.users["local"], now)
+	}
+}
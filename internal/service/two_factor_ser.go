@@ -0,0 +1,514 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/config"
+	"CMS/internal/dto"
+	"CMS/internal/metrics"
+	"CMS/internal/model"
+	pkg "CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
+	"CMS/internal/pkg/totp"
+	"CMS/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const twoFactorSweepTimeout = 10 * time.Second // 单次清理超时
+
+// TwoFactor TOTP两步验证：绑定验证器（otpauth URI）→ 提交动态码确认开启 → 下发一次性恢复码；
+// 开启后密码登录只签发短期挑战令牌，凭挑战令牌+动态码（或恢复码）才能换取登录凭证。
+// TOTP密钥加密存储，恢复码与挑战令牌只存哈希，同一时间步的动态码只能使用一次
+type TwoFactor struct {
+	repo repository.TwoFactorRepo
+	cfg  config.TwoFactorConfig
+}
+
+// NewTwoFactor 创建两步验证服务
+func NewTwoFactor(repo repository.TwoFactorRepo, cfg config.TwoFactorConfig) *TwoFactor {
+	return &TwoFactor{repo: repo, cfg: cfg}
+}
+
+// ChallengeTTL 挑战令牌有效期（用于登录响应中提示）
+func (t *TwoFactor) ChallengeTTL() time.Duration {
+	return t.cfg.ChallengeTTL
+}
+
+// Enabled 账号是否已开启两步验证
+func (t *TwoFactor) Enabled(ctx context.Context, userUUID string) (bool, error) {
+	tf, err := t.repo.GetTwoFactor(ctx, userUUID)
+	if err != nil {
+		return false, err
+	}
+	return tf != nil && tf.Enabled, nil
+}
+
+// Status 查询两步验证状态
+func (t *TwoFactor) Status(ctx context.Context, userUUID string) (*dto.TwoFactorStatusResp, error) {
+	tf, err := t.repo.GetTwoFactor(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &dto.TwoFactorStatusResp{}
+	if tf == nil {
+		return resp, nil
+	}
+	resp.Enabled = tf.Enabled
+	resp.Pending = !tf.Enabled
+	if tf.EnableTime.Valid {
+		resp.EnableTime = tf.EnableTime.Time.Format("2006-01-02 15:04:05")
+	}
+	if tf.Enabled {
+		if resp.RecoveryCodesLeft, err = t.repo.CountRecoveryCodes(ctx, userUUID); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Setup 生成新的TOTP密钥（待确认状态，重复调用会覆盖未确认的密钥），account为验证器App中显示的账号名
+func (t *TwoFactor) Setup(ctx context.Context, userUUID, account string) (*dto.TwoFactorSetupResp, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := totp.SealSecret(t.cfg.EncryptionKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("加密TOTP密钥失败：%w", err)
+	}
+	saved, err := t.repo.SavePendingSecret(ctx, userUUID, sealed, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, apperr.FailedPrecondition("两步验证已开启，如需更换验证器请先关闭")
+	}
+	return &dto.TwoFactorSetupResp{
+		Secret:     secret,
+		OtpauthURI: totp.URI(t.cfg.Issuer, account, secret),
+	}, nil
+}
+
+// Enable 提交动态码确认绑定并开启两步验证，返回一次性恢复码（只返回这一次）
+func (t *TwoFactor) Enable(ctx context.Context, userUUID, code string) ([]string, error) {
+	tx, err := t.repo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启两步验证事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tf, err := t.repo.LockTwoFactor(ctx, tx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, apperr.FailedPrecondition("请先绑定验证器")
+	}
+	if tf.Enabled {
+		return nil, apperr.FailedPrecondition("两步验证已开启")
+	}
+	secret, err := totp.OpenSecret(t.cfg.EncryptionKey, tf.SecretEnc)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), t.cfg.Skew, tf.LastStep)
+	if !ok {
+		return nil, apperr.ErrTwoFactorCodeInvalid.WithMsg("动态码错误，请确认验证器时间准确后重试")
+	}
+
+	now := time.Now()
+	if err := t.repo.EnableTwoFactor(ctx, tx, userUUID, step, now); err != nil {
+		return nil, err
+	}
+	codes, err := t.replaceRecoveryCodes(ctx, tx, userUUID, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交开启两步验证事务失败：%w", err)
+	}
+	return codes, nil
+}
+
+// Disable 校验动态码（或恢复码）后关闭两步验证，在调用方事务内执行（调用方已校验登录密码）
+func (t *TwoFactor) Disable(ctx context.Context, tx *sql.Tx, userUUID, code string) error {
+	tf, err := t.repo.LockTwoFactor(ctx, tx, userUUID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		return apperr.FailedPrecondition("两步验证未开启")
+	}
+	ok, err := t.verify(ctx, tx, tf, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperr.ErrTwoFactorCodeInvalid
+	}
+	return t.repo.DeleteTwoFactor(ctx, tx, userUUID)
+}
+
+// RegenerateRecoveryCodes 校验动态码（或恢复码）后重新生成恢复码，旧恢复码全部作废
+func (t *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) ([]string, error) {
+	tx, err := t.repo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开启生成恢复码事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	tf, err := t.repo.LockTwoFactor(ctx, tx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || !tf.Enabled {
+		return nil, apperr.FailedPrecondition("两步验证未开启")
+	}
+	ok, err := t.verify(ctx, tx, tf, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperr.ErrTwoFactorCodeInvalid
+	}
+	codes, err := t.replaceRecoveryCodes(ctx, tx, userUUID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交生成恢复码事务失败：%w", err)
+	}
+	return codes, nil
+}
+
+// IssueChallenge 密码校验通过后签发登录挑战令牌，返回明文令牌（仅下发给客户端）
+func (t *TwoFactor) IssueChallenge(ctx context.Context, userUUID, clientIP string) (string, error) {
+	token, hash, err := pkg.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	if err := t.repo.CreateChallenge(ctx, &model.TwoFactorChallenge{
+		TokenHash:  hash,
+		UserUUID:   userUUID,
+		IP:         clientIP,
+		ExpiresAt:  now.Add(t.cfg.ChallengeTTL),
+		CreateTime: now,
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ChallengeUser 查询挑战令牌所属账号（用于校验前检查账号锁定），令牌无效或已过期返回错误
+func (t *TwoFactor) ChallengeUser(ctx context.Context, token string) (string, error) {
+	challenge, err := t.repo.GetChallenge(ctx, nil, pkg.HashRefreshToken(strings.TrimSpace(token)))
+	if err != nil {
+		return "", err
+	}
+	if challenge == nil || !challenge.ExpiresAt.After(time.Now()) {
+		return "", apperr.ErrTwoFactorChallenge
+	}
+	return challenge.UserUUID, nil
+}
+
+// Redeem 校验挑战令牌与动态码（或恢复码）：通过后令牌立即失效；
+// 失败次数达到上限后令牌作废，需重新输入密码登录
+func (t *TwoFactor) Redeem(ctx context.Context, token, code string) error {
+	tokenHash := pkg.HashRefreshToken(strings.TrimSpace(token))
+
+	tx, err := t.repo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启两步验证登录事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// 1. 锁定挑战令牌（并发提交同一令牌时串行校验）
+	challenge, err := t.repo.GetChallenge(ctx, tx, tokenHash)
+	if err != nil {
+		return err
+	}
+	if challenge == nil || !challenge.ExpiresAt.After(time.Now()) {
+		return apperr.ErrTwoFactorChallenge
+	}
+
+	// 2. 锁定两步验证设置并校验动态码/恢复码
+	tf, err := t.repo.LockTwoFactor(ctx, tx, challenge.UserUUID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || !tf.Enabled {
+		// 签发挑战后两步验证已被关闭，挑战作废
+		if err := t.repo.DeleteChallenge(ctx, tx, tokenHash); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("提交两步验证登录事务失败：%w", err)
+		}
+		return apperr.ErrTwoFactorChallenge
+	}
+	ok, err := t.verify(ctx, tx, tf, code)
+	if err != nil {
+		return err
+	}
+
+	// 3. 通过或失败次数达到上限都删除挑战，否则失败次数+1（失败结果同样需要提交）
+	left := t.cfg.ChallengeMaxAttempts - challenge.Attempts - 1
+	if ok || left <= 0 {
+		err = t.repo.DeleteChallenge(ctx, tx, tokenHash)
+	} else {
+		err = t.repo.IncrChallengeAttempts(ctx, tx, tokenHash)
+	}
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交两步验证登录事务失败：%w", err)
+	}
+
+	switch {
+	case ok:
+		return nil
+	case left <= 0:
+		// 挑战已作废需重新登录，同时保留动态码错误作为原因（调用方据此累计登录失败次数）
+		return apperr.ErrTwoFactorChallenge.WithMsg("动态码错误次数过多，请重新登录").Wrap(apperr.ErrTwoFactorCodeInvalid)
+	default:
+		return apperr.ErrTwoFactorCodeInvalid.WithMsgf("动态码错误，还可尝试%d次", left)
+	}
+}
+
+// Run 后台定期清理过期的挑战令牌（ctx取消时退出）
+func (t *TwoFactor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweep 清理过期挑战令牌
+func (t *TwoFactor) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), twoFactorSweepTimeout)
+	defer cancel()
+	if _, err := t.repo.PurgeExpiredChallenges(ctx, time.Now()); err != nil {
+		logger.FromContext(ctx).Error("[两步验证] 清理过期挑战令牌失败", "error", err)
+	}
+}
+
+// verify 事务内校验动态码或恢复码（tf须已加锁）：6位数字按TOTP校验并记录时间步，其余按恢复码校验并标记已使用
+func (t *TwoFactor) verify(ctx context.Context, tx *sql.Tx, tf *model.UserTwoFactor, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		secret, err := totp.OpenSecret(t.cfg.EncryptionKey, tf.SecretEnc)
+		if err != nil {
+			return false, err
+		}
+		step, ok := totp.Validate(secret, code, time.Now(), t.cfg.Skew, tf.LastStep)
+		if !ok {
+			return false, nil
+		}
+		if err := t.repo.UpdateLastStep(ctx, tx, tf.UserUUID, step); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if code == "" {
+		return false, nil
+	}
+	return t.repo.UseRecoveryCode(ctx, tx, tf.UserUUID, totp.HashRecoveryCode(code), time.Now())
+}
+
+// replaceRecoveryCodes 生成新恢复码并整体替换，返回明文
+func (t *TwoFactor) replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userUUID string, now time.Time) ([]string, error) {
+	codes, err := totp.GenerateRecoveryCodes(t.cfg.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, c := range codes {
+		hashes = append(hashes, totp.HashRecoveryCode(c))
+	}
+	if err := t.repo.ReplaceRecoveryCodes(ctx, tx, userUUID, hashes, now); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// isTOTPCode 是否为6位数字动态码
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// TwoFactorStatus 查询当前账号两步验证状态
+func (s *staffServiceImpl) TwoFactorStatus(ctx context.Context, userUUID string) (*dto.TwoFactorStatusResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	return s.twoFactor.Status(ctx, userUUID)
+}
+
+// SetupTwoFactor 生成TOTP密钥及otpauth URI（验证器App中以用户名显示账号）
+func (s *staffServiceImpl) SetupTwoFactor(ctx context.Context, userUUID string) (*dto.TwoFactorSetupResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	return s.twoFactor.Setup(ctx, userUUID, user.Username)
+}
+
+// EnableTwoFactor 提交动态码确认开启两步验证，返回一次性恢复码
+func (s *staffServiceImpl) EnableTwoFactor(ctx context.Context, userUUID, code string) (*dto.TwoFactorRecoveryCodesResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	codes, err := s.twoFactor.Enable(ctx, userUUID, code)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorRecoveryCodesResp{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor 关闭两步验证：锁定用户 → 校验登录密码 → 校验动态码/恢复码并删除设置（同一事务）
+func (s *staffServiceImpl) DisableTwoFactor(ctx context.Context, userUUID string, req dto.TwoFactorDisableReq) error {
+	if strings.TrimSpace(userUUID) == "" {
+		return apperr.InvalidArgument("用户UUID不能为空")
+	}
+
+	tx, err := s.userRepo.GetDB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开启关闭两步验证事务失败：%w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	user, err := s.userRepo.LockUser(ctx, tx, userUUID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return apperr.ErrUserNotFound
+	}
+	if !pkg.CheckPassword(req.Password, user.PasswordHash) {
+		return apperr.ErrWrongPassword.WithMsg("登录密码错误")
+	}
+	if err := s.twoFactor.Disable(ctx, tx, userUUID, req.Code); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交关闭两步验证事务失败：%w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 校验动态码（或恢复码）后重新生成恢复码
+func (s *staffServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userUUID, code string) (*dto.TwoFactorRecoveryCodesResp, error) {
+	if strings.TrimSpace(userUUID) == "" {
+		return nil, apperr.InvalidArgument("用户UUID不能为空")
+	}
+	codes, err := s.twoFactor.RegenerateRecoveryCodes(ctx, userUUID, code)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorRecoveryCodesResp{RecoveryCodes: codes}, nil
+}
+
+// LoginTwoFactor 两步验证登录：凭密码登录返回的挑战令牌+动态码（或恢复码）签发访问Token+刷新令牌
+// 动态码错误与密码错误共用账号/IP失败计数，达到阈值后同样临时锁定
+func (s *staffServiceImpl) LoginTwoFactor(ctx context.Context, req dto.TwoFactorLoginReq, clientIP, userAgent string) (resp *dto.LoginResponse, err error) {
+	defer func() { recordLogin(metrics.LoginMethodTwoFactor, resp, err) }()
+	ipKey := ipLockKey(clientIP)
+	if err := s.loginGuard.Check(ctx, ipKey); err != nil {
+		return nil, err
+	}
+
+	userUUID, err := s.twoFactor.ChallengeUser(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetUserByUuid(ctx, userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败：%w", err)
+	}
+	user.UUID = userUUID // GetUserByUuid不返回uuid字段
+	accountKey := accountLockKey(user, "")
+	attempt := &model.LoginAttempt{
+		UserUUID:   userUUID,
+		Identifier: user.Username,
+		IP:         clientIP,
+		UserAgent:  userAgent,
+		CreateTime: time.Now(),
+	}
+
+	if err := s.loginGuard.Check(ctx, accountKey); err != nil {
+		attempt.Result = model.LoginResultLocked
+		s.loginGuard.Record(ctx, attempt)
+		return nil, err
+	}
+	if err := s.twoFactor.Redeem(ctx, req.ChallengeToken, req.Code); err != nil {
+		if errors.Is(err, apperr.ErrTwoFactorCodeInvalid) {
+			s.loginGuard.Fail(ctx, accountKey, ipKey)
+			attempt.Result = model.LoginResultTwoFactorFail
+			s.loginGuard.Record(ctx, attempt)
+		}
+		return nil, err
+	}
+
+	resp, err = s.issueSession(ctx, user)
+	if err != nil {
+		if checkUserStatus(user) != nil {
+			attempt.Result = model.LoginResultDisabled
+			s.loginGuard.Record(ctx, attempt)
+		}
+		return nil, err
+	}
+	s.loginGuard.Reset(ctx, accountKey)
+	attempt.Result = model.LoginResultSuccess
+	s.loginGuard.Record(ctx, attempt)
+	return resp, nil
+}
+
+// beginLogin 第一步校验（密码/邮箱验证码）通过后开启登录：未开启两步验证直接签发会话，
+// 已开启则只签发短期挑战令牌，需调用LoginTwoFactor完成登录
+func (s *staffServiceImpl) beginLogin(ctx context.Context, user *model.User, clientIP string) (*dto.LoginResponse, error) {
+	if err := checkUserStatus(user); err != nil {
+		return nil, err
+	}
+	enabled, err := s.twoFactor.Enabled(ctx, user.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return s.issueSession(ctx, user)
+	}
+
+	token, err := s.twoFactor.IssueChallenge(ctx, user.UUID, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResponse{
+		UserID:             user.ID,
+		Username:           user.Username,
+		TwoFactorRequired:  true,
+		ChallengeToken:     token,
+		ChallengeExpiresIn: int64(s.twoFactor.ChallengeTTL() / time.Second),
+	}, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/config"
+	"CMS/internal/model"
+	pkg "CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const verifyCodeSweepTimeout = 10 * time.Second // 单次清理超时
+
+// VerifyCodes 邮箱验证码签发与校验：同一邮箱/IP有发送冷却，单个验证码有校验次数上限，
+// 验证码只以哈希形式存储，过期记录由后台协程定期清理
+type VerifyCodes struct {
+	store repository.CodeStore
+	cfg   config.VerifyCodeConfig
+}
+
+// NewVerifyCodes 创建验证码服务
+func NewVerifyCodes(store repository.CodeStore, cfg config.VerifyCodeConfig) *VerifyCodes {
+	return &VerifyCodes{store: store, cfg: cfg}
+}
+
+// TTL 验证码有效期（用于邮件中提示）
+func (v *VerifyCodes) TTL() time.Duration {
+	return v.cfg.TTL
+}
+
+// Issue 签发验证码：占用邮箱与IP的发送冷却 → 生成6位验证码 → 保存哈希，返回明文验证码由调用方发送
+func (v *VerifyCodes) Issue(ctx context.Context, purpose, email, clientIP string) (string, error) {
+	if err := v.Throttle(ctx, purpose, email, clientIP); err != nil {
+		return "", err
+	}
+	return v.Generate(ctx, purpose, email, clientIP)
+}
+
+// Throttle 占用邮箱与IP的发送冷却（冷却期内返回ErrSendTooFrequent）
+// 需要先判断账号状态再决定是否发送的场景（如忘记密码），先调用Throttle再调用Generate，保证无论是否实际发送都受同样的冷却限制
+func (v *VerifyCodes) Throttle(ctx context.Context, purpose, email, clientIP string) error {
+	email = normalizeEmail(email)
+	if email == "" {
+		return apperr.InvalidArgument("邮箱不能为空")
+	}
+	now := time.Now()
+
+	emailKey := cooldownKey(purpose, "email", email)
+	if err := v.acquireCooldown(ctx, emailKey, v.cfg.EmailCooldown, now); err != nil {
+		return err
+	}
+	if clientIP != "" {
+		if err := v.acquireCooldown(ctx, cooldownKey(purpose, "ip", clientIP), v.cfg.IPCooldown, now); err != nil {
+			// IP受限时归还已占用的邮箱冷却，避免该邮箱被无故限制
+			v.releaseCooldown(ctx, emailKey)
+			return err
+		}
+	}
+	return nil
+}
+
+// Generate 生成6位验证码并保存哈希（调用方需已通过Throttle占用冷却；失败时撤销并归还冷却）
+func (v *VerifyCodes) Generate(ctx context.Context, purpose, email, clientIP string) (string, error) {
+	email = normalizeEmail(email)
+	now := time.Now()
+
+	code, err := pkg.GenerateVerifyCode()
+	if err != nil {
+		v.Cancel(ctx, purpose, email, clientIP)
+		return "", err
+	}
+	if err := v.store.SaveCode(ctx, &model.VerifyCode{
+		Purpose:    purpose,
+		Email:      email,
+		CodeHash:   hashVerifyCode(purpose, email, code),
+		ExpiresAt:  now.Add(v.cfg.TTL),
+		CreateTime: now,
+	}); err != nil {
+		v.Cancel(ctx, purpose, email, clientIP)
+		return "", err
+	}
+	return code, nil
+}
+
+// Cancel 撤销刚签发的验证码并归还发送冷却（邮件发送失败时调用，用户可立即重试）
+func (v *VerifyCodes) Cancel(ctx context.Context, purpose, email, clientIP string) {
+	email = normalizeEmail(email)
+	if err := v.store.DeleteCode(ctx, purpose, email); err != nil {
+		logger.FromContext(ctx).Error("[验证码] 撤销验证码失败", "email", email, "error", err)
+	}
+	v.releaseCooldown(ctx, cooldownKey(purpose, "email", email))
+	if clientIP != "" {
+		v.releaseCooldown(ctx, cooldownKey(purpose, "ip", clientIP))
+	}
+}
+
+// Verify 校验验证码（通过后验证码立即失效；错误次数达到上限后需重新获取）
+func (v *VerifyCodes) Verify(ctx context.Context, purpose, email, code string) error {
+	email = normalizeEmail(email)
+	code = strings.TrimSpace(code)
+	if email == "" || code == "" {
+		return apperr.InvalidArgument("邮箱和验证码不能为空")
+	}
+
+	result, left, err := v.store.CheckCode(ctx, purpose, email, hashVerifyCode(purpose, email, code), v.cfg.MaxAttempts, time.Now())
+	if err != nil {
+		return fmt.Errorf("验证服务异常，请重试：%w", err)
+	}
+	switch result {
+	case repository.CodeMatched:
+		return nil
+	case repository.CodeExpired:
+		return apperr.ErrVerifyCodeInvalid.WithMsg("验证码已过期，请重新获取")
+	case repository.CodeMismatch:
+		return apperr.ErrVerifyCodeInvalid.WithMsgf("验证码错误，还可尝试%d次", left)
+	case repository.CodeTooManyAttempts:
+		return apperr.ErrVerifyCodeInvalid.WithMsg("验证码错误次数过多，请重新获取")
+	default:
+		return apperr.ErrVerifyCodeInvalid.WithMsg("验证码无效，请先获取验证码")
+	}
+}
+
+// Run 后台定期清理过期的验证码与冷却记录（ctx取消时退出）
+func (v *VerifyCodes) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			v.sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweep 清理过期记录
+func (v *VerifyCodes) sweep() {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyCodeSweepTimeout)
+	defer cancel()
+	if _, err := v.store.PurgeExpired(ctx, time.Now()); err != nil {
+		logger.FromContext(ctx).Error("[验证码] 清理过期记录失败", "error", err)
+	}
+}
+
+// acquireCooldown 占用发送冷却（间隔为0表示不限制）
+func (v *VerifyCodes) acquireCooldown(ctx context.Context, key string, cooldown time.Duration, now time.Time) error {
+	if cooldown <= 0 {
+		return nil
+	}
+	ok, until, err := v.store.AcquireCooldown(ctx, key, now.Add(cooldown), now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		wait := int(until.Sub(now).Round(time.Second) / time.Second)
+		if wait < 1 {
+			wait = 1
+		}
+		return apperr.ErrSendTooFrequent.WithMsgf("发送过于频繁，请%d秒后再试", wait)
+	}
+	return nil
+}
+
+// releaseCooldown 归还发送冷却（失败只记录日志，最坏情况是用户需等待冷却结束）
+func (v *VerifyCodes) releaseCooldown(ctx context.Context, key string) {
+	if err := v.store.ReleaseCooldown(ctx, key); err != nil {
+		logger.FromContext(ctx).Error("[验证码] 释放发送冷却失败", "key", key, "error", err)
+	}
+}
+
+// cooldownKey 发送冷却key（用途|维度|值）
+func cooldownKey(purpose, kind, value string) string {
+	return purpose + "|" + kind + "|" + value
+}
+
+// hashVerifyCode 验证码哈希（绑定用途与邮箱，同一验证码不能跨用途/邮箱使用）
+func hashVerifyCode(purpose, email, code string) string {
+	sum := sha256.Sum256([]byte(purpose + "|" + email + "|" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeEmail 邮箱统一去空格并转小写
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"CMS/internal/apperr"
+	"CMS/internal/config"
+	"CMS/internal/model"
+	"CMS/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestVerifyCodes() *VerifyCodes {
+	return NewVerifyCodes(repository.NewMemoryCodeStore(), config.VerifyCodeConfig{
+		TTL:           5 * time.Minute,
+		EmailCooldown: time.Minute,
+		IPCooldown:    time.Minute,
+		MaxAttempts:   3,
+	})
+}
+
+func TestVerifyCodesIPCooldown(t *testing.T) {
+	v := newTestVerifyCodes()
+	ctx := context.Background()
+	purpose := model.CodePurposeVerifyEmail
+
+	if _, err := v.Issue(ctx, purpose, "a@example.com", "203.0.113.7"); err != nil {
+		t.Fatalf("首次发送返回错误：%v", err)
+	}
+	// 同一IP换邮箱仍受IP冷却限制
+	if _, err := v.Issue(ctx, purpose, "b@example.com", "203.0.113.7"); !errors.Is(err, apperr.ErrSendTooFrequent) {
+		t.Fatalf("同一IP向其他邮箱发送返回%v，期望ErrSendTooFrequent", err)
+	}
+	// IP受限时归还了b的邮箱冷却：换一个IP可以立即发送
+	if _, err := v.Issue(ctx, purpose, "b@example.com", "198.51.100.9"); err != nil {
+		t.Fatalf("其他IP向b发送返回错误：%v", err)
+	}
+	// 冷却按用途隔离
+	if _, err := v.Issue(ctx, model.CodePurposeResetPassword, "c@example.com", "203.0.113.7"); err != nil {
+		t.Fatalf("不同用途发送返回错误：%v", err)
+	}
+}
+
+func TestVerifyCodesVerify(t *testing.T) {
+	v := newTestVerifyCodes()
+	ctx := context.Background()
+	purpose := model.CodePurposeLogin
+
+	code, err := v.Issue(ctx, purpose, "A@Example.com ", "")
+	if err != nil {
+		t.Fatalf("发送返回错误：%v", err)
+	}
+	wrong := "000000"
+	if wrong == code {
+		wrong = "111111"
+	}
+	if err := v.Verify(ctx, purpose, "a@example.com", wrong); !errors.Is(err, apperr.ErrVerifyCodeInvalid) {
+		t.Fatalf("错误验证码返回%v，期望ErrVerifyCodeInvalid", err)
+	}
+	// 邮箱大小写/空格不影响校验，校验通过后验证码立即失效
+	if err := v.Verify(ctx, purpose, "a@example.com", code); err != nil {
+		t.Fatalf("正确验证码返回错误：%v", err)
+	}
+	if err := v.Verify(ctx, purpose, "a@example.com", code); !errors.Is(err, apperr.ErrVerifyCodeInvalid) {
+		t.Fatalf("重复使用验证码返回%v，期望ErrVerifyCodeInvalid", err)
+	}
+}
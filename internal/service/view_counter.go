@@ -0,0 +1,161 @@
+package service
+
+import (
+	"CMS/internal/pkg/logger"
+	"CMS/internal/repository"
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultViewWindow        = 30 * time.Minute // 同一浏览者对同一资源的去重窗口
+	DefaultViewFlushInterval = 10 * time.Second // 浏览量批量刷盘间隔
+	viewFlushTimeout         = 5 * time.Second  // 单次刷盘超时
+	// DefaultViewMaxTracked 去重记录上限：达到上限时淘汰最早的一条记录（O(1)），内存不随请求无限增长
+	DefaultViewMaxTracked = 100000
+)
+
+// ViewCounter 浏览量计数器：窗口期内同一浏览者只计一次，增量在内存聚合后由后台协程批量写入resources.view_count
+type ViewCounter struct {
+	resourceRepo  repository.ResourceRepo
+	window        time.Duration
+	flushInterval time.Duration
+	maxTracked    int
+
+	mu      sync.Mutex
+	seen    map[string]*list.Element // 浏览者+资源ID → order中的节点
+	order   *list.List               // 去重记录按计数时间从早到晚排列（元素为*viewEntry），淘汰与过期清理都从队首开始
+	pending map[uint64]uint64        // 资源ID → 未刷盘的浏览增量
+}
+
+// viewEntry 一条去重记录
+type viewEntry struct {
+	key  string
+	last time.Time // 最近一次计数时间
+}
+
+// NewViewCounter 创建浏览量计数器（window/flushInterval传0使用默认值）
+func NewViewCounter(resourceRepo repository.ResourceRepo, window, flushInterval time.Duration) *ViewCounter {
+	if window <= 0 {
+		window = DefaultViewWindow
+	}
+	if flushInterval <= 0 {
+		flushInterval = DefaultViewFlushInterval
+	}
+	return &ViewCounter{
+		resourceRepo:  resourceRepo,
+		window:        window,
+		flushInterval: flushInterval,
+		maxTracked:    DefaultViewMaxTracked,
+		seen:          make(map[string]*list.Element),
+		order:         list.New(),
+		pending:       make(map[uint64]uint64),
+	}
+}
+
+// Seen 浏览者是否已在去重窗口内浏览过该资源（只读，不计数）
+func (v *ViewCounter) Seen(resourceID uint64, viewer string) bool {
+	key := viewKey(resourceID, viewer)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	elem, ok := v.seen[key]
+	return ok && time.Since(elem.Value.(*viewEntry).last) < v.window
+}
+
+// Record 记录一次浏览，返回是否计入（窗口期内重复浏览返回false）
+// 去重记录达到上限时淘汰最早的一条，单次调用为O(1)；过期记录由后台协程定期清理
+func (v *ViewCounter) Record(resourceID uint64, viewer string) bool {
+	key := viewKey(resourceID, viewer)
+	now := time.Now()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if elem, ok := v.seen[key]; ok {
+		entry := elem.Value.(*viewEntry)
+		if now.Sub(entry.last) < v.window {
+			return false
+		}
+		entry.last = now
+		v.order.MoveToBack(elem)
+	} else {
+		if len(v.seen) >= v.maxTracked {
+			oldest := v.order.Front()
+			v.order.Remove(oldest)
+			delete(v.seen, oldest.Value.(*viewEntry).key)
+		}
+		v.seen[key] = v.order.PushBack(&viewEntry{key: key, last: now})
+	}
+	v.pending[resourceID]++
+	return true
+}
+
+// Run 后台刷盘循环（阻塞，ctx取消后做最后一次刷盘再返回）
+func (v *ViewCounter) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			v.flushWithTimeout(context.Background())
+			v.sweep()
+		case <-ctx.Done():
+			v.flushWithTimeout(context.Background())
+			return
+		}
+	}
+}
+
+// Flush 将内存中的浏览增量批量写入数据库；写入失败时增量合并回内存，等待下次重试
+func (v *ViewCounter) Flush(ctx context.Context) error {
+	v.mu.Lock()
+	if len(v.pending) == 0 {
+		v.mu.Unlock()
+		return nil
+	}
+	batch := v.pending
+	v.pending = make(map[uint64]uint64)
+	v.mu.Unlock()
+
+	if err := v.resourceRepo.AddViewCounts(ctx, batch); err != nil {
+		v.mu.Lock()
+		for id, n := range batch {
+			v.pending[id] += n
+		}
+		v.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// flushWithTimeout 带超时刷盘（失败仅记录日志，不中断后台循环）
+func (v *ViewCounter) flushWithTimeout(parent context.Context) {
+	ctx, cancel := context.WithTimeout(parent, viewFlushTimeout)
+	defer cancel()
+	if err := v.Flush(ctx); err != nil {
+		logger.FromContext(ctx).Error("[浏览量计数] 刷盘失败", "error", err)
+	}
+}
+
+// sweep 清理已过去重窗口的浏览记录（从队首开始，遇到未过期的记录即停止，只遍历过期部分）
+func (v *ViewCounter) sweep() {
+	now := time.Now()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for elem := v.order.Front(); elem != nil; elem = v.order.Front() {
+		entry := elem.Value.(*viewEntry)
+		if now.Sub(entry.last) < v.window {
+			return
+		}
+		v.order.Remove(elem)
+		delete(v.seen, entry.key)
+	}
+}
+
+// viewKey 去重记录的key：浏览者+资源ID
+func viewKey(resourceID uint64, viewer string) string {
+	return viewer + "|" + strconv.FormatUint(resourceID, 10)
+}
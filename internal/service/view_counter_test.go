@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestViewCounterDedup(t *testing.T) {
+	v := NewViewCounter(nil, time.Minute, time.Minute)
+
+	if !v.Record(1, "ip:203.0.113.7") {
+		t.Fatal("首次浏览应计数")
+	}
+	if v.Record(1, "ip:203.0.113.7") {
+		t.Error("窗口期内重复浏览不应计数")
+	}
+	if !v.Record(2, "ip:203.0.113.7") || !v.Record(1, "u:alice") {
+		t.Error("不同资源/不同浏览者应各自计数")
+	}
+	if !v.Seen(1, "ip:203.0.113.7") || v.Seen(3, "ip:203.0.113.7") {
+		t.Error("Seen结果与已记录的浏览不一致")
+	}
+	if got := v.pending[1]; got != 2 {
+		t.Errorf("资源1待刷盘增量=%d，期望2", got)
+	}
+}
+
+func TestViewCounterEvictsOldestAtCap(t *testing.T) {
+	v := NewViewCounter(nil, time.Hour, time.Minute)
+	v.maxTracked = 3
+
+	for _, viewer := range []string{"a", "b", "c", "d"} {
+		if !v.Record(1, viewer) {
+			t.Fatalf("%s首次浏览应计数", viewer)
+		}
+	}
+	if len(v.seen) != 3 || v.order.Len() != 3 {
+		t.Fatalf("去重记录数=%d/%d，期望不超过上限3", len(v.seen), v.order.Len())
+	}
+	// 达到上限时淘汰最早的a，其余记录保留
+	if v.Seen(1, "a") {
+		t.Error("最早的记录应被淘汰")
+	}
+	for _, viewer := range []string{"b", "c", "d"} {
+		if !v.Seen(1, viewer) {
+			t.Errorf("%s的记录不应被淘汰", viewer)
+		}
+	}
+}
+
+func TestViewCounterSweepExpired(t *testing.T) {
+	v := NewViewCounter(nil, time.Minute, time.Minute)
+	v.Record(1, "old")
+	v.Record(1, "new")
+	// 将old的计数时间拨回窗口之外
+	v.seen[viewKey(1, "old")].Value.(*viewEntry).last = time.Now().Add(-2 * time.Minute)
+
+	v.sweep()
+	if _, ok := v.seen[viewKey(1, "old")]; ok {
+		t.Error("过期记录应被清理")
+	}
+	if _, ok := v.seen[viewKey(1, "new")]; !ok || v.order.Len() != 1 {
+		t.Error("未过期记录应保留")
+	}
+}
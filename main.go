@@ -1,12 +1,30 @@
 package main
 
 import (
+	"CMS/internal/config"
 	"CMS/internal/handler"
+	"CMS/internal/mail"
+	"CMS/internal/metrics"
+	"CMS/internal/middleware"
+	"CMS/internal/migrate"
+	"CMS/internal/payment"
 	"CMS/internal/pkg" // 统一导入pkg包
+	jwtpkg "CMS/internal/pkg/jwt"
+	"CMS/internal/pkg/logger"
 	"CMS/internal/repository"
 	"CMS/internal/router"
+	"CMS/internal/search"
 	"CMS/internal/service"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
 	"regexp"
+	"sync"
+	"syscall"
 
 	// 必须引入生成的docs包（swag init后自动创建，替换为你的项目实际模块路径）
 
@@ -48,33 +66,222 @@ func main() {
 		_ = v.RegisterValidation("phone", validatePhone)
 	}
 
+	// ========== 子命令：migrate up|down|status，只需数据库配置，执行数据库迁移后退出 ==========
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// ========== 加载配置：config/app.yaml（或CMS_CONFIG指定的文件）+ 环境变量覆盖，校验失败直接退出 ==========
+	cfg, err := config.Load("")
+	if err != nil {
+		panic("加载配置失败：" + err.Error())
+	}
+
+	// 结构化日志：按log配置输出到标准输出（敏感字段脱敏），标准库log的输出同样经由slog
+	slog.SetDefault(logger.New(cfg.Log))
+
+	// 注入JWT、文件存储配置
+	jwtCfg := jwtpkg.NewJWTConfig(cfg.JWT)
+	jwtpkg.SetJWTConfig(jwtCfg)
+	middleware.SetJWTConfig(jwtCfg)
+	jwtpkg.SetStorageConfig(cfg.Storage)
+
 	// ========== 核心修改：初始化原生MySQL连接（替换GORM） ==========
-	dsn := "root:123456@tcp(127.0.0.1:3306)/go_project?charset=utf8mb4&parseTime=True&loc=Local"
 	// 注意：gormDB 改为 db（原生*sql.DB）
-	db, err := pkg.InitMySQL(dsn)
+	db, err := pkg.InitMySQL(cfg.Database)
 	if err != nil {
 		panic("数据库连接失败：" + err.Error())
 	}
 	defer db.Close() // 程序退出时关闭连接（关键，防止连接泄露）
 
+	// 数据库结构版本校验：存在未执行的迁移时拒绝启动（先执行 migrate up）
+	migrator, err := migrate.New(db)
+	if err != nil {
+		panic("加载数据库迁移失败：" + err.Error())
+	}
+	if err := migrator.Check(context.Background()); err != nil {
+		panic("数据库结构校验失败：" + err.Error())
+	}
+
+	// 邮件发送：smtp/file/memory由mail.driver选择，未配置时发送邮件直接报错
+	mailer, err := mail.New(cfg.Mail, cfg.SMTP)
+	if err != nil {
+		panic("初始化邮件发送失败：" + err.Error())
+	}
+
 	// ========== 以下逻辑保持不变（接口兼容） ==========
 	// 初始化仓储层（传入原生*sql.DB）
 	userRepo := repository.NewUserRepo(db)
 	useraccRepo := repository.NewAccountRepo(db)
 	resourceRepo := repository.NewResourceRepo(db)
+	commentRepo := repository.NewCommentRepo(db)
+	likeRepo := repository.NewLikeRepo(db)
+	purchaseRepo := repository.NewPurchaseRepo(db)
+	tagRepo := repository.NewTagRepo(db)
+	categoryRepo := repository.NewCategoryRepo(db)
+	rechargeRepo := repository.NewRechargeOrderRepo(db)
+	auditRepo := repository.NewAuditRepo(db)
+
+	// 支付渠道：接入真实渠道时在此注册对应PaymentProvider；Mock渠道可直接模拟支付入账，仅在payment.mock_enabled开启时注册
+	var payProviders []payment.PaymentProvider
+	if cfg.Payment.MockEnabled {
+		slog.Warn("已启用Mock支付渠道，任何有充值权限的用户均可模拟支付入账，生产环境必须关闭payment.mock_enabled")
+		payProviders = append(payProviders, payment.NewMockProvider(cfg.Payment.MockSecret, cfg.Payment.MockNotifyURL))
+	}
+
+	tokenRepo := repository.NewTokenRepo(db)
+
+	// 后台任务统一由bgCtx控制，优雅关闭时取消并等待全部退出（浏览量计数器退出前会把内存增量刷盘）
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var workers sync.WaitGroup
+
+	// 浏览量计数器：内存去重聚合，后台协程定时批量刷盘
+	viewCounter := service.NewViewCounter(resourceRepo, service.DefaultViewWindow, service.DefaultViewFlushInterval)
+	workers.Go(func() { viewCounter.Run(bgCtx) })
+
+	// 访问Token吊销名单：启动时从数据库加载并定期同步（多实例共享），JWTMiddleware每次请求检查
+	denylist := service.NewTokenDenylist(tokenRepo)
+	if err := denylist.Load(bgCtx); err != nil {
+		panic("加载Token吊销名单失败：" + err.Error())
+	}
+	middleware.SetRevocationChecker(denylist)
+	workers.Go(func() { denylist.Run(bgCtx, service.DefaultDenylistSweepInterval, service.DefaultDenylistSyncInterval) })
+
+	// 邮箱验证码：默认MySQL存储（多实例共享），后台协程定期清理过期记录
+	codeStore := repository.NewCodeStore(db)
+	if cfg.VerifyCode.Store == config.CodeStoreMemory {
+		codeStore = repository.NewMemoryCodeStore()
+	}
+	verifyCodes := service.NewVerifyCodes(codeStore, cfg.VerifyCode)
+	workers.Go(func() { verifyCodes.Run(bgCtx, cfg.VerifyCode.SweepInterval) })
+
+	// 密码登录防暴力破解：账号/IP失败计数与临时锁定，后台协程定期清理过期计数和登录记录
+	loginGuard := service.NewLoginGuard(repository.NewLoginAttemptRepo(db), cfg.LoginGuard)
+	workers.Go(func() { loginGuard.Run(bgCtx, cfg.LoginGuard.SweepInterval) })
+
+	// TOTP两步验证：后台协程定期清理过期的登录挑战令牌
+	twoFactor := service.NewTwoFactor(repository.NewTwoFactorRepo(db), cfg.TwoFactor)
+	workers.Go(func() { twoFactor.Run(bgCtx, cfg.TwoFactor.SweepInterval) })
+
+	// 资源全文检索：默认MySQL FULLTEXT（ngram），memory方式启动时全量加载到进程内倒排索引并定期重建
+	var searchIndex search.SearchIndex = search.NewMySQLIndex(db)
+	if cfg.Search.Driver == config.SearchDriverMemory {
+		memIndex := search.NewMemoryIndex(db)
+		if err := memIndex.Rebuild(bgCtx); err != nil {
+			panic("加载全文检索索引失败：" + err.Error())
+		}
+		slog.Info("内存全文检索索引已加载", "resources", memIndex.Len())
+		workers.Go(func() { memIndex.Run(bgCtx, cfg.Search.RebuildInterval) })
+		searchIndex = memIndex
+	}
 
 	// 初始化业务层
-	staffSvc := service.NewStaffService(userRepo, useraccRepo)
-	accSvc := service.NewAccountService(useraccRepo, userRepo)
-	resourceSvc := service.NewResourceService(resourceRepo, userRepo, useraccRepo)
+	staffSvc := service.NewStaffService(userRepo, useraccRepo, tokenRepo, denylist, jwtCfg, mailer, verifyCodes, loginGuard, twoFactor)
+	accSvc := service.NewAccountService(useraccRepo, userRepo, rechargeRepo, payProviders...)
+	resourceSvc := service.NewResourceService(resourceRepo, userRepo, useraccRepo, commentRepo, likeRepo, purchaseRepo, tagRepo, categoryRepo, viewCounter, mailer, searchIndex, cfg.Search)
+	adminSvc := service.NewAdminService(userRepo, useraccRepo, resourceRepo, commentRepo, likeRepo, purchaseRepo, tagRepo, categoryRepo, auditRepo, tokenRepo, denylist, searchIndex)
 	// 初始化处理器
-	staffHandler := handler.NewStaffHandler(staffSvc, accSvc, resourceSvc)
+	staffHandler := handler.NewStaffHandler(staffSvc, accSvc, resourceSvc, adminSvc)
+
+	healthHandler := handler.NewHealthHandler(db)
 
 	// 初始化路由
-	r := router.SetupRouter(staffHandler)
+	r, err := router.SetupRouter(staffHandler, healthHandler, cfg)
+	if err != nil {
+		panic("初始化路由失败：" + err.Error())
+	}
+
+	// 启动服务（读写/空闲超时来自server配置，防止慢连接长期占用）
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr(),
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("HTTP服务启动", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
 
-	// 启动服务
-	if err := r.Run(":8080"); err != nil {
-		panic("服务启动失败：" + err.Error())
+	// Prometheus指标单独监听server.metrics_addr（默认仅本机），不经过对外端口，无需鉴权
+	var metricsSrv *http.Server
+	if cfg.Server.MetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsSrv = &http.Server{
+			Addr:              cfg.Server.MetricsAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: cfg.Server.ReadTimeout,
+			ReadTimeout:       cfg.Server.ReadTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		}
+		go func() {
+			slog.Info("指标服务启动", "addr", metricsSrv.Addr)
+			if err := metricsSrv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("指标服务：%w", err)
+			}
+		}()
+	}
+
+	// 等待SIGTERM/SIGINT（部署滚动更新、Ctrl+C）或服务异常退出
+	sigCtx, stopSignal := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignal()
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			panic("服务启动失败：" + err.Error())
+		}
+	case <-sigCtx.Done():
+	}
+	stopSignal() // 再次收到信号时按默认行为立即退出
+
+	// ========== 优雅关闭：摘除流量 → 等待进行中请求完成 → 停止后台任务 → 关闭数据库 ==========
+	slog.Info("收到退出信号，开始优雅关闭", "timeout", cfg.Server.ShutdownTimeout.String())
+	healthHandler.SetDraining()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("等待进行中请求超时，强制关闭连接", "error", err)
+	}
+	if metricsSrv != nil {
+		_ = metricsSrv.Shutdown(shutdownCtx)
+	}
+
+	stopBackground()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		slog.Error("等待后台任务退出超时，浏览量等内存数据可能未完整刷盘")
+	}
+	slog.Info("服务已关闭")
+}
+
+// runMigrate 执行migrate子命令：只加载并校验数据库配置（首次部署执行迁移时无需准备JWT密钥、SMTP等业务配置）
+func runMigrate(args []string) error {
+	cfg, err := config.LoadDatabase("")
+	if err != nil {
+		return fmt.Errorf("加载配置失败：%w", err)
+	}
+	slog.SetDefault(logger.New(cfg.Log))
+
+	db, err := pkg.InitMySQL(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("数据库连接失败：%w", err)
 	}
+	defer db.Close()
+	return migrate.Run(context.Background(), db, args, os.Stdout)
 }
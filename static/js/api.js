@@ -73,7 +73,7 @@ function handleEmptyFields(data) {
     window.location.href = "/page/login";
 } else {
     // 4️⃣ 错误信息兜底
-    errorTip.innerText = res.message || "注册失败";
+    errorTip.innerText = res.msg || "注册失败";
 }
 } catch (err) {
     console.error("注册异常：", err);
@@ -62,8 +62,8 @@ async function handleLogin() {
 
         // 4️⃣ 正确判断后端响应（code=200 为成功）
         if (res.code === 200) {
-            // 从 res.message 中解构数据（核心修正：替换 res.data → res.message）
-            const { token, user_id, username, role } = res.message || {};
+            // 从 res.data 中解构登录信息
+            const { token, user_id, username, role } = res.data || {};
 
             // 校验 Token 必传
             if (!token) {
@@ -80,10 +80,8 @@ async function handleLogin() {
             // 6️⃣ 登录成功跳转
             window.location.href = "/page/index";
         } else {
-            // 处理后端返回的错误信息（兼容 message 是字符串/对象的情况）
-            const errorMsg = typeof res.message === 'string'
-                ? res.message
-                : (res.message?.error || "登录失败：账号或密码错误");
+            // 处理后端返回的错误信息
+            const errorMsg = res.msg || "登录失败：账号或密码错误";
             errorTip.innerText = errorMsg;
         }
     } catch (err) {